@@ -0,0 +1,182 @@
+package accessRecordingStorer
+
+import (
+	"fmt"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var _ types.Storer = (*accessRecordingStorer)(nil)
+
+// AccessReport holds the access distribution recorded by an access recording storer, since its creation (or last reset)
+type AccessReport struct {
+	NumReads             uint64
+	NumWrites            uint64
+	NumUniqueReadKeys    uint64
+	NumUniqueWrittenKeys uint64
+	TopReadKeys          []KeyReadCount
+}
+
+// accessRecordingStorer is a storer decorator recording the access distribution of the wrapped storer: the (approximate)
+// top-K most read keys, the number of reads and writes, as well as estimates of the number of distinct keys read and written.
+// Get, GetFromEpoch, SearchFirst, Has and the bulk getters count as reads (one for each key), while Put, PutInEpoch,
+// Remove and RemoveFromCurrentEpoch count as writes, regardless of their outcome.
+type accessRecordingStorer struct {
+	storer types.Storer
+
+	readsSketch     countMinSketch
+	topReadKeys     *topKeys
+	uniqueReadKeys  hyperLogLog
+	uniqueWriteKeys hyperLogLog
+	numReads        atomic.Counter
+	numWrites       atomic.Counter
+}
+
+// NewAccessRecordingStorer creates a storer recording the accesses to the provided one, keeping track of its topK most read keys
+func NewAccessRecordingStorer(inner types.Storer, topK int) (*accessRecordingStorer, error) {
+	if check.IfNil(inner) {
+		return nil, common.ErrNilStorer
+	}
+	if topK <= 0 {
+		return nil, fmt.Errorf("%w: topK is invalid", common.ErrInvalidConfig)
+	}
+
+	return &accessRecordingStorer{
+		storer:      inner,
+		topReadKeys: newTopKeys(topK),
+	}, nil
+}
+
+func (ars *accessRecordingStorer) recordRead(key []byte) {
+	hash := hashKey(key)
+	numReads := ars.readsSketch.increment(hash)
+	ars.topReadKeys.offer(key, numReads)
+	ars.uniqueReadKeys.add(hash)
+	ars.numReads.Increment()
+}
+
+func (ars *accessRecordingStorer) recordWrite(key []byte) {
+	ars.uniqueWriteKeys.add(hashKey(key))
+	ars.numWrites.Increment()
+}
+
+// Put records the write, then puts the data in the wrapped storer
+func (ars *accessRecordingStorer) Put(key, data []byte) error {
+	ars.recordWrite(key)
+	return ars.storer.Put(key, data)
+}
+
+// PutInEpoch records the write, then puts the data in the wrapped storer
+func (ars *accessRecordingStorer) PutInEpoch(key, data []byte, epoch uint32) error {
+	ars.recordWrite(key)
+	return ars.storer.PutInEpoch(key, data, epoch)
+}
+
+// Get records the read, then gets the value from the wrapped storer
+func (ars *accessRecordingStorer) Get(key []byte) ([]byte, error) {
+	ars.recordRead(key)
+	return ars.storer.Get(key)
+}
+
+// GetFromEpoch records the read, then gets the value from the wrapped storer
+func (ars *accessRecordingStorer) GetFromEpoch(key []byte, epoch uint32) ([]byte, error) {
+	ars.recordRead(key)
+	return ars.storer.GetFromEpoch(key, epoch)
+}
+
+// GetBulkFromEpoch records a read for each key, then gets the values from the wrapped storer
+func (ars *accessRecordingStorer) GetBulkFromEpoch(keys [][]byte, epoch uint32) ([]storageCore.KeyValuePair, error) {
+	for _, key := range keys {
+		ars.recordRead(key)
+	}
+
+	return ars.storer.GetBulkFromEpoch(keys, epoch)
+}
+
+// GetBulk records a read for each key, then gets the values from the wrapped storer
+func (ars *accessRecordingStorer) GetBulk(keys [][]byte) (map[string][]byte, error) {
+	for _, key := range keys {
+		ars.recordRead(key)
+	}
+
+	return ars.storer.GetBulk(keys)
+}
+
+// Has records the read, then checks the presence of the key in the wrapped storer
+func (ars *accessRecordingStorer) Has(key []byte) error {
+	ars.recordRead(key)
+	return ars.storer.Has(key)
+}
+
+// SearchFirst records the read, then searches the key in the wrapped storer
+func (ars *accessRecordingStorer) SearchFirst(key []byte) ([]byte, error) {
+	ars.recordRead(key)
+	return ars.storer.SearchFirst(key)
+}
+
+// Remove records the write, then removes the key from the wrapped storer
+func (ars *accessRecordingStorer) Remove(key []byte) error {
+	ars.recordWrite(key)
+	return ars.storer.Remove(key)
+}
+
+// RemoveFromCurrentEpoch records the write, then removes the key from the wrapped storer
+func (ars *accessRecordingStorer) RemoveFromCurrentEpoch(key []byte) error {
+	ars.recordWrite(key)
+	return ars.storer.RemoveFromCurrentEpoch(key)
+}
+
+// ClearCache clears the cache of the wrapped storer
+func (ars *accessRecordingStorer) ClearCache() {
+	ars.storer.ClearCache()
+}
+
+// GetOldestEpoch returns the oldest epoch of the wrapped storer
+func (ars *accessRecordingStorer) GetOldestEpoch() (uint32, error) {
+	return ars.storer.GetOldestEpoch()
+}
+
+// RangeKeys iterates over the (key, value) pairs of the wrapped storer, without recording them as reads
+func (ars *accessRecordingStorer) RangeKeys(handler func(key []byte, value []byte) bool) {
+	ars.storer.RangeKeys(handler)
+}
+
+// DestroyUnit destroys the wrapped storer
+func (ars *accessRecordingStorer) DestroyUnit() error {
+	return ars.storer.DestroyUnit()
+}
+
+// Close closes the wrapped storer
+func (ars *accessRecordingStorer) Close() error {
+	return ars.storer.Close()
+}
+
+// Report returns the access distribution recorded since the creation of the storer (or since the last reset)
+func (ars *accessRecordingStorer) Report() AccessReport {
+	return AccessReport{
+		NumReads:             ars.numReads.GetUint64(),
+		NumWrites:            ars.numWrites.GetUint64(),
+		NumUniqueReadKeys:    ars.uniqueReadKeys.estimate(),
+		NumUniqueWrittenKeys: ars.uniqueWriteKeys.estimate(),
+		TopReadKeys:          ars.topReadKeys.get(),
+	}
+}
+
+// Reset discards the recorded access distribution. The accesses happening concurrently with the reset might be partially recorded.
+func (ars *accessRecordingStorer) Reset() {
+	ars.readsSketch.reset()
+	ars.topReadKeys.reset()
+	ars.uniqueReadKeys.reset()
+	ars.uniqueWriteKeys.reset()
+	ars.numReads.Reset()
+	ars.numWrites.Reset()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ars *accessRecordingStorer) IsInterfaceNil() bool {
+	return ars == nil
+}
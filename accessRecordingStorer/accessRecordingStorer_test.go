@@ -0,0 +1,216 @@
+package accessRecordingStorer_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/accessRecordingStorer"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/multiversx/mx-chain-storage-go/memorydb"
+	"github.com/multiversx/mx-chain-storage-go/storageUnit"
+	"github.com/stretchr/testify/assert"
+)
+
+func createUnit() *storageUnit.Unit {
+	cache, _ := lrucache.NewCache(1000)
+	unit, _ := storageUnit.NewStorageUnit(cache, memorydb.New())
+	return unit
+}
+
+func TestNewAccessRecordingStorer(t *testing.T) {
+	t.Parallel()
+
+	ars, err := accessRecordingStorer.NewAccessRecordingStorer(nil, 10)
+	assert.True(t, check.IfNil(ars))
+	assert.Equal(t, common.ErrNilStorer, err)
+
+	ars, err = accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 0)
+	assert.True(t, check.IfNil(ars))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	ars, err = accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 10)
+	assert.False(t, check.IfNil(ars))
+	assert.Nil(t, err)
+}
+
+func TestAccessRecordingStorer_ForwardsTheOperations(t *testing.T) {
+	t.Parallel()
+
+	unit := createUnit()
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(unit, 10)
+
+	err := ars.Put([]byte("a"), []byte("aaa"))
+	assert.Nil(t, err)
+	assert.Nil(t, unit.Has([]byte("a")))
+
+	value, err := ars.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("aaa"), value)
+
+	values, err := ars.GetBulk([][]byte{[]byte("a"), []byte("missing")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("aaa")}, values)
+
+	err = ars.Remove([]byte("a"))
+	assert.Nil(t, err)
+	assert.NotNil(t, unit.Has([]byte("a")))
+	assert.NotNil(t, ars.Has([]byte("a")))
+}
+
+func TestAccessRecordingStorer_Report(t *testing.T) {
+	t.Parallel()
+
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 3)
+
+	for i := 0; i < 20; i++ {
+		_ = ars.Put([]byte(fmt.Sprintf("key-%d", i)), []byte("value"))
+	}
+	_ = ars.Remove([]byte("key-0"))
+
+	// key-i is read i times (for i in [1, 10]), key-10 being the most read one
+	for i := 1; i <= 10; i++ {
+		for j := 0; j < i; j++ {
+			_, _ = ars.Get([]byte(fmt.Sprintf("key-%d", i)))
+		}
+	}
+	_ = ars.Has([]byte("key-1"))
+	_, _ = ars.GetBulk([][]byte{[]byte("key-2"), []byte("missing")})
+
+	report := ars.Report()
+	assert.Equal(t, uint64(55+3), report.NumReads)
+	assert.Equal(t, uint64(21), report.NumWrites)
+	assert.Equal(t, uint64(11), report.NumUniqueReadKeys)
+	assert.Equal(t, uint64(20), report.NumUniqueWrittenKeys)
+	assert.Equal(t, []accessRecordingStorer.KeyReadCount{
+		{Key: []byte("key-10"), NumReads: 10},
+		{Key: []byte("key-9"), NumReads: 9},
+		{Key: []byte("key-8"), NumReads: 8},
+	}, report.TopReadKeys)
+}
+
+func TestAccessRecordingStorer_TopKeysFollowTheDistributionShift(t *testing.T) {
+	t.Parallel()
+
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 2)
+
+	for i := 0; i < 5; i++ {
+		_, _ = ars.Get([]byte("a"))
+		_, _ = ars.Get([]byte("b"))
+	}
+	for i := 0; i < 10; i++ {
+		_, _ = ars.Get([]byte("c"))
+	}
+
+	report := ars.Report()
+	assert.Equal(t, 2, len(report.TopReadKeys))
+	assert.Equal(t, []byte("c"), report.TopReadKeys[0].Key)
+	assert.Equal(t, uint64(10), report.TopReadKeys[0].NumReads)
+	assert.Equal(t, uint64(5), report.TopReadKeys[1].NumReads)
+}
+
+func TestAccessRecordingStorer_Reset(t *testing.T) {
+	t.Parallel()
+
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 3)
+
+	_ = ars.Put([]byte("a"), []byte("aaa"))
+	_, _ = ars.Get([]byte("a"))
+	_, _ = ars.Get([]byte("a"))
+	ars.Reset()
+
+	assert.Equal(t, accessRecordingStorer.AccessReport{
+		TopReadKeys: make([]accessRecordingStorer.KeyReadCount, 0),
+	}, ars.Report())
+
+	_, _ = ars.Get([]byte("a"))
+	report := ars.Report()
+	assert.Equal(t, uint64(1), report.NumReads)
+	assert.Equal(t, []accessRecordingStorer.KeyReadCount{{Key: []byte("a"), NumReads: 1}}, report.TopReadKeys)
+}
+
+func TestAccessRecordingStorer_UniqueKeysEstimate(t *testing.T) {
+	t.Parallel()
+
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 10)
+
+	numKeys := 50000
+	for i := 0; i < numKeys; i++ {
+		_ = ars.Has([]byte(fmt.Sprintf("key-%d", i)))
+	}
+
+	// The standard error of the estimate is about 3.25%
+	estimate := float64(ars.Report().NumUniqueReadKeys)
+	assert.InEpsilon(t, float64(numKeys), estimate, 0.1)
+}
+
+func TestAccessRecordingStorer_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(createUnit(), 5)
+
+	numOperations := 1000
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key-%d", idx%20))
+			switch idx % 10 {
+			case 0:
+				ars.Reset()
+			case 1:
+				_ = ars.Report()
+			case 2:
+				_ = ars.Put(key, key)
+			default:
+				_, _ = ars.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	assert.True(t, len(ars.Report().TopReadKeys) <= 5)
+}
+
+func createKeys(numKeys int) [][]byte {
+	keys := make([][]byte, numKeys)
+	for i := 0; i < numKeys; i++ {
+		keys[i] = []byte(fmt.Sprintf("a key of a usual length, e.g. a hash - %d", i))
+	}
+
+	return keys
+}
+
+// The recording overhead is the difference between the two benchmarks below
+
+func BenchmarkAccessRecordingStorer_Get(b *testing.B) {
+	unit := createUnit()
+	ars, _ := accessRecordingStorer.NewAccessRecordingStorer(unit, 100)
+	keys := createKeys(1000)
+	for _, key := range keys {
+		_ = unit.Put(key, key)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = ars.Get(keys[i%len(keys)])
+	}
+}
+
+func BenchmarkAccessRecordingStorer_GetWithoutRecording(b *testing.B) {
+	unit := createUnit()
+	keys := createKeys(1000)
+	for _, key := range keys {
+		_ = unit.Put(key, key)
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = unit.Get(keys[i%len(keys)])
+	}
+}
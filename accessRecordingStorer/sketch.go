@@ -0,0 +1,112 @@
+package accessRecordingStorer
+
+import (
+	"math"
+	"math/bits"
+	"sync/atomic"
+)
+
+const sketchDepth = 4
+const sketchWidth = 4096
+const sketchWidthMask = sketchWidth - 1
+
+const hllPrecision = 10
+const hllNumRegisters = 1 << hllPrecision
+
+const fnvOffset64 = 14695981039346656037
+const fnvPrime64 = 1099511628211
+
+// hashKey computes the (FNV-1a, then mixed) 64 bits hash of a key, without allocations
+func hashKey(key []byte) uint64 {
+	hash := uint64(fnvOffset64)
+	for _, b := range key {
+		hash ^= uint64(b)
+		hash *= fnvPrime64
+	}
+
+	// finalizer of splitmix64, spreading the entropy over all the bits (required by the HyperLogLog registers)
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+
+	return hash
+}
+
+// countMinSketch estimates the number of occurrences of the keys, never underestimating them.
+// The counters are updated atomically, thus the sketch can be used concurrently without locks.
+type countMinSketch struct {
+	counters [sketchDepth][sketchWidth]uint32
+}
+
+// increment records one occurrence of the key (given its hash) and returns the new estimate of its occurrences
+func (sketch *countMinSketch) increment(hash uint64) uint64 {
+	h1 := uint32(hash)
+	h2 := uint32(hash>>32) | 1
+
+	estimate := uint32(math.MaxUint32)
+	for row := 0; row < sketchDepth; row++ {
+		column := (h1 + uint32(row)*h2) & sketchWidthMask
+		count := atomic.AddUint32(&sketch.counters[row][column], 1)
+		if count < estimate {
+			estimate = count
+		}
+	}
+
+	return uint64(estimate)
+}
+
+func (sketch *countMinSketch) reset() {
+	for row := 0; row < sketchDepth; row++ {
+		for column := 0; column < sketchWidth; column++ {
+			atomic.StoreUint32(&sketch.counters[row][column], 0)
+		}
+	}
+}
+
+// hyperLogLog estimates the number of distinct keys. The registers are updated atomically.
+type hyperLogLog struct {
+	registers [hllNumRegisters]uint32
+}
+
+func (hll *hyperLogLog) add(hash uint64) {
+	index := hash >> (64 - hllPrecision)
+	rank := uint32(bits.LeadingZeros64(hash<<hllPrecision|1<<(hllPrecision-1))) + 1
+
+	register := &hll.registers[index]
+	for {
+		current := atomic.LoadUint32(register)
+		if current >= rank || atomic.CompareAndSwapUint32(register, current, rank) {
+			return
+		}
+	}
+}
+
+func (hll *hyperLogLog) estimate() uint64 {
+	sum := float64(0)
+	numZeroRegisters := 0
+	for i := 0; i < hllNumRegisters; i++ {
+		register := atomic.LoadUint32(&hll.registers[i])
+		if register == 0 {
+			numZeroRegisters++
+		}
+		sum += math.Ldexp(1, -int(register))
+	}
+
+	m := float64(hllNumRegisters)
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && numZeroRegisters > 0 {
+		// linear counting performs better for small cardinalities
+		estimate = m * math.Log(m/float64(numZeroRegisters))
+	}
+
+	return uint64(estimate + 0.5)
+}
+
+func (hll *hyperLogLog) reset() {
+	for i := 0; i < hllNumRegisters; i++ {
+		atomic.StoreUint32(&hll.registers[i], 0)
+	}
+}
@@ -0,0 +1,140 @@
+package accessRecordingStorer
+
+import (
+	"container/heap"
+	"sort"
+	"sync"
+	"sync/atomic"
+)
+
+// KeyReadCount holds the (estimated) number of reads of a key
+type KeyReadCount struct {
+	Key      []byte
+	NumReads uint64
+}
+
+type topKeyItem struct {
+	key      string
+	numReads uint64
+	index    int
+}
+
+// topKeyItemsHeap is a min-heap of items, by the number of reads
+type topKeyItemsHeap []*topKeyItem
+
+func (h topKeyItemsHeap) Len() int {
+	return len(h)
+}
+
+func (h topKeyItemsHeap) Less(i, j int) bool {
+	return h[i].numReads < h[j].numReads
+}
+
+func (h topKeyItemsHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index = i
+	h[j].index = j
+}
+
+func (h *topKeyItemsHeap) Push(x interface{}) {
+	item := x.(*topKeyItem)
+	item.index = len(*h)
+	*h = append(*h, item)
+}
+
+func (h *topKeyItemsHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+
+	return item
+}
+
+// topKeys keeps the K keys having the highest (estimated) number of reads
+type topKeys struct {
+	maxNumKeys int
+
+	mutItems sync.Mutex
+	items    topKeyItemsHeap
+	byKey    map[string]*topKeyItem
+
+	// minNumReadsToEnter is the number of reads a key needs in order to (possibly) enter the top, once the top is full.
+	// It allows the hot path to skip the lock for the keys not being candidates.
+	minNumReadsToEnter uint64
+}
+
+func newTopKeys(maxNumKeys int) *topKeys {
+	return &topKeys{
+		maxNumKeys: maxNumKeys,
+		items:      make(topKeyItemsHeap, 0, maxNumKeys),
+		byKey:      make(map[string]*topKeyItem, maxNumKeys),
+	}
+}
+
+// offer records the new estimate of the number of reads of a key.
+// The keys already in the top always pass the threshold, since their estimates never decrease.
+func (top *topKeys) offer(key []byte, numReads uint64) {
+	if numReads < atomic.LoadUint64(&top.minNumReadsToEnter) {
+		return
+	}
+
+	top.mutItems.Lock()
+	defer top.mutItems.Unlock()
+
+	item, exists := top.byKey[string(key)]
+	switch {
+	case exists:
+		if numReads > item.numReads {
+			item.numReads = numReads
+			heap.Fix(&top.items, item.index)
+		}
+	case len(top.items) < top.maxNumKeys:
+		item = &topKeyItem{key: string(key), numReads: numReads}
+		heap.Push(&top.items, item)
+		top.byKey[item.key] = item
+	case numReads > top.items[0].numReads:
+		evicted := top.items[0]
+		delete(top.byKey, evicted.key)
+
+		item = &topKeyItem{key: string(key), numReads: numReads, index: 0}
+		top.items[0] = item
+		top.byKey[item.key] = item
+		heap.Fix(&top.items, 0)
+	default:
+		return
+	}
+
+	if len(top.items) == top.maxNumKeys {
+		atomic.StoreUint64(&top.minNumReadsToEnter, top.items[0].numReads)
+	}
+}
+
+// get returns the keys in the top, in descending order by the number of reads
+func (top *topKeys) get() []KeyReadCount {
+	top.mutItems.Lock()
+	result := make([]KeyReadCount, 0, len(top.items))
+	for _, item := range top.items {
+		result = append(result, KeyReadCount{
+			Key:      []byte(item.key),
+			NumReads: item.numReads,
+		})
+	}
+	top.mutItems.Unlock()
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].NumReads > result[j].NumReads
+	})
+
+	return result
+}
+
+func (top *topKeys) reset() {
+	top.mutItems.Lock()
+	defer top.mutItems.Unlock()
+
+	top.items = make(topKeyItemsHeap, 0, top.maxNumKeys)
+	top.byKey = make(map[string]*topKeyItem, top.maxNumKeys)
+	atomic.StoreUint64(&top.minNumReadsToEnter, 0)
+}
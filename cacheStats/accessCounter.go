@@ -0,0 +1,124 @@
+package cacheStats
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+// MaxTrackedKeys is the maximum number of keys for which the accesses are counted (between two snapshots), in order to bound the memory usage.
+// Once the limit is reached, the accesses of the keys not tracked yet are ignored.
+const MaxTrackedKeys = 10000
+
+const fnvOffset64 = 14695981039346656037
+const fnvPrime64 = 1099511628211
+
+// AccessCounter counts the accesses (reads) of each key of a cache, in order to identify the hot keys.
+// The counting is disabled by default (see SetEnabled). The keys are tracked by their (fixed-size) hash, see HashOfKey.
+// Counting the accesses of an already tracked key only requires a read lock and an atomic increment.
+type AccessCounter struct {
+	enabled atomic.Flag
+	mut     sync.RWMutex
+	counts  map[uint64]*atomic.Counter
+}
+
+// NewAccessCounter creates a new (disabled) access counter
+func NewAccessCounter() *AccessCounter {
+	return &AccessCounter{
+		counts: make(map[uint64]*atomic.Counter),
+	}
+}
+
+// HashOfKey returns the (FNV-1a, 64 bits) hash by which a key is tracked, without allocations
+func HashOfKey(key []byte) uint64 {
+	hash := uint64(fnvOffset64)
+	for _, b := range key {
+		hash ^= uint64(b)
+		hash *= fnvPrime64
+	}
+
+	return hash
+}
+
+// SetEnabled enables or disables the counting. Disabling it also drops the counts recorded so far.
+func (counter *AccessCounter) SetEnabled(enabled bool) {
+	counter.enabled.SetValue(enabled)
+	if enabled {
+		return
+	}
+
+	counter.mut.Lock()
+	counter.counts = make(map[uint64]*atomic.Counter)
+	counter.mut.Unlock()
+}
+
+// RecordAccess increments the access counter of the given key (if the counting is enabled)
+func (counter *AccessCounter) RecordAccess(key []byte) {
+	if !counter.enabled.IsSet() {
+		return
+	}
+
+	keyHash := HashOfKey(key)
+
+	// The increment happens under the read lock, so that it cannot be lost by a concurrent snapshot
+	counter.mut.RLock()
+	keyCounter, ok := counter.counts[keyHash]
+	if ok {
+		keyCounter.Increment()
+	}
+	isFull := len(counter.counts) >= MaxTrackedKeys
+	counter.mut.RUnlock()
+
+	if ok || isFull {
+		return
+	}
+
+	counter.mut.Lock()
+	defer counter.mut.Unlock()
+
+	keyCounter, ok = counter.counts[keyHash]
+	if !ok {
+		if len(counter.counts) >= MaxTrackedKeys {
+			return
+		}
+
+		keyCounter = &atomic.Counter{}
+		counter.counts[keyHash] = keyCounter
+	}
+
+	keyCounter.Increment()
+}
+
+// GetAccessCounts returns a snapshot of the access counts, sorted by count (descending), then by the hash of the key (ascending),
+// then resets the counters.
+func (counter *AccessCounter) GetAccessCounts() []types.KeyAccessCount {
+	counter.mut.Lock()
+	counts := counter.counts
+	counter.counts = make(map[uint64]*atomic.Counter)
+	counter.mut.Unlock()
+
+	snapshot := make([]types.KeyAccessCount, 0, len(counts))
+	for keyHash, keyCounter := range counts {
+		snapshot = append(snapshot, types.KeyAccessCount{
+			KeyHash: keyHash,
+			Count:   keyCounter.Get(),
+		})
+	}
+
+	sort.Slice(snapshot, func(i, j int) bool {
+		if snapshot[i].Count != snapshot[j].Count {
+			return snapshot[i].Count > snapshot[j].Count
+		}
+
+		return snapshot[i].KeyHash < snapshot[j].KeyHash
+	})
+
+	return snapshot
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (counter *AccessCounter) IsInterfaceNil() bool {
+	return counter == nil
+}
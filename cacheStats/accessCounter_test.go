@@ -0,0 +1,145 @@
+package cacheStats
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func newEnabledAccessCounter() *AccessCounter {
+	counter := NewAccessCounter()
+	counter.SetEnabled(true)
+	return counter
+}
+
+func TestAccessCounter_ShouldBeDisabledByDefault(t *testing.T) {
+	counter := NewAccessCounter()
+
+	counter.RecordAccess([]byte("a"))
+	require.Empty(t, counter.GetAccessCounts())
+
+	counter.SetEnabled(true)
+	counter.RecordAccess([]byte("a"))
+	require.Len(t, counter.GetAccessCounts(), 1)
+
+	// Disabling drops the counts recorded so far
+	counter.RecordAccess([]byte("a"))
+	counter.SetEnabled(false)
+	counter.RecordAccess([]byte("a"))
+	require.Empty(t, counter.GetAccessCounts())
+}
+
+func TestAccessCounter_GetAccessCountsShouldReset(t *testing.T) {
+	counter := newEnabledAccessCounter()
+
+	counter.RecordAccess([]byte("a"))
+	counter.RecordAccess([]byte("b"))
+	counter.RecordAccess([]byte("b"))
+	counter.RecordAccess([]byte("c"))
+	counter.RecordAccess([]byte("b"))
+	counter.RecordAccess([]byte("c"))
+
+	// Sorted by count, descending
+	expected := []types.KeyAccessCount{
+		{KeyHash: HashOfKey([]byte("b")), Count: 3},
+		{KeyHash: HashOfKey([]byte("c")), Count: 2},
+		{KeyHash: HashOfKey([]byte("a")), Count: 1},
+	}
+	require.Equal(t, expected, counter.GetAccessCounts())
+	require.Empty(t, counter.GetAccessCounts())
+
+	counter.RecordAccess([]byte("a"))
+	require.Equal(t, []types.KeyAccessCount{{KeyHash: HashOfKey([]byte("a")), Count: 1}}, counter.GetAccessCounts())
+}
+
+func TestAccessCounter_GetAccessCountsShouldBreakTiesByHash(t *testing.T) {
+	counter := newEnabledAccessCounter()
+
+	for i := 0; i < 100; i++ {
+		counter.RecordAccess([]byte(fmt.Sprintf("key-%d", i)))
+	}
+
+	counts := counter.GetAccessCounts()
+	require.Len(t, counts, 100)
+	for i := 1; i < len(counts); i++ {
+		require.Less(t, counts[i-1].KeyHash, counts[i].KeyHash)
+	}
+}
+
+func TestHashOfKey(t *testing.T) {
+	// Reference values of FNV-1a (64 bits)
+	require.Equal(t, uint64(0xcbf29ce484222325), HashOfKey(nil))
+	require.Equal(t, uint64(0xaf63dc4c8601ec8c), HashOfKey([]byte("a")))
+	require.Equal(t, uint64(0x85944171f73967e8), HashOfKey([]byte("foobar")))
+}
+
+func TestAccessCounter_ShouldCapTheNumberOfTrackedKeys(t *testing.T) {
+	counter := newEnabledAccessCounter()
+
+	for i := 0; i < MaxTrackedKeys+100; i++ {
+		counter.RecordAccess([]byte(fmt.Sprintf("key-%d", i)))
+	}
+
+	// Already tracked keys are still counted
+	counter.RecordAccess([]byte("key-0"))
+
+	counts := counter.GetAccessCounts()
+	require.Equal(t, MaxTrackedKeys, len(counts))
+	require.Equal(t, types.KeyAccessCount{KeyHash: HashOfKey([]byte("key-0")), Count: 2}, counts[0])
+	for _, count := range counts {
+		require.NotEqual(t, HashOfKey([]byte(fmt.Sprintf("key-%d", MaxTrackedKeys))), count.KeyHash)
+	}
+}
+
+func TestAccessCounter_ConcurrentAccesses(t *testing.T) {
+	counter := newEnabledAccessCounter()
+
+	numWorkers := 8
+	numAccesses := 1000
+	total := int64(0)
+	mutTotal := sync.Mutex{}
+
+	wg := sync.WaitGroup{}
+	wg.Add(numWorkers + 1)
+	for i := 0; i < numWorkers; i++ {
+		go func(worker int) {
+			defer wg.Done()
+
+			for j := 0; j < numAccesses; j++ {
+				counter.RecordAccess([]byte(fmt.Sprintf("key-%d", j%10)))
+			}
+		}(i)
+	}
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			sum := int64(0)
+			for _, count := range counter.GetAccessCounts() {
+				sum += count.Count
+			}
+
+			mutTotal.Lock()
+			total += sum
+			mutTotal.Unlock()
+		}
+	}()
+	wg.Wait()
+
+	for _, count := range counter.GetAccessCounts() {
+		total += count.Count
+	}
+
+	require.Equal(t, int64(numWorkers*numAccesses), total)
+}
+
+func TestAccessCounter_IsInterfaceNil(t *testing.T) {
+	var counter *AccessCounter
+	require.True(t, counter.IsInterfaceNil())
+
+	counter = NewAccessCounter()
+	require.False(t, counter.IsInterfaceNil())
+}
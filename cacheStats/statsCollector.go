@@ -0,0 +1,75 @@
+package cacheStats
+
+import (
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+// StatsCollector maintains the hit, miss, put and eviction counters of a cache.
+// All the operations are lock-free, thus they can be called on the hot paths of the cache.
+type StatsCollector struct {
+	hits      atomic.Counter
+	misses    atomic.Counter
+	puts      atomic.Counter
+	evictions atomic.Counter
+	startTime atomic.Counter
+}
+
+// NewStatsCollector creates a new stats collector
+func NewStatsCollector() *StatsCollector {
+	collector := &StatsCollector{}
+	collector.startTime.Set(time.Now().UnixNano())
+
+	return collector
+}
+
+// RecordLookup records a hit or a miss, depending on whether the looked up key has been found
+func (collector *StatsCollector) RecordLookup(found bool) {
+	if found {
+		collector.hits.Increment()
+		return
+	}
+
+	collector.misses.Increment()
+}
+
+// RecordPut records an addition to the cache
+func (collector *StatsCollector) RecordPut() {
+	collector.puts.Increment()
+}
+
+// RecordEvictions records the given number of evictions
+func (collector *StatsCollector) RecordEvictions(numEvictions int) {
+	collector.evictions.Add(int64(numEvictions))
+}
+
+// Stats returns the collected statistics, along with the provided current length and size of the cache
+func (collector *StatsCollector) Stats(length int, sizeInBytes uint64) types.CacheStats {
+	uptime := time.Now().UnixNano() - collector.startTime.Get()
+
+	return types.CacheStats{
+		Hits:        collector.hits.GetUint64(),
+		Misses:      collector.misses.GetUint64(),
+		Puts:        collector.puts.GetUint64(),
+		Evictions:   collector.evictions.GetUint64(),
+		Len:         length,
+		SizeInBytes: sizeInBytes,
+		Uptime:      time.Duration(uptime),
+	}
+}
+
+// Reset zeroes the counters and restarts the uptime
+func (collector *StatsCollector) Reset() {
+	collector.hits.Reset()
+	collector.misses.Reset()
+	collector.puts.Reset()
+	collector.evictions.Reset()
+	collector.startTime.Set(time.Now().UnixNano())
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (collector *StatsCollector) IsInterfaceNil() bool {
+	return collector == nil
+}
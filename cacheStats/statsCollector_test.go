@@ -0,0 +1,45 @@
+package cacheStats
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsCollector_StatsAndReset(t *testing.T) {
+	collector := NewStatsCollector()
+
+	collector.RecordLookup(true)
+	collector.RecordLookup(true)
+	collector.RecordLookup(false)
+	collector.RecordPut()
+	collector.RecordEvictions(3)
+	time.Sleep(50 * time.Millisecond)
+
+	stats := collector.Stats(7, 42)
+	require.Equal(t, uint64(2), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(1), stats.Puts)
+	require.Equal(t, uint64(3), stats.Evictions)
+	require.Equal(t, 7, stats.Len)
+	require.Equal(t, uint64(42), stats.SizeInBytes)
+	require.True(t, stats.Uptime >= 50*time.Millisecond)
+
+	collector.Reset()
+
+	stats = collector.Stats(7, 42)
+	require.Equal(t, uint64(0), stats.Hits)
+	require.Equal(t, uint64(0), stats.Misses)
+	require.Equal(t, uint64(0), stats.Puts)
+	require.Equal(t, uint64(0), stats.Evictions)
+	require.True(t, stats.Uptime < 50*time.Millisecond)
+}
+
+func TestStatsCollector_IsInterfaceNil(t *testing.T) {
+	var collector *StatsCollector
+	require.True(t, collector.IsInterfaceNil())
+
+	collector = NewStatsCollector()
+	require.False(t, collector.IsInterfaceNil())
+}
@@ -84,3 +84,78 @@ var ErrInvalidCacheExpiry = errors.New("invalid cache expiry")
 
 // ErrDBIsClosed is raised when the DB is closed
 var ErrDBIsClosed = errors.New("DB is closed")
+
+// ErrNilSizer signals that a nil sizer function has been provided
+var ErrNilSizer = errors.New("nil sizer")
+
+// ErrInvalidSweepInterval signals that an invalid sweep interval was provided
+var ErrInvalidSweepInterval = errors.New("invalid sweep interval")
+
+// ErrCacheStatsNotAvailable signals that the cacher is not able to report its usage statistics
+var ErrCacheStatsNotAvailable = errors.New("cache stats not available")
+
+// ErrNilWriter signals that a nil writer has been provided
+var ErrNilWriter = errors.New("nil writer")
+
+// ErrNilReader signals that a nil reader has been provided
+var ErrNilReader = errors.New("nil reader")
+
+// ErrInvalidBatchSize signals that an invalid batch size has been provided
+var ErrInvalidBatchSize = errors.New("invalid batch size")
+
+// ErrNotProtoMarshalizable signals that an object cannot be marshalled in protobuf format
+var ErrNotProtoMarshalizable = errors.New("object is not protobuf marshalizable")
+
+// ErrNilTxImportHandler signals that a nil tx import handler has been provided
+var ErrNilTxImportHandler = errors.New("nil tx import handler")
+
+// ErrNilTransaction signals that a nil transaction has been provided
+var ErrNilTransaction = errors.New("nil transaction")
+
+// ErrInvalidTrustLevel signals that an invalid trust level has been provided
+var ErrInvalidTrustLevel = errors.New("invalid trust level")
+
+// ErrImportRecordTooLarge signals that an imported record exceeds the maximum accepted size
+var ErrImportRecordTooLarge = errors.New("import record too large")
+
+// ErrCacheNotResizable signals that the underlying cache does not support resizing
+var ErrCacheNotResizable = errors.New("cache is not resizable")
+
+// ErrNilTxListForSender signals that a nil list of transactions (for a sender) has been provided
+var ErrNilTxListForSender = errors.New("nil tx list for sender")
+
+// ErrNilStorageUnit signals that a nil storage unit has been provided
+var ErrNilStorageUnit = errors.New("nil storage unit")
+
+// ErrNegativeCacheNotEnabled signals that the negative cache of the storage unit is not enabled
+var ErrNegativeCacheNotEnabled = errors.New("negative cache not enabled")
+
+// ErrInvalidMaxEntries signals that an invalid maximum number of entries has been provided
+var ErrInvalidMaxEntries = errors.New("invalid max entries")
+
+// ErrNonceOverflow signals that a transaction with the maximum possible nonce has been provided
+var ErrNonceOverflow = errors.New("nonce overflow")
+
+// ErrCorruptedSnapshot signals that a cache snapshot cannot be read, since it is truncated or corrupted
+var ErrCorruptedSnapshot = errors.New("corrupted cache snapshot")
+
+// ErrUnsupportedSnapshotVersion signals that the version of a cache snapshot is not supported
+var ErrUnsupportedSnapshotVersion = errors.New("unsupported cache snapshot version")
+
+// ErrAtomicBatchNotSupported signals that the persister is not able to write a batch of entries atomically
+var ErrAtomicBatchNotSupported = errors.New("atomic batch not supported by the persister")
+
+// ErrNilStorer signals that a nil storer has been provided
+var ErrNilStorer = errors.New("nil storer")
+
+// ErrInvalidTxValue signals that the value of a transaction is invalid
+var ErrInvalidTxValue = errors.New("invalid transaction value")
+
+// ErrExpiringEntriesNotSupported signals that the persister is not able to store entries that expire
+var ErrExpiringEntriesNotSupported = errors.New("expiring entries not supported by the persister")
+
+// ErrInvalidTTL signals that an invalid time to live has been provided
+var ErrInvalidTTL = errors.New("invalid TTL")
+
+// ErrInvalidExpiringValue signals that a value stored by an expiring persister could not be decoded
+var ErrInvalidExpiringValue = errors.New("invalid expiring value")
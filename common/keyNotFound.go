@@ -0,0 +1,27 @@
+package common
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NewKeyNotFoundError returns ErrKeyNotFound, wrapped along with the source (e.g. the identifier of a storage unit or the path
+// of a persister) and the (hex-encoded) key, so that errors.Is(err, ErrKeyNotFound) holds
+func NewKeyNotFoundError(source string, key []byte) error {
+	return fmt.Errorf("%w: source = %s, key = %s", ErrKeyNotFound, source, hex.EncodeToString(key))
+}
+
+// IsNotFoundError checks whether the error signals a missing key. This is the recommended check: besides the errors wrapping
+// ErrKeyNotFound, it also recognizes the (legacy) errors of the components which only mention "not found" in their message.
+func IsNotFoundError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, ErrKeyNotFound) {
+		return true
+	}
+
+	return strings.Contains(err.Error(), "not found")
+}
@@ -0,0 +1,31 @@
+package common_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKeyNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	err := common.NewKeyNotFoundError("db/Accounts", []byte{0xab, 0xcd})
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.Equal(t, "key not found: source = db/Accounts, key = abcd", err.Error())
+
+	wrapped := fmt.Errorf("%w while loading", err)
+	assert.ErrorIs(t, wrapped, common.ErrKeyNotFound)
+}
+
+func TestIsNotFoundError(t *testing.T) {
+	t.Parallel()
+
+	assert.False(t, common.IsNotFoundError(nil))
+	assert.False(t, common.IsNotFoundError(errors.New("disk failure")))
+	assert.True(t, common.IsNotFoundError(common.ErrKeyNotFound))
+	assert.True(t, common.IsNotFoundError(common.NewKeyNotFoundError("db", []byte("a"))))
+	assert.True(t, common.IsNotFoundError(errors.New("entry not found")))
+}
@@ -0,0 +1,23 @@
+package disabled
+
+type iterator struct{}
+
+// Next returns false
+func (it *iterator) Next() bool {
+	return false
+}
+
+// Key returns nil
+func (it *iterator) Key() []byte {
+	return nil
+}
+
+// Value returns nil
+func (it *iterator) Value() []byte {
+	return nil
+}
+
+// Close returns nil
+func (it *iterator) Close() error {
+	return nil
+}
@@ -2,6 +2,7 @@ package disabled
 
 import (
 	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 type persister struct{}
@@ -49,6 +50,11 @@ func (p *persister) DestroyClosed() error {
 // RangeKeys does nothing
 func (p *persister) RangeKeys(_ func(key []byte, val []byte) bool) {}
 
+// NewIterator returns an empty iterator
+func (p *persister) NewIterator(_ []byte) (types.Iterator, error) {
+	return &iterator{}, nil
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (p *persister) IsInterfaceNil() bool {
 	return p == nil
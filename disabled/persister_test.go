@@ -29,6 +29,13 @@ func TestPersister_MethodsDoNotPanic(t *testing.T) {
 	assert.Nil(t, p.DestroyClosed())
 	p.RangeKeys(nil)
 
+	it, err := p.NewIterator(nil)
+	assert.Nil(t, err)
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Key())
+	assert.Nil(t, it.Value())
+	assert.Nil(t, it.Close())
+
 	val, err := p.Get(nil)
 	assert.Nil(t, val)
 	assert.Equal(t, common.ErrKeyNotFound, err)
@@ -0,0 +1,195 @@
+package fifocache
+
+import (
+	"hash/fnv"
+	"sync"
+	"sync/atomic"
+)
+
+const numAddedDataWorkers = 4
+const addedDataQueueSize = 1024
+
+type addedDataHandler struct {
+	id      string
+	handler func(key []byte, value interface{})
+}
+
+type addedDataEvent struct {
+	key   []byte
+	value interface{}
+}
+
+// addedDataNotifier dispatches the "added data" events of the cache to the registered handlers.
+// The handlers are held in a copy-on-write slice (atomically swapped on registration), so that the notifying callers
+// do not contend on a lock. The events are dispatched by a bounded pool of workers, each with its own queue; the worker
+// is chosen by the hash of the key, thus the events of a given key are dispatched in order. The handlers are looked up
+// at dispatch time, so that an unregistered handler is not called anymore, even for the already queued events.
+// The workers are started when the first handler is registered and stopped on close.
+// The notifying caller is never blocked: if the queue of a worker is full (e.g. a slow handler, or a handler which adds data
+// to the cache itself), the event is dispatched on a goroutine of its own (as if there was no pool of workers), thus the order
+// of the events of that key is not guaranteed anymore. Such events are counted (see numOverflowedEvents).
+type addedDataNotifier struct {
+	numOverflowedEvents uint64
+	mutRegistration     sync.Mutex
+	handlers            atomic.Value
+
+	mutWorkers sync.RWMutex
+	queues     []chan addedDataEvent
+	chClose    chan struct{}
+	wgWorkers  sync.WaitGroup
+	isStarted  bool
+	isClosed   bool
+}
+
+func newAddedDataNotifier() *addedDataNotifier {
+	notifier := &addedDataNotifier{
+		chClose: make(chan struct{}),
+	}
+	notifier.handlers.Store(make([]addedDataHandler, 0))
+
+	return notifier
+}
+
+func (notifier *addedDataNotifier) registerHandler(handler func(key []byte, value interface{}), id string) {
+	notifier.mutRegistration.Lock()
+	oldHandlers := notifier.getHandlers()
+	newHandlers := make([]addedDataHandler, 0, len(oldHandlers)+1)
+	for _, item := range oldHandlers {
+		if item.id != id {
+			newHandlers = append(newHandlers, item)
+		}
+	}
+	newHandlers = append(newHandlers, addedDataHandler{id: id, handler: handler})
+	notifier.handlers.Store(newHandlers)
+	notifier.mutRegistration.Unlock()
+
+	notifier.startIfNecessary()
+}
+
+func (notifier *addedDataNotifier) unregisterHandler(id string) {
+	notifier.mutRegistration.Lock()
+	defer notifier.mutRegistration.Unlock()
+
+	oldHandlers := notifier.getHandlers()
+	newHandlers := make([]addedDataHandler, 0, len(oldHandlers))
+	for _, item := range oldHandlers {
+		if item.id != id {
+			newHandlers = append(newHandlers, item)
+		}
+	}
+	notifier.handlers.Store(newHandlers)
+}
+
+func (notifier *addedDataNotifier) getHandlers() []addedDataHandler {
+	return notifier.handlers.Load().([]addedDataHandler)
+}
+
+func (notifier *addedDataNotifier) startIfNecessary() {
+	notifier.mutWorkers.Lock()
+	defer notifier.mutWorkers.Unlock()
+
+	if notifier.isStarted || notifier.isClosed {
+		return
+	}
+
+	notifier.isStarted = true
+	notifier.queues = make([]chan addedDataEvent, numAddedDataWorkers)
+	notifier.wgWorkers.Add(numAddedDataWorkers)
+
+	for i := range notifier.queues {
+		notifier.queues[i] = make(chan addedDataEvent, addedDataQueueSize)
+		go notifier.processEvents(notifier.queues[i])
+	}
+}
+
+// notify queues the event to the worker responsible for the key (or, if its queue is full, dispatches it on a new goroutine).
+// It never blocks, and it should not be called while holding the locks of the cache.
+func (notifier *addedDataNotifier) notify(key []byte, value interface{}) {
+	if len(notifier.getHandlers()) == 0 {
+		return
+	}
+
+	queue, ok := notifier.getQueue(key)
+	if !ok {
+		return
+	}
+
+	event := addedDataEvent{key: key, value: value}
+	select {
+	case queue <- event:
+	case <-notifier.chClose:
+	default:
+		atomic.AddUint64(&notifier.numOverflowedEvents, 1)
+		go notifier.dispatch(event)
+	}
+}
+
+func (notifier *addedDataNotifier) getQueue(key []byte) (chan addedDataEvent, bool) {
+	notifier.mutWorkers.RLock()
+	defer notifier.mutWorkers.RUnlock()
+
+	if !notifier.isStarted || notifier.isClosed {
+		return nil, false
+	}
+
+	return notifier.queues[computeWorkerIndex(key)], true
+}
+
+// processEvents dispatches the events of the queue until the notifier is closed, then dispatches the remaining queued events.
+// The queues are never closed (thus the notifying callers are allowed to send without holding mutWorkers).
+func (notifier *addedDataNotifier) processEvents(queue chan addedDataEvent) {
+	defer notifier.wgWorkers.Done()
+
+	for {
+		select {
+		case event := <-queue:
+			notifier.dispatch(event)
+		case <-notifier.chClose:
+			notifier.drain(queue)
+			return
+		}
+	}
+}
+
+func (notifier *addedDataNotifier) drain(queue chan addedDataEvent) {
+	for {
+		select {
+		case event := <-queue:
+			notifier.dispatch(event)
+		default:
+			return
+		}
+	}
+}
+
+func (notifier *addedDataNotifier) dispatch(event addedDataEvent) {
+	for _, item := range notifier.getHandlers() {
+		item.handler(event.key, event.value)
+	}
+}
+
+func (notifier *addedDataNotifier) getNumOverflowedEvents() uint64 {
+	return atomic.LoadUint64(&notifier.numOverflowedEvents)
+}
+
+// close stops the workers, after the already queued events are dispatched. Calling close multiple times is allowed.
+func (notifier *addedDataNotifier) close() {
+	notifier.mutWorkers.Lock()
+	if notifier.isClosed {
+		notifier.mutWorkers.Unlock()
+		return
+	}
+
+	notifier.isClosed = true
+	close(notifier.chClose)
+	notifier.mutWorkers.Unlock()
+
+	notifier.wgWorkers.Wait()
+}
+
+func computeWorkerIndex(key []byte) int {
+	hasher := fnv.New32a()
+	_, _ = hasher.Write(key)
+
+	return int(hasher.Sum32() % numAddedDataWorkers)
+}
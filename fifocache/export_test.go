@@ -1,5 +1,14 @@
 package fifocache
 
 func (c *FIFOShardedCache) AddedDataHandlers() map[string]func(key []byte, value interface{}) {
-	return c.mapDataHandlers
+	handlers := make(map[string]func(key []byte, value interface{}))
+	for _, item := range c.addedDataNotifier.getHandlers() {
+		handlers[item.id] = item.handler
+	}
+
+	return handlers
+}
+
+func (c *FIFOShardedCache) NumOverflowedAddedDataEvents() uint64 {
+	return c.addedDataNotifier.getNumOverflowedEvents()
 }
@@ -4,31 +4,108 @@ import (
 	"sync"
 
 	cmap "github.com/multiversx/concurrent-map"
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
 	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/cacheStats"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/removalNotifier"
 	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 var _ types.Cacher = (*FIFOShardedCache)(nil)
+var _ types.InspectableCacher = (*FIFOShardedCache)(nil)
 
 var log = logger.GetOrCreate("storage/fifocache")
 
 // FIFOShardedCache implements a First In First Out eviction cache
 type FIFOShardedCache struct {
-	cache   *cmap.ConcurrentMap
-	maxsize int
+	// mutCache is only write-locked by Resize, when the underlying concurrent map is replaced
+	mutCache  sync.RWMutex
+	cache     *cmap.ConcurrentMap
+	maxsize   int
+	numShards int
 
-	mutAddedDataHandlers sync.RWMutex
-	mapDataHandlers      map[string]func(key []byte, value interface{})
+	addedDataNotifier *addedDataNotifier
+	removalNotifier   *removalNotifier.RemovalNotifier
+
+	// The underlying concurrent map evicts silently, thus the number of evictions is derived from
+	// the number of added and removed keys (see Stats)
+	stats           *cacheStats.StatsCollector
+	accessCounter   *cacheStats.AccessCounter
+	numAdded        atomic.Counter
+	numRemoved      atomic.Counter
+	lenAtStatsReset atomic.Counter
+
+	insertionSequence atomic.Counter
+
+	shardAccounts          []*shardAccount
+	maxSizeInBytesPerShard int64
+}
+
+// fifoEntry wraps a value held by the underlying concurrent map, along with its insertion sequence number and its (accounted) size.
+// The sequence is global (across shards), so that the oldest entry of the whole cache can be found (see RemoveOldest).
+type fifoEntry struct {
+	key      string
+	value    interface{}
+	sequence int64
+	size     int64
+	account  *shardAccount
+	released atomic.Flag
+}
+
+func (c *FIFOShardedCache) newEntry(key string, value interface{}, sizeInBytes int, account *shardAccount) *fifoEntry {
+	return &fifoEntry{
+		key:      key,
+		value:    value,
+		sequence: c.insertionSequence.Increment(),
+		size:     computeAccountedSize(sizeInBytes),
+		account:  account,
+	}
+}
+
+func unwrapValue(wrapped interface{}, ok bool) (interface{}, bool) {
+	if !ok {
+		return nil, false
+	}
+
+	entry, isEntry := wrapped.(*fifoEntry)
+	if !isEntry {
+		return wrapped, true
+	}
+
+	return entry.value, true
 }
 
 // NewShardedCache creates a new cache instance
 func NewShardedCache(size int, shards int) (*FIFOShardedCache, error) {
+	return NewShardedCacheWithSizeInBytes(size, shards, 0)
+}
+
+// NewShardedCacheWithSizeInBytes creates a new cache instance, bounded by the number of entries and by their cumulative size in bytes
+// (zero meaning no budget in bytes). The budget in bytes is distributed evenly among the shards: when a shard exceeds its share,
+// its oldest entries are evicted. The entries added with a non-positive size are accounted with a conservative default size.
+func NewShardedCacheWithSizeInBytes(size int, shards int, maxSizeInBytes int64) (*FIFOShardedCache, error) {
+	if maxSizeInBytes < 0 {
+		return nil, common.ErrCacheCapacityInvalid
+	}
+
 	cache := cmap.New(size, shards)
 	fifoShardedCache := &FIFOShardedCache{
-		cache:                cache,
-		maxsize:              size,
-		mutAddedDataHandlers: sync.RWMutex{},
-		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
+		cache:             cache,
+		maxsize:           size,
+		numShards:         shards,
+		addedDataNotifier: newAddedDataNotifier(),
+		removalNotifier:   removalNotifier.NewRemovalNotifier(),
+		stats:             cacheStats.NewStatsCollector(),
+		accessCounter:     cacheStats.NewAccessCounter(),
+		shardAccounts:     newShardAccounts(shards),
+	}
+
+	if maxSizeInBytes > 0 {
+		fifoShardedCache.maxSizeInBytesPerShard = maxSizeInBytes / int64(shards)
+		if fifoShardedCache.maxSizeInBytesPerShard == 0 {
+			fifoShardedCache.maxSizeInBytesPerShard = 1
+		}
 	}
 
 	return fifoShardedCache, nil
@@ -36,85 +113,273 @@ func NewShardedCache(size int, shards int) (*FIFOShardedCache, error) {
 
 // Clear is used to completely clear the cache.
 func (c *FIFOShardedCache) Clear() {
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
 	keys := c.cache.Keys()
 	for _, key := range keys {
-		c.cache.Remove(key)
+		c.removeWithReason(key, types.RemovalReasonCleared)
 	}
 }
 
 // Put adds a value to the cache.  Returns true if an eviction occurred.
-// the int parameter for size is not used as, for now, fifo sharded cache can not count for its contained data size
-func (c *FIFOShardedCache) Put(key []byte, value interface{}, _ int) (evicted bool) {
-	c.cache.Set(string(key), value)
-	c.callAddedDataHandlers(key, value)
+// The size in bytes is accounted (see SizeInBytesContained) and, if a budget in bytes is set, enforced for the shard of the key.
+func (c *FIFOShardedCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	c.mutCache.RLock()
+	account := c.getShardAccount(string(key))
+	account.mutAdd.Lock()
+
+	entry := c.newEntry(string(key), value, sizeInBytes, account)
+	previous, isOldKey := c.cache.Get(string(key))
+	c.cache.Set(string(key), entry)
+	c.recordPut(!isOldKey)
+	if isOldKey {
+		releaseWrapped(previous)
+	}
+
+	c.addToAccount(account, entry)
+	account.mutAdd.Unlock()
+	c.mutCache.RUnlock()
+
+	c.addedDataNotifier.notify(key, value)
 
 	return true
 }
 
-// RegisterHandler registers a new handler to be called when a new data is added
+// PutBulk adds the provided entries to the cache. The entries are grouped by shard, and the lock of each shard is acquired
+// only once, while the budget in bytes (if any) is enforced once per shard, after all its entries have been added.
+// The added data handlers are notified after releasing the locks, in the order of the entries.
+// Returns, for each entry, whether an eviction occurred (as Put does).
+func (c *FIFOShardedCache) PutBulk(entries []types.KeyValueSizePair) []bool {
+	entriesByShard := make([][]types.KeyValueSizePair, len(c.shardAccounts))
+	for _, entry := range entries {
+		shardIndex := c.getShardIndex(string(entry.Key))
+		entriesByShard[shardIndex] = append(entriesByShard[shardIndex], entry)
+	}
+
+	c.mutCache.RLock()
+	for shardIndex, shardEntries := range entriesByShard {
+		if len(shardEntries) > 0 {
+			c.putBulkInShard(c.shardAccounts[shardIndex], shardEntries)
+		}
+	}
+	c.mutCache.RUnlock()
+
+	evicted := make([]bool, len(entries))
+	for i, entry := range entries {
+		c.addedDataNotifier.notify(entry.Key, entry.Value)
+		evicted[i] = true
+	}
+
+	return evicted
+}
+
+// This function should only be called under the (already acquired) c.mutCache
+func (c *FIFOShardedCache) putBulkInShard(account *shardAccount, entries []types.KeyValueSizePair) {
+	account.mutAdd.Lock()
+	defer account.mutAdd.Unlock()
+
+	for _, kv := range entries {
+		entry := c.newEntry(string(kv.Key), kv.Value, kv.SizeInBytes, account)
+		previous, isOldKey := c.cache.Get(entry.key)
+		c.cache.Set(entry.key, entry)
+		c.recordPut(!isOldKey)
+		if isOldKey {
+			releaseWrapped(previous)
+		}
+
+		account.entries.PushBack(entry)
+		account.numBytes.Add(entry.size)
+	}
+
+	c.notifyEvicted(c.applySizeConstraints(account))
+}
+
+// This function should only be called under the (already acquired) c.mutCache and account.mutAdd
+func (c *FIFOShardedCache) addToAccount(account *shardAccount, entry *fifoEntry) {
+	account.entries.PushBack(entry)
+	account.numBytes.Add(entry.size)
+
+	c.notifyEvicted(c.applySizeConstraints(account))
+}
+
+func (c *FIFOShardedCache) notifyEvicted(evicted []*fifoEntry) {
+	for _, evictedEntry := range evicted {
+		c.removalNotifier.Notify([]byte(evictedEntry.key), evictedEntry.value, types.RemovalReasonEvicted)
+	}
+}
+
+// RegisterHandler registers a new handler to be called when a new data is added.
+// The handlers are called on a pool of worker goroutines, in order for each key (see addedDataNotifier).
 func (c *FIFOShardedCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
 	if handler == nil {
 		log.Error("attempt to register a nil handler to a cacher object")
 		return
 	}
 
-	c.mutAddedDataHandlers.Lock()
-	c.mapDataHandlers[id] = handler
-	c.mutAddedDataHandlers.Unlock()
+	c.addedDataNotifier.registerHandler(handler, id)
 }
 
-// UnRegisterHandler removes the handler from the list
+// UnRegisterHandler removes the handler from the list. The handler is not called anymore, not even for the already queued data.
 func (c *FIFOShardedCache) UnRegisterHandler(id string) {
-	c.mutAddedDataHandlers.Lock()
-	delete(c.mapDataHandlers, id)
-	c.mutAddedDataHandlers.Unlock()
+	c.addedDataNotifier.unregisterHandler(id)
 }
 
 // Get looks up a key's value from the cache.
 func (c *FIFOShardedCache) Get(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	c.accessCounter.RecordAccess(key)
+
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
+	value, ok = unwrapValue(c.cache.Get(string(key)))
+	c.stats.RecordLookup(ok)
+
+	return value, ok
 }
 
 // Has checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *FIFOShardedCache) Has(key []byte) bool {
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
 	return c.cache.Has(string(key))
 }
 
 // Peek returns the key value (or undefined if not found) without updating
-// the "recently used"-ness of the key.
+// the "recently used"-ness of the key. The FIFO order, the counters and the handlers of the cache are not affected.
+// Only the shard holding the key is (read) locked.
 func (c *FIFOShardedCache) Peek(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
+	// The concurrent map only read-locks the shard of the key
+	return unwrapValue(c.cache.Get(string(key)))
 }
 
 // HasOrAdd checks if a key is in the cache without updating the
 // recent-ness or deleting it for being stale, and if not, adds the value.
 // Returns whether the item existed before and whether it has been added.
-func (c *FIFOShardedCache) HasOrAdd(key []byte, value interface{}, _ int) (has, added bool) {
-	added = c.cache.SetIfAbsent(string(key), value)
+func (c *FIFOShardedCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	c.mutCache.RLock()
+	account := c.getShardAccount(string(key))
+	account.mutAdd.Lock()
 
+	entry := c.newEntry(string(key), value, sizeInBytes, account)
+	added = c.cache.SetIfAbsent(string(key), entry)
 	if added {
-		c.callAddedDataHandlers(key, value)
+		c.recordPut(true)
+		c.addToAccount(account, entry)
 	}
 
-	return !added, added
-}
+	account.mutAdd.Unlock()
+	c.mutCache.RUnlock()
 
-func (c *FIFOShardedCache) callAddedDataHandlers(key []byte, value interface{}) {
-	c.mutAddedDataHandlers.RLock()
-	for _, handler := range c.mapDataHandlers {
-		go handler(key, value)
+	if added {
+		c.addedDataNotifier.notify(key, value)
 	}
-	c.mutAddedDataHandlers.RUnlock()
+
+	return !added, added
 }
 
 // Remove removes the provided key from the cache.
 func (c *FIFOShardedCache) Remove(key []byte) {
-	c.cache.Remove(string(key))
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
+	c.removeWithReason(string(key), types.RemovalReasonRemoved)
+}
+
+// This function should only be called under the (already acquired) c.mutCache
+func (c *FIFOShardedCache) removeWithReason(key string, reason types.RemovalReason) {
+	// The removal is serialized with the additions into the shard, so that it is not mistaken for an eviction (see applySizeConstraints)
+	account := c.getShardAccount(key)
+	account.mutAdd.Lock()
+	wrapped, ok := c.cache.Pop(key)
+	value, ok := unwrapValue(wrapped, ok)
+	if ok {
+		releaseWrapped(wrapped)
+	}
+	account.mutAdd.Unlock()
+
+	if ok {
+		c.numRemoved.Increment()
+		c.removalNotifier.Notify([]byte(key), value, reason)
+	}
+}
+
+// RemoveOldest removes the oldest entry of the whole cache (across all shards), which is notified to the removal handlers
+// with the "explicit-oldest" reason. It returns the removed entry, or ok=false if the cache is empty.
+// Useful for consumers implementing their own admission control, in order to free exactly one slot.
+// Finding the oldest entry requires a scan over all the shards.
+func (c *FIFOShardedCache) RemoveOldest() (key []byte, value interface{}, ok bool) {
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
+	for {
+		oldestKey, oldestEntry := c.findOldestEntry()
+		if oldestEntry == nil {
+			return nil, nil, false
+		}
+
+		account := c.getShardAccount(oldestKey)
+		account.mutAdd.Lock()
+		removed := c.cache.RemoveCb(oldestKey, func(_ string, current interface{}, exists bool) bool {
+			return exists && current == oldestEntry
+		})
+		if removed {
+			oldestEntry.release()
+		}
+		account.mutAdd.Unlock()
+
+		if removed {
+			c.numRemoved.Increment()
+			c.removalNotifier.Notify([]byte(oldestKey), oldestEntry.value, types.RemovalReasonExplicitOldest)
+			return []byte(oldestKey), oldestEntry.value, true
+		}
+
+		// Removed or replaced in the meantime, look again
+	}
+}
+
+// This function should only be called under the (already acquired) c.mutCache
+func (c *FIFOShardedCache) findOldestEntry() (string, *fifoEntry) {
+	var oldestKey string
+	var oldestEntry *fifoEntry
+
+	c.cache.IterCb(func(key string, wrapped interface{}) {
+		entry, isEntry := wrapped.(*fifoEntry)
+		if !isEntry {
+			return
+		}
+		if oldestEntry == nil || entry.sequence < oldestEntry.sequence {
+			oldestKey = key
+			oldestEntry = entry
+		}
+	})
+
+	return oldestKey, oldestEntry
+}
+
+// RegisterHandlerForRemoval registers a new handler to be called when an entry is removed, cleared or evicted. The evictions performed
+// by the underlying concurrent map (when a shard is full) and the ones due to the budget in bytes are notified with the "evicted" reason,
+// while the entries dropped by shrinking the cache (see Resize) are notified with the "resized" reason.
+// The handlers are called on a dedicated goroutine, outside the internal locks of the cache (see removalNotifier.RemovalNotifier).
+func (c *FIFOShardedCache) RegisterHandlerForRemoval(handler func(key []byte, value interface{}, reason types.RemovalReason), id string) {
+	c.removalNotifier.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandlerForRemoval removes the removal handler having the given id
+func (c *FIFOShardedCache) UnRegisterHandlerForRemoval(id string) {
+	c.removalNotifier.UnRegisterHandler(id)
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
 func (c *FIFOShardedCache) Keys() [][]byte {
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
 	res := c.cache.Keys()
 	r := make([][]byte, len(res))
 
@@ -125,23 +390,148 @@ func (c *FIFOShardedCache) Keys() [][]byte {
 	return r
 }
 
+// ForEach iterates over the entries of the cache, until the callback returns false. Each shard is iterated over a snapshot
+// of its entries (taken under the lock of the shard), thus the callback is free to mutate the cache. There is no ordering guarantee.
+func (c *FIFOShardedCache) ForEach(fn func(key []byte, value interface{}) bool) {
+	if fn == nil {
+		return
+	}
+
+	c.mutCache.RLock()
+	cache := c.cache
+	c.mutCache.RUnlock()
+
+	// The buffered iterator holds all the entries, so breaking early does not leave any blocked goroutine behind
+	for tuple := range cache.IterBuffered() {
+		value, _ := unwrapValue(tuple.Val, true)
+		if !fn([]byte(tuple.Key), value) {
+			return
+		}
+	}
+}
+
 // Len returns the number of items in the cache.
 func (c *FIFOShardedCache) Len() int {
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
 	return c.cache.Count()
 }
 
-// SizeInBytesContained returns 0
+// SizeInBytesContained returns the (accounted) size in bytes of the entries in the cache. Since the evictions of the
+// underlying concurrent map are detected right after the fact, the returned value might briefly overestimate the actual size.
 func (c *FIFOShardedCache) SizeInBytesContained() uint64 {
-	return 0
+	total := int64(0)
+	for _, account := range c.shardAccounts {
+		total += account.numBytes.Get()
+	}
+
+	if total < 0 {
+		return 0
+	}
+
+	return uint64(total)
 }
 
 // MaxSize returns the maximum number of items which can be stored in cache.
 func (c *FIFOShardedCache) MaxSize() int {
+	c.mutCache.RLock()
+	defer c.mutCache.RUnlock()
+
 	return c.maxsize
 }
 
-// Close does nothing for this cacher implementation
+// Resize changes the capacity of the cache, distributing it proportionally among the shards.
+// Each shard keeps its newest entries, while the dropped ones are notified to the removal handlers (with the "resized" reason).
+// Growing the cache does not drop any entry. It returns the number of dropped entries.
+func (c *FIFOShardedCache) Resize(newSize int) (evicted int, err error) {
+	if c == nil {
+		return 0, common.ErrNilCacher
+	}
+	if newSize < 1 {
+		return 0, common.ErrCacheSizeInvalid
+	}
+
+	c.mutCache.Lock()
+	defer c.mutCache.Unlock()
+
+	oldCache := c.cache
+	newCache := cmap.New(newSize, c.numShards)
+
+	// The keys of each shard are provided from oldest to newest, and the new map has the same number of shards (thus the same
+	// shard for each key), so the FIFO order of each shard is preserved
+	keys := oldCache.Keys()
+	for _, key := range keys {
+		value, ok := oldCache.Get(key)
+		if ok {
+			newCache.Set(key, value)
+		}
+	}
+
+	for _, key := range keys {
+		if newCache.Has(key) {
+			continue
+		}
+
+		wrapped, _ := oldCache.Get(key)
+		releaseWrapped(wrapped)
+		value, _ := unwrapValue(wrapped, true)
+		c.removalNotifier.Notify([]byte(key), value, types.RemovalReasonResized)
+		evicted++
+	}
+
+	c.cache = newCache
+	c.maxsize = newSize
+
+	return evicted, nil
+}
+
+func (c *FIFOShardedCache) recordPut(isNewKey bool) {
+	c.stats.RecordPut()
+	if isNewKey {
+		c.numAdded.Increment()
+	}
+}
+
+// Stats returns the usage statistics of the cache. Hits and misses are recorded by Get.
+// Since the underlying concurrent map evicts silently, the number of evictions is an estimation, computed as
+// the number of added keys minus the number of removed keys and the growth in length (since the last reset).
+func (c *FIFOShardedCache) Stats() types.CacheStats {
+	length := c.Len()
+	stats := c.stats.Stats(length, c.SizeInBytesContained())
+
+	numEvictions := c.numAdded.Get() - c.numRemoved.Get() - (int64(length) - c.lenAtStatsReset.Get())
+	if numEvictions > 0 {
+		stats.Evictions = uint64(numEvictions)
+	}
+
+	return stats
+}
+
+// ResetStats resets the usage statistics of the cache
+func (c *FIFOShardedCache) ResetStats() {
+	c.stats.Reset()
+	c.numAdded.Reset()
+	c.numRemoved.Reset()
+	c.lenAtStatsReset.Set(int64(c.Len()))
+}
+
+// SetAccessCountingEnabled enables (or disables) the counting of the reads of each key (see GetAccessCounts), disabled by default
+func (c *FIFOShardedCache) SetAccessCountingEnabled(enabled bool) {
+	c.accessCounter.SetEnabled(enabled)
+}
+
+// GetAccessCounts returns how many times each key has been read (see Get) since the previous call, sorted by count (descending),
+// then resets the counters. The keys are identified by their hash (see cacheStats.HashOfKey).
+// At most cacheStats.MaxTrackedKeys keys are tracked between two calls. Nothing is counted unless enabled, see SetAccessCountingEnabled.
+func (c *FIFOShardedCache) GetAccessCounts() []types.KeyAccessCount {
+	return c.accessCounter.GetAccessCounts()
+}
+
+// Close stops the notifications (both for the added and for the removed data)
 func (c *FIFOShardedCache) Close() error {
+	c.addedDataNotifier.close()
+	c.removalNotifier.Close()
 	return nil
 }
 
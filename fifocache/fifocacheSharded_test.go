@@ -3,11 +3,15 @@ package fifocache_test
 import (
 	"bytes"
 	"fmt"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
+	"github.com/multiversx/mx-chain-storage-go/cacheStats"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/fifocache"
+	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -133,6 +137,58 @@ func TestFIFOShardedCache_PeekPresent(t *testing.T) {
 	assert.Equal(t, val, v, "expected to find %s but found %s", val, v)
 }
 
+func TestFIFOShardedCache_PeekShouldNotAffectOrder(t *testing.T) {
+	// A shard of size 3 holds at most 2 items
+	c, _ := fifocache.NewShardedCache(3, 1)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+
+	_, ok := c.Peek([]byte("a"))
+	assert.True(t, ok)
+
+	// "a" is still the oldest, thus evicted
+	c.Put([]byte("c"), "c", 0)
+	_, ok = c.Peek([]byte("a"))
+	assert.False(t, ok)
+	assert.True(t, c.Has([]byte("b")))
+	assert.True(t, c.Has([]byte("c")))
+}
+
+func TestFIFOShardedCache_PeekConcurrentWithEviction(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(16, 2)
+
+	numOperations := 10000
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < numOperations; i++ {
+			key := []byte(fmt.Sprintf("key%d", i))
+			c.Put(key, string(key), 0)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < numOperations; i++ {
+			key := []byte(fmt.Sprintf("key%d", i))
+			value, ok := c.Peek(key)
+			if ok {
+				assert.Equal(t, string(key), value)
+			} else {
+				assert.Nil(t, value)
+			}
+		}
+	}()
+
+	wg.Wait()
+	assert.LessOrEqual(t, c.Len(), 16)
+}
+
 func TestFIFOShardedCache_HasOrAddNotPresent(t *testing.T) {
 	key, val := []byte("key7"), []byte("value7")
 	c, err := fifocache.NewShardedCache(10, 2)
@@ -373,3 +429,809 @@ func TestFIFOShardedCache_CacherRegisterHasOrAddAddedDataHandlerNotAddedShouldNo
 
 	assert.Equal(t, 1, len(c.AddedDataHandlers()))
 }
+
+func TestFIFOShardedCache_AddedDataHandlersShouldPreserveOrderPerKey(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 2)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	// The events fit the queue of a worker (otherwise, the overflowed events are not ordered anymore)
+	numPuts := 500
+	mut := sync.Mutex{}
+	valuesByKey := make(map[string][]int)
+	wg := sync.WaitGroup{}
+	wg.Add(2 * numPuts)
+
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		mut.Lock()
+		valuesByKey[string(key)] = append(valuesByKey[string(key)], value.(int))
+		mut.Unlock()
+		wg.Done()
+	}, "recorder")
+
+	for i := 0; i < numPuts; i++ {
+		c.Put([]byte("a"), i, 0)
+		c.Put([]byte("b"), i, 0)
+	}
+
+	wg.Wait()
+
+	for _, key := range []string{"a", "b"} {
+		values := valuesByKey[key]
+		assert.Equal(t, numPuts, len(values))
+		for i, value := range values {
+			assert.Equal(t, i, value)
+		}
+	}
+}
+
+func TestFIFOShardedCache_AddedDataHandlerShouldBeAllowedToAddDataWhileTheQueueIsFull(t *testing.T) {
+	t.Parallel()
+
+	numPuts := 5000
+	c, _ := fifocache.NewShardedCache(4*numPuts, 2)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	blocked := make(chan struct{})
+	unblock := make(chan struct{})
+	onceBlock := sync.Once{}
+	wg := sync.WaitGroup{}
+	wg.Add(2 * numPuts)
+
+	// The handler re-enters the cache, and (at first) blocks its worker, until the queues are full
+	c.RegisterHandler(func(key []byte, _ interface{}) {
+		defer wg.Done()
+
+		if strings.HasSuffix(string(key), "-derived") {
+			return
+		}
+
+		onceBlock.Do(func() {
+			close(blocked)
+			<-unblock
+		})
+		c.Put([]byte(string(key)+"-derived"), 0, 0)
+	}, "reentrant")
+
+	for i := 0; i < numPuts; i++ {
+		c.Put([]byte(fmt.Sprintf("key-%d", i)), i, 0)
+	}
+	<-blocked
+	close(unblock)
+
+	chDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(chDone)
+	}()
+
+	select {
+	case <-chDone:
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "timeout while waiting for the added data handlers")
+	}
+
+	assert.Equal(t, 2*numPuts, c.Len())
+	assert.Greater(t, c.NumOverflowedAddedDataEvents(), uint64(0))
+}
+
+func TestFIFOShardedCache_UnRegisterHandlerShouldTakeEffectPromptly(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 2)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	blocked := make(chan struct{})
+	unblock := make(chan struct{})
+	numCallsOfRemoved := uint32(0)
+	mut := sync.Mutex{}
+
+	c.RegisterHandler(func(_ []byte, _ interface{}) {
+		mut.Lock()
+		numCallsOfRemoved++
+		mut.Unlock()
+	}, "removed")
+	c.RegisterHandler(func(_ []byte, value interface{}) {
+		if value.(int) == 0 {
+			close(blocked)
+			<-unblock
+		}
+	}, "blocker")
+
+	// The worker gets blocked on the first event, while the next events (of the same key) are queued
+	for i := 0; i < 10; i++ {
+		c.Put([]byte("key"), i, 0)
+	}
+	<-blocked
+
+	c.UnRegisterHandler("removed")
+	assert.Equal(t, 1, len(c.AddedDataHandlers()))
+	close(unblock)
+
+	// Close waits for the queued events to be dispatched
+	_ = c.Close()
+
+	// Only the first event reached the unregistered handler
+	mut.Lock()
+	assert.Equal(t, uint32(1), numCallsOfRemoved)
+	mut.Unlock()
+}
+
+func TestFIFOShardedCache_CloseShouldStopAddedDataNotifications(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 2)
+	c.RegisterHandler(func(_ []byte, _ interface{}) {
+		assert.Fail(t, "should have not been called")
+	}, "handler")
+
+	err := c.Close()
+	assert.Nil(t, err)
+	err = c.Close()
+	assert.Nil(t, err)
+
+	c.Put([]byte("a"), "a", 0)
+	c.HasOrAdd([]byte("b"), "b", 0)
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestFIFOShardedCache_RegisterHandlerForRemoval(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(10, 2)
+
+	removals := make(map[string]types.RemovalReason)
+	c.RegisterHandlerForRemoval(func(key []byte, value interface{}, reason types.RemovalReason) {
+		assert.Equal(t, string(key), value)
+		removals[string(key)] = reason
+	}, "recorder")
+	c.RegisterHandlerForRemoval(func(key []byte, _ interface{}, _ types.RemovalReason) {
+		assert.Fail(t, "should have been unregistered", string(key))
+	}, "unregistered")
+	c.UnRegisterHandlerForRemoval("unregistered")
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Remove([]byte("a"))
+	c.Remove([]byte("missing"))
+	c.Clear()
+
+	// Close dispatches the pending notifications
+	err := c.Close()
+	assert.Nil(t, err)
+
+	expected := map[string]types.RemovalReason{
+		"a": types.RemovalReasonRemoved,
+		"b": types.RemovalReasonCleared,
+	}
+	assert.Equal(t, expected, removals)
+}
+
+func TestFIFOShardedCache_RegisterHandlerForRemovalShouldNotifyEvictionsOfConcurrentMap(t *testing.T) {
+	c, _ := fifocache.NewShardedCache(4, 1)
+
+	mut := sync.Mutex{}
+	evicted := make([]string, 0)
+	removed := make([]string, 0)
+	c.RegisterHandlerForRemoval(func(key []byte, value interface{}, reason types.RemovalReason) {
+		assert.Equal(t, string(key), value)
+
+		mut.Lock()
+		defer mut.Unlock()
+
+		switch reason {
+		case types.RemovalReasonEvicted:
+			evicted = append(evicted, string(key))
+		case types.RemovalReasonRemoved:
+			removed = append(removed, string(key))
+		default:
+			assert.Fail(t, "unexpected reason", reason)
+		}
+	}, "recorder")
+
+	// A shard of size N holds N-1 items
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"}
+	for _, key := range keys {
+		c.Put([]byte(key), key, 0)
+	}
+	assert.Equal(t, [][]byte{[]byte("k5"), []byte("k6"), []byte("k7")}, c.Keys())
+
+	// Re-adding a key makes it the newest, thus the oldest one is evicted
+	c.Put([]byte("k7"), "k7", 0)
+	c.Remove([]byte("k6"))
+	assert.Equal(t, [][]byte{[]byte("k7")}, c.Keys())
+
+	// Close dispatches the pending notifications
+	err := c.Close()
+	assert.Nil(t, err)
+
+	assert.Equal(t, []string{"k0", "k1", "k2", "k3", "k4", "k5"}, evicted)
+	assert.Equal(t, []string{"k6"}, removed)
+}
+
+func TestFIFOShardedCache_RemoveOldest(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+
+	key, value, ok := c.RemoveOldest()
+	assert.False(t, ok)
+	assert.Nil(t, key)
+	assert.Nil(t, value)
+
+	removals := make([]string, 0)
+	c.RegisterHandlerForRemoval(func(key []byte, value interface{}, reason types.RemovalReason) {
+		assert.Equal(t, string(key), value)
+		assert.Equal(t, types.RemovalReasonExplicitOldest, reason)
+		removals = append(removals, string(key))
+	}, "recorder")
+
+	// The keys are spread over the shards, while the order of insertion is global
+	keys := []string{"k0", "k1", "k2", "k3", "k4", "k5", "k6", "k7"}
+	for _, key := range keys {
+		c.Put([]byte(key), key, 0)
+	}
+	// Re-adding a key makes it the newest
+	c.Put([]byte("k0"), "k0", 0)
+	// While HasOrAdd on an existing key does not
+	_, _ = c.HasOrAdd([]byte("k1"), "k1", 0)
+
+	expectedOrder := []string{"k1", "k2", "k3", "k4", "k5", "k6", "k7", "k0"}
+	for _, expectedKey := range expectedOrder {
+		key, value, ok = c.RemoveOldest()
+		assert.True(t, ok)
+		assert.Equal(t, []byte(expectedKey), key)
+		assert.Equal(t, expectedKey, value)
+	}
+
+	_, _, ok = c.RemoveOldest()
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+
+	// Close dispatches the pending notifications
+	err := c.Close()
+	assert.Nil(t, err)
+	assert.Equal(t, expectedOrder, removals)
+}
+
+func TestFIFOShardedCache_RemoveOldestAfterResize(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+	for i := 0; i < 10; i++ {
+		c.Put([]byte(fmt.Sprintf("k%d", i)), i, 0)
+	}
+
+	_, err := c.Resize(200)
+	assert.Nil(t, err)
+
+	key, value, ok := c.RemoveOldest()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("k0"), key)
+	assert.Equal(t, 0, value)
+	assert.Equal(t, 9, c.Len())
+}
+
+func TestFIFOShardedCache_StatsAndResetStats(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(3, 1)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Put([]byte("b"), "b", 0)
+	_, _ = c.HasOrAdd([]byte("c"), "c", 0)
+	c.Remove([]byte("c"))
+	_, _ = c.HasOrAdd([]byte("d"), "d", 0)
+	_, _ = c.HasOrAdd([]byte("e"), "e", 0)
+
+	_, ok := c.Get([]byte("e"))
+	assert.True(t, ok)
+	_, ok = c.Get([]byte("a"))
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(6), stats.Puts)
+	assert.Equal(t, uint64(2), stats.Evictions)
+	assert.Equal(t, 2, stats.Len)
+
+	c.ResetStats()
+	_, _ = c.HasOrAdd([]byte("f"), "f", 0)
+
+	stats = c.Stats()
+	assert.Equal(t, uint64(0), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Puts)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 2, stats.Len)
+}
+
+func TestFIFOShardedCache_Resize(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(5, 1)
+
+	mut := sync.Mutex{}
+	removals := make(map[string]types.RemovalReason)
+	c.RegisterHandlerForRemoval(func(key []byte, _ interface{}, reason types.RemovalReason) {
+		mut.Lock()
+		removals[string(key)] = reason
+		mut.Unlock()
+	}, "recorder")
+
+	// A shard of size N holds N-1 items
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Put([]byte("c"), "c", 0)
+	c.Put([]byte("d"), "d", 0)
+
+	evicted, err := c.Resize(0)
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+	assert.Equal(t, 0, evicted)
+
+	evicted, err = c.Resize(10)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, evicted)
+	assert.Equal(t, 10, c.MaxSize())
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")}, c.Keys())
+
+	evicted, err = c.Resize(3)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, evicted)
+	assert.Equal(t, 3, c.MaxSize())
+	assert.Equal(t, [][]byte{[]byte("c"), []byte("d")}, c.Keys())
+
+	value, ok := c.Get([]byte("d"))
+	assert.True(t, ok)
+	assert.Equal(t, "d", value)
+
+	// The eviction performed by the concurrent map is notified, as well
+	c.Put([]byte("e"), "e", 0)
+	assert.Equal(t, [][]byte{[]byte("d"), []byte("e")}, c.Keys())
+
+	// Close dispatches the pending notifications
+	_ = c.Close()
+
+	mut.Lock()
+	expected := map[string]types.RemovalReason{
+		"a": types.RemovalReasonResized,
+		"b": types.RemovalReasonResized,
+		"c": types.RemovalReasonEvicted,
+	}
+	assert.Equal(t, expected, removals)
+	mut.Unlock()
+}
+
+func TestFIFOShardedCache_ResizeConcurrentWithOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			c.Put(key, i, 0)
+			_, _ = c.Get(key)
+			_ = c.Len()
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 50; i++ {
+			_, _ = c.Resize(50 + i)
+		}
+	}()
+
+	wg.Wait()
+	assert.Equal(t, 99, c.MaxSize())
+}
+
+func TestFIFOShardedCache_ResizeOnNilCacheShouldErr(t *testing.T) {
+	t.Parallel()
+
+	var cache *fifocache.FIFOShardedCache
+	assert.True(t, cache.IsInterfaceNil())
+
+	evicted, err := cache.Resize(10)
+	assert.Equal(t, common.ErrNilCacher, err)
+	assert.Zero(t, evicted)
+}
+
+func TestFIFOShardedCache_ForEach(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+	c.ForEach(nil)
+
+	for i := 0; i < 10; i++ {
+		c.Put([]byte(fmt.Sprintf("key-%d", i)), i, 0)
+	}
+
+	entries := make(map[string]interface{})
+	c.ForEach(func(key []byte, value interface{}) bool {
+		entries[string(key)] = value
+		return true
+	})
+	assert.Equal(t, 10, len(entries))
+	for i := 0; i < 10; i++ {
+		assert.Equal(t, i, entries[fmt.Sprintf("key-%d", i)])
+	}
+
+	numVisited := 0
+	c.ForEach(func(key []byte, value interface{}) bool {
+		numVisited++
+		return numVisited < 3
+	})
+	assert.Equal(t, 3, numVisited)
+
+	// Mutating (and even resizing) the cache from within the callback does not deadlock
+	c.ForEach(func(key []byte, value interface{}) bool {
+		c.Remove(key)
+		_, _ = c.Resize(50)
+		return true
+	})
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestFIFOShardedCache_ForEachConcurrentWithOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(100, 4)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			c.Put([]byte(fmt.Sprintf("key-%d", i)), i, 0)
+			c.Remove([]byte(fmt.Sprintf("key-%d", i/2)))
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			c.ForEach(func(key []byte, value interface{}) bool {
+				assert.NotNil(t, value)
+				return true
+			})
+		}
+	}()
+
+	wg.Wait()
+}
+
+func BenchmarkFIFOShardedCache_PutWithAddedDataHandlers(b *testing.B) {
+	c, _ := fifocache.NewShardedCache(100000, 16)
+	defer func() {
+		_ = c.Close()
+	}()
+
+	numHandlers := 3
+	for i := 0; i < numHandlers; i++ {
+		c.RegisterHandler(func(_ []byte, _ interface{}) {}, fmt.Sprintf("handler-%d", i))
+	}
+
+	numPutters := 8
+	numPutsPerPutter := b.N/numPutters + 1
+
+	b.ResetTimer()
+
+	wg := sync.WaitGroup{}
+	wg.Add(numPutters)
+	for i := 0; i < numPutters; i++ {
+		go func(putterIndex int) {
+			defer wg.Done()
+
+			for j := 0; j < numPutsPerPutter; j++ {
+				key := []byte(fmt.Sprintf("key-%d-%d", putterIndex, j))
+				c.Put(key, j, 0)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestNewShardedCacheWithSizeInBytes_NegativeSizeInBytesShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := fifocache.NewShardedCacheWithSizeInBytes(10, 2, -1)
+	assert.Nil(t, c)
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+}
+
+func TestFIFOShardedCache_SizeInBytesContained(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(10, 1)
+
+	c.Put([]byte("a"), "a", 100)
+	_, _ = c.HasOrAdd([]byte("b"), "b", 200)
+	_, _ = c.HasOrAdd([]byte("b"), "b", 5000)
+	assert.Equal(t, uint64(300), c.SizeInBytesContained())
+
+	// Overwrite
+	c.Put([]byte("a"), "a", 50)
+	assert.Equal(t, uint64(250), c.SizeInBytesContained())
+
+	// Unsized entries are accounted with a default size
+	c.Put([]byte("c"), "c", 0)
+	assert.Equal(t, uint64(250+1024), c.SizeInBytesContained())
+
+	c.Remove([]byte("c"))
+	assert.Equal(t, uint64(250), c.SizeInBytesContained())
+
+	_, _, ok := c.RemoveOldest()
+	assert.True(t, ok)
+	assert.Equal(t, uint64(50), c.SizeInBytesContained())
+
+	c.Clear()
+	assert.Equal(t, uint64(0), c.SizeInBytesContained())
+
+	// The entries silently evicted by the underlying map (when full) are not accounted anymore
+	for i := 0; i < 100; i++ {
+		c.Put([]byte(fmt.Sprintf("key-%d", i)), i, 1)
+	}
+
+	assert.Less(t, c.Len(), 100)
+	assert.Equal(t, uint64(c.Len()), c.SizeInBytesContained())
+}
+
+func TestFIFOShardedCache_MaxSizeInBytesShouldEvictOldest(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCacheWithSizeInBytes(100, 1, 1000)
+
+	removals := make(map[string]types.RemovalReason)
+	c.RegisterHandlerForRemoval(func(key []byte, _ interface{}, reason types.RemovalReason) {
+		removals[string(key)] = reason
+	}, "recorder")
+
+	c.Put([]byte("a"), "a", 400)
+	c.Put([]byte("b"), "b", 400)
+	c.Put([]byte("c"), "c", 400)
+	assert.Equal(t, 2, c.Len())
+	assert.False(t, c.Has([]byte("a")))
+	assert.Equal(t, uint64(800), c.SizeInBytesContained())
+
+	// Unsized entries cannot bypass the budget
+	c.Put([]byte("d"), "d", 0)
+	assert.Equal(t, 1, c.Len())
+	assert.True(t, c.Has([]byte("d")))
+	assert.Equal(t, uint64(1024), c.SizeInBytesContained())
+
+	// A single (large) entry is kept
+	_, _ = c.HasOrAdd([]byte("e"), "e", 5000)
+	assert.Equal(t, 1, c.Len())
+	assert.True(t, c.Has([]byte("e")))
+
+	err := c.Close()
+	assert.Nil(t, err)
+
+	expected := map[string]types.RemovalReason{
+		"a": types.RemovalReasonEvicted,
+		"b": types.RemovalReasonEvicted,
+		"c": types.RemovalReasonEvicted,
+		"d": types.RemovalReasonEvicted,
+	}
+	assert.Equal(t, expected, removals)
+}
+
+func TestFIFOShardedCache_MaxSizeInBytesIsDistributedAmongShards(t *testing.T) {
+	t.Parallel()
+
+	numShards := 4
+	c, _ := fifocache.NewShardedCacheWithSizeInBytes(1000, numShards, 4000)
+
+	for i := 0; i < 500; i++ {
+		c.Put([]byte(fmt.Sprintf("key-%d", i)), i, 100)
+	}
+
+	// Each shard holds (at most) 1000 bytes
+	assert.LessOrEqual(t, c.SizeInBytesContained(), uint64(4000))
+	assert.Equal(t, uint64(c.Len()*100), c.SizeInBytesContained())
+}
+
+func TestFIFOShardedCache_SizeInBytesConcurrentWithOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCacheWithSizeInBytes(50, 4, 2000)
+
+	wg := sync.WaitGroup{}
+	for worker := 0; worker < 4; worker++ {
+		wg.Add(1)
+		go func(worker int) {
+			defer wg.Done()
+
+			for i := 0; i < 1000; i++ {
+				key := []byte(fmt.Sprintf("key-%d", (worker*1000+i)%120))
+				switch i % 5 {
+				case 0:
+					c.Remove(key)
+				case 1:
+					_, _ = c.HasOrAdd(key, i, 10)
+				case 2:
+					_, _, _ = c.RemoveOldest()
+				default:
+					c.Put(key, i, 10)
+				}
+			}
+		}(worker)
+	}
+	wg.Wait()
+
+	// Settle the lazily detected evictions
+	c.Clear()
+	for i := 0; i < 200; i++ {
+		c.Put([]byte(fmt.Sprintf("final-%d", i)), i, 10)
+	}
+
+	assert.Equal(t, uint64(c.Len()*10), c.SizeInBytesContained())
+}
+
+func createKeyValueSizePairs(numEntries int, sizeInBytes int) []types.KeyValueSizePair {
+	entries := make([]types.KeyValueSizePair, numEntries)
+	for i := range entries {
+		entries[i] = types.KeyValueSizePair{
+			Key:         []byte(fmt.Sprintf("key-%d", i)),
+			Value:       i,
+			SizeInBytes: sizeInBytes,
+		}
+	}
+
+	return entries
+}
+
+func TestFIFOShardedCache_PutBulk(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(1000, 4)
+
+	mutAdded := sync.Mutex{}
+	added := make(map[string]interface{})
+	wg := sync.WaitGroup{}
+	wg.Add(100)
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		mutAdded.Lock()
+		added[string(key)] = value
+		mutAdded.Unlock()
+		wg.Done()
+	}, "recorder")
+
+	entries := createKeyValueSizePairs(100, 10)
+	evicted := c.PutBulk(entries)
+	assert.Equal(t, len(entries), len(evicted))
+	for _, e := range evicted {
+		assert.True(t, e)
+	}
+
+	assert.Equal(t, 100, c.Len())
+	assert.Equal(t, uint64(1000), c.SizeInBytesContained())
+	for _, entry := range entries {
+		value, ok := c.Get(entry.Key)
+		assert.True(t, ok)
+		assert.Equal(t, entry.Value, value)
+	}
+
+	chDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(chDone)
+	}()
+	select {
+	case <-chDone:
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "the added data handlers should have been called")
+	}
+
+	mutAdded.Lock()
+	assert.Equal(t, 100, len(added))
+	mutAdded.Unlock()
+
+	// Overwrite
+	c.UnRegisterHandler("recorder")
+	_ = c.PutBulk([]types.KeyValueSizePair{{Key: []byte("key-0"), Value: "new", SizeInBytes: 20}})
+	value, _ := c.Get([]byte("key-0"))
+	assert.Equal(t, "new", value)
+	assert.Equal(t, uint64(1010), c.SizeInBytesContained())
+
+	assert.Empty(t, c.PutBulk(nil))
+	_ = c.Close()
+}
+
+func TestFIFOShardedCache_PutBulkShouldApplyMaxSizeInBytes(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCacheWithSizeInBytes(100, 1, 1000)
+
+	mutRemovals := sync.Mutex{}
+	removals := make(map[string]types.RemovalReason)
+	c.RegisterHandlerForRemoval(func(key []byte, _ interface{}, reason types.RemovalReason) {
+		mutRemovals.Lock()
+		removals[string(key)] = reason
+		mutRemovals.Unlock()
+	}, "recorder")
+
+	_ = c.PutBulk(createKeyValueSizePairs(5, 400))
+	assert.Equal(t, 2, c.Len())
+	assert.True(t, c.Has([]byte("key-3")))
+	assert.True(t, c.Has([]byte("key-4")))
+	assert.Equal(t, uint64(800), c.SizeInBytesContained())
+
+	_ = c.Close()
+
+	mutRemovals.Lock()
+	assert.Equal(t, 3, len(removals))
+	for _, reason := range removals {
+		assert.Equal(t, types.RemovalReasonEvicted, reason)
+	}
+	mutRemovals.Unlock()
+}
+
+func BenchmarkFIFOShardedCache_PutBulk(b *testing.B) {
+	entries := createKeyValueSizePairs(10000, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, _ := fifocache.NewShardedCacheWithSizeInBytes(20000, 16, 10000000)
+		_ = c.PutBulk(entries)
+	}
+}
+
+func BenchmarkFIFOShardedCache_PutSequential(b *testing.B) {
+	entries := createKeyValueSizePairs(10000, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, _ := fifocache.NewShardedCacheWithSizeInBytes(20000, 16, 10000000)
+		for _, entry := range entries {
+			_ = c.Put(entry.Key, entry.Value, entry.SizeInBytes)
+		}
+	}
+}
+
+func TestFIFOShardedCache_GetAccessCounts(t *testing.T) {
+	t.Parallel()
+
+	c, _ := fifocache.NewShardedCache(10, 2)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+
+	// Disabled by default
+	_, _ = c.Get([]byte("a"))
+	assert.Empty(t, c.GetAccessCounts())
+
+	c.SetAccessCountingEnabled(true)
+	_, _ = c.Get([]byte("a"))
+	_, _ = c.Get([]byte("b"))
+	_, _ = c.Get([]byte("b"))
+	_, _ = c.Get([]byte("missing"))
+	_ = c.Has([]byte("a"))
+	_, _ = c.Peek([]byte("a"))
+
+	// Sorted by count (descending), then by the hash of the key (ascending)
+	expected := []types.KeyAccessCount{
+		{KeyHash: cacheStats.HashOfKey([]byte("b")), Count: 2},
+		{KeyHash: cacheStats.HashOfKey([]byte("missing")), Count: 1},
+		{KeyHash: cacheStats.HashOfKey([]byte("a")), Count: 1},
+	}
+	assert.Equal(t, expected, c.GetAccessCounts())
+	assert.Empty(t, c.GetAccessCounts())
+}
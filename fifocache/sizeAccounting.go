@@ -0,0 +1,125 @@
+package fifocache
+
+import (
+	"container/list"
+	"hash/fnv"
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+)
+
+// defaultSizeInBytesOfUnsizedEntry is the (conservative) size accounted for the entries added with a non-positive size in bytes,
+// so that the budget in bytes cannot be bypassed by omitting the size
+const defaultSizeInBytesOfUnsizedEntry = 1024
+
+// shardAccount keeps track of the size in bytes of the entries of a shard of the underlying concurrent map, in insertion order.
+// Since the concurrent map evicts silently (its oldest key, when the shard is full), the entries dropped by the map are detected
+// right after the addition which caused their eviction (see applySizeConstraints).
+type shardAccount struct {
+	// mutAdd serializes the additions into (and the removals from) the shard, along with the enforcement of the budget in bytes
+	mutAdd   sync.Mutex
+	entries  *list.List
+	numBytes atomic.Counter
+}
+
+func newShardAccount() *shardAccount {
+	return &shardAccount{
+		entries: list.New(),
+	}
+}
+
+func newShardAccounts(numShards int) []*shardAccount {
+	accounts := make([]*shardAccount, numShards)
+	for i := range accounts {
+		accounts[i] = newShardAccount()
+	}
+
+	return accounts
+}
+
+// getShardAccount returns the account of the shard holding the key. It mirrors the sharding of the concurrent map (FNV-1, 32 bits).
+func (c *FIFOShardedCache) getShardAccount(key string) *shardAccount {
+	return c.shardAccounts[c.getShardIndex(key)]
+}
+
+func (c *FIFOShardedCache) getShardIndex(key string) uint32 {
+	hasher := fnv.New32()
+	_, _ = hasher.Write([]byte(key))
+
+	return hasher.Sum32() % uint32(len(c.shardAccounts))
+}
+
+func computeAccountedSize(sizeInBytes int) int64 {
+	if sizeInBytes <= 0 {
+		return defaultSizeInBytesOfUnsizedEntry
+	}
+
+	return int64(sizeInBytes)
+}
+
+// release subtracts the size of the entry from the account of its shard. It takes effect only once, no matter
+// how many times (or by which removal path) it is called. It returns whether the entry has been released by this call.
+func (entry *fifoEntry) release() bool {
+	if entry.account == nil || entry.released.SetReturningPrevious() {
+		return false
+	}
+
+	entry.account.numBytes.Subtract(entry.size)
+	return true
+}
+
+func releaseWrapped(wrapped interface{}) {
+	entry, isEntry := wrapped.(*fifoEntry)
+	if isEntry {
+		entry.release()
+	}
+}
+
+// This function should only be called under the (already acquired) c.mutCache
+func (c *FIFOShardedCache) holdsEntry(entry *fifoEntry) bool {
+	current, ok := c.cache.Get(entry.key)
+	return ok && current == entry
+}
+
+// applySizeConstraints releases the entries (at the front of the account) which are not held anymore by the concurrent map,
+// then, if a budget in bytes is set, evicts the oldest entries of the shard until the shard fits its budget (keeping at least one entry).
+// It returns the evicted entries: both the ones silently evicted by the concurrent map and the ones evicted due to the budget in bytes.
+// The removal paths release the entries under account.mutAdd, as well, thus an entry not held by the map, but not released yet,
+// has been evicted by the map.
+// This function should only be called under the (already acquired) c.mutCache and account.mutAdd
+func (c *FIFOShardedCache) applySizeConstraints(account *shardAccount) []*fifoEntry {
+	evicted := make([]*fifoEntry, 0)
+	for element := account.entries.Front(); element != nil; element = account.entries.Front() {
+		entry := element.Value.(*fifoEntry)
+		if c.holdsEntry(entry) {
+			break
+		}
+
+		// Silently evicted by the concurrent map (if not released yet), replaced, or already removed
+		if entry.release() {
+			evicted = append(evicted, entry)
+		}
+		account.entries.Remove(element)
+	}
+
+	if c.maxSizeInBytesPerShard == 0 {
+		return evicted
+	}
+
+	for account.numBytes.Get() > c.maxSizeInBytesPerShard && account.entries.Len() > 1 {
+		element := account.entries.Front()
+		entry := element.Value.(*fifoEntry)
+		account.entries.Remove(element)
+
+		_ = c.cache.RemoveCb(entry.key, func(_ string, current interface{}, exists bool) bool {
+			return exists && current == entry
+		})
+
+		// Evicted right now (or, beforehand, by the concurrent map), unless replaced or already removed
+		if entry.release() {
+			evicted = append(evicted, entry)
+		}
+	}
+
+	return evicted
+}
@@ -2,11 +2,14 @@ package immunitycache
 
 import (
 	"sync"
+	"time"
 
 	"github.com/multiversx/mx-chain-core-go/core/atomic"
 	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/cacheStats"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/monitoring"
+	"github.com/multiversx/mx-chain-storage-go/removalNotifier"
 	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
@@ -23,8 +26,20 @@ type ImmunityCache struct {
 	config                        CacheConfig
 	chunks                        []*immunityChunk
 	hospitality                   atomic.Counter
+	numImmune                     atomic.Counter
 	numCapacityReachedOccurrences atomic.Counter
+	removalNotifier               *removalNotifier.RemovalNotifier
+	stats                         *cacheStats.StatsCollector
 	mutex                         sync.RWMutex
+	immunityPrefixes              map[string]time.Time
+	mutImmunityPrefixes           sync.RWMutex
+}
+
+// ImmunityStats holds the usage statistics of an immunity cache
+type ImmunityStats struct {
+	types.CacheStats
+	NumImmune int `json:"numImmune"`
+	NumMortal int `json:"numMortal"`
 }
 
 // NewImmunityCache creates a new cache
@@ -38,37 +53,55 @@ func NewImmunityCache(config CacheConfig) (*ImmunityCache, error) {
 	}
 
 	cache := ImmunityCache{
-		config: config,
+		config:           config,
+		removalNotifier:  removalNotifier.NewRemovalNotifier(),
+		stats:            cacheStats.NewStatsCollector(),
+		immunityPrefixes: make(map[string]time.Time),
 	}
 
-	cache.initializeChunksWithLock()
+	_ = cache.initializeChunksWithLock()
 	return &cache, nil
 }
 
-func (ic *ImmunityCache) initializeChunksWithLock() {
+// initializeChunksWithLock returns the previous chunks (if any)
+func (ic *ImmunityCache) initializeChunksWithLock() []*immunityChunk {
 	ic.mutex.Lock()
 	defer ic.mutex.Unlock()
 
 	config := ic.config
 	chunkConfig := config.getChunkConfig()
+	previousChunks := ic.chunks
 
 	ic.chunks = make([]*immunityChunk, config.NumChunks)
 	for i := uint32(0); i < config.NumChunks; i++ {
 		ic.chunks[i] = newImmunityChunk(chunkConfig)
+		ic.chunks[i].onItemRemoved = ic.onItemRemoved
+		ic.chunks[i].onImmunityChanged = ic.onImmunityChanged
+		ic.chunks[i].isImmuneByPrefix = ic.isImmuneByPrefix
 	}
+	ic.numImmune.Reset()
+
+	return previousChunks
+}
+
+func (ic *ImmunityCache) onItemRemoved(item *cacheItem, reason types.RemovalReason) {
+	if reason == types.RemovalReasonEvicted {
+		ic.stats.RecordEvictions(1)
+	}
+
+	ic.removalNotifier.Notify([]byte(item.key), item.payload, reason)
+}
+
+func (ic *ImmunityCache) onImmunityChanged(delta int) {
+	ic.numImmune.Add(int64(delta))
 }
 
 // ImmunizeKeys marks items as immune to eviction
 func (ic *ImmunityCache) ImmunizeKeys(keys [][]byte) (numNowTotal, numFutureTotal int) {
-	immuneItemsCapacityReached := ic.CountImmune()+len(keys) > int(ic.config.MaxNumItems)
-	if immuneItemsCapacityReached {
-		logLevel := ic.decideLogLevelOnCapacityReached()
-		log.Log(logLevel, "ImmunityCache.ImmunizeKeys(): will not immunize", "err", common.ErrImmuneItemsCapacityReached)
+	if ic.isImmuneItemsCapacityReached(len(keys)) {
 		return
 	}
 
-	ic.forgetCapacityHadBeenReachedInThePast()
-
 	groups := ic.groupKeysByChunk(keys)
 
 	for chunkIndex, chunkKeys := range groups {
@@ -82,6 +115,31 @@ func (ic *ImmunityCache) ImmunizeKeys(keys [][]byte) (numNowTotal, numFutureTota
 	return
 }
 
+// ClearImmunity lifts the immunity (current or future) of the given keys, so that the items become evictable again.
+// It returns the number of keys whose immunity has been lifted.
+func (ic *ImmunityCache) ClearImmunity(keys [][]byte) (cleared int) {
+	groups := ic.groupKeysByChunk(keys)
+
+	for chunkIndex, chunkKeys := range groups {
+		chunk := ic.getChunkByIndexWithLock(chunkIndex)
+		cleared += chunk.ClearImmunity(chunkKeys)
+	}
+
+	return
+}
+
+func (ic *ImmunityCache) isImmuneItemsCapacityReached(numKeysToImmunize int) bool {
+	immuneItemsCapacityReached := ic.CountImmune()+numKeysToImmunize > int(ic.config.MaxNumItems)
+	if immuneItemsCapacityReached {
+		logLevel := ic.decideLogLevelOnCapacityReached()
+		log.Log(logLevel, "ImmunityCache.ImmunizeKeys(): will not immunize", "err", common.ErrImmuneItemsCapacityReached)
+		return true
+	}
+
+	ic.forgetCapacityHadBeenReachedInThePast()
+	return false
+}
+
 func (ic *ImmunityCache) decideLogLevelOnCapacityReached() logger.LogLevel {
 	logLevel := logger.LogDebug
 	if ic.numCapacityReachedOccurrences.GetUint64()%capacityReachedWarningPeriod == 0 {
@@ -139,6 +197,7 @@ func (ic *ImmunityCache) getChunkByKeyWithLock(key string) *immunityChunk {
 // Get gets an item (payload) by key
 func (ic *ImmunityCache) Get(key []byte) (value interface{}, ok bool) {
 	item, ok := ic.getItem(key)
+	ic.stats.RecordLookup(ok)
 	if ok {
 		return item.payload, true
 	}
@@ -171,6 +230,7 @@ func (ic *ImmunityCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int
 	has, added = chunk.AddItem(item)
 	if !has {
 		if added {
+			ic.stats.RecordPut()
 			ic.hospitality.Increment()
 		} else {
 			ic.hospitality.Decrement()
@@ -207,7 +267,22 @@ func (ic *ImmunityCache) RemoveOldest() {
 func (ic *ImmunityCache) Clear() {
 	// There is no need to explicitly remove each item for each chunk
 	// The garbage collector will remove the data from memory
-	ic.initializeChunksWithLock()
+	previousChunks := ic.initializeChunksWithLock()
+
+	if !ic.removalNotifier.HasHandlers() {
+		return
+	}
+
+	for _, chunk := range previousChunks {
+		chunk.ForEachItem(func(key []byte, value interface{}) {
+			ic.removalNotifier.Notify(key, value, types.RemovalReasonCleared)
+		})
+	}
+}
+
+// GetConfig returns (a copy of) the configuration of the cache
+func (ic *ImmunityCache) GetConfig() CacheConfig {
+	return ic.config
 }
 
 // MaxSize returns the capacity of the cache
@@ -249,6 +324,32 @@ func (ic *ImmunityCache) CountImmune() int {
 	return count
 }
 
+// NumImmune returns the number of items (within the map) currently immune to eviction
+func (ic *ImmunityCache) NumImmune() int {
+	return int(ic.numImmune.Get())
+}
+
+// NumMortal returns the number of items (within the map) which are not immune to eviction
+func (ic *ImmunityCache) NumMortal() int {
+	numMortal := ic.Count() - ic.NumImmune()
+	if numMortal < 0 {
+		return 0
+	}
+
+	return numMortal
+}
+
+// ImmuneKeys returns the keys of the items (within the map) currently immune to eviction
+func (ic *ImmunityCache) ImmuneKeys() [][]byte {
+	keys := make([][]byte, 0, ic.NumImmune())
+
+	for _, chunk := range ic.getChunksWithLock() {
+		keys = chunk.AppendImmuneKeys(keys)
+	}
+
+	return keys
+}
+
 // NumBytes estimates the size of the cache, in bytes
 func (ic *ImmunityCache) NumBytes() int {
 	numBytes := 0
@@ -280,6 +381,17 @@ func (ic *ImmunityCache) UnRegisterHandler(_ string) {
 	log.Error("ImmunityCache.UnRegisterHandler is not implemented")
 }
 
+// RegisterHandlerForRemoval registers a new handler to be called when an item is removed from the cache.
+// The handlers are called on a dedicated goroutine, outside the internal locks of the cache.
+func (ic *ImmunityCache) RegisterHandlerForRemoval(handler func(key []byte, value interface{}, reason types.RemovalReason), id string) {
+	ic.removalNotifier.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandlerForRemoval removes the removal handler having the given id
+func (ic *ImmunityCache) UnRegisterHandlerForRemoval(id string) {
+	ic.removalNotifier.UnRegisterHandler(id)
+}
+
 // ForEachItem iterates over the items in the cache
 func (ic *ImmunityCache) ForEachItem(function types.ForEachItem) {
 	for _, chunk := range ic.getChunksWithLock() {
@@ -287,10 +399,28 @@ func (ic *ImmunityCache) ForEachItem(function types.ForEachItem) {
 	}
 }
 
+// ForEach iterates over the items in the cache, until the callback returns false. Each chunk is iterated over a copy
+// of its items (taken under the lock of the chunk), thus the callback is free to mutate the cache.
+func (ic *ImmunityCache) ForEach(fn func(key []byte, value interface{}) bool) {
+	if fn == nil {
+		return
+	}
+
+	for _, chunk := range ic.getChunksWithLock() {
+		for _, item := range chunk.GetItemsInOrder() {
+			if !fn([]byte(item.key), item.payload) {
+				return
+			}
+		}
+	}
+}
+
 // Diagnose displays a summary of the internal state of the cache
 func (ic *ImmunityCache) Diagnose(_ bool) {
 	count := ic.Count()
 	countImmune := ic.CountImmune()
+	numImmune := ic.NumImmune()
+	numMortal := ic.NumMortal()
 	numBytes := ic.NumBytes()
 	hospitality := ic.hospitality.Get()
 
@@ -301,6 +431,8 @@ func (ic *ImmunityCache) Diagnose(_ bool) {
 			"name", ic.config.Name,
 			"count", count,
 			"countImmune", countImmune,
+			"numImmune", numImmune,
+			"numMortal", numMortal,
 			"numBytes", numBytes,
 			"hospitality", hospitality,
 		)
@@ -316,13 +448,35 @@ func (ic *ImmunityCache) Diagnose(_ bool) {
 		"name", ic.config.Name,
 		"count", count,
 		"countImmune", countImmune,
+		"numImmune", numImmune,
+		"numMortal", numMortal,
 		"numBytes", numBytes,
 		"hospitality", hospitality,
 	)
 }
 
-// Close does nothing for this cacher implementation
+// Stats returns the usage statistics of the cache. Hits and misses are recorded by Get.
+func (ic *ImmunityCache) Stats() types.CacheStats {
+	return ic.stats.Stats(ic.Count(), uint64(ic.NumBytes()))
+}
+
+// ImmunityStats returns the usage statistics of the cache, along with the number of immune and mortal items
+func (ic *ImmunityCache) ImmunityStats() ImmunityStats {
+	return ImmunityStats{
+		CacheStats: ic.Stats(),
+		NumImmune:  ic.NumImmune(),
+		NumMortal:  ic.NumMortal(),
+	}
+}
+
+// ResetStats resets the usage statistics of the cache
+func (ic *ImmunityCache) ResetStats() {
+	ic.stats.Reset()
+}
+
+// Close stops the removal notifications
 func (ic *ImmunityCache) Close() error {
+	ic.removalNotifier.Close()
 	return nil
 }
 
@@ -23,6 +23,15 @@ func (item *cacheItem) isImmuneToEviction() bool {
 	return item.isImmune.IsSet()
 }
 
-func (item *cacheItem) immunizeAgainstEviction() {
-	_ = item.isImmune.SetReturningPrevious()
+// immunizeAgainstEviction returns true if the item was not already immune
+func (item *cacheItem) immunizeAgainstEviction() bool {
+	wasImmune := item.isImmune.SetReturningPrevious()
+	return !wasImmune
+}
+
+// clearImmunity returns true if the item was immune
+func (item *cacheItem) clearImmunity() bool {
+	wasImmune := item.isImmune.IsSet()
+	item.isImmune.Reset()
+	return wasImmune
 }
@@ -4,11 +4,14 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"sync"
 	"testing"
+	"time"
 
 	logger "github.com/multiversx/mx-chain-logger-go"
 	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -55,6 +58,26 @@ func requireErrorOnNewCache(t *testing.T, config CacheConfig, errExpected error,
 	require.Contains(t, errReceived.Error(), errPartialMessage)
 }
 
+func TestImmunityCache_GetConfig(t *testing.T) {
+	config := CacheConfig{
+		Name:                        "test",
+		NumChunks:                   16,
+		MaxNumItems:                 math.MaxUint32,
+		MaxNumBytes:                 maxNumBytesUpperBound,
+		NumItemsToPreemptivelyEvict: 100,
+	}
+
+	cache, err := NewImmunityCache(config)
+	require.Nil(t, err)
+	require.Equal(t, config, cache.GetConfig())
+
+	// The returned value is a copy
+	returnedConfig := cache.GetConfig()
+	returnedConfig.NumChunks = 1
+	require.Equal(t, uint32(16), cache.GetConfig().NumChunks)
+	require.Len(t, cache.chunks, 16)
+}
+
 func TestImmunityCache_ImmunizeAgainstEviction(t *testing.T) {
 	cache := newCacheToTest(1, 8, maxNumBytesUpperBound)
 
@@ -77,6 +100,74 @@ func TestImmunityCache_ImmunizeAgainstEviction(t *testing.T) {
 	require.Equal(t, 2, cache.CountImmune())
 }
 
+func TestImmunityCache_NumImmuneAndNumMortal(t *testing.T) {
+	cache := newCacheToTest(4, 16, maxNumBytesUpperBound)
+
+	cache.addTestItems("a", "b", "c", "d")
+	require.Equal(t, 0, cache.NumImmune())
+	require.Equal(t, 4, cache.NumMortal())
+	require.Empty(t, cache.ImmuneKeys())
+
+	// Future immunity is not accounted until the items are added
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"a", "b", "e"}))
+	require.Equal(t, 2, cache.NumImmune())
+	require.Equal(t, 2, cache.NumMortal())
+	require.ElementsMatch(t, []string{"a", "b"}, keysAsStrings(cache.ImmuneKeys()))
+
+	// Immunizing again does not double count
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"a"}))
+	require.Equal(t, 2, cache.NumImmune())
+
+	cache.addTestItems("e", "f")
+	require.Equal(t, 3, cache.NumImmune())
+	require.Equal(t, 3, cache.NumMortal())
+	require.ElementsMatch(t, []string{"a", "b", "e"}, keysAsStrings(cache.ImmuneKeys()))
+
+	cache.Remove([]byte("a"))
+	cache.Remove([]byte("c"))
+	require.Equal(t, 2, cache.NumImmune())
+	require.Equal(t, 2, cache.NumMortal())
+
+	stats := cache.ImmunityStats()
+	require.Equal(t, 2, stats.NumImmune)
+	require.Equal(t, 2, stats.NumMortal)
+	require.Equal(t, 4, stats.Len)
+
+	cache.Clear()
+	require.Equal(t, 0, cache.NumImmune())
+	require.Equal(t, 0, cache.NumMortal())
+	require.Empty(t, cache.ImmuneKeys())
+}
+
+func TestImmunityCache_ClearImmunity(t *testing.T) {
+	cache := newCacheToTest(1, 5, maxNumBytesUpperBound)
+
+	cache.addTestItems("a", "b", "c", "d", "e")
+	_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"a", "b", "c", "d", "f"}))
+	require.Equal(t, 4, cache.NumImmune())
+	require.Equal(t, 5, cache.CountImmune())
+
+	// Only the mortal item is evicted
+	cache.addTestItems("x")
+	require.ElementsMatch(t, []string{"a", "b", "c", "d", "x"}, keysAsStrings(cache.Keys()))
+
+	cleared := cache.ClearImmunity(keysAsBytes([]string{"a", "b", "f", "unknown"}))
+	require.Equal(t, 3, cleared)
+	require.Equal(t, 2, cache.NumImmune())
+	require.Equal(t, 3, cache.NumMortal())
+	require.Equal(t, 2, cache.CountImmune())
+	require.ElementsMatch(t, []string{"c", "d"}, keysAsStrings(cache.ImmuneKeys()))
+
+	// The items are evictable again, right away
+	cache.addTestItems("y", "f")
+	require.ElementsMatch(t, []string{"c", "d", "x", "y", "f"}, keysAsStrings(cache.Keys()))
+	require.Equal(t, 2, cache.NumImmune())
+	require.Equal(t, 3, cache.NumMortal())
+
+	cleared = cache.ClearImmunity(keysAsBytes([]string{"a", "b"}))
+	require.Equal(t, 0, cleared)
+}
+
 func TestImmunityCache_ImmunizeDoesNothingIfCapacityReached(t *testing.T) {
 	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
 
@@ -129,6 +220,44 @@ func TestImmunityCache_AddThenRemove(t *testing.T) {
 	require.Equal(t, 0, cache.Len())
 }
 
+func TestImmunityCache_RegisterHandlerForRemoval(t *testing.T) {
+	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
+
+	removals := make(map[string]types.RemovalReason)
+	cache.RegisterHandlerForRemoval(func(key []byte, value interface{}, reason types.RemovalReason) {
+		require.Equal(t, "foo-"+string(key), value)
+		removals[string(key)] = reason
+	}, "recorder")
+	cache.RegisterHandlerForRemoval(func(key []byte, _ interface{}, _ types.RemovalReason) {
+		require.Fail(t, "should have been unregistered", string(key))
+	}, "unregistered")
+	cache.UnRegisterHandlerForRemoval("unregistered")
+
+	cache.addTestItems("a", "b", "c", "d")
+	cache.Remove([]byte("b"))
+	cache.Remove([]byte("x"))
+
+	// Now eviction takes place
+	cache.addTestItems("e", "f")
+	require.ElementsMatch(t, []string{"c", "d", "e", "f"}, keysAsStrings(cache.Keys()))
+
+	cache.Clear()
+
+	// Close dispatches the pending notifications
+	err := cache.Close()
+	require.Nil(t, err)
+
+	expected := map[string]types.RemovalReason{
+		"a": types.RemovalReasonEvicted,
+		"b": types.RemovalReasonRemoved,
+		"c": types.RemovalReasonCleared,
+		"d": types.RemovalReasonCleared,
+		"e": types.RemovalReasonCleared,
+		"f": types.RemovalReasonCleared,
+	}
+	require.Equal(t, expected, removals)
+}
+
 func TestImmunityCache_Get(t *testing.T) {
 	cache := newCacheToTest(1, 8, maxNumBytesUpperBound)
 
@@ -226,6 +355,61 @@ func TestImmunityCache_ForEachItem(t *testing.T) {
 	require.ElementsMatch(t, []string{"a", "b", "c", "d"}, keys)
 }
 
+func TestImmunityCache_ForEach(t *testing.T) {
+	cache := newCacheToTest(4, 16, 10000)
+	cache.ForEach(nil)
+
+	cache.addTestItems("a", "b", "c", "d")
+
+	entries := make(map[string]interface{})
+	cache.ForEach(func(key []byte, value interface{}) bool {
+		entries[string(key)] = value
+		return true
+	})
+	require.Equal(t, map[string]interface{}{"a": "foo-a", "b": "foo-b", "c": "foo-c", "d": "foo-d"}, entries)
+
+	numVisited := 0
+	cache.ForEach(func(key []byte, value interface{}) bool {
+		numVisited++
+		return numVisited < 2
+	})
+	require.Equal(t, 2, numVisited)
+
+	// Mutating the cache from within the callback does not deadlock
+	cache.ForEach(func(key []byte, value interface{}) bool {
+		cache.Remove(key)
+		return true
+	})
+	require.Equal(t, 0, cache.Len())
+}
+
+func TestImmunityCache_ForEachConcurrentWithOperations(t *testing.T) {
+	cache := newCacheToTest(4, 100, 100000)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		for i := 0; i < 1000; i++ {
+			cache.addTestItems(fmt.Sprintf("%d", i))
+			cache.Remove([]byte(fmt.Sprintf("%d", i/2)))
+		}
+		wg.Done()
+	}()
+
+	go func() {
+		for i := 0; i < 100; i++ {
+			cache.ForEach(func(key []byte, value interface{}) bool {
+				require.NotNil(t, value)
+				return true
+			})
+		}
+		wg.Done()
+	}()
+
+	wg.Wait()
+}
+
 // This information about (hash to chunk) distribution is useful to write tests
 func TestImmunityCache_Fnv32Hash(t *testing.T) {
 	// Cache with 2 chunks
@@ -336,6 +520,51 @@ func TestImmunityCache_ForgetCapacityHadBeenReachedInThePast(t *testing.T) {
 	require.Equal(t, uint64(0), cache.numCapacityReachedOccurrences.GetUint64())
 }
 
+// BenchmarkImmunityCache_PutWhileKeys measures the latency of Put, while Keys is called in a loop (on a large cache)
+func BenchmarkImmunityCache_PutWhileKeys(b *testing.B) {
+	numItems := 500_000
+	cache := newCacheToTest(16, uint32(numItems*2), maxNumBytesUpperBound)
+	for i := 0; i < numItems; i++ {
+		cache.Put([]byte(fmt.Sprintf("key-%d", i)), i, 1)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_ = cache.Keys()
+			}
+		}
+	}()
+
+	latencies := make([]time.Duration, b.N)
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		start := time.Now()
+		cache.Put([]byte(fmt.Sprintf("new-key-%d", i%numItems)), i, 1)
+		latencies[i] = time.Since(start)
+	}
+
+	b.StopTimer()
+	close(stop)
+	wg.Wait()
+
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i] < latencies[j]
+	})
+	b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns/op")
+	b.ReportMetric(float64(latencies[len(latencies)*999/1000].Nanoseconds()), "p99.9-ns/op")
+}
+
 func newCacheToTest(numChunks uint32, maxNumItems uint32, numMaxBytes uint32) *ImmunityCache {
 	cache, err := NewImmunityCache(CacheConfig{
 		Name:                        "test",
@@ -356,3 +585,28 @@ func (ic *ImmunityCache) addTestItems(keys ...string) {
 		_, _ = ic.HasOrAdd([]byte(key), fmt.Sprintf("foo-%s", key), 100)
 	}
 }
+
+func TestImmunityCache_StatsAndResetStats(t *testing.T) {
+	cache := newCacheToTest(1, 4, maxNumBytesUpperBound)
+
+	cache.addTestItems("a", "b", "c", "d", "e")
+	_, ok := cache.Get([]byte("e"))
+	require.True(t, ok)
+	_, ok = cache.Get([]byte("missing"))
+	require.False(t, ok)
+
+	stats := cache.Stats()
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(5), stats.Puts)
+	require.Equal(t, uint64(5-cache.Len()), stats.Evictions)
+	require.Equal(t, cache.Len(), stats.Len)
+	require.Equal(t, uint64(cache.NumBytes()), stats.SizeInBytes)
+
+	cache.ResetStats()
+	stats = cache.Stats()
+	require.Equal(t, uint64(0), stats.Hits)
+	require.Equal(t, uint64(0), stats.Puts)
+	require.Equal(t, uint64(0), stats.Evictions)
+	require.Equal(t, cache.Len(), stats.Len)
+}
@@ -2,6 +2,7 @@ package immunitycache
 
 import (
 	"container/list"
+	"strings"
 	"sync"
 
 	"github.com/multiversx/mx-chain-core-go/core"
@@ -13,16 +14,25 @@ var emptyStruct struct{}
 
 type immunityChunk struct {
 	config      immunityChunkConfig
-	items       map[string]chunkItemWrapper
+	items       map[string]*chunkItemWrapper
 	itemsAsList *list.List
 	immuneKeys  map[string]struct{}
 	numBytes    int
 	mutex       sync.RWMutex
+
+	// keys is maintained incrementally (appended on add, swap-removed on removal), so that AppendKeys only needs
+	// to copy it (a memory move) under the read lock, instead of iterating over the map of items
+	keys []string
+
+	onItemRemoved     func(item *cacheItem, reason types.RemovalReason)
+	onImmunityChanged func(delta int)
+	isImmuneByPrefix  func(key string) bool
 }
 
 type chunkItemWrapper struct {
 	item        *cacheItem
 	listElement *list.Element
+	keyIndex    int
 }
 
 func newImmunityChunk(config immunityChunkConfig) *immunityChunk {
@@ -30,7 +40,7 @@ func newImmunityChunk(config immunityChunkConfig) *immunityChunk {
 
 	return &immunityChunk{
 		config:      config,
-		items:       make(map[string]chunkItemWrapper),
+		items:       make(map[string]*chunkItemWrapper),
 		itemsAsList: list.New(),
 		immuneKeys:  make(map[string]struct{}),
 	}
@@ -46,7 +56,9 @@ func (chunk *immunityChunk) ImmunizeKeys(keys [][]byte) (numNow, numFuture int)
 
 		if ok {
 			// Item exists, immunize now!
-			item.immunizeAgainstEviction()
+			if item.immunizeAgainstEviction() {
+				chunk.notifyImmunityChangedNoLock(1)
+			}
 			numNow++
 		} else {
 			// Item not yet in cache, will be immunized in the future
@@ -60,6 +72,36 @@ func (chunk *immunityChunk) ImmunizeKeys(keys [][]byte) (numNow, numFuture int)
 	return
 }
 
+// ClearImmunity lifts the immunity (current or future) of the given keys, making the items evictable again.
+// It returns the number of keys whose immunity has been lifted.
+func (chunk *immunityChunk) ClearImmunity(keys [][]byte) (cleared int) {
+	chunk.mutex.Lock()
+	defer chunk.mutex.Unlock()
+
+	for _, key := range keys {
+		_, isImmuneKey := chunk.immuneKeys[string(key)]
+		if !isImmuneKey {
+			continue
+		}
+
+		delete(chunk.immuneKeys, string(key))
+		cleared++
+
+		item, ok := chunk.getItemNoLock(string(key))
+		if ok && item.clearImmunity() {
+			chunk.notifyImmunityChangedNoLock(-1)
+		}
+	}
+
+	return
+}
+
+func (chunk *immunityChunk) notifyImmunityChangedNoLock(delta int) {
+	if chunk.onImmunityChanged != nil {
+		chunk.onImmunityChanged(delta)
+	}
+}
+
 func (chunk *immunityChunk) getItemNoLock(key string) (*cacheItem, bool) {
 	wrapper, ok := chunk.items[key]
 	if !ok {
@@ -141,18 +183,27 @@ func (chunk *immunityChunk) removeOldestNoLock(numToRemove int) int {
 		elementToRemove := element
 		element = element.Next()
 
-		chunk.removeNoLock(elementToRemove)
+		chunk.removeNoLock(elementToRemove, types.RemovalReasonEvicted)
 		numRemoved++
 	}
 
 	return numRemoved
 }
 
-func (chunk *immunityChunk) removeNoLock(element *list.Element) {
+func (chunk *immunityChunk) removeNoLock(element *list.Element, reason types.RemovalReason) {
 	item := element.Value.(*cacheItem)
+	chunk.removeKeyNoLock(item.key)
 	delete(chunk.items, item.key)
 	chunk.itemsAsList.Remove(element)
 	chunk.trackNumBytesOnRemoveNoLock(item)
+
+	if item.isImmuneToEviction() {
+		chunk.notifyImmunityChangedNoLock(-1)
+	}
+
+	if chunk.onItemRemoved != nil {
+		chunk.onItemRemoved(item, reason)
+	}
 }
 
 func (chunk *immunityChunk) monitorEvictionNoLock(numRemoved int, err error) {
@@ -170,12 +221,38 @@ func (chunk *immunityChunk) itemExistsNoLock(item *cacheItem) bool {
 // In the map, we also need to hold a reference to the list element, to have O(1) removal.
 func (chunk *immunityChunk) addItemNoLock(item *cacheItem) {
 	element := chunk.itemsAsList.PushBack(item)
-	chunk.items[item.key] = chunkItemWrapper{item: item, listElement: element}
+	chunk.items[item.key] = &chunkItemWrapper{item: item, listElement: element, keyIndex: len(chunk.keys)}
+	chunk.keys = append(chunk.keys, item.key)
+}
+
+// removeKeyNoLock removes the key from "keys", by moving the last key in its place
+func (chunk *immunityChunk) removeKeyNoLock(key string) {
+	wrapper, ok := chunk.items[key]
+	if !ok {
+		return
+	}
+
+	lastIndex := len(chunk.keys) - 1
+	lastKey := chunk.keys[lastIndex]
+	chunk.keys[wrapper.keyIndex] = lastKey
+	chunk.items[lastKey].keyIndex = wrapper.keyIndex
+
+	chunk.keys[lastIndex] = ""
+	chunk.keys = chunk.keys[:lastIndex]
 }
 
 func (chunk *immunityChunk) immunizeItemOnAddNoLock(item *cacheItem) {
-	if _, immunize := chunk.immuneKeys[item.key]; immunize {
-		item.immunizeAgainstEviction()
+	_, immunize := chunk.immuneKeys[item.key]
+	if !immunize && chunk.isImmuneByPrefix != nil && chunk.isImmuneByPrefix(item.key) {
+		// Held in "immuneKeys" as well, so that the immunity can be lifted by key (see ClearImmunity)
+		chunk.immuneKeys[item.key] = emptyStruct
+		immunize = true
+	}
+
+	if immunize {
+		if item.immunizeAgainstEviction() {
+			chunk.notifyImmunityChangedNoLock(1)
+		}
 		// We do not remove the key from "immuneKeys", we hold it there until item's removal.
 	}
 }
@@ -205,7 +282,7 @@ func (chunk *immunityChunk) RemoveItem(key string) bool {
 		return false
 	}
 
-	chunk.removeNoLock(wrapper.listElement)
+	chunk.removeNoLock(wrapper.listElement, types.RemovalReasonRemoved)
 	return true
 }
 
@@ -256,13 +333,58 @@ func (chunk *immunityChunk) KeysInOrder() [][]byte {
 	return keys
 }
 
-// AppendKeys accumulates keys in a given slice
+// GetItemsInOrder gets (a copy of) the list of items, in order
+func (chunk *immunityChunk) GetItemsInOrder() []*cacheItem {
+	chunk.mutex.RLock()
+	defer chunk.mutex.RUnlock()
+
+	items := make([]*cacheItem, 0, chunk.itemsAsList.Len())
+	for element := chunk.itemsAsList.Front(); element != nil; element = element.Next() {
+		items = append(items, element.Value.(*cacheItem))
+	}
+
+	return items
+}
+
+// AppendKeys accumulates keys in a given slice. The read lock is only held while copying the (incrementally maintained) keys,
+// while the conversion of the keys happens outside the lock, so that the writers are not blocked for the whole duration.
 func (chunk *immunityChunk) AppendKeys(keysAccumulator [][]byte) [][]byte {
+	chunk.mutex.RLock()
+	keys := make([]string, len(chunk.keys))
+	copy(keys, chunk.keys)
+	chunk.mutex.RUnlock()
+
+	for _, key := range keys {
+		keysAccumulator = append(keysAccumulator, []byte(key))
+	}
+
+	return keysAccumulator
+}
+
+// AppendKeysWithPrefix accumulates the keys having the given prefix in a given slice
+func (chunk *immunityChunk) AppendKeysWithPrefix(keysAccumulator [][]byte, prefix []byte) [][]byte {
 	chunk.mutex.RLock()
 	defer chunk.mutex.RUnlock()
 
 	for key := range chunk.items {
-		keysAccumulator = append(keysAccumulator, []byte(key))
+		if strings.HasPrefix(key, string(prefix)) {
+			keysAccumulator = append(keysAccumulator, []byte(key))
+		}
+	}
+
+	return keysAccumulator
+}
+
+// AppendImmuneKeys accumulates the keys of the (existing) immune items in a given slice
+func (chunk *immunityChunk) AppendImmuneKeys(keysAccumulator [][]byte) [][]byte {
+	chunk.mutex.RLock()
+	defer chunk.mutex.RUnlock()
+
+	for key := range chunk.immuneKeys {
+		item, ok := chunk.getItemNoLock(key)
+		if ok && item.isImmuneToEviction() {
+			keysAccumulator = append(keysAccumulator, []byte(key))
+		}
 	}
 
 	return keysAccumulator
@@ -32,6 +32,59 @@ func TestImmunityChunk_ImmunizeKeys(t *testing.T) {
 	require.Equal(t, []string{"x", "z"}, keysAsStrings(chunk.KeysInOrder()))
 }
 
+func TestImmunityChunk_ClearImmunity(t *testing.T) {
+	numImmune := 0
+	chunk := newUnconstrainedChunkToTest()
+	chunk.onImmunityChanged = func(delta int) {
+		numImmune += delta
+	}
+
+	chunk.addTestItems("x", "y", "z")
+	_, _ = chunk.ImmunizeKeys(keysAsBytes([]string{"x", "z"}))
+	require.Equal(t, 2, numImmune)
+	require.ElementsMatch(t, []string{"x", "z"}, keysAsStrings(chunk.AppendImmuneKeys(nil)))
+
+	cleared := chunk.ClearImmunity(keysAsBytes([]string{"x", "y"}))
+	require.Equal(t, 1, cleared)
+	require.Equal(t, 1, numImmune)
+	require.Equal(t, []string{"z"}, keysAsStrings(chunk.AppendImmuneKeys(nil)))
+
+	numRemoved := chunk.RemoveOldest(42)
+	require.Equal(t, 2, numRemoved)
+	require.Equal(t, []string{"z"}, keysAsStrings(chunk.KeysInOrder()))
+
+	require.True(t, chunk.RemoveItem("z"))
+	require.Equal(t, 0, numImmune)
+}
+
+func TestImmunityChunk_AppendKeysAfterRemovals(t *testing.T) {
+	chunk := newChunkToTest(3, math.MaxUint32)
+	require.Empty(t, chunk.AppendKeys(nil))
+
+	chunk.addTestItems("x", "y", "z")
+	require.ElementsMatch(t, []string{"x", "y", "z"}, keysAsStrings(chunk.AppendKeys(nil)))
+
+	// Removal of a key in the middle
+	require.True(t, chunk.RemoveItem("y"))
+	require.ElementsMatch(t, []string{"x", "z"}, keysAsStrings(chunk.AppendKeys(nil)))
+
+	// Removal by eviction
+	chunk.addTestItems("a", "b")
+	require.ElementsMatch(t, []string{"z", "a", "b"}, keysAsStrings(chunk.AppendKeys(nil)))
+
+	// Removal of the last key
+	require.True(t, chunk.RemoveItem("b"))
+	require.False(t, chunk.RemoveItem("b"))
+	require.ElementsMatch(t, []string{"z", "a"}, keysAsStrings(chunk.AppendKeys(nil)))
+
+	require.Equal(t, 2, chunk.RemoveOldest(42))
+	require.Empty(t, chunk.AppendKeys(nil))
+
+	// Keys are appended to the accumulator
+	chunk.addTestItems("c")
+	require.Equal(t, []string{"foo", "c"}, keysAsStrings(chunk.AppendKeys([][]byte{[]byte("foo")})))
+}
+
 func TestImmunityChunk_AddItemIgnoresDuplicates(t *testing.T) {
 	chunk := newUnconstrainedChunkToTest()
 	chunk.addTestItems("x", "y", "z")
@@ -3,6 +3,7 @@ package immunitycache
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/multiversx/mx-chain-core-go/core"
 	"github.com/multiversx/mx-chain-storage-go/common"
@@ -22,6 +23,8 @@ type CacheConfig struct {
 	MaxNumItems                 uint32
 	MaxNumBytes                 uint32
 	NumItemsToPreemptivelyEvict uint32
+	// ImmunityPrefixSpan is the lifetime of the prefixes registered by ImmunizeKeysWithPrefix (zero means no expiry)
+	ImmunityPrefixSpan time.Duration
 }
 
 // Verify verifies the validity of the configuration
@@ -41,6 +44,9 @@ func (config *CacheConfig) Verify() error {
 	if config.NumItemsToPreemptivelyEvict < numItemsToPreemptivelyEvictLowerBound {
 		return fmt.Errorf("%w: config.NumItemsToPreemptivelyEvict is invalid", common.ErrInvalidConfig)
 	}
+	if config.ImmunityPrefixSpan < 0 {
+		return fmt.Errorf("%w: config.ImmunityPrefixSpan is invalid", common.ErrInvalidConfig)
+	}
 
 	return nil
 }
@@ -0,0 +1,119 @@
+package immunitycache
+
+import (
+	"strings"
+	"time"
+)
+
+// ImmunizeKeysWithPrefix marks the existing items having the given prefix as immune to eviction, and registers the prefix,
+// so that the items added in the future (if matching the prefix) are immune on insertion. The prefix expires after
+// "ImmunityPrefixSpan" (registering it again renews it). For overlapping prefixes, the longest matching one governs the expiry.
+// It returns the number of items immunized now, and 1 (accounting for the registered prefix) as the number of future immunizations.
+func (ic *ImmunityCache) ImmunizeKeysWithPrefix(prefix []byte) (numNow, numFuture int) {
+	if len(prefix) == 0 {
+		return 0, 0
+	}
+
+	keys := make([][]byte, 0)
+	for _, chunk := range ic.getChunksWithLock() {
+		keys = chunk.AppendKeysWithPrefix(keys, prefix)
+	}
+
+	if ic.isImmuneItemsCapacityReached(len(keys)) {
+		return 0, 0
+	}
+
+	numNow, numFuture = ic.ImmunizeKeys(keys)
+	ic.registerImmunityPrefix(string(prefix))
+
+	return numNow, numFuture + 1
+}
+
+func (ic *ImmunityCache) registerImmunityPrefix(prefix string) {
+	expiry := time.Time{}
+	if ic.config.ImmunityPrefixSpan > 0 {
+		expiry = time.Now().Add(ic.config.ImmunityPrefixSpan)
+	}
+
+	ic.mutImmunityPrefixes.Lock()
+	defer ic.mutImmunityPrefixes.Unlock()
+
+	ic.removeExpiredImmunityPrefixesNoLock()
+	ic.immunityPrefixes[prefix] = expiry
+}
+
+// This function should only be called under the (already acquired) ic.mutImmunityPrefixes
+func (ic *ImmunityCache) removeExpiredImmunityPrefixesNoLock() {
+	now := time.Now()
+
+	for prefix, expiry := range ic.immunityPrefixes {
+		if isImmunityPrefixExpired(expiry, now) {
+			delete(ic.immunityPrefixes, prefix)
+		}
+	}
+}
+
+func isImmunityPrefixExpired(expiry time.Time, now time.Time) bool {
+	return !expiry.IsZero() && now.After(expiry)
+}
+
+// RemoveImmunityPrefix removes a prefix registered by ImmunizeKeysWithPrefix, so that the items added in the future are not
+// immunized anymore because of it. The items already immunized are not affected (see ClearImmunity).
+// It returns true if the prefix was registered.
+func (ic *ImmunityCache) RemoveImmunityPrefix(prefix []byte) bool {
+	ic.mutImmunityPrefixes.Lock()
+	defer ic.mutImmunityPrefixes.Unlock()
+
+	_, ok := ic.immunityPrefixes[string(prefix)]
+	delete(ic.immunityPrefixes, string(prefix))
+
+	return ok
+}
+
+// CountImmunityPrefixes returns the number of registered (and not expired) prefixes
+func (ic *ImmunityCache) CountImmunityPrefixes() int {
+	ic.mutImmunityPrefixes.RLock()
+	defer ic.mutImmunityPrefixes.RUnlock()
+
+	now := time.Now()
+	count := 0
+	for _, expiry := range ic.immunityPrefixes {
+		if !isImmunityPrefixExpired(expiry, now) {
+			count++
+		}
+	}
+
+	return count
+}
+
+// isImmuneByPrefix is called by the chunks, when adding an item.
+// The longest registered prefix matching the key decides (by its expiry) whether the item is immune.
+// The capacity for immune items is taken into account, as well.
+func (ic *ImmunityCache) isImmuneByPrefix(key string) bool {
+	ic.mutImmunityPrefixes.RLock()
+	defer ic.mutImmunityPrefixes.RUnlock()
+
+	if len(ic.immunityPrefixes) == 0 {
+		return false
+	}
+
+	longestMatch := ""
+	longestMatchExpiry := time.Time{}
+	found := false
+
+	for prefix, expiry := range ic.immunityPrefixes {
+		if len(prefix) < len(longestMatch) || !strings.HasPrefix(key, prefix) {
+			continue
+		}
+
+		longestMatch = prefix
+		longestMatchExpiry = expiry
+		found = true
+	}
+
+	if !found || isImmunityPrefixExpired(longestMatchExpiry, time.Now()) {
+		return false
+	}
+
+	return ic.NumImmune() < int(ic.config.MaxNumItems)
+}
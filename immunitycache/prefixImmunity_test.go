@@ -0,0 +1,143 @@
+package immunitycache
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithImmunityPrefixSpanToTest(maxNumItems uint32, span time.Duration) *ImmunityCache {
+	cache, err := NewImmunityCache(CacheConfig{
+		Name:                        "test",
+		NumChunks:                   4,
+		MaxNumItems:                 maxNumItems,
+		MaxNumBytes:                 maxNumBytesUpperBound,
+		NumItemsToPreemptivelyEvict: 4,
+		ImmunityPrefixSpan:          span,
+	})
+	if err != nil {
+		panic(fmt.Sprintf("newCacheWithImmunityPrefixSpanToTest(): %s", err))
+	}
+
+	return cache
+}
+
+func TestImmunityCache_ImmunizeKeysWithPrefix(t *testing.T) {
+	t.Run("empty prefix", func(t *testing.T) {
+		cache := newCacheWithImmunityPrefixSpanToTest(16, 0)
+		cache.addTestItems("a", "b")
+
+		numNow, numFuture := cache.ImmunizeKeysWithPrefix(nil)
+		require.Equal(t, 0, numNow)
+		require.Equal(t, 0, numFuture)
+		require.Equal(t, 0, cache.CountImmunityPrefixes())
+	})
+
+	t.Run("should immunize existing and future items", func(t *testing.T) {
+		cache := newCacheWithImmunityPrefixSpanToTest(16, 0)
+		cache.addTestItems("mb1-a", "mb1-b", "mb2-a", "c")
+
+		numNow, numFuture := cache.ImmunizeKeysWithPrefix([]byte("mb1-"))
+		require.Equal(t, 2, numNow)
+		require.Equal(t, 1, numFuture)
+		require.Equal(t, 1, cache.CountImmunityPrefixes())
+		require.ElementsMatch(t, []string{"mb1-a", "mb1-b"}, keysAsStrings(cache.ImmuneKeys()))
+
+		cache.addTestItems("mb1-c", "mb2-b")
+		require.Equal(t, 3, cache.NumImmune())
+		require.ElementsMatch(t, []string{"mb1-a", "mb1-b", "mb1-c"}, keysAsStrings(cache.ImmuneKeys()))
+
+		// The immunity of the items can be lifted individually
+		cleared := cache.ClearImmunity(keysAsBytes([]string{"mb1-c"}))
+		require.Equal(t, 1, cleared)
+		require.Equal(t, 2, cache.NumImmune())
+	})
+
+	t.Run("should not immunize if capacity reached", func(t *testing.T) {
+		cache := newCacheWithImmunityPrefixSpanToTest(4, 0)
+		cache.addTestItems("x-a", "x-b", "x-c")
+		_, _ = cache.ImmunizeKeys(keysAsBytes([]string{"y", "z"}))
+
+		numNow, numFuture := cache.ImmunizeKeysWithPrefix([]byte("x-"))
+		require.Equal(t, 0, numNow)
+		require.Equal(t, 0, numFuture)
+		require.Equal(t, 0, cache.CountImmunityPrefixes())
+	})
+
+	t.Run("future items are not immunized beyond capacity", func(t *testing.T) {
+		cache := newCacheWithImmunityPrefixSpanToTest(4, 0)
+		_, _ = cache.ImmunizeKeysWithPrefix([]byte("x-"))
+
+		cache.addTestItems("x-a", "x-b", "x-c", "x-d", "x-e")
+		require.Equal(t, 4, cache.NumImmune())
+	})
+}
+
+func TestImmunityCache_RemoveImmunityPrefix(t *testing.T) {
+	cache := newCacheWithImmunityPrefixSpanToTest(16, 0)
+	cache.addTestItems("mb1-a")
+
+	_, _ = cache.ImmunizeKeysWithPrefix([]byte("mb1-"))
+	require.True(t, cache.RemoveImmunityPrefix([]byte("mb1-")))
+	require.False(t, cache.RemoveImmunityPrefix([]byte("mb1-")))
+	require.Equal(t, 0, cache.CountImmunityPrefixes())
+
+	// Already immunized items are not affected, new ones are not immunized anymore
+	cache.addTestItems("mb1-b")
+	require.Equal(t, []string{"mb1-a"}, keysAsStrings(cache.ImmuneKeys()))
+}
+
+func TestImmunityCache_ImmunityPrefixExpiry(t *testing.T) {
+	t.Run("prefix should expire", func(t *testing.T) {
+		cache := newCacheWithImmunityPrefixSpanToTest(16, 50*time.Millisecond)
+
+		_, _ = cache.ImmunizeKeysWithPrefix([]byte("mb1-"))
+		cache.addTestItems("mb1-a")
+		require.Equal(t, 1, cache.NumImmune())
+
+		time.Sleep(100 * time.Millisecond)
+		require.Equal(t, 0, cache.CountImmunityPrefixes())
+
+		cache.addTestItems("mb1-b")
+		require.Equal(t, []string{"mb1-a"}, keysAsStrings(cache.ImmuneKeys()))
+
+		// Registering again renews the prefix (and the expired ones are dropped)
+		_, _ = cache.ImmunizeKeysWithPrefix([]byte("mb1-"))
+		require.Equal(t, 1, len(cache.immunityPrefixes))
+		cache.addTestItems("mb1-c")
+		require.ElementsMatch(t, []string{"mb1-a", "mb1-b", "mb1-c"}, keysAsStrings(cache.ImmuneKeys()))
+	})
+
+	t.Run("longest matching prefix governs the expiry", func(t *testing.T) {
+		cache := newCacheWithImmunityPrefixSpanToTest(16, 50*time.Millisecond)
+
+		_, _ = cache.ImmunizeKeysWithPrefix([]byte("mb-long-"))
+		_, _ = cache.ImmunizeKeysWithPrefix([]byte("mb-"))
+
+		// "mb-" is still active, but it is overridden by the (expired) longer prefix
+		cache.immunityPrefixes["mb-long-"] = time.Now().Add(-time.Second)
+		cache.addTestItems("mb-long-a", "mb-short-a")
+		require.Equal(t, []string{"mb-short-a"}, keysAsStrings(cache.ImmuneKeys()))
+
+		// Registering "mb-long-" again also immunizes the existing "mb-long-a"
+		_, _ = cache.ImmunizeKeysWithPrefix([]byte("mb-long-"))
+		cache.immunityPrefixes["mb-"] = time.Now().Add(-time.Second)
+		cache.addTestItems("mb-long-b", "mb-short-b")
+		require.ElementsMatch(t, []string{"mb-short-a", "mb-long-a", "mb-long-b"}, keysAsStrings(cache.ImmuneKeys()))
+	})
+}
+
+func TestNewImmunityCache_InvalidImmunityPrefixSpan(t *testing.T) {
+	config := CacheConfig{
+		Name:                        "test",
+		NumChunks:                   16,
+		MaxNumItems:                 16,
+		MaxNumBytes:                 maxNumBytesUpperBound,
+		NumItemsToPreemptivelyEvict: 1,
+		ImmunityPrefixSpan:          -1,
+	}
+	requireErrorOnNewCache(t, config, common.ErrInvalidConfig, "config.ImmunityPrefixSpan")
+}
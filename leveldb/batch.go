@@ -3,6 +3,7 @@ package leveldb
 import (
 	"sync"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
 	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/syndtr/goleveldb/leveldb"
 )
@@ -26,6 +27,21 @@ func NewBatch() *batch {
 	}
 }
 
+// newBatchFromEntries creates a batch holding the provided entries, in order. An entry having a nil value marks its key for deletion.
+func newBatchFromEntries(entries []storageCore.KeyValuePair) *batch {
+	b := NewBatch()
+	for _, entry := range entries {
+		if entry.Value == nil {
+			_ = b.Delete(entry.Key)
+			continue
+		}
+
+		_ = b.Put(entry.Key, entry.Value)
+	}
+
+	return b
+}
+
 // Put inserts one entry - key, value pair - into the batch
 func (b *batch) Put(key []byte, val []byte) error {
 	b.mutBatch.Lock()
@@ -73,6 +89,22 @@ func (b *batch) IsRemoved(key []byte) bool {
 	return found
 }
 
+// pendingEntries returns the entries of the batch (an entry having a nil value marks its key for deletion)
+func (b *batch) pendingEntries() []storageCore.KeyValuePair {
+	b.mutBatch.RLock()
+	defer b.mutBatch.RUnlock()
+
+	entries := make([]storageCore.KeyValuePair, 0, len(b.cachedData)+len(b.removedData))
+	for key, value := range b.cachedData {
+		entries = append(entries, storageCore.KeyValuePair{Key: []byte(key), Value: value})
+	}
+	for key := range b.removedData {
+		entries = append(entries, storageCore.KeyValuePair{Key: []byte(key)})
+	}
+
+	return entries
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (b *batch) IsInterfaceNil() bool {
 	return b == nil
@@ -6,9 +6,14 @@ import (
 	"sync/atomic"
 	"time"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/syndtr/goleveldb/leveldb"
 	"github.com/syndtr/goleveldb/leveldb/errors"
+	"github.com/syndtr/goleveldb/leveldb/filter"
 	"github.com/syndtr/goleveldb/leveldb/opt"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 const resourceUnavailable = "resource temporarily unavailable"
@@ -18,6 +23,42 @@ const timeBetweenRetries = time.Second
 // loggingDBCounter this variable should be used only used in logging prints
 var loggingDBCounter = uint32(0)
 
+// TuningArgs holds the optional tuning parameters of the database. Zero values mean the LevelDB defaults.
+// For many small reads (e.g. trie nodes), a smaller block size and a bloom filter reduce the read amplification.
+type TuningArgs struct {
+	BlockSizeKB           int
+	BloomFilterBitsPerKey int
+	WriteBufferMB         int
+}
+
+func createOptions(maxOpenFiles int, tuning TuningArgs) (*opt.Options, error) {
+	if maxOpenFiles < 1 {
+		return nil, common.ErrInvalidNumOpenFiles
+	}
+	if tuning.BlockSizeKB < 0 {
+		return nil, fmt.Errorf("%w: BlockSizeKB is invalid", common.ErrInvalidConfig)
+	}
+	if tuning.BloomFilterBitsPerKey < 0 {
+		return nil, fmt.Errorf("%w: BloomFilterBitsPerKey is invalid", common.ErrInvalidConfig)
+	}
+	if tuning.WriteBufferMB < 0 {
+		return nil, fmt.Errorf("%w: WriteBufferMB is invalid", common.ErrInvalidConfig)
+	}
+
+	options := &opt.Options{
+		// disable internal cache
+		BlockCacheCapacity:     -1,
+		OpenFilesCacheCapacity: maxOpenFiles,
+		BlockSize:              tuning.BlockSizeKB * opt.KiB,
+		WriteBuffer:            tuning.WriteBufferMB * opt.MiB,
+	}
+	if tuning.BloomFilterBitsPerKey > 0 {
+		options.Filter = filter.NewBloomFilter(tuning.BloomFilterBitsPerKey)
+	}
+
+	return options, nil
+}
+
 func openLevelDB(path string, options *opt.Options) (*leveldb.DB, error) {
 	retries := 0
 	for {
@@ -79,6 +120,10 @@ type baseLevelDb struct {
 	db    *leveldb.DB
 }
 
+func (bldb *baseLevelDb) newKeyNotFoundError(key []byte) error {
+	return common.NewKeyNotFoundError(bldb.path, key)
+}
+
 func (bldb *baseLevelDb) getDbPointer() *leveldb.DB {
 	bldb.mutDb.RLock()
 	defer bldb.mutDb.RUnlock()
@@ -101,6 +146,15 @@ func (bldb *baseLevelDb) makeDbPointerNilReturningLast() *leveldb.DB {
 	return db
 }
 
+func getPendingEntries(batcher types.Batcher) []storageCore.KeyValuePair {
+	dbBatch, ok := batcher.(*batch)
+	if !ok {
+		return nil
+	}
+
+	return dbBatch.pendingEntries()
+}
+
 // RangeKeys will call the handler function for each (key, value) pair
 // If the handler returns true, the iteration will continue, otherwise will stop
 func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool) {
@@ -135,3 +189,19 @@ func (bldb *baseLevelDb) RangeKeys(handler func(key []byte, value []byte) bool)
 
 	iterator.Release()
 }
+
+// NewIterator returns an iterator over the persisted (key, value) pairs having the given prefix
+// An empty prefix will iterate over all pairs. The returned iterator must be closed after use.
+func (bldb *baseLevelDb) NewIterator(prefix []byte) (types.Iterator, error) {
+	db := bldb.getDbPointer()
+	if db == nil {
+		return nil, common.ErrDBIsClosed
+	}
+
+	var keysRange *util.Range
+	if len(prefix) > 0 {
+		keysRange = util.BytesPrefix(prefix)
+	}
+
+	return newDbIterator(db.NewIterator(keysRange, nil), bldb.path), nil
+}
@@ -0,0 +1,17 @@
+package leveldb
+
+import (
+	"sync/atomic"
+
+	"github.com/syndtr/goleveldb/leveldb/opt"
+)
+
+// NumLeakedIterators -
+func NumLeakedIterators() uint32 {
+	return atomic.LoadUint32(&numLeakedIterators)
+}
+
+// CreateOptions -
+func CreateOptions(maxOpenFiles int, tuning TuningArgs) (*opt.Options, error) {
+	return createOptions(maxOpenFiles, tuning)
+}
@@ -0,0 +1,115 @@
+package leveldb
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+
+	"github.com/multiversx/mx-chain-storage-go/types"
+	ldbIterator "github.com/syndtr/goleveldb/leveldb/iterator"
+)
+
+var _ types.Iterator = (*dbIterator)(nil)
+
+// numLeakedIterators counts the iterators which were garbage collected without being closed
+var numLeakedIterators = uint32(0)
+
+// dbIterator wraps a leveldb iterator. The underlying iterator works on an implicit snapshot of the database,
+// so the iteration is not affected by writes that happen after its creation.
+// Note that the pairs still held in the (not yet committed) batch of the persister are not visible.
+type dbIterator struct {
+	iterator ldbIterator.Iterator
+	path     string
+	closed   bool
+	mutState sync.Mutex
+}
+
+func newDbIterator(iterator ldbIterator.Iterator, path string) *dbIterator {
+	it := &dbIterator{
+		iterator: iterator,
+		path:     path,
+	}
+
+	runtime.SetFinalizer(it, func(it *dbIterator) {
+		if it.release() {
+			atomic.AddUint32(&numLeakedIterators, 1)
+			log.Warn("leveldb iterator was not closed", "path", it.path)
+		}
+	})
+
+	return it
+}
+
+// Next moves the iterator to the next (key, value) pair. It returns false if the iterator is exhausted or closed
+func (it *dbIterator) Next() bool {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed {
+		return false
+	}
+
+	return it.iterator.Next()
+}
+
+// Key returns a copy of the current key
+func (it *dbIterator) Key() []byte {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed {
+		return nil
+	}
+
+	return cloneBytes(it.iterator.Key())
+}
+
+// Value returns a copy of the current value
+func (it *dbIterator) Value() []byte {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed {
+		return nil
+	}
+
+	return cloneBytes(it.iterator.Value())
+}
+
+// Close releases the underlying leveldb iterator. Calling Close multiple times is allowed
+func (it *dbIterator) Close() error {
+	it.mutState.Lock()
+	err := it.iterator.Error()
+	it.mutState.Unlock()
+
+	it.release()
+	runtime.SetFinalizer(it, nil)
+
+	return err
+}
+
+// release returns true if the underlying iterator was released by this call
+func (it *dbIterator) release() bool {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed {
+		return false
+	}
+
+	it.iterator.Release()
+	it.closed = true
+
+	return true
+}
+
+func cloneBytes(buff []byte) []byte {
+	if buff == nil {
+		return nil
+	}
+
+	cloned := make([]byte, len(buff))
+	copy(cloned, buff)
+
+	return cloned
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/multiversx/mx-chain-core-go/core"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
 	logger "github.com/multiversx/mx-chain-logger-go"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/types"
@@ -40,6 +41,12 @@ type DB struct {
 // NewDB is a constructor for the leveldb persister
 // It creates the files in the location given as parameter
 func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (s *DB, err error) {
+	return NewDBWithTuning(path, batchDelaySeconds, maxBatchSize, maxOpenFiles, TuningArgs{})
+}
+
+// NewDBWithTuning is a constructor for the leveldb persister, allowing the tuning of the database (see TuningArgs)
+// It creates the files in the location given as parameter
+func NewDBWithTuning(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int, tuning TuningArgs) (s *DB, err error) {
 	constructorName := "NewDB"
 
 	sw := core.NewStopWatch()
@@ -52,14 +59,9 @@ func NewDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles in
 	}
 	sw.Stop(mkdirAllFunction)
 
-	if maxOpenFiles < 1 {
-		return nil, common.ErrInvalidNumOpenFiles
-	}
-
-	options := &opt.Options{
-		// disable internal cache
-		BlockCacheCapacity:     -1,
-		OpenFilesCacheCapacity: maxOpenFiles,
+	options, err := createOptions(maxOpenFiles, tuning)
+	if err != nil {
+		return nil, err
 	}
 
 	sw.Start(openLevelDBFunction)
@@ -160,6 +162,33 @@ func (s *DB) Put(key, val []byte) error {
 	return s.updateBatchWithIncrement()
 }
 
+// WriteBatch writes the provided entries into the database atomically (all or nothing), bypassing the pending batch.
+// An entry having a nil value marks its key for deletion. The pending batch is written first, so that its (older) entries
+// cannot override the provided ones afterwards.
+func (s *DB) WriteBatch(entries []storageCore.KeyValuePair) error {
+	s.mutBatch.Lock()
+	defer s.mutBatch.Unlock()
+
+	err := s.putBatch(s.batch)
+	if err != nil {
+		return err
+	}
+
+	s.batch.Reset()
+	s.sizeBatch = 0
+
+	return s.putBatch(newBatchFromEntries(entries))
+}
+
+// GetPendingEntries returns the entries written (or removed) but not yet flushed to the database, thus not visible to RangeKeys.
+// An entry having a nil value marks its key for deletion.
+func (s *DB) GetPendingEntries() []storageCore.KeyValuePair {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	return getPendingEntries(s.batch)
+}
+
 // Get returns the value associated to the key
 func (s *DB) Get(key []byte) ([]byte, error) {
 	db := s.getDbPointer()
@@ -168,7 +197,7 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 	}
 
 	if s.batch.IsRemoved(key) {
-		return nil, common.ErrKeyNotFound
+		return nil, s.newKeyNotFoundError(key)
 	}
 
 	data := s.batch.Get(key)
@@ -178,7 +207,7 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 
 	data, err := db.Get(key, nil)
 	if err == leveldb.ErrNotFound {
-		return nil, common.ErrKeyNotFound
+		return nil, s.newKeyNotFoundError(key)
 	}
 	if err != nil {
 		return nil, err
@@ -187,6 +216,40 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 	return data, nil
 }
 
+// GetMulti returns the values associated to the provided keys. The missing keys are not part of the result.
+// The values not yet flushed (still in the pending batch) are also considered.
+func (s *DB) GetMulti(keys [][]byte) (map[string][]byte, error) {
+	db := s.getDbPointer()
+	if db == nil {
+		return nil, common.ErrDBIsClosed
+	}
+
+	results := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		if s.batch.IsRemoved(key) {
+			continue
+		}
+
+		data := s.batch.Get(key)
+		if data != nil {
+			results[string(key)] = data
+			continue
+		}
+
+		data, err := db.Get(key, nil)
+		if err == leveldb.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results[string(key)] = data
+	}
+
+	return results, nil
+}
+
 // Has returns nil if the given key is present in the persistence medium
 func (s *DB) Has(key []byte) error {
 	db := s.getDbPointer()
@@ -195,7 +258,7 @@ func (s *DB) Has(key []byte) error {
 	}
 
 	if s.batch.IsRemoved(key) {
-		return common.ErrKeyNotFound
+		return s.newKeyNotFoundError(key)
 	}
 
 	data := s.batch.Get(key)
@@ -212,7 +275,7 @@ func (s *DB) Has(key []byte) error {
 		return nil
 	}
 
-	return common.ErrKeyNotFound
+	return s.newKeyNotFoundError(key)
 }
 
 // CreateBatch returns a batcher to be used for batch writing data to the database
@@ -11,10 +11,10 @@ import (
 
 	"github.com/multiversx/mx-chain-core-go/core"
 	"github.com/multiversx/mx-chain-core-go/core/closing"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/syndtr/goleveldb/leveldb"
-	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 var _ types.Persister = (*SerialDB)(nil)
@@ -30,11 +30,20 @@ type SerialDB struct {
 	dbAccess          chan serialQueryer
 	cancel            context.CancelFunc
 	closer            core.SafeCloser
+
+	// mutWrite serializes the writes of the batches, so that they reach the database in the order they have been taken
+	mutWrite sync.Mutex
 }
 
 // NewSerialDB is a constructor for the leveldb persister
 // It creates the files in the location given as parameter
 func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (s *SerialDB, err error) {
+	return NewSerialDBWithTuning(path, batchDelaySeconds, maxBatchSize, maxOpenFiles, TuningArgs{})
+}
+
+// NewSerialDBWithTuning is a constructor for the leveldb persister, allowing the tuning of the database (see TuningArgs)
+// It creates the files in the location given as parameter
+func NewSerialDBWithTuning(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int, tuning TuningArgs) (s *SerialDB, err error) {
 	constructorName := "NewSerialDB"
 
 	sw := core.NewStopWatch()
@@ -47,14 +56,9 @@ func NewSerialDB(path string, batchDelaySeconds int, maxBatchSize int, maxOpenFi
 	}
 	sw.Stop(mkdirAllFunction)
 
-	if maxOpenFiles < 1 {
-		return nil, common.ErrInvalidNumOpenFiles
-	}
-
-	options := &opt.Options{
-		// disable internal cache
-		BlockCacheCapacity:     -1,
-		OpenFilesCacheCapacity: maxOpenFiles,
+	options, err := createOptions(maxOpenFiles, tuning)
+	if err != nil {
+		return nil, err
 	}
 
 	sw.Start(openLevelDBFunction)
@@ -151,6 +155,15 @@ func (s *SerialDB) Put(key, val []byte) error {
 	return s.updateBatchWithIncrement()
 }
 
+// GetPendingEntries returns the entries written (or removed) but not yet flushed to the database, thus not visible to RangeKeys.
+// An entry having a nil value marks its key for deletion.
+func (s *SerialDB) GetPendingEntries() []storageCore.KeyValuePair {
+	s.mutBatch.RLock()
+	defer s.mutBatch.RUnlock()
+
+	return getPendingEntries(s.batch)
+}
+
 // Get returns the value associated to the key
 func (s *SerialDB) Get(key []byte) ([]byte, error) {
 	if s.isClosed() {
@@ -160,7 +173,7 @@ func (s *SerialDB) Get(key []byte) ([]byte, error) {
 	s.mutBatch.RLock()
 	if s.batch.IsRemoved(key) {
 		s.mutBatch.RUnlock()
-		return nil, common.ErrKeyNotFound
+		return nil, s.newKeyNotFoundError(key)
 	}
 
 	data := s.batch.Get(key)
@@ -184,7 +197,7 @@ func (s *SerialDB) Get(key []byte) ([]byte, error) {
 	close(ch)
 
 	if result.err == leveldb.ErrNotFound {
-		return nil, common.ErrKeyNotFound
+		return nil, s.newKeyNotFoundError(key)
 	}
 	if result.err != nil {
 		return nil, result.err
@@ -202,7 +215,7 @@ func (s *SerialDB) Has(key []byte) error {
 	s.mutBatch.RLock()
 	if s.batch.IsRemoved(key) {
 		s.mutBatch.RUnlock()
-		return common.ErrKeyNotFound
+		return s.newKeyNotFoundError(key)
 	}
 
 	data := s.batch.Get(key)
@@ -237,8 +250,35 @@ func (s *SerialDB) tryWriteInDbAccessChan(req serialQueryer) error {
 	}
 }
 
+// WriteBatch writes the provided entries into the database atomically (all or nothing), as a single request of the serial
+// processing loop, without mixing them with the pending batch. An entry having a nil value marks its key for deletion.
+// The pending batch is written first, so that its (older) entries cannot override the provided ones afterwards.
+func (s *SerialDB) WriteBatch(entries []storageCore.KeyValuePair) error {
+	if s.isClosed() {
+		return common.ErrDBIsClosed
+	}
+
+	s.mutWrite.Lock()
+	defer s.mutWrite.Unlock()
+
+	err := s.doPutBatch()
+	if err != nil {
+		return err
+	}
+
+	return s.writeBatchInDb(newBatchFromEntries(entries))
+}
+
 // putBatch writes the Batch data into the database
 func (s *SerialDB) putBatch() error {
+	s.mutWrite.Lock()
+	defer s.mutWrite.Unlock()
+
+	return s.doPutBatch()
+}
+
+// This function should only be called under the (already acquired) s.mutWrite
+func (s *SerialDB) doPutBatch() error {
 	s.mutBatch.Lock()
 	dbBatch, ok := s.batch.(*batch)
 	if !ok {
@@ -249,6 +289,10 @@ func (s *SerialDB) putBatch() error {
 	s.batch = NewBatch()
 	s.mutBatch.Unlock()
 
+	return s.writeBatchInDb(dbBatch)
+}
+
+func (s *SerialDB) writeBatchInDb(dbBatch *batch) error {
 	ch := make(chan error)
 	req := &putBatchAct{
 		batch:   dbBatch,
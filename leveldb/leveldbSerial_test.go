@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/leveldb"
 	"github.com/stretchr/testify/assert"
@@ -119,7 +120,7 @@ func TestSerialDB_RemoveBeforeTimeoutOK(t *testing.T) {
 	v, err := ldb.Get(key)
 
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_RemoveAfterTimeoutOK(t *testing.T) {
@@ -132,7 +133,32 @@ func TestSerialDB_RemoveAfterTimeoutOK(t *testing.T) {
 	v, err := ldb.Get(key)
 
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+}
+
+func TestSerialDB_WriteBatch(t *testing.T) {
+	ldb := createSerialLevelDb(t, 10, 100, 10)
+
+	_ = ldb.Put([]byte("a"), []byte("old-a"))
+	_ = ldb.Put([]byte("b"), []byte("old-b"))
+
+	err := ldb.WriteBatch([]storageCore.KeyValuePair{
+		{Key: []byte("a"), Value: []byte("new-a")},
+		{Key: []byte("b"), Value: nil},
+		{Key: []byte("c"), Value: []byte("new-c")},
+	})
+	assert.Nil(t, err)
+
+	value, err := ldb.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("new-a"), value)
+	assert.ErrorIs(t, ldb.Has([]byte("b")), common.ErrKeyNotFound)
+	value, _ = ldb.Get([]byte("c"))
+	assert.Equal(t, []byte("new-c"), value)
+
+	_ = ldb.Close()
+	err = ldb.WriteBatch([]storageCore.KeyValuePair{{Key: []byte("d"), Value: []byte("d")}})
+	assert.Equal(t, common.ErrDBIsClosed, err)
 }
 
 func TestSerialDB_GetPresent(t *testing.T) {
@@ -172,7 +198,7 @@ func TestSerialDB_HasNotPresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_RemovePresent(t *testing.T) {
@@ -184,7 +210,7 @@ func TestSerialDB_RemovePresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestSerialDB_RemoveNotPresent(t *testing.T) {
@@ -244,7 +270,7 @@ func TestSerialDB_SpecialValueTest(t *testing.T) {
 		require.Nil(t, err)
 
 		recovered, err := ldb.Get(key)
-		assert.Equal(t, common.ErrKeyNotFound, err)
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
 		assert.Nil(t, recovered)
 	})
 	t.Run("operations: put -> remove -> put -> get of 'removed' value", func(t *testing.T) {
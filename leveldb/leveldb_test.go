@@ -2,16 +2,20 @@ package leveldb_test
 
 import (
 	"crypto/rand"
+	"errors"
 	"fmt"
 	"os"
 	"path"
+	"runtime"
 	"testing"
 	"time"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/leveldb"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"github.com/syndtr/goleveldb/leveldb/opt"
 )
 
 func createLevelDb(t *testing.T, batchDelaySeconds int, maxBatchSize int, maxOpenFiles int) (p *leveldb.DB) {
@@ -82,6 +86,58 @@ func TestDB_DoubleOpenButClosedInTimeShouldWork(t *testing.T) {
 	_ = lvdb2.Close()
 }
 
+func TestDB_NewDBWithTuning(t *testing.T) {
+	t.Run("invalid tuning should error", func(t *testing.T) {
+		_, err := leveldb.NewDBWithTuning(t.TempDir(), 10, 1, 10, leveldb.TuningArgs{BlockSizeKB: -1})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+		_, err = leveldb.NewSerialDBWithTuning(t.TempDir(), 10, 1, 10, leveldb.TuningArgs{BloomFilterBitsPerKey: -1})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+		_, err = leveldb.NewDBWithTuning(t.TempDir(), 10, 1, 10, leveldb.TuningArgs{WriteBufferMB: -1})
+		assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+	})
+
+	t.Run("should apply the tuning", func(t *testing.T) {
+		options, err := leveldb.CreateOptions(10, leveldb.TuningArgs{
+			BlockSizeKB:           2,
+			BloomFilterBitsPerKey: 10,
+			WriteBufferMB:         8,
+		})
+		require.Nil(t, err)
+		assert.Equal(t, 2048, options.BlockSize)
+		assert.Equal(t, 8*1024*1024, options.WriteBuffer)
+		assert.NotNil(t, options.Filter)
+		assert.Equal(t, 10, options.OpenFilesCacheCapacity)
+
+		// Zero values fall back to the LevelDB defaults
+		options, err = leveldb.CreateOptions(10, leveldb.TuningArgs{})
+		require.Nil(t, err)
+		assert.Equal(t, opt.DefaultBlockSize, options.GetBlockSize())
+		assert.Equal(t, opt.DefaultWriteBuffer, options.GetWriteBuffer())
+		assert.Nil(t, options.GetFilter())
+	})
+
+	t.Run("tuned database should work", func(t *testing.T) {
+		ldb, err := leveldb.NewDBWithTuning(t.TempDir(), 10, 1, 10, leveldb.TuningArgs{
+			BlockSizeKB:           1,
+			BloomFilterBitsPerKey: 10,
+			WriteBufferMB:         1,
+		})
+		require.Nil(t, err)
+
+		key, val := []byte("key"), []byte("value")
+		err = ldb.Put(key, val)
+		assert.Nil(t, err)
+
+		retrieved, err := ldb.Get(key)
+		assert.Nil(t, err)
+		assert.Equal(t, val, retrieved)
+
+		_ = ldb.Close()
+	})
+}
+
 func TestDB_PutNoError(t *testing.T) {
 	key, val := []byte("key"), []byte("value")
 	ldb := createLevelDb(t, 10, 1, 10)
@@ -140,7 +196,7 @@ func TestDB_RemoveBeforeTimeoutOK(t *testing.T) {
 
 	v, err := ldb.Get(key)
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_RemoveAfterTimeoutOK(t *testing.T) {
@@ -155,7 +211,7 @@ func TestDB_RemoveAfterTimeoutOK(t *testing.T) {
 
 	v, err := ldb.Get(key)
 	assert.Nil(t, v)
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_GetPresent(t *testing.T) {
@@ -181,6 +237,66 @@ func TestDB_GetNotPresent(t *testing.T) {
 	assert.NotNil(t, err, "error expected but got nil, value %s", v)
 }
 
+func TestDB_GetMulti(t *testing.T) {
+	ldb := createLevelDb(t, 10, 2, 10)
+
+	_ = ldb.Put([]byte("a"), []byte("value-a"))
+	_ = ldb.Put([]byte("b"), []byte("value-b"))
+	// Pending in the batch
+	_ = ldb.Put([]byte("c"), []byte("value-c"))
+	_ = ldb.Remove([]byte("b"))
+
+	results, err := ldb.GetMulti([][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("missing")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "c": []byte("value-c")}, results)
+
+	_ = ldb.Close()
+	results, err = ldb.GetMulti([][]byte{[]byte("a")})
+	assert.Nil(t, results)
+	assert.Equal(t, common.ErrDBIsClosed, err)
+}
+
+func TestDB_WriteBatch(t *testing.T) {
+	ldb := createLevelDb(t, 10, 100, 10)
+
+	_ = ldb.Put([]byte("a"), []byte("old-a"))
+	_ = ldb.Put([]byte("b"), []byte("old-b"))
+
+	err := ldb.WriteBatch([]storageCore.KeyValuePair{
+		{Key: []byte("a"), Value: []byte("new-a")},
+		{Key: []byte("b"), Value: nil},
+		{Key: []byte("c"), Value: []byte("new-c")},
+	})
+	assert.Nil(t, err)
+
+	value, err := ldb.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("new-a"), value)
+	assert.ErrorIs(t, ldb.Has([]byte("b")), common.ErrKeyNotFound)
+	value, _ = ldb.Get([]byte("c"))
+	assert.Equal(t, []byte("new-c"), value)
+
+	_ = ldb.Close()
+	err = ldb.WriteBatch([]storageCore.KeyValuePair{{Key: []byte("d"), Value: []byte("d")}})
+	assert.Equal(t, common.ErrDBIsClosed, err)
+}
+
+func TestDB_GetPendingEntries(t *testing.T) {
+	ldb := createLevelDb(t, 10, 100, 10)
+
+	_ = ldb.Put([]byte("a"), []byte("a"))
+	_ = ldb.Put([]byte("b"), []byte("b"))
+	_ = ldb.Remove([]byte("b"))
+
+	entries := ldb.GetPendingEntries()
+	assert.Equal(t, 2, len(entries))
+	pending := make(map[string][]byte)
+	for _, entry := range entries {
+		pending[string(entry.Key)] = entry.Value
+	}
+	assert.Equal(t, map[string][]byte{"a": []byte("a"), "b": nil}, pending)
+}
+
 func TestDB_HasPresent(t *testing.T) {
 	key, val := []byte("key3"), []byte("value3")
 	ldb := createLevelDb(t, 10, 1, 10)
@@ -201,7 +317,12 @@ func TestDB_HasNotPresent(t *testing.T) {
 	err := ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.Contains(t, err.Error(), "key = 6b657934")
+
+	_, err = ldb.Get(key)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+	assert.Contains(t, err.Error(), "key = 6b657934")
 }
 
 func TestDB_RemovePresent(t *testing.T) {
@@ -219,7 +340,7 @@ func TestDB_RemovePresent(t *testing.T) {
 	err = ldb.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestDB_RemoveNotPresent(t *testing.T) {
@@ -281,6 +402,74 @@ func TestDB_RangeKeys(t *testing.T) {
 	assert.Equal(t, keysVals, recovered)
 }
 
+func TestDB_NewIterator(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 1, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("b-key2"), []byte("value2"))
+	_ = ldb.Put([]byte("a-key0"), []byte("value0"))
+	_ = ldb.Put([]byte("b-key1"), []byte("value1"))
+
+	it, err := ldb.NewIterator([]byte("b-"))
+	require.Nil(t, err)
+
+	// writes after the iterator creation are not visible, and do not affect the iterator
+	_ = ldb.Put([]byte("b-key3"), []byte("value3"))
+	_ = ldb.Remove([]byte("b-key1"))
+	_, _ = ldb.Get([]byte("a-key0"))
+
+	keys := make([]string, 0)
+	values := make([]string, 0)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		values = append(values, string(it.Value()))
+	}
+
+	assert.Equal(t, []string{"b-key1", "b-key2"}, keys)
+	assert.Equal(t, []string{"value1", "value2"}, values)
+	assert.Nil(t, it.Close())
+	assert.Nil(t, it.Close())
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Key())
+}
+
+func TestDB_NewIteratorAfterCloseShouldErr(t *testing.T) {
+	t.Parallel()
+
+	ldb := createLevelDb(t, 1, 1, 10)
+	_ = ldb.Close()
+
+	it, err := ldb.NewIterator(nil)
+	assert.Nil(t, it)
+	assert.Equal(t, common.ErrDBIsClosed, err)
+}
+
+func TestDB_NewIteratorNotClosedShouldBeDetected(t *testing.T) {
+	ldb := createLevelDb(t, 1, 1, 10)
+	defer func() {
+		_ = ldb.Close()
+	}()
+
+	_ = ldb.Put([]byte("key"), []byte("value"))
+
+	numLeakedBefore := leveldb.NumLeakedIterators()
+	func() {
+		it, _ := ldb.NewIterator(nil)
+		assert.True(t, it.Next())
+	}()
+
+	for i := 0; i < 100 && leveldb.NumLeakedIterators() == numLeakedBefore; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	assert.Equal(t, numLeakedBefore+1, leveldb.NumLeakedIterators())
+}
+
 func TestDB_PutGetLargeValue(t *testing.T) {
 	t.Parallel()
 
@@ -375,7 +564,7 @@ func TestDB_SpecialValueTest(t *testing.T) {
 		require.Nil(t, err)
 
 		recovered, err := ldb.Get(key)
-		assert.Equal(t, common.ErrKeyNotFound, err)
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
 		assert.Nil(t, recovered)
 	})
 	t.Run("operations: put -> remove -> put -> get of 'removed' value", func(t *testing.T) {
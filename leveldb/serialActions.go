@@ -78,7 +78,7 @@ func (h *hasAct) request(s *SerialDB) {
 		return
 	}
 
-	h.resChan <- common.ErrKeyNotFound
+	h.resChan <- s.newKeyNotFoundError(h.key)
 }
 
 func (h *hasAct) doHasRequest(s *SerialDB) (bool, error) {
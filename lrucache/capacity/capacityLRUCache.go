@@ -20,6 +20,7 @@ type capacityLRU struct {
 	//TODO investigate if we can replace this list with a binary tree. Check also the other implementation lruCache
 	evictList *list.List
 	items     map[interface{}]*list.Element
+	onEvict   func(key interface{}, value interface{})
 }
 
 // entry is used to hold a value in the evictList
@@ -31,6 +32,12 @@ type entry struct {
 
 // NewCapacityLRU constructs an CapacityLRU of the given size with a byte size capacity
 func NewCapacityLRU(size int, byteCapacity int64) (*capacityLRU, error) {
+	return NewCapacityLRUWithEviction(size, byteCapacity, nil)
+}
+
+// NewCapacityLRUWithEviction constructs an CapacityLRU of the given size with a byte size capacity and an eviction callback.
+// The callback is called (while holding the lock of the cache) for each removed entry, be it evicted, removed or purged.
+func NewCapacityLRUWithEviction(size int, byteCapacity int64, onEvict func(key interface{}, value interface{})) (*capacityLRU, error) {
 	if size < 1 {
 		return nil, common.ErrCacheSizeInvalid
 	}
@@ -42,6 +49,7 @@ func NewCapacityLRU(size int, byteCapacity int64) (*capacityLRU, error) {
 		maxCapacityInBytes: byteCapacity,
 		evictList:          list.New(),
 		items:              make(map[interface{}]*list.Element),
+		onEvict:            onEvict,
 	}
 	return c, nil
 }
@@ -51,6 +59,12 @@ func (c *capacityLRU) Purge() {
 	c.lock.Lock()
 	defer c.lock.Unlock()
 
+	if c.onEvict != nil {
+		for key, element := range c.items {
+			c.onEvict(key, element.Value.(*entry).value)
+		}
+	}
+
 	c.items = make(map[interface{}]*list.Element)
 	c.evictList.Init()
 	c.currentCapacityInBytes = 0
@@ -66,6 +80,21 @@ func (c *capacityLRU) AddSized(key, value interface{}, sizeInBytes int64) bool {
 	return c.evictIfNeeded()
 }
 
+// AddSizedBulk adds the provided values to the cache, holding the lock only once. The entries are added in order, thus
+// the outcome is the same as for consecutive calls of AddSized. Returns, for each entry, whether its addition caused an eviction.
+func (c *capacityLRU) AddSizedBulk(keys []interface{}, values []interface{}, sizesInBytes []int64) []bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	evicted := make([]bool, len(keys))
+	for i := range keys {
+		c.addSized(keys[i], values[i], sizesInBytes[i])
+		evicted[i] = c.evictIfNeeded()
+	}
+
+	return evicted
+}
+
 func (c *capacityLRU) addSized(key interface{}, value interface{}, sizeInBytes int64) {
 	if sizeInBytes < 0 {
 		log.Error("size LRU cache add error",
@@ -229,6 +258,20 @@ func (c *capacityLRU) Keys() []interface{} {
 	return keys
 }
 
+// GetOldest returns the oldest (least recently used) entry, without updating its "recently used"-ness
+func (c *capacityLRU) GetOldest() (key interface{}, value interface{}, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	ent := c.evictList.Back()
+	if ent == nil {
+		return nil, nil, false
+	}
+
+	kv := ent.Value.(*entry)
+	return kv.key, kv.value, true
+}
+
 // Len returns the number of items in the cache.
 func (c *capacityLRU) Len() int {
 	c.lock.Lock()
@@ -245,6 +288,28 @@ func (c *capacityLRU) SizeInBytesContained() uint64 {
 	return uint64(c.currentCapacityInBytes)
 }
 
+// Resize changes the maximum number of items of the cache, evicting the oldest items if needed.
+// It returns the number of evicted items.
+func (c *capacityLRU) Resize(size int) int {
+	if size < 1 {
+		log.Warn("capacityLRU.Resize: invalid size, not resized", "size", size)
+		return 0
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.size = size
+
+	numEvicted := 0
+	for c.evictList.Len() > c.size {
+		c.removeOldest()
+		numEvicted++
+	}
+
+	return numEvicted
+}
+
 // removeOldest removes the oldest item from the cache.
 func (c *capacityLRU) removeOldest() {
 	ent := c.evictList.Back()
@@ -259,6 +324,10 @@ func (c *capacityLRU) removeElement(e *list.Element) {
 	kv := e.Value.(*entry)
 	delete(c.items, kv.key)
 	c.currentCapacityInBytes -= kv.size
+
+	if c.onEvict != nil {
+		c.onEvict(kv.key, kv.value)
+	}
 }
 
 func (c *capacityLRU) adjustSize(key interface{}, sizeInBytes int64) {
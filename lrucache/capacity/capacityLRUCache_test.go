@@ -497,3 +497,72 @@ func TestCapacityLRUCache_AddSizedAndReturnEvictedEvictionBySizeInBytesOneLargeE
 	assert.True(t, c.Contains(keys[1]))
 	assert.True(t, c.Contains(keys[2]))
 }
+
+func TestCapacityLRUCache_EvictionCallbackShouldBeCalledForEachRemovedEntry(t *testing.T) {
+	t.Parallel()
+
+	removed := make(map[interface{}]interface{})
+	cache, _ := NewCapacityLRUWithEviction(2, 100, func(key interface{}, value interface{}) {
+		removed[key] = value
+	})
+
+	cache.AddSized("a", "A", 10)
+	cache.AddSized("b", "B", 10)
+	cache.AddSized("c", "C", 10)
+	assert.Equal(t, map[interface{}]interface{}{"a": "A"}, removed)
+
+	cache.Remove("b")
+	assert.Equal(t, map[interface{}]interface{}{"a": "A", "b": "B"}, removed)
+
+	cache.Purge()
+	assert.Equal(t, map[interface{}]interface{}{"a": "A", "b": "B", "c": "C"}, removed)
+}
+
+func TestCapacityLRUCache_Resize(t *testing.T) {
+	t.Parallel()
+
+	evictedKeys := make([]interface{}, 0)
+	c, _ := NewCapacityLRUWithEviction(4, 1000, func(key interface{}, _ interface{}) {
+		evictedKeys = append(evictedKeys, key)
+	})
+
+	for i := 0; i < 4; i++ {
+		c.AddSized(i, i, 1)
+	}
+
+	assert.Equal(t, 0, c.Resize(0))
+	assert.Equal(t, 0, c.Resize(10))
+	assert.Equal(t, 4, c.Len())
+
+	assert.Equal(t, 3, c.Resize(1))
+	assert.Equal(t, []interface{}{0, 1, 2}, evictedKeys)
+	assert.Equal(t, []interface{}{3}, c.Keys())
+	assert.Equal(t, uint64(1), c.SizeInBytesContained())
+
+	c.AddSized(4, 4, 1)
+	assert.Equal(t, []interface{}{4}, c.Keys())
+}
+
+func TestCapacityLRUCache_GetOldest(t *testing.T) {
+	t.Parallel()
+
+	c := createDefaultCache()
+
+	key, value, ok := c.GetOldest()
+	assert.False(t, ok)
+	assert.Nil(t, key)
+	assert.Nil(t, value)
+
+	c.AddSized("a", 1, 1)
+	c.AddSized("b", 2, 1)
+	c.AddSized("c", 3, 1)
+	_, _ = c.Get("a")
+
+	key, value, ok = c.GetOldest()
+	assert.True(t, ok)
+	assert.Equal(t, "b", key)
+	assert.Equal(t, 2, value)
+
+	// Not removed, not made the most recently used
+	assert.Equal(t, []interface{}{"b", "c", "a"}, c.Keys())
+}
@@ -0,0 +1,364 @@
+package capacity
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+)
+
+// recentRatio is the fraction of the capacity (in number of items) targeted for the entries seen only once
+const recentRatio = 0.25
+
+// ghostRatio is the number of keys (as a fraction of the capacity) remembered after their eviction from the "recent" queue
+const ghostRatio = 0.5
+
+// capacityTwoQueue implements a (thread safe) scan-resistant 2Q cache with a max capacity size. The entries seen once are held
+// in a FIFO queue ("recent"), while the entries seen at least twice are promoted to an LRU queue ("frequent").
+// The keys evicted from the "recent" queue are remembered for a while ("ghost" entries), so that they are directly admitted
+// to the "frequent" queue if added again. Thus, sequential scans do not evict the working set (held in the "frequent" queue).
+type capacityTwoQueue struct {
+	lock                   sync.Mutex
+	size                   int
+	maxCapacityInBytes     int64
+	currentCapacityInBytes int64
+	recent                 *list.List
+	frequent               *list.List
+	items                  map[interface{}]*list.Element
+	ghost                  *list.List
+	ghostItems             map[interface{}]*list.Element
+	onEvict                func(key interface{}, value interface{})
+}
+
+// twoQueueEntry is used to hold a value in the "recent" or "frequent" queue
+type twoQueueEntry struct {
+	key        interface{}
+	value      interface{}
+	size       int64
+	isFrequent bool
+}
+
+// NewCapacityTwoQueueWithEviction constructs a 2Q cache of the given size with a byte size capacity and an eviction callback.
+// The callback is called (while holding the lock of the cache) for each removed entry, be it evicted, removed or purged.
+func NewCapacityTwoQueueWithEviction(size int, byteCapacity int64, onEvict func(key interface{}, value interface{})) (*capacityTwoQueue, error) {
+	if size < 1 {
+		return nil, common.ErrCacheSizeInvalid
+	}
+	if byteCapacity < 1 {
+		return nil, common.ErrCacheCapacityInvalid
+	}
+
+	return &capacityTwoQueue{
+		size:               size,
+		maxCapacityInBytes: byteCapacity,
+		recent:             list.New(),
+		frequent:           list.New(),
+		items:              make(map[interface{}]*list.Element),
+		ghost:              list.New(),
+		ghostItems:         make(map[interface{}]*list.Element),
+		onEvict:            onEvict,
+	}, nil
+}
+
+// Purge is used to completely clear the cache (including the ghost entries).
+func (c *capacityTwoQueue) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if c.onEvict != nil {
+		for key, element := range c.items {
+			c.onEvict(key, element.Value.(*twoQueueEntry).value)
+		}
+	}
+
+	c.items = make(map[interface{}]*list.Element)
+	c.recent.Init()
+	c.frequent.Init()
+	c.ghostItems = make(map[interface{}]*list.Element)
+	c.ghost.Init()
+	c.currentCapacityInBytes = 0
+}
+
+// AddSized adds a value to the cache. Returns true if an eviction occurred.
+func (c *capacityTwoQueue) AddSized(key, value interface{}, sizeInBytes int64) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if !c.addSized(key, value, sizeInBytes) {
+		return false
+	}
+
+	return c.evictIfNeeded()
+}
+
+// AddSizedIfMissing checks if a key is in the cache (without promoting it), and if not, adds the value.
+// Returns whether the key was found and whether an eviction occurred.
+func (c *capacityTwoQueue) AddSizedIfMissing(key, value interface{}, sizeInBytes int64) (bool, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.items[key]
+	if ok {
+		return true, false
+	}
+
+	if !c.addSized(key, value, sizeInBytes) {
+		return false, false
+	}
+
+	return false, c.evictIfNeeded()
+}
+
+func (c *capacityTwoQueue) addSized(key interface{}, value interface{}, sizeInBytes int64) bool {
+	if sizeInBytes < 0 {
+		log.Error("size 2Q cache add error",
+			"key", fmt.Sprintf("%v", key),
+			"value", fmt.Sprintf("%v", value),
+			"error", common.ErrNegativeSizeInBytes,
+		)
+
+		return false
+	}
+
+	element, ok := c.items[key]
+	if ok {
+		ent := element.Value.(*twoQueueEntry)
+		c.currentCapacityInBytes += sizeInBytes - ent.size
+		ent.value = value
+		ent.size = sizeInBytes
+		c.touch(element)
+		return true
+	}
+
+	ent := &twoQueueEntry{
+		key:   key,
+		value: value,
+		size:  sizeInBytes,
+	}
+
+	// A key seen recently (but evicted from the "recent" queue) is admitted directly to the "frequent" queue
+	ghostElement, wasGhost := c.ghostItems[key]
+	if wasGhost {
+		c.ghost.Remove(ghostElement)
+		delete(c.ghostItems, key)
+
+		ent.isFrequent = true
+		c.items[key] = c.frequent.PushFront(ent)
+	} else {
+		c.items[key] = c.recent.PushFront(ent)
+	}
+
+	c.currentCapacityInBytes += sizeInBytes
+	return true
+}
+
+// touch promotes an entry of the "recent" queue to the "frequent" one, or marks an entry of the "frequent" queue as most recently used
+func (c *capacityTwoQueue) touch(element *list.Element) {
+	ent := element.Value.(*twoQueueEntry)
+	if ent.isFrequent {
+		c.frequent.MoveToFront(element)
+		return
+	}
+
+	c.recent.Remove(element)
+	ent.isFrequent = true
+	c.items[ent.key] = c.frequent.PushFront(ent)
+}
+
+// Get looks up a key's value from the cache, promoting the entry.
+func (c *capacityTwoQueue) Get(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	c.touch(element)
+	return element.Value.(*twoQueueEntry).value, true
+}
+
+// Contains checks if a key is in the cache, without promoting the entry.
+func (c *capacityTwoQueue) Contains(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	_, ok := c.items[key]
+	return ok
+}
+
+// Peek returns the key's value (or nil if not found), without promoting the entry.
+func (c *capacityTwoQueue) Peek(key interface{}) (interface{}, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	return element.Value.(*twoQueueEntry).value, true
+}
+
+// Remove removes the provided key from the cache (the key is not remembered as a ghost entry), returning if the key was contained.
+func (c *capacityTwoQueue) Remove(key interface{}) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element, ok := c.items[key]
+	if !ok {
+		return false
+	}
+
+	c.removeElement(element)
+	return true
+}
+
+// Keys returns a slice of the keys in the cache: the ones seen once (from oldest to newest),
+// followed by the ones seen at least twice (from least recently used to most recently used).
+func (c *capacityTwoQueue) Keys() []interface{} {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	keys := make([]interface{}, 0, len(c.items))
+	for element := c.recent.Back(); element != nil; element = element.Prev() {
+		keys = append(keys, element.Value.(*twoQueueEntry).key)
+	}
+	for element := c.frequent.Back(); element != nil; element = element.Prev() {
+		keys = append(keys, element.Value.(*twoQueueEntry).key)
+	}
+
+	return keys
+}
+
+// Len returns the number of items in the cache.
+func (c *capacityTwoQueue) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return len(c.items)
+}
+
+// SizeInBytesContained returns the size in bytes of all contained elements
+func (c *capacityTwoQueue) SizeInBytesContained() uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return uint64(c.currentCapacityInBytes)
+}
+
+// GetOldest returns the entry which would be evicted next, without promoting it
+func (c *capacityTwoQueue) GetOldest() (key interface{}, value interface{}, ok bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	element := c.getEvictionCandidate()
+	if element == nil {
+		return nil, nil, false
+	}
+
+	ent := element.Value.(*twoQueueEntry)
+	return ent.key, ent.value, true
+}
+
+// Resize changes the maximum number of items of the cache, evicting items if needed.
+// It returns the number of evicted items.
+func (c *capacityTwoQueue) Resize(size int) int {
+	if size < 1 {
+		log.Warn("capacityTwoQueue.Resize: invalid size, not resized", "size", size)
+		return 0
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.size = size
+
+	numEvicted := 0
+	for c.shouldEvict() {
+		c.evictOne()
+		numEvicted++
+	}
+
+	c.trimGhosts()
+
+	return numEvicted
+}
+
+// getEvictionCandidate returns the oldest entry of the "recent" queue if the queue exceeds its target size
+// (or if the "frequent" queue is empty), otherwise the least recently used entry of the "frequent" queue
+func (c *capacityTwoQueue) getEvictionCandidate() *list.Element {
+	recentTarget := int(float64(c.size) * recentRatio)
+	if c.recent.Len() > 0 && (c.recent.Len() > recentTarget || c.frequent.Len() == 0) {
+		return c.recent.Back()
+	}
+
+	return c.frequent.Back()
+}
+
+func (c *capacityTwoQueue) evictOne() {
+	element := c.getEvictionCandidate()
+	if element == nil {
+		return
+	}
+
+	ent := element.Value.(*twoQueueEntry)
+	c.removeElement(element)
+
+	if !ent.isFrequent {
+		c.ghostItems[ent.key] = c.ghost.PushFront(ent.key)
+		c.trimGhosts()
+	}
+}
+
+func (c *capacityTwoQueue) trimGhosts() {
+	maxGhosts := int(float64(c.size) * ghostRatio)
+	for c.ghost.Len() > maxGhosts {
+		oldest := c.ghost.Back()
+		c.ghost.Remove(oldest)
+		delete(c.ghostItems, oldest.Value)
+	}
+}
+
+// removeElement is used to remove a given list element from the cache
+func (c *capacityTwoQueue) removeElement(element *list.Element) {
+	ent := element.Value.(*twoQueueEntry)
+	if ent.isFrequent {
+		c.frequent.Remove(element)
+	} else {
+		c.recent.Remove(element)
+	}
+
+	delete(c.items, ent.key)
+	c.currentCapacityInBytes -= ent.size
+
+	if c.onEvict != nil {
+		c.onEvict(ent.key, ent.value)
+	}
+}
+
+func (c *capacityTwoQueue) shouldEvict() bool {
+	if len(c.items) <= 1 {
+		// keep at least one element, no matter how large it is
+		return false
+	}
+
+	return len(c.items) > c.size || c.currentCapacityInBytes > c.maxCapacityInBytes
+}
+
+func (c *capacityTwoQueue) evictIfNeeded() bool {
+	evicted := false
+	for c.shouldEvict() {
+		c.evictOne()
+		evicted = true
+	}
+
+	return evicted
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *capacityTwoQueue) IsInterfaceNil() bool {
+	return c == nil
+}
@@ -0,0 +1,208 @@
+package capacity
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewCapacityTwoQueueWithEviction(t *testing.T) {
+	t.Parallel()
+
+	cache, err := NewCapacityTwoQueueWithEviction(0, 1, nil)
+	assert.True(t, check.IfNil(cache))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	cache, err = NewCapacityTwoQueueWithEviction(1, 0, nil)
+	assert.True(t, check.IfNil(cache))
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+
+	cache, err = NewCapacityTwoQueueWithEviction(1, 5, nil)
+	assert.False(t, check.IfNil(cache))
+	assert.Nil(t, err)
+}
+
+func TestCapacityTwoQueue_AddSizedNegativeSizeInBytesShouldReturn(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityTwoQueueWithEviction(10, 100, nil)
+	evicted := cache.AddSized("key", "value", -1)
+	assert.False(t, evicted)
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCapacityTwoQueue_AddSizedShouldAccountTheSize(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityTwoQueueWithEviction(10, 100, nil)
+	cache.AddSized("a", "a", 10)
+	cache.AddSized("b", "b", 20)
+	assert.Equal(t, uint64(30), cache.SizeInBytesContained())
+
+	// Overwrite
+	cache.AddSized("a", "aa", 15)
+	assert.Equal(t, uint64(35), cache.SizeInBytesContained())
+	value, ok := cache.Peek("a")
+	assert.True(t, ok)
+	assert.Equal(t, "aa", value)
+
+	cache.Remove("b")
+	assert.Equal(t, uint64(15), cache.SizeInBytesContained())
+
+	cache.Purge()
+	assert.Equal(t, uint64(0), cache.SizeInBytesContained())
+	assert.Equal(t, 0, cache.Len())
+}
+
+func TestCapacityTwoQueue_ShouldEvictWhenCapacityInBytesExceeded(t *testing.T) {
+	t.Parallel()
+
+	evictedKeys := make([]interface{}, 0)
+	cache, _ := NewCapacityTwoQueueWithEviction(10, 100, func(key interface{}, _ interface{}) {
+		evictedKeys = append(evictedKeys, key)
+	})
+
+	assert.False(t, cache.AddSized("a", "a", 40))
+	assert.False(t, cache.AddSized("b", "b", 40))
+	assert.True(t, cache.AddSized("c", "c", 40))
+	assert.Equal(t, []interface{}{"a"}, evictedKeys)
+	assert.Equal(t, uint64(80), cache.SizeInBytesContained())
+
+	// A single (large) element is kept
+	cache.Purge()
+	evictedKeys = evictedKeys[:0]
+	assert.False(t, cache.AddSized("huge", "huge", 1000))
+	assert.Equal(t, 1, cache.Len())
+}
+
+func TestCapacityTwoQueue_GetShouldPromoteToFrequent(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityTwoQueueWithEviction(4, 1000, nil)
+	cache.AddSized("a", "a", 1)
+	cache.AddSized("b", "b", 1)
+
+	_, ok := cache.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, []interface{}{"b", "a"}, cache.Keys())
+
+	// "recent" entries are evicted first, even if newer than "a"
+	cache.AddSized("c", "c", 1)
+	cache.AddSized("d", "d", 1)
+	cache.AddSized("e", "e", 1)
+	assert.Equal(t, []interface{}{"e", "a"}, cache.Keys()[2:])
+	assert.False(t, cache.Contains("b"))
+	assert.True(t, cache.Contains("a"))
+}
+
+func TestCapacityTwoQueue_GhostEntryShouldBeAdmittedToFrequent(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityTwoQueueWithEviction(4, 1000, nil)
+	for i := 0; i < 5; i++ {
+		cache.AddSized(i, i, 1)
+	}
+
+	assert.False(t, cache.Contains(0))
+
+	// "0" is remembered as a ghost entry, thus directly admitted to the "frequent" queue
+	cache.AddSized(0, 0, 1)
+	keys := cache.Keys()
+	assert.Equal(t, 0, keys[len(keys)-1])
+}
+
+func TestCapacityTwoQueue_GetOldest(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityTwoQueueWithEviction(4, 1000, nil)
+	_, _, ok := cache.GetOldest()
+	assert.False(t, ok)
+
+	cache.AddSized("a", "a", 1)
+	cache.AddSized("b", "b", 1)
+	key, value, ok := cache.GetOldest()
+	assert.True(t, ok)
+	assert.Equal(t, "a", key)
+	assert.Equal(t, "a", value)
+}
+
+func TestCapacityTwoQueue_Resize(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := NewCapacityTwoQueueWithEviction(10, 1000, nil)
+	for i := 0; i < 10; i++ {
+		cache.AddSized(i, i, 1)
+	}
+
+	assert.Equal(t, 0, cache.Resize(0))
+	assert.Equal(t, 6, cache.Resize(4))
+	assert.Equal(t, 4, cache.Len())
+	assert.Equal(t, uint64(4), cache.SizeInBytesContained())
+}
+
+func TestCapacityTwoQueue_ScanWorkloadShouldNotEvictTheWorkingSet(t *testing.T) {
+	t.Parallel()
+
+	size := 200
+	numHotKeys := 100
+	scanLength := 1000
+	numRounds := 20
+
+	lruCache, _ := NewCapacityLRUWithEviction(size, 1_000_000, nil)
+	twoQueueCache, _ := NewCapacityTwoQueueWithEviction(size, 1_000_000, nil)
+
+	lruHitRate := simulateScanWithWorkingSet(lruCache, numHotKeys, scanLength, numRounds)
+	twoQueueHitRate := simulateScanWithWorkingSet(twoQueueCache, numHotKeys, scanLength, numRounds)
+
+	// with LRU, each scan flushes the working set, so only the second pass of each round hits
+	assert.InDelta(t, 0.5, lruHitRate, 0.01)
+	assert.Greater(t, twoQueueHitRate, 0.95)
+}
+
+type sizedCache interface {
+	AddSized(key, value interface{}, sizeInBytes int64) bool
+	Get(key interface{}) (interface{}, bool)
+}
+
+// simulateScanWithWorkingSet interleaves accesses to a working set with sequential scans (of never repeated keys)
+// and returns the hit rate of the working set
+func simulateScanWithWorkingSet(cache sizedCache, numHotKeys int, scanLength int, numRounds int) float64 {
+	numHits := 0
+	numAccesses := 0
+	scanKey := 0
+
+	access := func(key string) bool {
+		_, ok := cache.Get(key)
+		if !ok {
+			cache.AddSized(key, key, 1)
+		}
+
+		return ok
+	}
+
+	for round := 0; round < numRounds; round++ {
+		// the working set is accessed twice per round
+		for pass := 0; pass < 2; pass++ {
+			for i := 0; i < numHotKeys; i++ {
+				hit := access(fmt.Sprintf("hot-%d", i))
+				if round > 0 {
+					// the first round only warms up the cache
+					numAccesses++
+					if hit {
+						numHits++
+					}
+				}
+			}
+		}
+
+		for i := 0; i < scanLength; i++ {
+			_ = access(fmt.Sprintf("scan-%d", scanKey))
+			scanKey++
+		}
+	}
+
+	return float64(numHits) / float64(numAccesses)
+}
@@ -0,0 +1,193 @@
+package lrucache
+
+import (
+	"context"
+	"time"
+
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+// PutWithTTL adds a value to the cache, to be treated as missing once the provided time-to-live elapses.
+// Expired entries are removed lazily (on access) or by the sweeping goroutine (see NewCacheWithTTL).
+// Returns true if an eviction occurred.
+func (c *lruCache) PutWithTTL(key []byte, value interface{}, sizeInBytes int, ttl time.Duration) (evicted bool) {
+	if ttl <= 0 {
+		log.Trace("lruCache.PutWithTTL: value not added, ttl is not positive", "key", key, "ttl", ttl)
+		return false
+	}
+
+	size, ok := c.computeSize(key, value, sizeInBytes)
+	if !ok {
+		return false
+	}
+
+	evicted = c.cache.AddSized(string(key), value, size)
+	c.setExpiry(string(key), time.Now().Add(ttl))
+	c.stats.RecordPut()
+
+	c.callAddedDataHandlers(key, value)
+
+	return evicted
+}
+
+func (c *lruCache) setExpiry(key string, expiry time.Time) {
+	c.mutExpiries.Lock()
+	defer c.mutExpiries.Unlock()
+
+	if c.expiries == nil {
+		c.expiries = make(map[string]time.Time)
+	}
+
+	c.expiries[key] = expiry
+}
+
+// removeExpiry makes the entry (if any) live forever
+func (c *lruCache) removeExpiry(key string) {
+	c.mutExpiries.RLock()
+	_, ok := c.expiries[key]
+	c.mutExpiries.RUnlock()
+
+	if !ok {
+		return
+	}
+
+	c.mutExpiries.Lock()
+	delete(c.expiries, key)
+	c.mutExpiries.Unlock()
+}
+
+func (c *lruCache) removeExpiries(keys []interface{}) {
+	c.mutExpiries.Lock()
+	defer c.mutExpiries.Unlock()
+
+	if len(c.expiries) == 0 {
+		return
+	}
+
+	for _, key := range keys {
+		keyString, _ := key.(string)
+		delete(c.expiries, keyString)
+	}
+}
+
+func (c *lruCache) clearExpiries() {
+	c.mutExpiries.Lock()
+	defer c.mutExpiries.Unlock()
+
+	if len(c.expiries) > 0 {
+		c.expiries = make(map[string]time.Time)
+	}
+}
+
+// setBulkRemovalReason sets the reason of the removals performed by a bulk operation (e.g. Clear),
+// to be reported for the entries leaving the cache until the reason is reset (with an empty value)
+func (c *lruCache) setBulkRemovalReason(reason types.RemovalReason) {
+	c.mutExpiries.Lock()
+	c.bulkRemovalReason = reason
+	c.mutExpiries.Unlock()
+}
+
+// removeIfExpired returns true if the entry was expired (thus removed)
+func (c *lruCache) removeIfExpired(key string) bool {
+	c.mutExpiries.RLock()
+	expiry, ok := c.expiries[key]
+	c.mutExpiries.RUnlock()
+
+	if !ok || time.Now().Before(expiry) {
+		return false
+	}
+
+	c.removeWithReason(key, types.RemovalReasonExpired)
+	return true
+}
+
+// removeWithReason removes the entry, letting the eviction callback know about the reason of the removal.
+// Returns true if the entry was held by the cache.
+func (c *lruCache) removeWithReason(key string, reason types.RemovalReason) bool {
+	c.mutExpiries.Lock()
+	c.pendingRemovals[key] = reason
+	c.mutExpiries.Unlock()
+
+	removed := c.cache.Remove(key)
+
+	c.mutExpiries.Lock()
+	delete(c.pendingRemovals, key)
+	delete(c.expiries, key)
+	c.mutExpiries.Unlock()
+
+	return removed
+}
+
+// onEvicted is called by the underlying cache (under its own lock) for each entry leaving the cache
+func (c *lruCache) onEvicted(key interface{}, value interface{}) {
+	keyString, _ := key.(string)
+
+	c.mutExpiries.Lock()
+	reason, ok := c.pendingRemovals[keyString]
+	if !ok {
+		reason = c.getImplicitRemovalReason(keyString)
+	}
+	delete(c.expiries, keyString)
+	c.mutExpiries.Unlock()
+
+	if reason == types.RemovalReasonEvicted || reason == types.RemovalReasonResized {
+		c.stats.RecordEvictions(1)
+	}
+	if c.onRemoved != nil {
+		c.onRemoved([]byte(keyString), value, reason)
+	}
+	c.removalNotifier.Notify([]byte(keyString), value, reason)
+}
+
+// This function should only be called under the (already acquired) c.mutExpiries
+func (c *lruCache) getImplicitRemovalReason(key string) types.RemovalReason {
+	if len(c.bulkRemovalReason) > 0 {
+		return c.bulkRemovalReason
+	}
+
+	expiry, ok := c.expiries[key]
+	if ok && !time.Now().Before(expiry) {
+		return types.RemovalReasonExpired
+	}
+
+	return types.RemovalReasonEvicted
+}
+
+// startSweeping periodically removes the expired entries, until the context is cancelled
+func (c *lruCache) startSweeping(ctx context.Context, sweepInterval time.Duration) {
+	timer := time.NewTimer(sweepInterval)
+	defer timer.Stop()
+
+	for {
+		timer.Reset(sweepInterval)
+
+		select {
+		case <-timer.C:
+			c.sweep()
+		case <-ctx.Done():
+			log.Debug("closing lruCache's sweep go routine...")
+			return
+		}
+	}
+}
+
+func (c *lruCache) sweep() {
+	now := time.Now()
+	expiredKeys := make([]string, 0)
+
+	c.mutExpiries.RLock()
+	for key, expiry := range c.expiries {
+		if !now.Before(expiry) {
+			expiredKeys = append(expiredKeys, key)
+		}
+	}
+	c.mutExpiries.RUnlock()
+
+	for _, key := range expiredKeys {
+		c.removeWithReason(key, types.RemovalReasonExpired)
+	}
+
+	if len(expiredKeys) > 0 {
+		log.Trace("lruCache.sweep", "num expired", len(expiredKeys))
+	}
+}
@@ -0,0 +1,186 @@
+package lrucache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+type removalsRecorder struct {
+	mut      sync.Mutex
+	removals map[string]types.RemovalReason
+}
+
+func newRemovalsRecorder() *removalsRecorder {
+	return &removalsRecorder{
+		removals: make(map[string]types.RemovalReason),
+	}
+}
+
+func (recorder *removalsRecorder) onRemoved(key []byte, _ interface{}, reason types.RemovalReason) {
+	recorder.mut.Lock()
+	recorder.removals[string(key)] = reason
+	recorder.mut.Unlock()
+}
+
+func (recorder *removalsRecorder) getReason(key string) types.RemovalReason {
+	recorder.mut.Lock()
+	defer recorder.mut.Unlock()
+
+	return recorder.removals[key]
+}
+
+func TestNewCacheWithTTL(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewCacheWithTTL(0, 0, nil)
+	assert.True(t, check.IfNil(c))
+	assert.NotNil(t, err)
+
+	c, err = lrucache.NewCacheWithTTL(10, -time.Second, nil)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrInvalidSweepInterval, err)
+
+	c, err = lrucache.NewCacheWithTTL(10, 0, nil)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+}
+
+func TestLRUCache_PutWithTTLExpiredEntriesShouldBeMissing(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+
+	c.PutWithTTL([]byte("a"), "a", 0, 50*time.Millisecond)
+	c.PutWithTTL([]byte("b"), "b", 0, 50*time.Millisecond)
+	c.PutWithTTL([]byte("c"), "c", 0, 50*time.Millisecond)
+	c.Put([]byte("forever"), "forever", 0)
+
+	value, ok := c.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	assert.True(t, c.Has([]byte("b")))
+
+	time.Sleep(100 * time.Millisecond)
+
+	value, ok = c.Get([]byte("a"))
+	assert.False(t, ok)
+	assert.Nil(t, value)
+	assert.False(t, c.Has([]byte("b")))
+	value, ok = c.Peek([]byte("c"))
+	assert.False(t, ok)
+	assert.Nil(t, value)
+	assert.True(t, c.Has([]byte("forever")))
+
+	// Expired entries are lazily removed
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRUCache_PutWithTTLNonPositiveTTLShouldNotAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+
+	c.PutWithTTL([]byte("a"), "a", 0, 0)
+	assert.False(t, c.Has([]byte("a")))
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRUCache_PutShouldResetTTL(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+
+	c.PutWithTTL([]byte("a"), "a", 0, 50*time.Millisecond)
+	c.Put([]byte("a"), "a", 0)
+
+	c.PutWithTTL([]byte("b"), "b", 0, 50*time.Millisecond)
+	c.PutWithTTL([]byte("b"), "b", 0, time.Hour)
+
+	time.Sleep(100 * time.Millisecond)
+
+	assert.True(t, c.Has([]byte("a")))
+	assert.True(t, c.Has([]byte("b")))
+}
+
+func TestLRUCache_HasOrAddOnExpiredEntryShouldAdd(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+
+	c.PutWithTTL([]byte("a"), "old", 0, 50*time.Millisecond)
+	has, added := c.HasOrAdd([]byte("a"), "new", 0)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	time.Sleep(100 * time.Millisecond)
+
+	has, added = c.HasOrAdd([]byte("a"), "new", 0)
+	assert.False(t, has)
+	assert.True(t, added)
+
+	value, ok := c.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "new", value)
+}
+
+func TestLRUCacheWithTTL_RemovalCallbacksShouldHaveReasons(t *testing.T) {
+	t.Parallel()
+
+	recorder := newRemovalsRecorder()
+	c, _ := lrucache.NewCacheWithTTL(3, 0, recorder.onRemoved)
+
+	c.PutWithTTL([]byte("expiring"), "expiring", 0, 50*time.Millisecond)
+	c.Put([]byte("removed"), "removed", 0)
+	c.Put([]byte("evicted"), "evicted", 0)
+
+	c.Remove([]byte("removed"))
+	assert.Equal(t, types.RemovalReasonRemoved, recorder.getReason("removed"))
+
+	time.Sleep(100 * time.Millisecond)
+	_, ok := c.Get([]byte("expiring"))
+	assert.False(t, ok)
+	assert.Equal(t, types.RemovalReasonExpired, recorder.getReason("expiring"))
+
+	c.Put([]byte("x"), "x", 0)
+	c.Put([]byte("y"), "y", 0)
+	c.Put([]byte("z"), "z", 0)
+	assert.Equal(t, types.RemovalReasonEvicted, recorder.getReason("evicted"))
+
+	c.Clear()
+	assert.Equal(t, types.RemovalReasonCleared, recorder.getReason("x"))
+	assert.Equal(t, types.RemovalReasonCleared, recorder.getReason("y"))
+	assert.Equal(t, types.RemovalReasonCleared, recorder.getReason("z"))
+}
+
+func TestLRUCacheWithTTL_SweeperShouldRemoveExpiredEntries(t *testing.T) {
+	t.Parallel()
+
+	recorder := newRemovalsRecorder()
+	c, _ := lrucache.NewCacheWithTTL(10, 10*time.Millisecond, recorder.onRemoved)
+
+	c.PutWithTTL([]byte("a"), "a", 0, 20*time.Millisecond)
+	c.Put([]byte("b"), "b", 0)
+
+	assert.Eventually(t, func() bool {
+		return c.Len() == 1
+	}, time.Second, 5*time.Millisecond)
+	assert.Equal(t, types.RemovalReasonExpired, recorder.getReason("a"))
+	assert.True(t, c.Has([]byte("b")))
+
+	err := c.Close()
+	assert.Nil(t, err)
+
+	// After close, the entries are only removed lazily
+	c.PutWithTTL([]byte("c"), "c", 0, 10*time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 2, c.Len())
+	assert.False(t, c.Has([]byte("c")))
+	assert.Equal(t, 1, c.Len())
+}
@@ -1,94 +1,281 @@
 package lrucache
 
 import (
+	"context"
+	"math"
 	"sync"
+	"time"
 
 	lru "github.com/hashicorp/golang-lru"
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
 	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/cacheStats"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/lrucache/capacity"
+	"github.com/multiversx/mx-chain-storage-go/removalNotifier"
 	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 var _ types.Cacher = (*lruCache)(nil)
+var _ types.InspectableCacher = (*lruCache)(nil)
 
 var log = logger.GetOrCreate("storage/lrucache")
 
 // LRUCache implements a Least Recently Used eviction cache
 type lruCache struct {
 	cache   types.SizedLRUCacheHandler
-	maxsize int
+	maxsize atomic.Counter
+
+	sizer          func(value interface{}) uint64
+	maxSizeInBytes uint64
+
+	mutExpiries       sync.RWMutex
+	expiries          map[string]time.Time
+	pendingRemovals   map[string]types.RemovalReason
+	bulkRemovalReason types.RemovalReason
+	onRemoved         func(key []byte, value interface{}, reason types.RemovalReason)
+	removalNotifier   *removalNotifier.RemovalNotifier
+	cancelSweep       context.CancelFunc
+	stats             *cacheStats.StatsCollector
+	accessCounter     *cacheStats.AccessCounter
 
 	mutAddedDataHandlers sync.RWMutex
 	mapDataHandlers      map[string]func(key []byte, value interface{})
+	mutResize            sync.Mutex
+}
+
+type keyValuePair struct {
+	key   []byte
+	value interface{}
+}
+
+type resizableCache interface {
+	Resize(size int) int
+}
+
+type oldestEntryProvider interface {
+	GetOldest() (key interface{}, value interface{}, ok bool)
+}
+
+type bulkSizedAdder interface {
+	AddSizedBulk(keys []interface{}, values []interface{}, sizesInBytes []int64) []bool
 }
 
 // NewCache creates a new LRU cache instance
 func NewCache(size int) (*lruCache, error) {
-	cache, err := lru.New(size)
+	c := newEmptyLRUCache(size)
+
+	cache, err := lru.NewWithEvict(size, c.onEvicted)
 	if err != nil {
 		return nil, err
 	}
 
-	c := createLRUCache(size, cache)
+	c.setLRUCache(cache)
 
 	return c, nil
 }
 
 // NewCacheWithEviction creates a new sized LRU cache instance with eviction function
 func NewCacheWithEviction(size int, onEvicted func(key interface{}, value interface{})) (*lruCache, error) {
-	cache, err := lru.NewWithEvict(size, onEvicted)
+	c := newEmptyLRUCache(size)
+
+	cache, err := lru.NewWithEvict(size, func(key interface{}, value interface{}) {
+		if onEvicted != nil {
+			onEvicted(key, value)
+		}
+		c.onEvicted(key, value)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	c.setLRUCache(cache)
+
+	return c, nil
+}
+
+// NewCacheWithTTL creates a new LRU cache instance supporting per-key time-to-live (see PutWithTTL).
+// If sweepInterval is not zero, a background goroutine removes the expired entries periodically, until Close is called.
+// The (optional) onRemoved callback is called for each entry leaving the cache, along with the reason of the removal.
+func NewCacheWithTTL(
+	size int,
+	sweepInterval time.Duration,
+	onRemoved func(key []byte, value interface{}, reason types.RemovalReason),
+) (*lruCache, error) {
+	if sweepInterval < 0 {
+		return nil, common.ErrInvalidSweepInterval
+	}
+
+	c := newEmptyLRUCache(size)
+	c.onRemoved = onRemoved
+
+	cache, err := lru.NewWithEvict(size, c.onEvicted)
 	if err != nil {
 		return nil, err
 	}
 
-	c := createLRUCache(size, cache)
+	c.setLRUCache(cache)
+
+	if sweepInterval > 0 {
+		var ctx context.Context
+		ctx, c.cancelSweep = context.WithCancel(context.Background())
+		go c.startSweeping(ctx, sweepInterval)
+	}
 
 	return c, nil
 }
 
-func createLRUCache(size int, cache *lru.Cache) *lruCache {
+func newEmptyLRUCache(size int) *lruCache {
 	c := &lruCache{
-		cache: &simpleLRUCacheAdapter{
-			LRUCacheHandler: cache,
-		},
-		maxsize:              size,
+		expiries:             make(map[string]time.Time),
+		pendingRemovals:      make(map[string]types.RemovalReason),
+		removalNotifier:      removalNotifier.NewRemovalNotifier(),
+		stats:                cacheStats.NewStatsCollector(),
+		accessCounter:        cacheStats.NewAccessCounter(),
 		mutAddedDataHandlers: sync.RWMutex{},
 		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
 	}
+	c.maxsize.Set(int64(size))
+
 	return c
 }
 
+func (c *lruCache) setLRUCache(cache *lru.Cache) {
+	c.cache = &simpleLRUCacheAdapter{
+		LRUCacheHandler: cache,
+	}
+}
+
 // NewCacheWithSizeInBytes creates a new sized LRU cache instance
 func NewCacheWithSizeInBytes(size int, sizeInBytes int64) (*lruCache, error) {
-	cache, err := capacity.NewCapacityLRU(size, sizeInBytes)
+	c := newEmptyLRUCache(size)
+
+	cache, err := capacity.NewCapacityLRUWithEviction(size, sizeInBytes, c.onEvicted)
 	if err != nil {
 		return nil, err
 	}
 
-	c := &lruCache{
-		cache:                cache,
-		maxsize:              size,
-		mutAddedDataHandlers: sync.RWMutex{},
-		mapDataHandlers:      make(map[string]func(key []byte, value interface{})),
+	c.cache = cache
+
+	return c, nil
+}
+
+// NewTwoQueueCacheWithSizeInBytes creates a new sized, scan-resistant cache instance, using the 2Q admission policy:
+// the entries seen only once cannot evict the entries seen at least twice (the working set).
+func NewTwoQueueCacheWithSizeInBytes(size int, sizeInBytes int64) (*lruCache, error) {
+	c := newEmptyLRUCache(size)
+
+	cache, err := capacity.NewCapacityTwoQueueWithEviction(size, sizeInBytes, c.onEvicted)
+	if err != nil {
+		return nil, err
 	}
 
+	c.cache = cache
+
+	return c, nil
+}
+
+// NewLRUCacheWithSize creates a new LRU cache instance bounded only by the cumulative size of the stored values.
+// The size of each value is computed by the provided sizer (the sizeInBytes argument of Put and HasOrAdd is ignored).
+// Least recently used entries are evicted until the cumulative size fits under maxBytes, while values
+// larger than maxBytes are rejected.
+func NewLRUCacheWithSize(maxBytes uint64, sizer func(value interface{}) uint64) (*lruCache, error) {
+	if sizer == nil {
+		return nil, common.ErrNilSizer
+	}
+	if maxBytes > math.MaxInt64 {
+		return nil, common.ErrCacheCapacityInvalid
+	}
+
+	c := newEmptyLRUCache(math.MaxInt32)
+	c.sizer = sizer
+	c.maxSizeInBytes = maxBytes
+
+	cache, err := capacity.NewCapacityLRUWithEviction(math.MaxInt32, int64(maxBytes), c.onEvicted)
+	if err != nil {
+		return nil, err
+	}
+
+	c.cache = cache
+
 	return c, nil
 }
 
 // Clear is used to completely clear the cache.
 func (c *lruCache) Clear() {
+	c.setBulkRemovalReason(types.RemovalReasonCleared)
 	c.cache.Purge()
+	c.setBulkRemovalReason("")
+
+	c.clearExpiries()
 }
 
 // Put adds a value to the cache.  Returns true if an eviction occurred.
 func (c *lruCache) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
-	evicted = c.cache.AddSized(string(key), value, int64(sizeInBytes))
+	size, ok := c.computeSize(key, value, sizeInBytes)
+	if !ok {
+		return false
+	}
+
+	evicted = c.cache.AddSized(string(key), value, size)
+	c.removeExpiry(string(key))
+	c.stats.RecordPut()
 
 	c.callAddedDataHandlers(key, value)
 
 	return evicted
 }
 
+// PutBulk adds the provided entries to the cache, in order. If the underlying cache supports it, its lock is acquired only once
+// for all the entries. The added data handlers are called after all the entries have been added.
+// Returns, for each entry, whether an eviction occurred (as Put does). The entries rejected by the sizer are not added.
+func (c *lruCache) PutBulk(entries []types.KeyValueSizePair) []bool {
+	evicted := make([]bool, len(entries))
+
+	keys := make([]interface{}, 0, len(entries))
+	values := make([]interface{}, 0, len(entries))
+	sizes := make([]int64, 0, len(entries))
+	positions := make([]int, 0, len(entries))
+	for i, entry := range entries {
+		size, ok := c.computeSize(entry.Key, entry.Value, entry.SizeInBytes)
+		if !ok {
+			continue
+		}
+
+		keys = append(keys, string(entry.Key))
+		values = append(values, entry.Value)
+		sizes = append(sizes, size)
+		positions = append(positions, i)
+	}
+
+	adder, isBulkAdder := c.cache.(bulkSizedAdder)
+	if isBulkAdder {
+		evictedByAdded := adder.AddSizedBulk(keys, values, sizes)
+		for i, position := range positions {
+			evicted[position] = evictedByAdded[i]
+		}
+	} else {
+		for i, position := range positions {
+			evicted[position] = c.cache.AddSized(keys[i], values[i], sizes[i])
+		}
+	}
+
+	c.removeExpiries(keys)
+	for range positions {
+		c.stats.RecordPut()
+	}
+
+	c.mutAddedDataHandlers.RLock()
+	for _, position := range positions {
+		for _, handler := range c.mapDataHandlers {
+			go handler(entries[position].Key, entries[position].Value)
+		}
+	}
+	c.mutAddedDataHandlers.RUnlock()
+
+	return evicted
+}
+
 // RegisterHandler registers a new handler to be called when a new data is added
 func (c *lruCache) RegisterHandler(handler func(key []byte, value interface{}), id string) {
 	if handler == nil {
@@ -110,18 +297,36 @@ func (c *lruCache) UnRegisterHandler(id string) {
 
 // Get looks up a key's value from the cache.
 func (c *lruCache) Get(key []byte) (value interface{}, ok bool) {
-	return c.cache.Get(string(key))
+	c.accessCounter.RecordAccess(key)
+
+	if c.removeIfExpired(string(key)) {
+		c.stats.RecordLookup(false)
+		return nil, false
+	}
+
+	value, ok = c.cache.Get(string(key))
+	c.stats.RecordLookup(ok)
+
+	return value, ok
 }
 
 // Has checks if a key is in the cache, without updating the
 // recent-ness or deleting it for being stale.
 func (c *lruCache) Has(key []byte) bool {
+	if c.removeIfExpired(string(key)) {
+		return false
+	}
+
 	return c.cache.Contains(string(key))
 }
 
 // Peek returns the key value (or undefined if not found) without updating
 // the "recently used"-ness of the key.
 func (c *lruCache) Peek(key []byte) (value interface{}, ok bool) {
+	if c.removeIfExpired(string(key)) {
+		return nil, false
+	}
+
 	v, ok := c.cache.Peek(string(key))
 
 	if !ok {
@@ -135,15 +340,38 @@ func (c *lruCache) Peek(key []byte) (value interface{}, ok bool) {
 // recent-ness or deleting it for being stale,  and if not, adds the value.
 // Returns whether found and whether an eviction occurred.
 func (c *lruCache) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
-	has, _ = c.cache.AddSizedIfMissing(string(key), value, int64(sizeInBytes))
+	size, ok := c.computeSize(key, value, sizeInBytes)
+	if !ok {
+		return c.Has(key), false
+	}
+
+	_ = c.removeIfExpired(string(key))
+	has, _ = c.cache.AddSizedIfMissing(string(key), value, size)
 
 	if !has {
+		c.removeExpiry(string(key))
+		c.stats.RecordPut()
 		c.callAddedDataHandlers(key, value)
 	}
 
 	return has, !has
 }
 
+// computeSize returns the size to be accounted for the value, and false if the value cannot be stored because it is too large
+func (c *lruCache) computeSize(key []byte, value interface{}, sizeInBytes int) (int64, bool) {
+	if c.sizer == nil {
+		return int64(sizeInBytes), true
+	}
+
+	size := c.sizer(value)
+	if size > c.maxSizeInBytes {
+		log.Trace("lruCache: value too large, rejected", "key", key, "size", size, "max size", c.maxSizeInBytes)
+		return 0, false
+	}
+
+	return int64(size), true
+}
+
 func (c *lruCache) callAddedDataHandlers(key []byte, value interface{}) {
 	c.mutAddedDataHandlers.RLock()
 	for _, handler := range c.mapDataHandlers {
@@ -154,7 +382,7 @@ func (c *lruCache) callAddedDataHandlers(key []byte, value interface{}) {
 
 // Remove removes the provided key from the cache.
 func (c *lruCache) Remove(key []byte) {
-	c.cache.Remove(string(key))
+	c.removeWithReason(string(key), types.RemovalReasonRemoved)
 }
 
 // Keys returns a slice of the keys in the cache, from oldest to newest.
@@ -169,6 +397,62 @@ func (c *lruCache) Keys() [][]byte {
 	return r
 }
 
+// ForEach iterates over the entries of the cache (from oldest to newest), until the callback returns false.
+// The entries are copied before the iteration, so the callback is not invoked under the internal lock of the cache
+// and it is free to mutate the cache. The "recently used"-ness of the entries is not affected. Expired entries are skipped.
+func (c *lruCache) ForEach(fn func(key []byte, value interface{}) bool) {
+	if fn == nil {
+		return
+	}
+
+	keys := c.cache.Keys()
+	entries := make([]keyValuePair, 0, len(keys))
+	for _, key := range keys {
+		keyAsBytes := []byte(key.(string))
+		value, ok := c.Peek(keyAsBytes)
+		if !ok {
+			// Removed in the meantime
+			continue
+		}
+
+		entries = append(entries, keyValuePair{key: keyAsBytes, value: value})
+	}
+
+	for _, entry := range entries {
+		if !fn(entry.key, entry.value) {
+			return
+		}
+	}
+}
+
+// RemoveOldest removes the least recently used entry, which is notified to the removal handlers with the "explicit-oldest" reason.
+// It returns the removed entry, or ok=false if the cache is empty (or if the underlying cache cannot provide its oldest entry).
+// Useful for consumers implementing their own admission control, in order to free exactly one slot.
+func (c *lruCache) RemoveOldest() (key []byte, value interface{}, ok bool) {
+	return c.removeOldestWithReason(types.RemovalReasonExplicitOldest)
+}
+
+func (c *lruCache) removeOldestWithReason(reason types.RemovalReason) (key []byte, value interface{}, ok bool) {
+	provider, isProvider := c.cache.(oldestEntryProvider)
+	if !isProvider {
+		return nil, nil, false
+	}
+
+	for {
+		oldestKey, oldestValue, found := provider.GetOldest()
+		if !found {
+			return nil, nil, false
+		}
+
+		keyString, _ := oldestKey.(string)
+		if c.removeWithReason(keyString, reason) {
+			return []byte(keyString), oldestValue, true
+		}
+
+		// Removed in the meantime, look again
+	}
+}
+
 // Len returns the number of items in the cache.
 func (c *lruCache) Len() int {
 	return c.cache.Len()
@@ -181,11 +465,98 @@ func (c *lruCache) SizeInBytesContained() uint64 {
 
 // MaxSize returns the maximum number of items which can be stored in cache.
 func (c *lruCache) MaxSize() int {
-	return c.maxsize
+	return int(c.maxsize.Get())
 }
 
-// Close does nothing for this cacher implementation
+// Resize changes the maximum number of items which can be stored in the cache. When shrinking, the least recently used
+// entries are evicted (and notified to the removal handlers with the "resized" reason). Growing does not drop any entry.
+// It returns the number of evicted entries.
+// The entries dropped by shrinking are removed one by one, before changing the capacity of the underlying cache, so that
+// the "resized" reason is only reported for them (and not for the evictions caused by the concurrent additions). The entries added
+// concurrently, in excess of the new capacity, are evicted by the underlying cache (and notified with the "evicted" reason).
+func (c *lruCache) Resize(newSize int) (evicted int, err error) {
+	if c == nil {
+		return 0, common.ErrNilCacher
+	}
+	if newSize < 1 {
+		return 0, common.ErrCacheSizeInvalid
+	}
+
+	resizable, ok := c.getResizableCache()
+	if !ok {
+		return 0, common.ErrCacheNotResizable
+	}
+
+	c.mutResize.Lock()
+	defer c.mutResize.Unlock()
+
+	numInExcess := c.cache.Len() - newSize
+	for ; evicted < numInExcess; evicted++ {
+		_, _, removed := c.removeOldestWithReason(types.RemovalReasonResized)
+		if !removed {
+			break
+		}
+	}
+
+	evicted += resizable.Resize(newSize)
+	c.maxsize.Set(int64(newSize))
+
+	return evicted, nil
+}
+
+// getResizableCache returns the underlying cache, if it can be resized (see Resize)
+func (c *lruCache) getResizableCache() (resizableCache, bool) {
+	adapter, isAdapter := c.cache.(*simpleLRUCacheAdapter)
+	if isAdapter {
+		resizable, ok := adapter.LRUCacheHandler.(resizableCache)
+		return resizable, ok
+	}
+
+	resizable, ok := c.cache.(resizableCache)
+	return resizable, ok
+}
+
+// RegisterHandlerForRemoval registers a new handler to be called when an entry is removed from the cache.
+// The handlers are called on a dedicated goroutine, outside the internal locks of the cache.
+func (c *lruCache) RegisterHandlerForRemoval(handler func(key []byte, value interface{}, reason types.RemovalReason), id string) {
+	c.removalNotifier.RegisterHandler(handler, id)
+}
+
+// UnRegisterHandlerForRemoval removes the removal handler having the given id
+func (c *lruCache) UnRegisterHandlerForRemoval(id string) {
+	c.removalNotifier.UnRegisterHandler(id)
+}
+
+// Stats returns the usage statistics of the cache. Hits and misses are recorded by Get.
+func (c *lruCache) Stats() types.CacheStats {
+	return c.stats.Stats(c.Len(), c.SizeInBytesContained())
+}
+
+// ResetStats resets the usage statistics of the cache
+func (c *lruCache) ResetStats() {
+	c.stats.Reset()
+}
+
+// SetAccessCountingEnabled enables (or disables) the counting of the reads of each key (see GetAccessCounts), disabled by default
+func (c *lruCache) SetAccessCountingEnabled(enabled bool) {
+	c.accessCounter.SetEnabled(enabled)
+}
+
+// GetAccessCounts returns how many times each key has been read (see Get) since the previous call, sorted by count (descending),
+// then resets the counters. The keys are identified by their hash (see cacheStats.HashOfKey).
+// At most cacheStats.MaxTrackedKeys keys are tracked between two calls. Nothing is counted unless enabled, see SetAccessCountingEnabled.
+func (c *lruCache) GetAccessCounts() []types.KeyAccessCount {
+	return c.accessCounter.GetAccessCounts()
+}
+
+// Close stops the sweeping goroutine and the removal notifications, if any
 func (c *lruCache) Close() error {
+	if c.cancelSweep != nil {
+		c.cancelSweep()
+	}
+
+	c.removalNotifier.Close()
+
 	return nil
 }
 
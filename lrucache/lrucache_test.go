@@ -3,13 +3,16 @@ package lrucache_test
 import (
 	"bytes"
 	"fmt"
+	"math"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/cacheStats"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -64,6 +67,133 @@ func TestNewCacheWithSizeInBytes_ShouldWork(t *testing.T) {
 	assert.Nil(t, err)
 }
 
+//------- NewTwoQueueCacheWithSizeInBytes
+
+func TestNewTwoQueueCacheWithSizeInBytes_BadArgumentsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewTwoQueueCacheWithSizeInBytes(0, 100000)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+
+	c, err = lrucache.NewTwoQueueCacheWithSizeInBytes(1, 0)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+}
+
+func TestNewTwoQueueCacheWithSizeInBytes_ShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewTwoQueueCacheWithSizeInBytes(4, 100000)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+
+	for i := 0; i < 5; i++ {
+		c.Put([]byte{byte(i)}, i, 10)
+	}
+
+	assert.Equal(t, 4, c.Len())
+	assert.Equal(t, uint64(40), c.SizeInBytesContained())
+	assert.False(t, c.Has([]byte{0}))
+
+	value, ok := c.Get([]byte{4})
+	assert.True(t, ok)
+	assert.Equal(t, 4, value)
+}
+
+//------- NewLRUCacheWithSize
+
+func byteSliceSizer(value interface{}) uint64 {
+	return uint64(len(value.([]byte)))
+}
+
+func TestNewLRUCacheWithSize_NilSizerShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewLRUCacheWithSize(100, nil)
+
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrNilSizer, err)
+}
+
+func TestNewLRUCacheWithSize_BadMaxBytesShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewLRUCacheWithSize(0, byteSliceSizer)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+
+	c, err = lrucache.NewLRUCacheWithSize(math.MaxUint64, byteSliceSizer)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrCacheCapacityInvalid, err)
+}
+
+func TestNewLRUCacheWithSize_ShouldWork(t *testing.T) {
+	t.Parallel()
+
+	c, err := lrucache.NewLRUCacheWithSize(100, byteSliceSizer)
+
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+}
+
+func TestLRUCacheWithSize_PutShouldEvictLeastRecentlyUsedByTotalSize(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewLRUCacheWithSize(100, byteSliceSizer)
+
+	// The provided sizeInBytes is ignored, the sizer is used instead
+	evicted := c.Put([]byte("a"), make([]byte, 40), 0)
+	assert.False(t, evicted)
+	evicted = c.Put([]byte("b"), make([]byte, 40), 0)
+	assert.False(t, evicted)
+	assert.Equal(t, uint64(80), c.SizeInBytesContained())
+
+	// Touch "a", so that "b" becomes the least recently used
+	_, _ = c.Get([]byte("a"))
+
+	evicted = c.Put([]byte("c"), make([]byte, 30), 0)
+	assert.True(t, evicted)
+	assert.Equal(t, uint64(70), c.SizeInBytesContained())
+	assert.True(t, c.Has([]byte("a")))
+	assert.False(t, c.Has([]byte("b")))
+	assert.True(t, c.Has([]byte("c")))
+
+	// Growing an existing value evicts others
+	_ = c.Put([]byte("a"), make([]byte, 90), 0)
+	assert.Equal(t, uint64(90), c.SizeInBytesContained())
+	assert.Equal(t, 1, c.Len())
+}
+
+func TestLRUCacheWithSize_PutTooLargeValueShouldBeRejected(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewLRUCacheWithSize(100, byteSliceSizer)
+	_ = c.Put([]byte("a"), make([]byte, 50), 0)
+	_ = c.Put([]byte("b"), make([]byte, 50), 0)
+
+	evicted := c.Put([]byte("c"), make([]byte, 101), 0)
+	assert.False(t, evicted)
+	assert.False(t, c.Has([]byte("c")))
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, uint64(100), c.SizeInBytesContained())
+
+	has, added := c.HasOrAdd([]byte("c"), make([]byte, 101), 0)
+	assert.False(t, has)
+	assert.False(t, added)
+	assert.False(t, c.Has([]byte("c")))
+
+	has, added = c.HasOrAdd([]byte("a"), make([]byte, 101), 0)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	// Exactly at the cap is accepted
+	_ = c.Put([]byte("d"), make([]byte, 100), 0)
+	assert.True(t, c.Has([]byte("d")))
+	assert.Equal(t, 1, c.Len())
+	assert.Equal(t, uint64(100), c.SizeInBytesContained())
+}
+
 func TestLRUCache_PutNotPresent(t *testing.T) {
 	t.Parallel()
 
@@ -418,3 +548,455 @@ func TestLRUCache_CloseShouldNotErr(t *testing.T) {
 	err := c.Close()
 	assert.Nil(t, err)
 }
+
+func TestLRUCache_RegisterHandlerForRemoval(t *testing.T) {
+	t.Parallel()
+
+	t.Run("simple LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(2)
+		testRemovalHandlers(t, c)
+	})
+
+	t.Run("capacity LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(2, 1000)
+		testRemovalHandlers(t, c)
+	})
+}
+
+func testRemovalHandlers(t *testing.T, c types.Cacher) {
+	cache := c.(interface {
+		types.Cacher
+		RegisterHandlerForRemoval(handler func(key []byte, value interface{}, reason types.RemovalReason), id string)
+		UnRegisterHandlerForRemoval(id string)
+	})
+
+	removals := make(map[string]types.RemovalReason)
+	cache.RegisterHandlerForRemoval(func(key []byte, value interface{}, reason types.RemovalReason) {
+		assert.Equal(t, string(key), value)
+		removals[string(key)] = reason
+	}, "recorder")
+	cache.RegisterHandlerForRemoval(func(key []byte, _ interface{}, _ types.RemovalReason) {
+		assert.Fail(t, "should have been unregistered", string(key))
+	}, "unregistered")
+	cache.UnRegisterHandlerForRemoval("unregistered")
+
+	cache.Put([]byte("a"), "a", 1)
+	cache.Put([]byte("b"), "b", 1)
+	cache.Put([]byte("c"), "c", 1)
+	cache.Remove([]byte("b"))
+	cache.Remove([]byte("missing"))
+	cache.Clear()
+
+	// Close dispatches the pending notifications
+	err := cache.Close()
+	assert.Nil(t, err)
+
+	expected := map[string]types.RemovalReason{
+		"a": types.RemovalReasonEvicted,
+		"b": types.RemovalReasonRemoved,
+		"c": types.RemovalReasonCleared,
+	}
+	assert.Equal(t, expected, removals)
+}
+
+func TestLRUCache_RemoveOldest(t *testing.T) {
+	t.Parallel()
+
+	t.Run("simple LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(10)
+		testRemoveOldest(t, c)
+	})
+
+	t.Run("capacity LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+		testRemoveOldest(t, c)
+	})
+}
+
+func testRemoveOldest(t *testing.T, c types.Cacher) {
+	cache := c.(interface {
+		types.Cacher
+		RemoveOldest() (key []byte, value interface{}, ok bool)
+		RegisterHandlerForRemoval(handler func(key []byte, value interface{}, reason types.RemovalReason), id string)
+	})
+
+	key, value, ok := cache.RemoveOldest()
+	assert.False(t, ok)
+	assert.Nil(t, key)
+	assert.Nil(t, value)
+
+	removals := make(map[string]types.RemovalReason)
+	cache.RegisterHandlerForRemoval(func(key []byte, value interface{}, reason types.RemovalReason) {
+		assert.Equal(t, string(key), value)
+		removals[string(key)] = reason
+	}, "recorder")
+
+	cache.Put([]byte("a"), "a", 1)
+	cache.Put([]byte("b"), "b", 1)
+	cache.Put([]byte("c"), "c", 1)
+	// "a" becomes the most recently used
+	_, _ = cache.Get([]byte("a"))
+
+	key, value, ok = cache.RemoveOldest()
+	assert.True(t, ok)
+	assert.Equal(t, []byte("b"), key)
+	assert.Equal(t, "b", value)
+
+	key, _, _ = cache.RemoveOldest()
+	assert.Equal(t, []byte("c"), key)
+	key, _, _ = cache.RemoveOldest()
+	assert.Equal(t, []byte("a"), key)
+
+	_, _, ok = cache.RemoveOldest()
+	assert.False(t, ok)
+	assert.Equal(t, 0, cache.Len())
+
+	// Close dispatches the pending notifications
+	err := cache.Close()
+	assert.Nil(t, err)
+
+	expected := map[string]types.RemovalReason{
+		"a": types.RemovalReasonExplicitOldest,
+		"b": types.RemovalReasonExplicitOldest,
+		"c": types.RemovalReasonExplicitOldest,
+	}
+	assert.Equal(t, expected, removals)
+}
+
+func TestLRUCache_StatsAndResetStats(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(2)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	_, _ = c.HasOrAdd([]byte("b"), "b", 0)
+	_, _ = c.HasOrAdd([]byte("c"), "c", 0)
+	c.Remove([]byte("b"))
+
+	_, ok := c.Get([]byte("c"))
+	assert.True(t, ok)
+	_, ok = c.Get([]byte("a"))
+	assert.False(t, ok)
+
+	stats := c.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(3), stats.Puts)
+	assert.Equal(t, uint64(1), stats.Evictions)
+	assert.Equal(t, 1, stats.Len)
+
+	c.ResetStats()
+	c.Clear()
+
+	stats = c.Stats()
+	assert.Equal(t, uint64(0), stats.Hits)
+	assert.Equal(t, uint64(0), stats.Misses)
+	assert.Equal(t, uint64(0), stats.Puts)
+	assert.Equal(t, uint64(0), stats.Evictions)
+	assert.Equal(t, 0, stats.Len)
+}
+
+func TestLRUCache_Resize(t *testing.T) {
+	t.Parallel()
+
+	t.Run("simple LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(4)
+		testResize(t, c)
+	})
+
+	t.Run("capacity LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(4, 1000)
+		testResize(t, c)
+	})
+}
+
+func testResize(t *testing.T, c types.Cacher) {
+	cache := c.(interface {
+		types.Cacher
+		Resize(newSize int) (int, error)
+		RegisterHandlerForRemoval(handler func(key []byte, value interface{}, reason types.RemovalReason), id string)
+	})
+
+	removals := make(map[string]types.RemovalReason)
+	cache.RegisterHandlerForRemoval(func(key []byte, _ interface{}, reason types.RemovalReason) {
+		removals[string(key)] = reason
+	}, "recorder")
+
+	cache.Put([]byte("a"), "a", 1)
+	cache.Put([]byte("b"), "b", 1)
+	cache.Put([]byte("c"), "c", 1)
+	cache.Put([]byte("d"), "d", 1)
+	_, _ = cache.Get([]byte("a"))
+
+	evicted, err := cache.Resize(0)
+	assert.Equal(t, common.ErrCacheSizeInvalid, err)
+	assert.Equal(t, 0, evicted)
+
+	evicted, err = cache.Resize(8)
+	assert.Nil(t, err)
+	assert.Equal(t, 0, evicted)
+	assert.Equal(t, 8, cache.MaxSize())
+	assert.Equal(t, 4, cache.Len())
+
+	evicted, err = cache.Resize(2)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, evicted)
+	assert.Equal(t, 2, cache.MaxSize())
+	assert.Equal(t, [][]byte{[]byte("d"), []byte("a")}, cache.Keys())
+
+	cache.Put([]byte("e"), "e", 1)
+	assert.Equal(t, [][]byte{[]byte("a"), []byte("e")}, cache.Keys())
+
+	// Close dispatches the pending notifications
+	_ = cache.Close()
+
+	expected := map[string]types.RemovalReason{
+		"b": types.RemovalReasonResized,
+		"c": types.RemovalReasonResized,
+		"d": types.RemovalReasonEvicted,
+	}
+	assert.Equal(t, expected, removals)
+}
+
+func TestLRUCache_ForEach(t *testing.T) {
+	t.Parallel()
+
+	t.Run("simple LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(10)
+		testForEach(t, c)
+	})
+
+	t.Run("capacity LRU", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(10, 1000)
+		testForEach(t, c)
+	})
+}
+
+func testForEach(t *testing.T, c types.Cacher) {
+	cache := c.(interface {
+		types.Cacher
+		ForEach(fn func(key []byte, value interface{}) bool)
+	})
+
+	cache.ForEach(nil)
+
+	cache.Put([]byte("a"), "va", 1)
+	cache.Put([]byte("b"), "vb", 1)
+	cache.Put([]byte("c"), "vc", 1)
+	_, _ = cache.Get([]byte("a"))
+
+	keys := make([]string, 0)
+	values := make([]interface{}, 0)
+	cache.ForEach(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		values = append(values, value)
+		return true
+	})
+	assert.Equal(t, []string{"b", "c", "a"}, keys)
+	assert.Equal(t, []interface{}{"vb", "vc", "va"}, values)
+
+	// The iteration does not affect the "recently used"-ness of the entries
+	assert.Equal(t, [][]byte{[]byte("b"), []byte("c"), []byte("a")}, cache.Keys())
+
+	keys = make([]string, 0)
+	cache.ForEach(func(key []byte, value interface{}) bool {
+		keys = append(keys, string(key))
+		return len(keys) < 2
+	})
+	assert.Equal(t, []string{"b", "c"}, keys)
+
+	// Mutating the cache from within the callback does not deadlock
+	cache.ForEach(func(key []byte, value interface{}) bool {
+		cache.Remove(key)
+		cache.Put([]byte("new-"+string(key)), value, 1)
+		return true
+	})
+	assert.Equal(t, 3, cache.Len())
+	assert.True(t, cache.Has([]byte("new-a")))
+}
+
+func TestLRUCache_ForEachConcurrentWithOperations(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(100)
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			c.Put(key, i, 0)
+			c.Remove([]byte(fmt.Sprintf("key-%d", i/2)))
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			c.ForEach(func(key []byte, value interface{}) bool {
+				assert.NotNil(t, value)
+				return true
+			})
+		}
+	}()
+
+	wg.Wait()
+}
+
+func createKeyValueSizePairs(numEntries int, sizeInBytes int) []types.KeyValueSizePair {
+	entries := make([]types.KeyValueSizePair, numEntries)
+	for i := range entries {
+		entries[i] = types.KeyValueSizePair{
+			Key:         []byte(fmt.Sprintf("key-%d", i)),
+			Value:       i,
+			SizeInBytes: sizeInBytes,
+		}
+	}
+
+	return entries
+}
+
+func TestLRUCache_PutBulk(t *testing.T) {
+	t.Parallel()
+
+	t.Run("sized cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCacheWithSizeInBytes(100, 250)
+		testPutBulk(t, c)
+	})
+	t.Run("simple cache", func(t *testing.T) {
+		t.Parallel()
+
+		c, _ := lrucache.NewCache(2)
+		testPutBulk(t, c)
+	})
+}
+
+type bulkPutter interface {
+	types.Cacher
+	PutBulk(entries []types.KeyValueSizePair) []bool
+}
+
+func testPutBulk(t *testing.T, c bulkPutter) {
+	wg := sync.WaitGroup{}
+	wg.Add(3)
+	c.RegisterHandler(func(_ []byte, _ interface{}) {
+		wg.Done()
+	}, "counter")
+
+	evicted := c.PutBulk(createKeyValueSizePairs(3, 100))
+	assert.Equal(t, []bool{false, false, true}, evicted)
+	assert.Equal(t, 2, c.Len())
+	assert.False(t, c.Has([]byte("key-0")))
+	assert.True(t, c.Has([]byte("key-1")))
+	assert.True(t, c.Has([]byte("key-2")))
+
+	chDone := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(chDone)
+	}()
+	select {
+	case <-chDone:
+	case <-time.After(timeoutWaitForWaitGroups):
+		assert.Fail(t, "the added data handlers should have been called")
+	}
+
+	assert.Empty(t, c.PutBulk(nil))
+}
+
+func TestLRUCache_PutBulkWithSizerShouldSkipTooLargeValues(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewLRUCacheWithSize(100, func(value interface{}) uint64 {
+		return uint64(len(value.(string)))
+	})
+
+	evicted := c.PutBulk([]types.KeyValueSizePair{
+		{Key: []byte("a"), Value: string(make([]byte, 60))},
+		{Key: []byte("b"), Value: string(make([]byte, 200))},
+		{Key: []byte("c"), Value: string(make([]byte, 60))},
+	})
+	assert.Equal(t, []bool{false, false, true}, evicted)
+	assert.False(t, c.Has([]byte("a")))
+	assert.False(t, c.Has([]byte("b")))
+	assert.True(t, c.Has([]byte("c")))
+	assert.Equal(t, uint64(60), c.SizeInBytesContained())
+}
+
+func BenchmarkLRUCache_PutBulk(b *testing.B) {
+	entries := createKeyValueSizePairs(10000, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, _ := lrucache.NewCacheWithSizeInBytes(20000, 10000000)
+		_ = c.PutBulk(entries)
+	}
+}
+
+func BenchmarkLRUCache_PutSequential(b *testing.B) {
+	entries := createKeyValueSizePairs(10000, 100)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c, _ := lrucache.NewCacheWithSizeInBytes(20000, 10000000)
+		for _, entry := range entries {
+			_ = c.Put(entry.Key, entry.Value, entry.SizeInBytes)
+		}
+	}
+}
+
+func TestLRUCache_GetAccessCounts(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+	testGetAccessCounts(t, c)
+}
+
+func testGetAccessCounts(t *testing.T, c types.InspectableCacher) {
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+
+	// Disabled by default
+	_, _ = c.Get([]byte("a"))
+	assert.Empty(t, c.GetAccessCounts())
+
+	c.SetAccessCountingEnabled(true)
+	_, _ = c.Get([]byte("a"))
+	_, _ = c.Get([]byte("b"))
+	_, _ = c.Get([]byte("b"))
+	_, _ = c.Get([]byte("missing"))
+	_ = c.Has([]byte("a"))
+	_, _ = c.Peek([]byte("a"))
+
+	// Sorted by count (descending), then by the hash of the key (ascending)
+	expected := []types.KeyAccessCount{
+		{KeyHash: cacheStats.HashOfKey([]byte("b")), Count: 2},
+		{KeyHash: cacheStats.HashOfKey([]byte("missing")), Count: 1},
+		{KeyHash: cacheStats.HashOfKey([]byte("a")), Count: 1},
+	}
+	assert.Equal(t, expected, c.GetAccessCounts())
+	assert.Empty(t, c.GetAccessCounts())
+}
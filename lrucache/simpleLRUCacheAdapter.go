@@ -19,6 +19,16 @@ func (slca *simpleLRUCacheAdapter) AddSizedIfMissing(key, value interface{}, _ i
 	return slca.ContainsOrAdd(key, value)
 }
 
+// GetOldest returns the oldest (least recently used) entry, if the wrapped cache is able to provide it
+func (slca *simpleLRUCacheAdapter) GetOldest() (key interface{}, value interface{}, ok bool) {
+	provider, isProvider := slca.LRUCacheHandler.(oldestEntryProvider)
+	if !isProvider {
+		return nil, nil, false
+	}
+
+	return provider.GetOldest()
+}
+
 // SizeInBytesContained returns 0
 func (slca *simpleLRUCacheAdapter) SizeInBytesContained() uint64 {
 	return 0
@@ -0,0 +1,234 @@
+package lrucache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+)
+
+const snapshotMagic = "LRUS"
+const snapshotVersion = uint32(1)
+const snapshotPrefixSize = 4
+
+// maxSnapshotRecordSize guards against allocating huge buffers when reading a corrupted stream
+const maxSnapshotRecordSize = 64 * 1024 * 1024
+
+// SaveTo writes the entries of the cache (from oldest to newest) to the provided writer, so that they can be restored by LoadFrom.
+// The snapshot consists of a header (magic and version), the number of records, the records themselves
+// (key and marshalled value, each prefixed by its length) and a trailing CRC-32 checksum. All integers are 4 bytes, big endian.
+// The "recently used"-ness of the entries is not affected.
+func (c *lruCache) SaveTo(w io.Writer, marshal func(value interface{}) ([]byte, error)) error {
+	if c == nil {
+		return common.ErrNilCacher
+	}
+	if w == nil {
+		return common.ErrNilWriter
+	}
+	if marshal == nil {
+		return common.ErrNilMarshalizer
+	}
+
+	records := make([]keyValuePair, 0, c.Len())
+	var errMarshal error
+	c.ForEach(func(key []byte, value interface{}) bool {
+		var buff []byte
+		buff, errMarshal = marshal(value)
+		if errMarshal != nil {
+			errMarshal = fmt.Errorf("%w for key %x", errMarshal, key)
+			return false
+		}
+
+		records = append(records, keyValuePair{key: key, value: buff})
+		return true
+	})
+	if errMarshal != nil {
+		return errMarshal
+	}
+
+	checksum := crc32.NewIEEE()
+	writer := io.MultiWriter(w, checksum)
+
+	err := writeSnapshotHeader(writer, len(records))
+	if err != nil {
+		return err
+	}
+
+	for _, record := range records {
+		err = writeSnapshotField(writer, record.key)
+		if err != nil {
+			return err
+		}
+
+		err = writeSnapshotField(writer, record.value.([]byte))
+		if err != nil {
+			return err
+		}
+	}
+
+	return writeSnapshotUint32(w, checksum.Sum32())
+}
+
+// LoadFrom adds to the cache the entries read from the provided reader, as written by SaveTo. The whole snapshot is read
+// and verified before any entry is added, so that a truncated or corrupted snapshot leaves the cache untouched.
+// The existing entries are kept (an existing key wins over the record having the same key). If the records do not fit
+// in the free slots of the cache, the oldest records are dropped; the size in bytes (as given by sizeOf) is subject to the usual eviction.
+func (c *lruCache) LoadFrom(r io.Reader, unmarshal func([]byte) (interface{}, error), sizeOf func(interface{}) int) error {
+	if c == nil {
+		return common.ErrNilCacher
+	}
+	if r == nil {
+		return common.ErrNilReader
+	}
+	if unmarshal == nil {
+		return common.ErrNilMarshalizer
+	}
+	if sizeOf == nil {
+		return common.ErrNilSizer
+	}
+
+	records, err := readSnapshot(r)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]keyValuePair, 0, len(records))
+	for _, record := range records {
+		value, errUnmarshal := unmarshal(record.value.([]byte))
+		if errUnmarshal != nil {
+			return fmt.Errorf("%w: %v for key %x", common.ErrCorruptedSnapshot, errUnmarshal, record.key)
+		}
+
+		entries = append(entries, keyValuePair{key: record.key, value: value})
+	}
+
+	numFreeSlots := c.MaxSize() - c.Len()
+	if numFreeSlots < 0 {
+		numFreeSlots = 0
+	}
+	if len(entries) > numFreeSlots {
+		entries = entries[len(entries)-numFreeSlots:]
+	}
+
+	// From oldest to newest, so that the newest records end up as the most recently used
+	for _, entry := range entries {
+		_, _ = c.HasOrAdd(entry.key, entry.value, sizeOf(entry.value))
+	}
+
+	return nil
+}
+
+func writeSnapshotHeader(w io.Writer, numRecords int) error {
+	_, err := w.Write([]byte(snapshotMagic))
+	if err != nil {
+		return err
+	}
+
+	err = writeSnapshotUint32(w, snapshotVersion)
+	if err != nil {
+		return err
+	}
+
+	return writeSnapshotUint32(w, uint32(numRecords))
+}
+
+func writeSnapshotField(w io.Writer, field []byte) error {
+	err := writeSnapshotUint32(w, uint32(len(field)))
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(field)
+	return err
+}
+
+func writeSnapshotUint32(w io.Writer, value uint32) error {
+	buff := make([]byte, snapshotPrefixSize)
+	binary.BigEndian.PutUint32(buff, value)
+
+	_, err := w.Write(buff)
+	return err
+}
+
+// readSnapshot reads and verifies the whole snapshot; the values of the returned records are the marshalled values
+func readSnapshot(r io.Reader) ([]keyValuePair, error) {
+	checksum := crc32.NewIEEE()
+	reader := io.TeeReader(r, checksum)
+
+	magic := make([]byte, len(snapshotMagic))
+	_, err := io.ReadFull(reader, magic)
+	if err != nil || !bytes.Equal(magic, []byte(snapshotMagic)) {
+		return nil, fmt.Errorf("%w: invalid header", common.ErrCorruptedSnapshot)
+	}
+
+	version, err := readSnapshotUint32(reader)
+	if err != nil {
+		return nil, err
+	}
+	if version != snapshotVersion {
+		return nil, fmt.Errorf("%w: %d", common.ErrUnsupportedSnapshotVersion, version)
+	}
+
+	numRecords, err := readSnapshotUint32(reader)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]keyValuePair, 0)
+	for i := uint32(0); i < numRecords; i++ {
+		key, errRead := readSnapshotField(reader)
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		value, errRead := readSnapshotField(reader)
+		if errRead != nil {
+			return nil, errRead
+		}
+
+		records = append(records, keyValuePair{key: key, value: value})
+	}
+
+	// The checksum itself is not part of the checksummed content
+	computedChecksum := checksum.Sum32()
+	expectedChecksum, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if computedChecksum != expectedChecksum {
+		return nil, fmt.Errorf("%w: checksum mismatch", common.ErrCorruptedSnapshot)
+	}
+
+	return records, nil
+}
+
+func readSnapshotField(r io.Reader) ([]byte, error) {
+	length, err := readSnapshotUint32(r)
+	if err != nil {
+		return nil, err
+	}
+	if length > maxSnapshotRecordSize {
+		return nil, fmt.Errorf("%w: record too large", common.ErrCorruptedSnapshot)
+	}
+
+	buff := make([]byte, length)
+	_, err = io.ReadFull(r, buff)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", common.ErrCorruptedSnapshot, err)
+	}
+
+	return buff, nil
+}
+
+func readSnapshotUint32(r io.Reader) (uint32, error) {
+	buff := make([]byte, snapshotPrefixSize)
+	_, err := io.ReadFull(r, buff)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", common.ErrCorruptedSnapshot, err)
+	}
+
+	return binary.BigEndian.Uint32(buff), nil
+}
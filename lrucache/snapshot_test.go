@@ -0,0 +1,185 @@
+package lrucache_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func marshalString(value interface{}) ([]byte, error) {
+	return []byte(value.(string)), nil
+}
+
+func unmarshalString(buff []byte) (interface{}, error) {
+	return string(buff), nil
+}
+
+func sizeOfString(value interface{}) int {
+	return len(value.(string))
+}
+
+func createSnapshotOfCache(t *testing.T, numEntries int) []byte {
+	c, _ := lrucache.NewCache(100)
+	for i := 0; i < numEntries; i++ {
+		c.Put([]byte(fmt.Sprintf("key-%d", i)), fmt.Sprintf("value-%d", i), 0)
+	}
+
+	buff := &bytes.Buffer{}
+	err := c.SaveTo(buff, marshalString)
+	require.Nil(t, err)
+
+	return buff.Bytes()
+}
+
+func TestLRUCache_SaveToInvalidArgumentsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+	c.Put([]byte("a"), "a", 0)
+
+	err := c.SaveTo(nil, marshalString)
+	assert.Equal(t, common.ErrNilWriter, err)
+
+	err = c.SaveTo(&bytes.Buffer{}, nil)
+	assert.Equal(t, common.ErrNilMarshalizer, err)
+
+	expectedErr := errors.New("expected error")
+	err = c.SaveTo(&bytes.Buffer{}, func(_ interface{}) ([]byte, error) {
+		return nil, expectedErr
+	})
+	assert.True(t, errors.Is(err, expectedErr))
+}
+
+func TestLRUCache_LoadFromInvalidArgumentsShouldErr(t *testing.T) {
+	t.Parallel()
+
+	c, _ := lrucache.NewCache(10)
+
+	err := c.LoadFrom(nil, unmarshalString, sizeOfString)
+	assert.Equal(t, common.ErrNilReader, err)
+
+	err = c.LoadFrom(&bytes.Buffer{}, nil, sizeOfString)
+	assert.Equal(t, common.ErrNilMarshalizer, err)
+
+	err = c.LoadFrom(&bytes.Buffer{}, unmarshalString, nil)
+	assert.Equal(t, common.ErrNilSizer, err)
+}
+
+func TestLRUCache_SaveToAndLoadFrom(t *testing.T) {
+	t.Parallel()
+
+	snapshot := createSnapshotOfCache(t, 10)
+
+	c, _ := lrucache.NewCacheWithSizeInBytes(100, 100000)
+	err := c.LoadFrom(bytes.NewReader(snapshot), unmarshalString, sizeOfString)
+	require.Nil(t, err)
+
+	assert.Equal(t, 10, c.Len())
+	assert.Equal(t, uint64(10*len("value-0")), c.SizeInBytesContained())
+	for i := 0; i < 10; i++ {
+		value, ok := c.Peek([]byte(fmt.Sprintf("key-%d", i)))
+		assert.True(t, ok)
+		assert.Equal(t, fmt.Sprintf("value-%d", i), value)
+	}
+
+	// The order (from oldest to newest) is preserved
+	assert.Equal(t, []byte("key-0"), c.Keys()[0])
+	assert.Equal(t, []byte("key-9"), c.Keys()[9])
+
+	// An empty cache yields an empty (but valid) snapshot
+	empty, _ := lrucache.NewCache(10)
+	buff := &bytes.Buffer{}
+	err = empty.SaveTo(buff, marshalString)
+	require.Nil(t, err)
+	err = c.LoadFrom(buff, unmarshalString, sizeOfString)
+	require.Nil(t, err)
+	assert.Equal(t, 10, c.Len())
+}
+
+func TestLRUCache_LoadFromShouldMergeWithExistingEntries(t *testing.T) {
+	t.Parallel()
+
+	snapshot := createSnapshotOfCache(t, 5)
+
+	c, _ := lrucache.NewCache(100)
+	c.Put([]byte("key-0"), "existing", 0)
+	c.Put([]byte("other"), "other", 0)
+
+	err := c.LoadFrom(bytes.NewReader(snapshot), unmarshalString, sizeOfString)
+	require.Nil(t, err)
+
+	assert.Equal(t, 6, c.Len())
+	value, _ := c.Peek([]byte("key-0"))
+	assert.Equal(t, "existing", value)
+	value, _ = c.Peek([]byte("key-4"))
+	assert.Equal(t, "value-4", value)
+	assert.True(t, c.Has([]byte("other")))
+}
+
+func TestLRUCache_LoadFromShouldDropOldestRecordsWhenCapacityExceeded(t *testing.T) {
+	t.Parallel()
+
+	snapshot := createSnapshotOfCache(t, 10)
+
+	c, _ := lrucache.NewCache(5)
+	c.Put([]byte("existing"), "existing", 0)
+
+	err := c.LoadFrom(bytes.NewReader(snapshot), unmarshalString, sizeOfString)
+	require.Nil(t, err)
+
+	assert.Equal(t, 5, c.Len())
+	assert.True(t, c.Has([]byte("existing")))
+	assert.False(t, c.Has([]byte("key-5")))
+	for i := 6; i < 10; i++ {
+		assert.True(t, c.Has([]byte(fmt.Sprintf("key-%d", i))))
+	}
+}
+
+func TestLRUCache_LoadFromCorruptedSnapshotShouldLeaveCacheIntact(t *testing.T) {
+	t.Parallel()
+
+	snapshot := createSnapshotOfCache(t, 10)
+
+	corruptions := map[string][]byte{
+		"empty":            {},
+		"invalid magic":    append([]byte("XXXX"), snapshot[4:]...),
+		"truncated":        snapshot[:len(snapshot)-10],
+		"missing checksum": snapshot[:len(snapshot)-4],
+		"flipped byte":     flipByte(snapshot, 30),
+		"flipped checksum": flipByte(snapshot, len(snapshot)-1),
+		"unsupported":      flipByte(snapshot, 7),
+		"huge record":      append(append([]byte{}, snapshot[:12]...), 0xFF, 0xFF, 0xFF, 0xFF),
+		"too many records": flipByte(snapshot, 8),
+	}
+
+	for name, corrupted := range corruptions {
+		c, _ := lrucache.NewCache(100)
+		c.Put([]byte("a"), "a", 0)
+
+		err := c.LoadFrom(bytes.NewReader(corrupted), unmarshalString, sizeOfString)
+		isExpectedErr := errors.Is(err, common.ErrCorruptedSnapshot) || errors.Is(err, common.ErrUnsupportedSnapshotVersion)
+		assert.True(t, isExpectedErr, name)
+		assert.Equal(t, 1, c.Len(), name)
+		assert.True(t, c.Has([]byte("a")), name)
+	}
+
+	// A value which cannot be unmarshalled aborts the loading, as well
+	c, _ := lrucache.NewCache(100)
+	err := c.LoadFrom(bytes.NewReader(snapshot), func(_ []byte) (interface{}, error) {
+		return nil, errors.New("cannot unmarshal")
+	}, sizeOfString)
+	assert.True(t, errors.Is(err, common.ErrCorruptedSnapshot))
+	assert.Equal(t, 0, c.Len())
+}
+
+func flipByte(buff []byte, index int) []byte {
+	flipped := append([]byte{}, buff...)
+	flipped[index] ^= 0xFF
+	return flipped
+}
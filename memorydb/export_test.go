@@ -0,0 +1,10 @@
+package memorydb
+
+import (
+	"sync/atomic"
+)
+
+// NumLeakedIterators -
+func NumLeakedIterators() uint32 {
+	return atomic.LoadUint32(&numLeakedIterators)
+}
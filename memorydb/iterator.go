@@ -0,0 +1,120 @@
+package memorydb
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var _ types.Iterator = (*snapshotIterator)(nil)
+
+var log = logger.GetOrCreate("storage/memorydb")
+
+// numLeakedIterators counts the iterators which were garbage collected without being closed
+var numLeakedIterators = uint32(0)
+
+type keyValuePair struct {
+	key   []byte
+	value []byte
+}
+
+// snapshotIterator iterates over a sorted snapshot of (key, value) pairs, taken at construction time
+type snapshotIterator struct {
+	pairs    []keyValuePair
+	index    int
+	closed   bool
+	mutState sync.Mutex
+}
+
+// newSnapshotIterator creates an iterator over the provided pairs. The pairs are sorted by key
+func newSnapshotIterator(pairs []keyValuePair) *snapshotIterator {
+	sort.Slice(pairs, func(i, j int) bool {
+		return bytes.Compare(pairs[i].key, pairs[j].key) < 0
+	})
+
+	it := &snapshotIterator{
+		pairs: pairs,
+		index: -1,
+	}
+
+	runtime.SetFinalizer(it, func(it *snapshotIterator) {
+		if it.release() {
+			atomic.AddUint32(&numLeakedIterators, 1)
+			log.Warn("memorydb iterator was not closed")
+		}
+	})
+
+	return it
+}
+
+// Next moves the iterator to the next (key, value) pair. It returns false if the iterator is exhausted or closed
+func (it *snapshotIterator) Next() bool {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed || it.index >= len(it.pairs)-1 {
+		return false
+	}
+
+	it.index++
+	return true
+}
+
+// Key returns the current key
+func (it *snapshotIterator) Key() []byte {
+	pair, ok := it.current()
+	if !ok {
+		return nil
+	}
+
+	return pair.key
+}
+
+// Value returns the current value
+func (it *snapshotIterator) Value() []byte {
+	pair, ok := it.current()
+	if !ok {
+		return nil
+	}
+
+	return pair.value
+}
+
+func (it *snapshotIterator) current() (keyValuePair, bool) {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed || it.index < 0 || it.index >= len(it.pairs) {
+		return keyValuePair{}, false
+	}
+
+	return it.pairs[it.index], true
+}
+
+// Close drops the snapshot. Calling Close multiple times is allowed
+func (it *snapshotIterator) Close() error {
+	it.release()
+	runtime.SetFinalizer(it, nil)
+
+	return nil
+}
+
+// release returns true if the snapshot was dropped by this call
+func (it *snapshotIterator) release() bool {
+	it.mutState.Lock()
+	defer it.mutState.Unlock()
+
+	if it.closed {
+		return false
+	}
+
+	it.pairs = nil
+	it.closed = true
+
+	return true
+}
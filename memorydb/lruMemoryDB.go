@@ -1,6 +1,8 @@
 package memorydb
 
 import (
+	"bytes"
+
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/lrucache"
 	"github.com/multiversx/mx-chain-storage-go/types"
@@ -8,6 +10,8 @@ import (
 
 var _ types.Persister = (*lruDB)(nil)
 
+const lruMemoryDBSource = "lruMemoryDB"
+
 // lruDB represents the memory database storage. It holds a LRU of key value pairs
 // and a mutex to handle concurrent accesses to the map
 type lruDB struct {
@@ -34,12 +38,12 @@ func (l *lruDB) Put(key, val []byte) error {
 func (l *lruDB) Get(key []byte) ([]byte, error) {
 	val, ok := l.cacher.Get(key)
 	if !ok {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewKeyNotFoundError(lruMemoryDBSource, key)
 	}
 
 	mrsVal, ok := val.([]byte)
 	if !ok {
-		return nil, common.ErrKeyNotFound
+		return nil, common.NewKeyNotFoundError(lruMemoryDBSource, key)
 	}
 	return mrsVal, nil
 }
@@ -50,7 +54,7 @@ func (l *lruDB) Has(key []byte) error {
 	if has {
 		return nil
 	}
-	return common.ErrKeyNotFound
+	return common.NewKeyNotFoundError(lruMemoryDBSource, key)
 }
 
 // Close closes the files/resources associated to the storage medium
@@ -101,6 +105,31 @@ func (l *lruDB) RangeKeys(handler func(key []byte, value []byte) bool) {
 	}
 }
 
+// NewIterator returns an iterator over a sorted snapshot of the (key, value) pairs having the given prefix
+func (l *lruDB) NewIterator(prefix []byte) (types.Iterator, error) {
+	keys := l.cacher.Keys()
+	pairs := make([]keyValuePair, 0, len(keys))
+	for _, k := range keys {
+		if !bytes.HasPrefix(k, prefix) {
+			continue
+		}
+
+		v, ok := l.cacher.Peek(k)
+		if !ok {
+			continue
+		}
+
+		vBuff, ok := v.([]byte)
+		if !ok {
+			continue
+		}
+
+		pairs = append(pairs, keyValuePair{key: k, value: vBuff})
+	}
+
+	return newSnapshotIterator(pairs), nil
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (l *lruDB) IsInterfaceNil() bool {
 	return l == nil
@@ -75,7 +75,7 @@ func TestLruDB_HasNotPresent(t *testing.T) {
 
 	err = mdb.Has(key)
 
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestLruDB_DeletePresent(t *testing.T) {
@@ -94,7 +94,7 @@ func TestLruDB_DeletePresent(t *testing.T) {
 
 	err = mdb.Has(key)
 
-	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestLruDB_DeleteNotPresent(t *testing.T) {
@@ -155,3 +155,21 @@ func TestLruDB_RangeKeys(t *testing.T) {
 
 	assert.Equal(t, keysVals, recovered)
 }
+
+func TestLruDB_NewIterator(t *testing.T) {
+	mdb, _ := memorydb.NewlruDB(10000)
+	_ = mdb.Put([]byte("key2"), []byte("value2"))
+	_ = mdb.Put([]byte("key1"), []byte("value1"))
+	_ = mdb.Put([]byte("other"), []byte("other"))
+
+	it, err := mdb.NewIterator([]byte("key"))
+	assert.Nil(t, err)
+
+	keys := make([]string, 0)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+	}
+
+	assert.Equal(t, []string{"key1", "key2"}, keys)
+	assert.Nil(t, it.Close())
+}
@@ -1,16 +1,18 @@
 package memorydb
 
 import (
-	"encoding/base64"
-	"errors"
-	"fmt"
+	"bytes"
 	"sync"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 var _ types.Persister = (*DB)(nil)
 
+const memoryDBSource = "memorydb"
+
 // DB represents the memory database storage. It holds a map of key value pairs
 // and a mutex to handle concurrent accesses to the map
 type DB struct {
@@ -36,6 +38,23 @@ func (s *DB) Put(key, val []byte) error {
 	return nil
 }
 
+// WriteBatch applies the provided entries atomically (under a single lock). An entry having a nil value marks its key for deletion.
+func (s *DB) WriteBatch(entries []storageCore.KeyValuePair) error {
+	s.mutx.Lock()
+	defer s.mutx.Unlock()
+
+	for _, entry := range entries {
+		if entry.Value == nil {
+			delete(s.db, string(entry.Key))
+			continue
+		}
+
+		s.db[string(entry.Key)] = entry.Value
+	}
+
+	return nil
+}
+
 // Get gets the value associated to the key, or reports an error
 func (s *DB) Get(key []byte) ([]byte, error) {
 	s.mutx.RLock()
@@ -44,12 +63,28 @@ func (s *DB) Get(key []byte) ([]byte, error) {
 	val, ok := s.db[string(key)]
 
 	if !ok {
-		return nil, fmt.Errorf("key: %s not found", base64.StdEncoding.EncodeToString(key))
+		return nil, common.NewKeyNotFoundError(memoryDBSource, key)
 	}
 
 	return val, nil
 }
 
+// GetMulti gets the values associated to the provided keys, holding the lock only once. The missing keys are not part of the result.
+func (s *DB) GetMulti(keys [][]byte) (map[string][]byte, error) {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	results := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		val, ok := s.db[string(key)]
+		if ok {
+			results[string(key)] = val
+		}
+	}
+
+	return results, nil
+}
+
 // Has returns true if the given key is present in the persistence medium, false otherwise
 func (s *DB) Has(key []byte) error {
 	s.mutx.RLock()
@@ -58,7 +93,7 @@ func (s *DB) Has(key []byte) error {
 	_, ok := s.db[string(key)]
 
 	if !ok {
-		return errors.New("key not found")
+		return common.NewKeyNotFoundError(memoryDBSource, key)
 	}
 	return nil
 }
@@ -106,6 +141,24 @@ func (s *DB) RangeKeys(handler func(key []byte, value []byte) bool) {
 	}
 }
 
+// NewIterator returns an iterator over a sorted snapshot of the (key, value) pairs having the given prefix
+func (s *DB) NewIterator(prefix []byte) (types.Iterator, error) {
+	s.mutx.RLock()
+	defer s.mutx.RUnlock()
+
+	pairs := make([]keyValuePair, 0, len(s.db))
+	for k, v := range s.db {
+		key := []byte(k)
+		if !bytes.HasPrefix(key, prefix) {
+			continue
+		}
+
+		pairs = append(pairs, keyValuePair{key: key, value: v})
+	}
+
+	return newSnapshotIterator(pairs), nil
+}
+
 // DestroyClosed removes the storage medium stored data
 func (s *DB) DestroyClosed() error {
 	return s.Destroy()
@@ -1,8 +1,12 @@
 package memorydb_test
 
 import (
+	"runtime"
 	"testing"
+	"time"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/memorydb"
 	"github.com/stretchr/testify/assert"
 )
@@ -35,6 +39,46 @@ func TestGetNotPresent(t *testing.T) {
 	assert.NotNil(t, err, "error expected but got nil, value %s", v)
 }
 
+func TestGetNotPresentShouldReturnKeyNotFound(t *testing.T) {
+	mdb := memorydb.New()
+
+	_, err := mdb.Get([]byte("missing"))
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+}
+
+func TestGetMulti(t *testing.T) {
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("a"), []byte("value-a"))
+	_ = mdb.Put([]byte("b"), []byte("value-b"))
+
+	results, err := mdb.GetMulti([][]byte{[]byte("a"), []byte("missing"), []byte("b")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, results)
+
+	results, err = mdb.GetMulti(nil)
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+func TestWriteBatch(t *testing.T) {
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("a"), []byte("old-a"))
+	_ = mdb.Put([]byte("b"), []byte("old-b"))
+
+	err := mdb.WriteBatch([]storageCore.KeyValuePair{
+		{Key: []byte("a"), Value: []byte("new-a")},
+		{Key: []byte("b"), Value: nil},
+		{Key: []byte("c"), Value: []byte("new-c")},
+	})
+	assert.Nil(t, err)
+
+	value, _ := mdb.Get([]byte("a"))
+	assert.Equal(t, []byte("new-a"), value)
+	assert.NotNil(t, mdb.Has([]byte("b")))
+	value, _ = mdb.Get([]byte("c"))
+	assert.Equal(t, []byte("new-c"), value)
+}
+
 func TestHasPresent(t *testing.T) {
 	key, val := []byte("key3"), []byte("value3")
 	mdb := memorydb.New()
@@ -120,3 +164,51 @@ func Test_RangeKeys(t *testing.T) {
 
 	assert.Equal(t, keysVals, recovered)
 }
+
+func TestDB_NewIterator(t *testing.T) {
+	t.Parallel()
+
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("b-key2"), []byte("value2"))
+	_ = mdb.Put([]byte("a-key0"), []byte("value0"))
+	_ = mdb.Put([]byte("b-key1"), []byte("value1"))
+
+	it, err := mdb.NewIterator([]byte("b-"))
+	assert.Nil(t, err)
+
+	// writes after the iterator creation are not visible
+	_ = mdb.Put([]byte("b-key3"), []byte("value3"))
+	_ = mdb.Remove([]byte("b-key1"))
+
+	keys := make([]string, 0)
+	values := make([]string, 0)
+	for it.Next() {
+		keys = append(keys, string(it.Key()))
+		values = append(values, string(it.Value()))
+	}
+
+	assert.Equal(t, []string{"b-key1", "b-key2"}, keys)
+	assert.Equal(t, []string{"value1", "value2"}, values)
+	assert.Nil(t, it.Close())
+	assert.Nil(t, it.Close())
+	assert.False(t, it.Next())
+	assert.Nil(t, it.Key())
+}
+
+func TestDB_NewIteratorNotClosedShouldBeDetected(t *testing.T) {
+	mdb := memorydb.New()
+	_ = mdb.Put([]byte("key"), []byte("value"))
+
+	numLeakedBefore := memorydb.NumLeakedIterators()
+	func() {
+		it, _ := mdb.NewIterator(nil)
+		assert.True(t, it.Next())
+	}()
+
+	for i := 0; i < 100 && memorydb.NumLeakedIterators() == numLeakedBefore; i++ {
+		runtime.GC()
+		time.Sleep(time.Millisecond * 10)
+	}
+
+	assert.Equal(t, numLeakedBefore+1, memorydb.NumLeakedIterators())
+}
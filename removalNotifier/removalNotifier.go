@@ -0,0 +1,165 @@
+package removalNotifier
+
+import (
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var log = logger.GetOrCreate("storage/removalNotifier")
+
+// removalEventsQueueSize is the capacity of the queue of pending removal events
+const removalEventsQueueSize = 1024
+
+type removalEvent struct {
+	key    []byte
+	value  interface{}
+	reason types.RemovalReason
+}
+
+// RemovalNotifier dispatches the removal events of a cache to the registered handlers.
+// The events are queued (without blocking the caller, which may hold internal locks of the cache) in a bounded queue,
+// and the handlers are called, in order, on a dedicated goroutine. The goroutine is started when the first handler
+// is registered and stopped on Close.
+// Overflow policy: if the queue is full (the handlers do not keep up with the removals), the new events are dropped,
+// and counted (see NumDroppedEvents).
+type RemovalNotifier struct {
+	mutHandlers sync.RWMutex
+	handlers    map[string]types.RemovalHandler
+
+	// mutQueue guards the closing of the queue (against the concurrent sends), along with the state flags
+	mutQueue         sync.RWMutex
+	queue            chan removalEvent
+	done             chan struct{}
+	isStarted        bool
+	isClosed         bool
+	numDroppedEvents atomic.Counter
+}
+
+// NewRemovalNotifier creates a new removal notifier
+func NewRemovalNotifier() *RemovalNotifier {
+	return newRemovalNotifierWithQueueSize(removalEventsQueueSize)
+}
+
+func newRemovalNotifierWithQueueSize(queueSize int) *RemovalNotifier {
+	return &RemovalNotifier{
+		handlers: make(map[string]types.RemovalHandler),
+		queue:    make(chan removalEvent, queueSize),
+		done:     make(chan struct{}),
+	}
+}
+
+// RegisterHandler registers a new handler to be called when an entry is removed
+func (rn *RemovalNotifier) RegisterHandler(handler types.RemovalHandler, id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil removal handler to a cacher object")
+		return
+	}
+
+	rn.mutHandlers.Lock()
+	rn.handlers[id] = handler
+	rn.mutHandlers.Unlock()
+
+	rn.startIfNecessary()
+}
+
+// UnRegisterHandler removes the handler having the given id
+func (rn *RemovalNotifier) UnRegisterHandler(id string) {
+	rn.mutHandlers.Lock()
+	delete(rn.handlers, id)
+	rn.mutHandlers.Unlock()
+}
+
+// HasHandlers returns true if at least one handler is registered
+func (rn *RemovalNotifier) HasHandlers() bool {
+	rn.mutHandlers.RLock()
+	defer rn.mutHandlers.RUnlock()
+
+	return len(rn.handlers) > 0
+}
+
+func (rn *RemovalNotifier) startIfNecessary() {
+	rn.mutQueue.Lock()
+	defer rn.mutQueue.Unlock()
+
+	if rn.isStarted || rn.isClosed {
+		return
+	}
+
+	rn.isStarted = true
+	go rn.processEvents()
+}
+
+// Notify queues a removal event. It never blocks, thus it can be called while holding internal locks of the cache.
+// If the queue is full, the event is dropped (see NumDroppedEvents).
+func (rn *RemovalNotifier) Notify(key []byte, value interface{}, reason types.RemovalReason) {
+	if !rn.HasHandlers() {
+		return
+	}
+
+	rn.mutQueue.RLock()
+	defer rn.mutQueue.RUnlock()
+
+	if rn.isClosed {
+		return
+	}
+
+	select {
+	case rn.queue <- removalEvent{key: key, value: value, reason: reason}:
+	default:
+		numDropped := rn.numDroppedEvents.Increment()
+		log.Trace("RemovalNotifier.Notify(): queue is full, event dropped", "key", key, "reason", reason, "numDropped", numDropped)
+	}
+}
+
+// NumDroppedEvents returns the number of events dropped because the queue was full
+func (rn *RemovalNotifier) NumDroppedEvents() uint64 {
+	return uint64(rn.numDroppedEvents.Get())
+}
+
+func (rn *RemovalNotifier) processEvents() {
+	defer close(rn.done)
+
+	// Events queued before Close are still dispatched
+	for event := range rn.queue {
+		rn.dispatch(event)
+	}
+}
+
+func (rn *RemovalNotifier) dispatch(event removalEvent) {
+	rn.mutHandlers.RLock()
+	handlers := make([]types.RemovalHandler, 0, len(rn.handlers))
+	for _, handler := range rn.handlers {
+		handlers = append(handlers, handler)
+	}
+	rn.mutHandlers.RUnlock()
+
+	for _, handler := range handlers {
+		handler(event.key, event.value, event.reason)
+	}
+}
+
+// Close stops the dispatching goroutine, after the already queued events are dispatched. Calling Close multiple times is allowed.
+func (rn *RemovalNotifier) Close() {
+	rn.mutQueue.Lock()
+	if rn.isClosed {
+		rn.mutQueue.Unlock()
+		return
+	}
+
+	rn.isClosed = true
+	isStarted := rn.isStarted
+	close(rn.queue)
+	rn.mutQueue.Unlock()
+
+	if isStarted {
+		<-rn.done
+	}
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (rn *RemovalNotifier) IsInterfaceNil() bool {
+	return rn == nil
+}
@@ -0,0 +1,141 @@
+package removalNotifier
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRemovalNotifier_NotifyShouldCallHandlersInOrder(t *testing.T) {
+	notifier := NewRemovalNotifier()
+
+	mut := sync.Mutex{}
+	keysA := make([]string, 0)
+	reasonsB := make([]types.RemovalReason, 0)
+
+	notifier.RegisterHandler(func(key []byte, _ interface{}, _ types.RemovalReason) {
+		mut.Lock()
+		keysA = append(keysA, string(key))
+		mut.Unlock()
+	}, "a")
+	notifier.RegisterHandler(func(_ []byte, _ interface{}, reason types.RemovalReason) {
+		mut.Lock()
+		reasonsB = append(reasonsB, reason)
+		mut.Unlock()
+	}, "b")
+
+	notifier.Notify([]byte("x"), 1, types.RemovalReasonRemoved)
+	notifier.Notify([]byte("y"), 2, types.RemovalReasonEvicted)
+	notifier.Notify([]byte("z"), 3, types.RemovalReasonCleared)
+
+	// Close dispatches the pending events
+	notifier.Close()
+
+	require.Equal(t, []string{"x", "y", "z"}, keysA)
+	require.Equal(t, []types.RemovalReason{types.RemovalReasonRemoved, types.RemovalReasonEvicted, types.RemovalReasonCleared}, reasonsB)
+}
+
+func TestRemovalNotifier_UnRegisterHandler(t *testing.T) {
+	notifier := NewRemovalNotifier()
+
+	numCalls := 0
+	notifier.RegisterHandler(func(_ []byte, _ interface{}, _ types.RemovalReason) {
+		numCalls++
+	}, "a")
+	notifier.RegisterHandler(nil, "nil")
+	require.True(t, notifier.HasHandlers())
+
+	notifier.UnRegisterHandler("a")
+	require.False(t, notifier.HasHandlers())
+
+	notifier.Notify([]byte("x"), 1, types.RemovalReasonRemoved)
+	notifier.Close()
+	require.Equal(t, 0, numCalls)
+}
+
+func TestRemovalNotifier_NotifyAfterCloseShouldNotPanic(t *testing.T) {
+	notifier := NewRemovalNotifier()
+
+	numCalls := 0
+	notifier.RegisterHandler(func(_ []byte, _ interface{}, _ types.RemovalReason) {
+		numCalls++
+	}, "a")
+
+	notifier.Close()
+	notifier.Close()
+	notifier.Notify([]byte("x"), 1, types.RemovalReasonRemoved)
+	require.Equal(t, 0, numCalls)
+}
+
+func TestRemovalNotifier_CloseWithoutHandlers(t *testing.T) {
+	notifier := NewRemovalNotifier()
+	notifier.Close()
+	require.False(t, notifier.isStarted)
+}
+
+func TestRemovalNotifier_ConcurrentNotifyAndClose(t *testing.T) {
+	notifier := NewRemovalNotifier()
+	notifier.RegisterHandler(func(_ []byte, _ interface{}, _ types.RemovalReason) {}, "a")
+
+	wg := sync.WaitGroup{}
+	wg.Add(11)
+
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+
+			for j := 0; j < 1000; j++ {
+				notifier.Notify([]byte("x"), j, types.RemovalReasonEvicted)
+			}
+		}()
+	}
+
+	go func() {
+		defer wg.Done()
+		notifier.Close()
+	}()
+
+	wg.Wait()
+}
+
+func TestRemovalNotifier_NotifyShouldDropEventsWhenQueueIsFull(t *testing.T) {
+	notifier := newRemovalNotifierWithQueueSize(4)
+
+	handlerEntered := make(chan struct{})
+	releaseHandler := make(chan struct{})
+	mut := sync.Mutex{}
+	keys := make([]string, 0)
+	notifier.RegisterHandler(func(key []byte, _ interface{}, _ types.RemovalReason) {
+		if string(key) == "blocking" {
+			close(handlerEntered)
+			<-releaseHandler
+		}
+
+		mut.Lock()
+		keys = append(keys, string(key))
+		mut.Unlock()
+	}, "a")
+
+	notifier.Notify([]byte("blocking"), 0, types.RemovalReasonEvicted)
+	<-handlerEntered
+
+	// The worker is blocked: 4 events fit the queue, the others are dropped (without blocking the caller)
+	for _, key := range []string{"a", "b", "c", "d", "e", "f"} {
+		notifier.Notify([]byte(key), 0, types.RemovalReasonEvicted)
+	}
+	require.Equal(t, uint64(2), notifier.NumDroppedEvents())
+
+	close(releaseHandler)
+	notifier.Close()
+	require.Equal(t, []string{"blocking", "a", "b", "c", "d"}, keys)
+}
+
+func TestRemovalNotifier_IsInterfaceNil(t *testing.T) {
+	var notifier *RemovalNotifier
+	require.True(t, notifier.IsInterfaceNil())
+
+	notifier = NewRemovalNotifier()
+	require.False(t, notifier.IsInterfaceNil())
+}
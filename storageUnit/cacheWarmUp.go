@@ -0,0 +1,165 @@
+package storageUnit
+
+import (
+	"context"
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+// CacheWarmUp tracks the (background) filling of a cache with entries read from a persister, see WarmUpFromStorer
+type CacheWarmUp struct {
+	numLoaded atomic.Counter
+	cancel    context.CancelFunc
+	done      chan struct{}
+
+	// modifiedKeys holds the keys modified (put or removed) through the storage unit while its warm-up is in progress
+	// (see Unit.WarmUpCache). Such keys are not loaded anymore, since the values read from the persister might be stale.
+	// It is nil for the warm-ups not started by a storage unit.
+	mutModifiedKeys sync.Mutex
+	modifiedKeys    map[string]struct{}
+}
+
+// WarmUpFromStorer starts filling the cache with (at most "maxEntries") entries read from the persister, in a background goroutine.
+// The persister is iterated using RangeKeys; only the keys accepted by "keySelector" (if provided) are loaded.
+// The entries are added using HasOrAdd, so that the values put in the cache while the warm-up is in progress take precedence
+// over the (older) values read from the persister.
+// Useful after a restart, so that the caches in front of hot storers are not cold.
+// The warm-up should be cancelled before closing the persister.
+func WarmUpFromStorer(cacher types.Cacher, persister types.Persister, maxEntries int, keySelector func(key []byte) bool) (*CacheWarmUp, error) {
+	if check.IfNil(cacher) {
+		return nil, common.ErrNilCacher
+	}
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+	if maxEntries <= 0 {
+		return nil, common.ErrInvalidMaxEntries
+	}
+
+	return startWarmUp(cacher, persister, maxEntries, keySelector, nil), nil
+}
+
+func startWarmUp(
+	cacher types.Cacher,
+	persister types.Persister,
+	maxEntries int,
+	keySelector func(key []byte) bool,
+	modifiedKeys map[string]struct{},
+) *CacheWarmUp {
+	ctx, cancel := context.WithCancel(context.Background())
+	warmUp := &CacheWarmUp{
+		cancel:       cancel,
+		done:         make(chan struct{}),
+		modifiedKeys: modifiedKeys,
+	}
+
+	go warmUp.run(ctx, cacher, persister, maxEntries, keySelector)
+
+	return warmUp
+}
+
+func (warmUp *CacheWarmUp) run(ctx context.Context, cacher types.Cacher, persister types.Persister, maxEntries int, keySelector func(key []byte) bool) {
+	defer close(warmUp.done)
+
+	numVisited := 0
+	persister.RangeKeys(func(key []byte, value []byte) bool {
+		if ctx.Err() != nil {
+			return false
+		}
+
+		numVisited++
+		if keySelector != nil && !keySelector(key) {
+			return true
+		}
+
+		added := warmUp.load(cacher, key, value)
+		if added {
+			warmUp.numLoaded.Increment()
+		}
+
+		return warmUp.numLoaded.Get() < int64(maxEntries)
+	})
+
+	log.Debug("cache warm-up ended", "numVisited", numVisited, "numLoaded", warmUp.numLoaded.Get(), "cancelled", ctx.Err() != nil)
+}
+
+// load adds the entry in the cache (unless already cached, or modified in the meantime). It returns whether the entry has been added.
+// The check of the modified keys and the addition are atomic with respect to markModified.
+func (warmUp *CacheWarmUp) load(cacher types.Cacher, key []byte, value []byte) bool {
+	warmUp.mutModifiedKeys.Lock()
+	defer warmUp.mutModifiedKeys.Unlock()
+
+	_, isModified := warmUp.modifiedKeys[string(key)]
+	if isModified {
+		return false
+	}
+
+	_, added := cacher.HasOrAdd(key, value, len(value))
+	return added
+}
+
+// markModified records a key modified through the storage unit, so that it is not loaded anymore.
+// It should be called before modifying the cache, so that a concurrent load of the key cannot bring back its previous value.
+func (warmUp *CacheWarmUp) markModified(key []byte) {
+	warmUp.mutModifiedKeys.Lock()
+	warmUp.modifiedKeys[string(key)] = struct{}{}
+	warmUp.mutModifiedKeys.Unlock()
+}
+
+// Cancel stops the warm-up (if still in progress) and waits for the background goroutine to exit
+func (warmUp *CacheWarmUp) Cancel() {
+	warmUp.cancel()
+
+	<-warmUp.done
+}
+
+// Done returns a channel that is closed when the warm-up ends (either completed or cancelled)
+func (warmUp *CacheWarmUp) Done() <-chan struct{} {
+	return warmUp.done
+}
+
+// NumLoaded returns the number of entries loaded into the cache so far
+func (warmUp *CacheWarmUp) NumLoaded() int {
+	return int(warmUp.numLoaded.Get())
+}
+
+// WarmUpCache starts filling the cache of the storage unit from its own persister, see WarmUpFromStorer.
+// The keys put or removed through the storage unit while the warm-up is in progress are not loaded from the persister,
+// so that the warm-up cannot bring back removed entries (or previous values).
+func (u *Unit) WarmUpCache(maxEntries int, keySelector func(key []byte) bool) (*CacheWarmUp, error) {
+	if u == nil {
+		return nil, common.ErrNilStorageUnit
+	}
+	if maxEntries <= 0 {
+		return nil, common.ErrInvalidMaxEntries
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	warmUp := startWarmUp(u.cacher, u.persister, maxEntries, keySelector, make(map[string]struct{}))
+	u.warmUps[warmUp] = struct{}{}
+	go u.untrackWarmUpWhenDone(warmUp)
+
+	return warmUp, nil
+}
+
+func (u *Unit) untrackWarmUpWhenDone(warmUp *CacheWarmUp) {
+	<-warmUp.Done()
+
+	u.lock.Lock()
+	delete(u.warmUps, warmUp)
+	u.lock.Unlock()
+}
+
+// markModifiedForWarmUps records the key as modified, for the warm-ups in progress
+// This function should only be called under the (already acquired) u.lock, before modifying the cache
+func (u *Unit) markModifiedForWarmUps(key []byte) {
+	for warmUp := range u.warmUps {
+		warmUp.markModified(key)
+	}
+}
@@ -0,0 +1,200 @@
+package storageUnit_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/multiversx/mx-chain-storage-go/memorydb"
+	"github.com/multiversx/mx-chain-storage-go/storageUnit"
+	"github.com/multiversx/mx-chain-storage-go/testscommon"
+	"github.com/stretchr/testify/assert"
+)
+
+func createPersisterWithEntries(numEntries int) *memorydb.DB {
+	db := memorydb.New()
+	for i := 0; i < numEntries; i++ {
+		_ = db.Put([]byte(fmt.Sprintf("key-%d", i)), []byte(fmt.Sprintf("value-%d", i)))
+	}
+
+	return db
+}
+
+func TestWarmUpFromStorer_InvalidArguments(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := lrucache.NewCache(10)
+	db := memorydb.New()
+
+	warmUp, err := storageUnit.WarmUpFromStorer(nil, db, 10, nil)
+	assert.Nil(t, warmUp)
+	assert.Equal(t, common.ErrNilCacher, err)
+
+	warmUp, err = storageUnit.WarmUpFromStorer(cache, nil, 10, nil)
+	assert.Nil(t, warmUp)
+	assert.Equal(t, common.ErrNilPersister, err)
+
+	warmUp, err = storageUnit.WarmUpFromStorer(cache, db, 0, nil)
+	assert.Nil(t, warmUp)
+	assert.Equal(t, common.ErrInvalidMaxEntries, err)
+}
+
+func TestWarmUpFromStorer_LoadsAllEntries(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := lrucache.NewCache(100)
+	db := createPersisterWithEntries(20)
+
+	warmUp, err := storageUnit.WarmUpFromStorer(cache, db, 100, nil)
+	assert.Nil(t, err)
+	<-warmUp.Done()
+
+	assert.Equal(t, 20, warmUp.NumLoaded())
+	assert.Equal(t, 20, cache.Len())
+
+	value, ok := cache.Get([]byte("key-7"))
+	assert.True(t, ok)
+	assert.Equal(t, []byte("value-7"), value)
+}
+
+func TestWarmUpFromStorer_RespectsMaxEntriesAndKeySelector(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := lrucache.NewCache(100)
+	db := createPersisterWithEntries(20)
+
+	warmUp, _ := storageUnit.WarmUpFromStorer(cache, db, 5, nil)
+	<-warmUp.Done()
+	assert.Equal(t, 5, warmUp.NumLoaded())
+	assert.Equal(t, 5, cache.Len())
+
+	cache, _ = lrucache.NewCache(100)
+	keySelector := func(key []byte) bool {
+		return string(key) == "key-3" || string(key) == "key-13"
+	}
+
+	warmUp, _ = storageUnit.WarmUpFromStorer(cache, db, 100, keySelector)
+	<-warmUp.Done()
+	assert.Equal(t, 2, warmUp.NumLoaded())
+	assert.True(t, cache.Has([]byte("key-3")))
+	assert.True(t, cache.Has([]byte("key-13")))
+}
+
+func TestWarmUpFromStorer_LivePutsTakePrecedence(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := lrucache.NewCache(100)
+	db := createPersisterWithEntries(10)
+
+	_ = cache.Put([]byte("key-4"), []byte("live"), len("live"))
+
+	warmUp, _ := storageUnit.WarmUpFromStorer(cache, db, 100, nil)
+	<-warmUp.Done()
+
+	assert.Equal(t, 9, warmUp.NumLoaded())
+	value, _ := cache.Get([]byte("key-4"))
+	assert.Equal(t, []byte("live"), value)
+}
+
+func TestWarmUpFromStorer_Cancel(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := lrucache.NewCache(1000)
+	firstEntryLoaded := make(chan struct{})
+	proceed := make(chan struct{})
+
+	persister := &testscommon.PersisterStub{
+		RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+			for i := 0; i < 1000; i++ {
+				if !handler([]byte(fmt.Sprintf("key-%d", i)), []byte("value")) {
+					return
+				}
+
+				if i == 0 {
+					close(firstEntryLoaded)
+					<-proceed
+				}
+			}
+		},
+	}
+
+	warmUp, _ := storageUnit.WarmUpFromStorer(cache, persister, 1000, nil)
+	<-firstEntryLoaded
+
+	go func() {
+		// give Cancel the time to cancel the warm-up, before unblocking the iteration
+		time.Sleep(100 * time.Millisecond)
+		close(proceed)
+	}()
+	warmUp.Cancel()
+
+	assert.Equal(t, 1, warmUp.NumLoaded())
+	assert.Equal(t, 1, cache.Len())
+
+	// Cancelling again (after the end) does not block
+	warmUp.Cancel()
+}
+
+func TestUnit_WarmUpCache(t *testing.T) {
+	t.Parallel()
+
+	var nilUnit *storageUnit.Unit
+	warmUp, err := nilUnit.WarmUpCache(10, nil)
+	assert.Nil(t, warmUp)
+	assert.Equal(t, common.ErrNilStorageUnit, err)
+
+	cache, _ := lrucache.NewCache(100)
+	db := createPersisterWithEntries(10)
+	unit, _ := storageUnit.NewStorageUnit(cache, db)
+
+	warmUp, err = unit.WarmUpCache(100, nil)
+	assert.Nil(t, err)
+	<-warmUp.Done()
+
+	assert.Equal(t, 10, warmUp.NumLoaded())
+	assert.Equal(t, 10, cache.Len())
+}
+
+func TestUnit_WarmUpCacheShouldNotLoadKeysRemovedInTheMeantime(t *testing.T) {
+	t.Parallel()
+
+	cache, _ := lrucache.NewCache(100)
+	reachedKey5 := make(chan struct{})
+	proceed := make(chan struct{})
+
+	persister := &testscommon.PersisterStub{
+		RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+			for i := 0; i < 10; i++ {
+				// The value of "key-5" has been read, but the entry is not loaded yet
+				if i == 5 {
+					close(reachedKey5)
+					<-proceed
+				}
+
+				if !handler([]byte(fmt.Sprintf("key-%d", i)), []byte("value")) {
+					return
+				}
+			}
+		},
+	}
+	unit, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	warmUp, err := unit.WarmUpCache(100, nil)
+	assert.Nil(t, err)
+	<-reachedKey5
+
+	_ = unit.Remove([]byte("key-5"))
+	_ = unit.Remove([]byte("key-8"))
+	_ = unit.Remove([]byte("key-2"))
+	close(proceed)
+	<-warmUp.Done()
+
+	// "key-2" has been loaded before its removal (then removed from the cache, as well)
+	assert.Equal(t, 8, warmUp.NumLoaded())
+	assert.Equal(t, 7, cache.Len())
+	assert.False(t, cache.Has([]byte("key-2")))
+	assert.False(t, cache.Has([]byte("key-5")))
+	assert.False(t, cache.Has([]byte("key-8")))
+}
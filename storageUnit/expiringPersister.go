@@ -0,0 +1,407 @@
+package storageUnit
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var _ types.Persister = (*expiringPersister)(nil)
+
+const expiringPersisterSource = "expiring persister"
+const plainValueHeader = byte(0)
+const expiringValueHeader = byte(1)
+const expiringValueHeaderSize = 1 + 8
+const maxNumScheduledDeletions = 1024
+
+// expiringPersister is a persister decorator able to store entries that expire (see PutWithExpiry). Each value is stored
+// prefixed by a header: one byte for the plain values (which never expire), or one byte followed by the expiry moment
+// (unix nanoseconds, 8 bytes, big endian) for the expiring ones. The expired entries are reported as missing, and they are
+// physically removed in background: on lookup, as well as by a periodic cleanup pass.
+type expiringPersister struct {
+	persister types.Persister
+
+	// mutWrite serializes the writes with the removal of the expired entries, so that a fresh entry is never removed
+	mutWrite sync.Mutex
+
+	scheduledDeletions chan []byte
+	cancel             func()
+	cleanupDone        chan struct{}
+}
+
+// NewExpiringPersister creates a persister able to store entries that expire, wrapping the provided one.
+// The expired entries are removed every cleanupInterval. The values stored by the wrapped persister must have been
+// written by an expiring persister, as well.
+func NewExpiringPersister(persister types.Persister, cleanupInterval time.Duration) (*expiringPersister, error) {
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+	if cleanupInterval <= 0 {
+		return nil, fmt.Errorf("%w: cleanup interval of the expired entries is invalid", common.ErrInvalidConfig)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ep := &expiringPersister{
+		persister:          persister,
+		scheduledDeletions: make(chan []byte, maxNumScheduledDeletions),
+		cancel:             cancel,
+		cleanupDone:        make(chan struct{}),
+	}
+
+	go ep.removeExpiredEntriesInBackground(ctx, cleanupInterval)
+
+	return ep, nil
+}
+
+func (ep *expiringPersister) removeExpiredEntriesInBackground(ctx context.Context, cleanupInterval time.Duration) {
+	defer close(ep.cleanupDone)
+
+	ticker := time.NewTicker(cleanupInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug("closing expiringPersister.removeExpiredEntriesInBackground go routine...")
+			return
+		case key := <-ep.scheduledDeletions:
+			ep.removeIfExpired(key, time.Now())
+		case <-ticker.C:
+			numRemoved := ep.RemoveExpiredEntries()
+			if numRemoved > 0 {
+				log.Debug("expiringPersister: removed expired entries", "num", numRemoved)
+			}
+		}
+	}
+}
+
+// RemoveExpiredEntries physically removes the expired entries, returning their number
+func (ep *expiringPersister) RemoveExpiredEntries() int {
+	now := time.Now()
+	expiredKeys := make([][]byte, 0)
+	ep.persister.RangeKeys(func(key []byte, value []byte) bool {
+		_, expiresAt, err := decodeExpiringValue(value)
+		if err == nil && isExpired(expiresAt, now) {
+			expiredKeys = append(expiredKeys, copyBytes(key))
+		}
+
+		return true
+	})
+
+	numRemoved := 0
+	for _, key := range expiredKeys {
+		if ep.removeIfExpired(key, now) {
+			numRemoved++
+		}
+	}
+
+	return numRemoved
+}
+
+// removeIfExpired checks the entry again (under the write lock), since it might have been overwritten in the meantime
+func (ep *expiringPersister) removeIfExpired(key []byte, now time.Time) bool {
+	ep.mutWrite.Lock()
+	defer ep.mutWrite.Unlock()
+
+	rawValue, err := ep.persister.Get(key)
+	if err != nil {
+		return false
+	}
+
+	_, expiresAt, err := decodeExpiringValue(rawValue)
+	if err != nil || !isExpired(expiresAt, now) {
+		return false
+	}
+
+	err = ep.persister.Remove(key)
+	if err != nil {
+		log.Debug("expiringPersister.removeIfExpired", "key", key, "error", err)
+		return false
+	}
+
+	return true
+}
+
+// scheduleDeletion does not block: if too many deletions are already scheduled, the entry is left to the periodic cleanup
+func (ep *expiringPersister) scheduleDeletion(key []byte) {
+	select {
+	case ep.scheduledDeletions <- copyBytes(key):
+	default:
+	}
+}
+
+// Put stores an entry which never expires
+func (ep *expiringPersister) Put(key, val []byte) error {
+	ep.mutWrite.Lock()
+	defer ep.mutWrite.Unlock()
+
+	return ep.persister.Put(key, encodePlainValue(val))
+}
+
+// PutWithExpiry stores an entry which expires at the given moment
+func (ep *expiringPersister) PutWithExpiry(key, val []byte, expiresAt time.Time) error {
+	ep.mutWrite.Lock()
+	defer ep.mutWrite.Unlock()
+
+	return ep.persister.Put(key, encodeExpiringValue(val, expiresAt))
+}
+
+// Get returns the value associated to the key, an expired entry being reported as missing
+func (ep *expiringPersister) Get(key []byte) ([]byte, error) {
+	value, _, err := ep.GetWithExpiry(key)
+	return value, err
+}
+
+// GetWithExpiry returns the value associated to the key, along with its expiry moment (zero for the entries which never expire).
+// An expired entry is reported as missing, its deletion being scheduled.
+func (ep *expiringPersister) GetWithExpiry(key []byte) ([]byte, time.Time, error) {
+	rawValue, err := ep.persister.Get(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	value, expiresAt, err := decodeExpiringValue(rawValue)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if isExpired(expiresAt, time.Now()) {
+		ep.scheduleDeletion(key)
+		return nil, time.Time{}, common.NewKeyNotFoundError(expiringPersisterSource, key)
+	}
+
+	return value, expiresAt, nil
+}
+
+// GetMulti gets the values associated to the provided keys. The missing (or expired) keys are not part of the result.
+func (ep *expiringPersister) GetMulti(keys [][]byte) (map[string][]byte, error) {
+	results := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, err := ep.Get(key)
+		if common.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results[string(key)] = value
+	}
+
+	return results, nil
+}
+
+// Has returns nil if the given key is present in the persister (and not expired)
+func (ep *expiringPersister) Has(key []byte) error {
+	_, err := ep.Get(key)
+	return err
+}
+
+// Remove removes the data associated to the given key
+func (ep *expiringPersister) Remove(key []byte) error {
+	ep.mutWrite.Lock()
+	defer ep.mutWrite.Unlock()
+
+	return ep.persister.Remove(key)
+}
+
+// WriteBatch writes the provided entries (which never expire) atomically. An entry having a nil value marks its key for deletion.
+func (ep *expiringPersister) WriteBatch(entries []storageCore.KeyValuePair) error {
+	writer, ok := ep.persister.(atomicBatchWriter)
+	if !ok {
+		return common.ErrAtomicBatchNotSupported
+	}
+
+	encodedEntries := make([]storageCore.KeyValuePair, 0, len(entries))
+	for _, entry := range entries {
+		encodedEntry := storageCore.KeyValuePair{Key: entry.Key}
+		if entry.Value != nil {
+			encodedEntry.Value = encodePlainValue(entry.Value)
+		}
+
+		encodedEntries = append(encodedEntries, encodedEntry)
+	}
+
+	ep.mutWrite.Lock()
+	defer ep.mutWrite.Unlock()
+
+	return writer.WriteBatch(encodedEntries)
+}
+
+// GetPendingEntries returns the entries written (or removed) into the wrapped persister, but not yet visible to its RangeKeys.
+// The expired entries are returned as removals.
+func (ep *expiringPersister) GetPendingEntries() []storageCore.KeyValuePair {
+	provider, ok := ep.persister.(pendingEntriesProvider)
+	if !ok {
+		return nil
+	}
+
+	now := time.Now()
+	pendingEntries := provider.GetPendingEntries()
+	decodedEntries := make([]storageCore.KeyValuePair, 0, len(pendingEntries))
+	for _, entry := range pendingEntries {
+		decodedEntry := storageCore.KeyValuePair{Key: entry.Key}
+		if entry.Value != nil {
+			value, expiresAt, err := decodeExpiringValue(entry.Value)
+			if err != nil {
+				continue
+			}
+			if !isExpired(expiresAt, now) {
+				decodedEntry.Value = value
+			}
+		}
+
+		decodedEntries = append(decodedEntries, decodedEntry)
+	}
+
+	return decodedEntries
+}
+
+// RangeKeys iterates over the (key, value) pairs of the persister, skipping the expired entries
+func (ep *expiringPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	now := time.Now()
+	ep.persister.RangeKeys(func(key []byte, rawValue []byte) bool {
+		value, expiresAt, err := decodeExpiringValue(rawValue)
+		if err != nil || isExpired(expiresAt, now) {
+			return true
+		}
+
+		return handler(key, value)
+	})
+}
+
+// NewIterator returns an iterator over the (key, value) pairs having the given prefix, skipping the expired entries
+func (ep *expiringPersister) NewIterator(prefix []byte) (types.Iterator, error) {
+	iterator, err := ep.persister.NewIterator(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	return &expiringIterator{
+		iterator: iterator,
+		now:      time.Now(),
+	}, nil
+}
+
+// Close stops the removal of the expired entries (waiting for a cleanup pass in progress, if any), then closes the wrapped persister
+func (ep *expiringPersister) Close() error {
+	ep.stopCleanup()
+	return ep.persister.Close()
+}
+
+// Destroy stops the removal of the expired entries (waiting for a cleanup pass in progress, if any), then destroys the wrapped persister
+func (ep *expiringPersister) Destroy() error {
+	ep.stopCleanup()
+	return ep.persister.Destroy()
+}
+
+func (ep *expiringPersister) stopCleanup() {
+	ep.cancel()
+	<-ep.cleanupDone
+}
+
+// DestroyClosed destroys the already closed wrapped persister
+func (ep *expiringPersister) DestroyClosed() error {
+	return ep.persister.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ep *expiringPersister) IsInterfaceNil() bool {
+	return ep == nil
+}
+
+// expiringIterator decodes the values of the wrapped iterator, skipping the expired entries
+type expiringIterator struct {
+	iterator types.Iterator
+	now      time.Time
+	value    []byte
+}
+
+// Next moves the iterator to the next (not expired) pair
+func (it *expiringIterator) Next() bool {
+	for it.iterator.Next() {
+		value, expiresAt, err := decodeExpiringValue(it.iterator.Value())
+		if err != nil || isExpired(expiresAt, it.now) {
+			continue
+		}
+
+		it.value = value
+		return true
+	}
+
+	return false
+}
+
+// Key returns the key of the current pair
+func (it *expiringIterator) Key() []byte {
+	return it.iterator.Key()
+}
+
+// Value returns the (decoded) value of the current pair
+func (it *expiringIterator) Value() []byte {
+	return it.value
+}
+
+// Close releases the resources of the wrapped iterator
+func (it *expiringIterator) Close() error {
+	return it.iterator.Close()
+}
+
+func encodePlainValue(value []byte) []byte {
+	encoded := make([]byte, 1+len(value))
+	encoded[0] = plainValueHeader
+	copy(encoded[1:], value)
+
+	return encoded
+}
+
+func encodeExpiringValue(value []byte, expiresAt time.Time) []byte {
+	encoded := make([]byte, expiringValueHeaderSize+len(value))
+	encoded[0] = expiringValueHeader
+	binary.BigEndian.PutUint64(encoded[1:], uint64(expiresAt.UnixNano()))
+	copy(encoded[expiringValueHeaderSize:], value)
+
+	return encoded
+}
+
+// decodeExpiringValue returns the value, along with its expiry moment (zero for the values which never expire)
+func decodeExpiringValue(encoded []byte) ([]byte, time.Time, error) {
+	if len(encoded) == 0 {
+		return nil, time.Time{}, common.ErrInvalidExpiringValue
+	}
+
+	switch encoded[0] {
+	case plainValueHeader:
+		return encoded[1:], time.Time{}, nil
+	case expiringValueHeader:
+		if len(encoded) < expiringValueHeaderSize {
+			return nil, time.Time{}, common.ErrInvalidExpiringValue
+		}
+
+		expiresAt := time.Unix(0, int64(binary.BigEndian.Uint64(encoded[1:])))
+		return encoded[expiringValueHeaderSize:], expiresAt, nil
+	default:
+		return nil, time.Time{}, common.ErrInvalidExpiringValue
+	}
+}
+
+func isExpired(expiresAt time.Time, now time.Time) bool {
+	return !expiresAt.IsZero() && !now.Before(expiresAt)
+}
+
+func copyBytes(data []byte) []byte {
+	result := make([]byte, len(data))
+	copy(result, data)
+
+	return result
+}
@@ -0,0 +1,246 @@
+package storageUnit_test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/memorydb"
+	"github.com/multiversx/mx-chain-storage-go/storageUnit"
+	"github.com/multiversx/mx-chain-storage-go/testscommon"
+	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func countEntries(db types.Persister) int {
+	numEntries := 0
+	db.RangeKeys(func(_ []byte, _ []byte) bool {
+		numEntries++
+		return true
+	})
+
+	return numEntries
+}
+
+func TestNewExpiringPersister(t *testing.T) {
+	t.Parallel()
+
+	ep, err := storageUnit.NewExpiringPersister(nil, time.Minute)
+	assert.True(t, check.IfNil(ep))
+	assert.Equal(t, common.ErrNilPersister, err)
+
+	ep, err = storageUnit.NewExpiringPersister(memorydb.New(), 0)
+	assert.True(t, check.IfNil(ep))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	ep, err = storageUnit.NewExpiringPersister(memorydb.New(), time.Minute)
+	assert.False(t, check.IfNil(ep))
+	assert.Nil(t, err)
+	_ = ep.Close()
+}
+
+func TestExpiringPersister_ExpiredEntriesAreMissing(t *testing.T) {
+	t.Parallel()
+
+	ep, _ := storageUnit.NewExpiringPersister(memorydb.New(), time.Minute)
+	defer func() {
+		_ = ep.Close()
+	}()
+
+	_ = ep.Put([]byte("plain"), []byte("aaa"))
+	_ = ep.PutWithExpiry([]byte("expired"), []byte("bbb"), time.Now().Add(-time.Second))
+	expiresAt := time.Now().Add(time.Hour)
+	_ = ep.PutWithExpiry([]byte("fresh"), []byte("ccc"), expiresAt)
+
+	value, err := ep.Get([]byte("plain"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("aaa"), value)
+
+	value, actualExpiresAt, err := ep.GetWithExpiry([]byte("fresh"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("ccc"), value)
+	assert.Equal(t, expiresAt.UnixNano(), actualExpiresAt.UnixNano())
+
+	_, err = ep.Get([]byte("expired"))
+	assert.True(t, common.IsNotFoundError(err))
+	assert.True(t, common.IsNotFoundError(ep.Has([]byte("expired"))))
+	assert.Nil(t, ep.Has([]byte("fresh")))
+
+	values, err := ep.GetMulti([][]byte{[]byte("plain"), []byte("expired"), []byte("fresh")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"plain": []byte("aaa"), "fresh": []byte("ccc")}, values)
+
+	ranged := make(map[string][]byte)
+	ep.RangeKeys(func(key []byte, value []byte) bool {
+		ranged[string(key)] = value
+		return true
+	})
+	assert.Equal(t, values, ranged)
+
+	iterator, err := ep.NewIterator(nil)
+	assert.Nil(t, err)
+	iterated := make(map[string][]byte)
+	for iterator.Next() {
+		iterated[string(iterator.Key())] = iterator.Value()
+	}
+	_ = iterator.Close()
+	assert.Equal(t, values, iterated)
+}
+
+func TestExpiringPersister_RemoveExpiredEntries(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	ep, _ := storageUnit.NewExpiringPersister(db, time.Minute)
+	defer func() {
+		_ = ep.Close()
+	}()
+
+	_ = ep.Put([]byte("plain"), []byte("aaa"))
+	_ = ep.PutWithExpiry([]byte("expired-1"), []byte("bbb"), time.Now().Add(-time.Second))
+	_ = ep.PutWithExpiry([]byte("expired-2"), []byte("ccc"), time.Now().Add(-time.Second))
+	_ = ep.PutWithExpiry([]byte("fresh"), []byte("ddd"), time.Now().Add(time.Hour))
+
+	assert.Equal(t, 2, ep.RemoveExpiredEntries())
+	assert.Equal(t, 2, countEntries(db))
+	assert.Nil(t, db.Has([]byte("plain")))
+	assert.Nil(t, db.Has([]byte("fresh")))
+	assert.Equal(t, 0, ep.RemoveExpiredEntries())
+}
+
+func TestExpiringPersister_ExpiredEntriesAreRemovedInBackground(t *testing.T) {
+	t.Parallel()
+
+	t.Run("on lookup", func(t *testing.T) {
+		t.Parallel()
+
+		db := memorydb.New()
+		ep, _ := storageUnit.NewExpiringPersister(db, time.Hour)
+		defer func() {
+			_ = ep.Close()
+		}()
+
+		_ = ep.PutWithExpiry([]byte("key"), []byte("value"), time.Now().Add(time.Millisecond*10))
+		time.Sleep(time.Millisecond * 20)
+		assert.NotNil(t, ep.Has([]byte("key")))
+
+		assert.Eventually(t, func() bool {
+			return countEntries(db) == 0
+		}, time.Second, time.Millisecond*10)
+	})
+	t.Run("periodically", func(t *testing.T) {
+		t.Parallel()
+
+		db := memorydb.New()
+		ep, _ := storageUnit.NewExpiringPersister(db, time.Millisecond*50)
+		defer func() {
+			_ = ep.Close()
+		}()
+
+		_ = ep.PutWithExpiry([]byte("key"), []byte("value"), time.Now().Add(time.Millisecond*10))
+
+		assert.Eventually(t, func() bool {
+			return countEntries(db) == 0
+		}, time.Second, time.Millisecond*10)
+	})
+}
+
+func TestExpiringPersister_CloseShouldWaitForTheCleanupInProgress(t *testing.T) {
+	t.Parallel()
+
+	for _, operation := range []string{"close", "destroy"} {
+		operation := operation
+		t.Run(operation, func(t *testing.T) {
+			t.Parallel()
+
+			cleanupStarted := make(chan struct{})
+			onceStarted := sync.Once{}
+			isCleanupInProgress := atomic.Flag{}
+			wasClosedDuringCleanup := atomic.Flag{}
+			onClose := func() error {
+				if isCleanupInProgress.IsSet() {
+					wasClosedDuringCleanup.SetValue(true)
+				}
+				return nil
+			}
+
+			persister := &testscommon.PersisterStub{
+				RangeKeysCalled: func(handler func(key []byte, val []byte) bool) {
+					isCleanupInProgress.SetValue(true)
+					onceStarted.Do(func() {
+						close(cleanupStarted)
+					})
+					time.Sleep(time.Millisecond * 100)
+					isCleanupInProgress.SetValue(false)
+				},
+				CloseCalled:   onClose,
+				DestroyCalled: onClose,
+			}
+
+			ep, _ := storageUnit.NewExpiringPersister(persister, time.Millisecond)
+			<-cleanupStarted
+
+			var err error
+			if operation == "close" {
+				err = ep.Close()
+			} else {
+				err = ep.Destroy()
+			}
+			assert.Nil(t, err)
+			assert.False(t, wasClosedDuringCleanup.IsSet())
+		})
+	}
+}
+
+func TestExpiringPersister_OverwrittenEntryIsNotRemoved(t *testing.T) {
+	t.Parallel()
+
+	db := memorydb.New()
+	ep, _ := storageUnit.NewExpiringPersister(db, time.Minute)
+	defer func() {
+		_ = ep.Close()
+	}()
+
+	_ = ep.PutWithExpiry([]byte("key"), []byte("old"), time.Now().Add(-time.Second))
+	_ = ep.Put([]byte("key"), []byte("new"))
+
+	assert.Equal(t, 0, ep.RemoveExpiredEntries())
+	value, err := ep.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("new"), value)
+}
+
+func TestExpiringPersister_WriteBatch(t *testing.T) {
+	t.Parallel()
+
+	ep, _ := storageUnit.NewExpiringPersister(memorydb.New(), time.Minute)
+	defer func() {
+		_ = ep.Close()
+	}()
+
+	_ = ep.Put([]byte("b"), []byte("bbb"))
+	err := ep.WriteBatch([]storageCore.KeyValuePair{
+		{Key: []byte("a"), Value: []byte("aaa")},
+		{Key: []byte("b"), Value: nil},
+	})
+	assert.Nil(t, err)
+
+	value, err := ep.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("aaa"), value)
+	assert.True(t, common.IsNotFoundError(ep.Has([]byte("b"))))
+
+	epWithoutBatches, _ := storageUnit.NewExpiringPersister(&testscommon.PersisterStub{}, time.Minute)
+	defer func() {
+		_ = epWithoutBatches.Close()
+	}()
+
+	err = epWithoutBatches.WriteBatch([]storageCore.KeyValuePair{{Key: []byte("a"), Value: []byte("aaa")}})
+	assert.Equal(t, common.ErrAtomicBatchNotSupported, err)
+	assert.Nil(t, epWithoutBatches.GetPendingEntries())
+}
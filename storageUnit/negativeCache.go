@@ -0,0 +1,121 @@
+package storageUnit
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+)
+
+// NegativeCacheStats holds the usage statistics of the negative cache of a storage unit
+type NegativeCacheStats struct {
+	NumHits   uint64
+	NumMisses uint64
+	Len       int
+}
+
+type negativeCacheEntry struct {
+	key        string
+	expiryTime time.Time
+}
+
+// negativeCache is a bounded set of keys (known to be missing from the persister), each with a time-to-live.
+// When the capacity is reached, the oldest entries are dropped.
+type negativeCache struct {
+	capacity  int
+	ttl       time.Duration
+	entries   map[string]*list.Element
+	order     *list.List
+	numHits   atomic.Counter
+	numMisses atomic.Counter
+	getNow    func() time.Time
+	mutex     sync.Mutex
+}
+
+func newNegativeCache(capacity int, ttl time.Duration) *negativeCache {
+	return &negativeCache{
+		capacity: capacity,
+		ttl:      ttl,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+		getNow:   time.Now,
+	}
+}
+
+// has checks whether the key is recorded as missing (and not expired). It also updates the hit counters.
+func (nc *negativeCache) has(key []byte) bool {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	element, ok := nc.entries[string(key)]
+	if ok && nc.getNow().After(element.Value.(*negativeCacheEntry).expiryTime) {
+		nc.removeElementNoLock(element)
+		ok = false
+	}
+
+	if ok {
+		nc.numHits.Increment()
+	} else {
+		nc.numMisses.Increment()
+	}
+
+	return ok
+}
+
+// add records the key as missing
+func (nc *negativeCache) add(key []byte) {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	element, ok := nc.entries[string(key)]
+	if ok {
+		nc.removeElementNoLock(element)
+	}
+
+	for nc.order.Len() >= nc.capacity {
+		nc.removeElementNoLock(nc.order.Front())
+	}
+
+	entry := &negativeCacheEntry{
+		key:        string(key),
+		expiryTime: nc.getNow().Add(nc.ttl),
+	}
+	nc.entries[entry.key] = nc.order.PushBack(entry)
+}
+
+// remove invalidates the negative entry of the key (if any)
+func (nc *negativeCache) remove(key []byte) {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	element, ok := nc.entries[string(key)]
+	if ok {
+		nc.removeElementNoLock(element)
+	}
+}
+
+func (nc *negativeCache) clear() {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	nc.entries = make(map[string]*list.Element)
+	nc.order.Init()
+}
+
+func (nc *negativeCache) removeElementNoLock(element *list.Element) {
+	entry := element.Value.(*negativeCacheEntry)
+	delete(nc.entries, entry.key)
+	nc.order.Remove(element)
+}
+
+func (nc *negativeCache) getStats() NegativeCacheStats {
+	nc.mutex.Lock()
+	defer nc.mutex.Unlock()
+
+	return NegativeCacheStats{
+		NumHits:   nc.numHits.GetUint64(),
+		NumMisses: nc.numMisses.GetUint64(),
+		Len:       nc.order.Len(),
+	}
+}
@@ -24,6 +24,11 @@ func (ns *NilStorer) GetBulkFromEpoch(_ [][]byte, _ uint32) ([]storageCore.KeyVa
 	return nil, nil
 }
 
+// GetBulk will return an empty result
+func (ns *NilStorer) GetBulk(_ [][]byte) (map[string][]byte, error) {
+	return make(map[string][]byte), nil
+}
+
 // SearchFirst will do nothing
 func (ns *NilStorer) SearchFirst(_ []byte) ([]byte, error) {
 	return nil, nil
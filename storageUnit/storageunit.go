@@ -1,12 +1,13 @@
 package storageUnit
 
 import (
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"path/filepath"
 	"sync"
 	"time"
 
+	"github.com/multiversx/mx-chain-core-go/core"
 	"github.com/multiversx/mx-chain-core-go/core/check"
 	"github.com/multiversx/mx-chain-core-go/hashing"
 	"github.com/multiversx/mx-chain-core-go/hashing/blake2b"
@@ -14,6 +15,7 @@ import (
 	"github.com/multiversx/mx-chain-core-go/hashing/keccak"
 	storageCore "github.com/multiversx/mx-chain-core-go/storage"
 	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/accessRecordingStorer"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/fifocache"
 	"github.com/multiversx/mx-chain-storage-go/leveldb"
@@ -21,6 +23,7 @@ import (
 	"github.com/multiversx/mx-chain-storage-go/memorydb"
 	"github.com/multiversx/mx-chain-storage-go/monitoring"
 	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/multiversx/mx-chain-storage-go/wal"
 )
 
 var _ types.Storer = (*Unit)(nil)
@@ -39,6 +42,7 @@ const (
 	LRUCache         CacheType = "LRU"
 	SizeLRUCache     CacheType = "SizeLRU"
 	FIFOShardedCache CacheType = "FIFOSharded"
+	TwoQueueCache    CacheType = "TwoQueue"
 )
 
 var log = logger.GetOrCreate("storage/storageUnit")
@@ -61,6 +65,11 @@ const (
 )
 
 const minimumSizeForLRUCache = 1024
+const defaultNumShards = 16
+const walDirectoryName = "WAL"
+const defaultWALMaxSegmentSizeInBytes = 64 * 1024 * 1024
+const defaultAccessRecordingTopK = 100
+const defaultExpiredEntriesCleanupIntervalInSeconds = 60
 
 // MaxRetriesToCreateDB represents the maximum number of times to try to create DB if it failed
 const MaxRetriesToCreateDB = 10
@@ -72,6 +81,9 @@ const SleepTimeBetweenCreateDBRetries = 5 * time.Second
 type UnitConfig struct {
 	CacheConf CacheConfig
 	DBConf    DBConfig
+	// RecordAccesses is a diagnostics flag, wrapping the unit into an access recording storer (see the accessRecordingStorer package)
+	RecordAccesses      bool
+	AccessRecordingTopK int
 }
 
 // CacheConfig holds the configurable elements of a cache
@@ -82,7 +94,58 @@ type CacheConfig struct {
 	SizeInBytesPerSender uint32
 	Capacity             uint32
 	SizePerSender        uint32
-	Shards               uint32
+	// Shards is the number of shards of a FIFOSharded cache (defaults to 16, but no more than Capacity)
+	Shards uint32
+	// MaxSizeInBytes enables (if not zero) the budget in bytes of a FIFOSharded cache, which otherwise ignores SizeInBytes
+	MaxSizeInBytes uint64
+	// AccessCountingEnabled enables the counting of the reads of each key, see types.InspectableCacher
+	AccessCountingEnabled bool
+	// NegativeCacheCapacity enables (if not zero) the negative cache of the storage unit, see Unit.EnableNegativeCache
+	NegativeCacheCapacity uint32
+	NegativeCacheTTL      time.Duration
+}
+
+// Verify verifies the validity of the configuration
+func (config *CacheConfig) Verify() error {
+	switch config.Type {
+	case LRUCache, SizeLRUCache, TwoQueueCache, FIFOShardedCache:
+	default:
+		return common.ErrNotSupportedCacheType
+	}
+	if config.Capacity == 0 {
+		return fmt.Errorf("%w: config.Capacity is invalid", common.ErrInvalidConfig)
+	}
+	if config.Type == LRUCache && config.SizeInBytes != 0 {
+		return common.ErrLRUCacheWithProvidedSize
+	}
+	if (config.Type == SizeLRUCache || config.Type == TwoQueueCache) && config.SizeInBytes < minimumSizeForLRUCache {
+		return fmt.Errorf("%w, provided %d, minimum %d",
+			common.ErrLRUCacheInvalidSize,
+			config.SizeInBytes,
+			minimumSizeForLRUCache,
+		)
+	}
+	if config.Type == FIFOShardedCache && (config.Shards == 0 || config.Shards > config.Capacity) {
+		return fmt.Errorf("%w: config.Shards is invalid", common.ErrInvalidConfig)
+	}
+	if config.Type != FIFOShardedCache && config.MaxSizeInBytes != 0 {
+		return fmt.Errorf("%w: config.MaxSizeInBytes is only supported by %s caches", common.ErrInvalidConfig, FIFOShardedCache)
+	}
+	if config.NegativeCacheCapacity > 0 && config.NegativeCacheTTL <= 0 {
+		return fmt.Errorf("%w: TTL of the negative cache is invalid", common.ErrInvalidConfig)
+	}
+
+	return nil
+}
+
+// withDefaults returns a copy of the configuration, having the defaults applied for the optional fields left unset
+func (config *CacheConfig) withDefaults() CacheConfig {
+	configWithDefaults := *config
+	if config.Type == FIFOShardedCache && config.Shards == 0 {
+		configWithDefaults.Shards = core.MinUint32(defaultNumShards, config.Capacity)
+	}
+
+	return configWithDefaults
 }
 
 // String returns a readable representation of the object
@@ -102,21 +165,75 @@ type DBConfig struct {
 	BatchDelaySeconds int
 	MaxBatchSize      int
 	MaxOpenFiles      int
+	// The LevelDB tuning parameters are optional, zero values meaning the LevelDB defaults
+	LevelDBBlockSizeKB           int
+	LevelDBBloomFilterBitsPerKey int
+	LevelDBWriteBufferMB         int
+	// WALEnabled makes the writes go through a write-ahead log (kept under FilePath), so that the writes still pending
+	// in the batch of the persister survive a crash. The log is committed and truncated once it exceeds WALMaxSegmentSizeInBytes.
+	WALEnabled               bool
+	WALMaxSegmentSizeInBytes uint64
+	// ExpiringEntriesEnabled allows the unit to store entries that expire (see Unit.PutWithExpiry). The values are stored
+	// prefixed by their expiry, thus the flag must not be toggled for an existing database. The expired entries are
+	// physically removed every ExpiredEntriesCleanupIntervalInSeconds (defaults to 60).
+	ExpiringEntriesEnabled                 bool
+	ExpiredEntriesCleanupIntervalInSeconds int
+}
+
+// multiGetter defines a persister able to fetch more keys at once
+type multiGetter interface {
+	GetMulti(keys [][]byte) (map[string][]byte, error)
+}
+
+// atomicBatchWriter defines a persister able to write more entries atomically
+type atomicBatchWriter interface {
+	WriteBatch(entries []storageCore.KeyValuePair) error
+}
+
+// pendingEntriesProvider defines a persister holding written entries not yet visible to its RangeKeys (e.g. a pending batch)
+type pendingEntriesProvider interface {
+	GetPendingEntries() []storageCore.KeyValuePair
+}
+
+// expiringEntriesPersister defines a persister able to store entries that expire
+type expiringEntriesPersister interface {
+	PutWithExpiry(key, value []byte, expiresAt time.Time) error
+	GetWithExpiry(key []byte) ([]byte, time.Time, error)
+}
+
+// expiringCachedValue is the cached value of an entry that expires
+type expiringCachedValue struct {
+	value     []byte
+	expiresAt time.Time
 }
 
 // Unit represents a storer's data bank
 // holding the cache and persistence unit
 type Unit struct {
-	lock      sync.RWMutex
-	persister types.Persister
-	cacher    types.Cacher
+	lock          sync.RWMutex
+	persister     types.Persister
+	cacher        types.Cacher
+	negativeCache *negativeCache
+	// warmUps holds the warm-ups of the cache in progress, see WarmUpCache
+	warmUps map[*CacheWarmUp]struct{}
+	// name identifies the unit in the returned errors (the path of the database, for the units created from config)
+	name string
 }
 
 // Put adds data to both cache and persistence medium
 func (u *Unit) Put(key, data []byte) error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
+	if u.negativeCache != nil {
+		u.negativeCache.remove(key)
+	}
+	u.markModifiedForWarmUps(key)
+
 	u.cacher.Put(key, data, len(data))
 
 	err := u.persister.Put(key, data)
@@ -128,6 +245,82 @@ func (u *Unit) Put(key, data []byte) error {
 	return err
 }
 
+// PutWithExpiry adds data to both cache and persistence medium, the entry expiring after the given time-to-live.
+// Once expired, the entry is reported as missing (by Get, GetBulk and Has), and it is eventually removed from the persister.
+// The persister must be able to store entries that expire (see DBConfig.ExpiringEntriesEnabled).
+func (u *Unit) PutWithExpiry(key, data []byte, ttl time.Duration) error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+	if ttl <= 0 {
+		return common.ErrInvalidTTL
+	}
+
+	persister, ok := u.persister.(expiringEntriesPersister)
+	if !ok {
+		return common.ErrExpiringEntriesNotSupported
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	if u.negativeCache != nil {
+		u.negativeCache.remove(key)
+	}
+	u.markModifiedForWarmUps(key)
+
+	expiresAt := time.Now().Add(ttl)
+	u.putInCache(key, data, expiresAt)
+
+	err := persister.PutWithExpiry(key, data, expiresAt)
+	if err != nil {
+		u.cacher.Remove(key)
+		return err
+	}
+
+	return nil
+}
+
+// PutBatch writes the provided entries atomically (all or nothing) in the persister, then applies them to the cache, so that
+// a group of related entries is never partially visible. An entry having a nil value marks its key for removal.
+// On failure, the cache is not affected. The persister must be able to write batches atomically.
+func (u *Unit) PutBatch(entries []storageCore.KeyValuePair) error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+
+	writer, ok := u.persister.(atomicBatchWriter)
+	if !ok {
+		return common.ErrAtomicBatchNotSupported
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	for _, entry := range entries {
+		u.markModifiedForWarmUps(entry.Key)
+	}
+
+	err := writer.WriteBatch(entries)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		if entry.Value == nil {
+			u.cacher.Remove(entry.Key)
+			continue
+		}
+
+		if u.negativeCache != nil {
+			u.negativeCache.remove(entry.Key)
+		}
+		u.cacher.Put(entry.Key, entry.Value, len(entry.Value))
+	}
+
+	return nil
+}
+
 // PutInEpoch will call the Put method as this storer doesn't handle epochs
 func (u *Unit) PutInEpoch(key, data []byte, _ uint32) error {
 	return u.Put(key, data)
@@ -140,7 +333,12 @@ func (u *Unit) GetOldestEpoch() (uint32, error) {
 
 // Close will close unit
 func (u *Unit) Close() error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+
 	u.cacher.Clear()
+	u.clearNegativeCache()
 
 	err := u.persister.Close()
 	if err != nil {
@@ -151,40 +349,147 @@ func (u *Unit) Close() error {
 	return nil
 }
 
-// RangeKeys can iterate over the persisted (key, value) pairs calling the provided handler
+// RangeKeys iterates over the (key, value) pairs of the unit, until the handler returns false. The entries written but not yet
+// flushed by the persister (if it holds such entries) are yielded first, then the persisted ones, skipping the keys already
+// yielded or pending removal, so that each key is yielded at most once, with its latest value.
+// The handler is not called under the lock of the unit.
 func (u *Unit) RangeKeys(handler func(key []byte, value []byte) bool) {
-	u.persister.RangeKeys(handler)
+	if u == nil || handler == nil {
+		return
+	}
+
+	provider, ok := u.persister.(pendingEntriesProvider)
+	if !ok {
+		u.persister.RangeKeys(handler)
+		return
+	}
+
+	pendingEntries := provider.GetPendingEntries()
+	pendingKeys := make(map[string]struct{}, len(pendingEntries))
+	for _, entry := range pendingEntries {
+		pendingKeys[string(entry.Key)] = struct{}{}
+		if entry.Value == nil {
+			// Pending removal
+			continue
+		}
+
+		if !handler(entry.Key, entry.Value) {
+			return
+		}
+	}
+
+	u.persister.RangeKeys(func(key []byte, value []byte) bool {
+		_, isPending := pendingKeys[string(key)]
+		if isPending {
+			return true
+		}
+
+		return handler(key, value)
+	})
 }
 
 // Get searches the key in the cache. In case it is not found,
 // it further searches it in the associated database.
 // In case it is found in the database, the cache is updated with the value as well.
+// If the negative cache is enabled, the keys missing from the database are remembered for a while,
+// so that subsequent lookups of such keys do not hit the database again.
 func (u *Unit) Get(key []byte) ([]byte, error) {
+	if u == nil {
+		return nil, common.ErrNilStorageUnit
+	}
+
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
-	v, ok := u.cacher.Get(key)
-	var err error
+	buff, ok := u.getFromCache(key)
+	if ok {
+		return buff, nil
+	}
 
-	if !ok {
-		// not found in cache
-		// search it in second persistence medium
+	// not found in cache
+	// search it in second persistence medium, unless known to be missing
 
-		v, err = u.persister.Get(key)
-		if err != nil {
-			return nil, err
+	if u.negativeCache != nil && u.negativeCache.has(key) {
+		return nil, u.newKeyNotFoundError(key)
+	}
+
+	buff, expiresAt, err := u.getFromPersister(key)
+	if common.IsNotFoundError(err) {
+		if u.negativeCache != nil {
+			u.negativeCache.add(key)
 		}
 
-		buff, okAssertion := v.([]byte)
-		if !okAssertion {
-			return nil, fmt.Errorf("key: %s is not a byte slice", base64.StdEncoding.EncodeToString(key))
+		return nil, u.newKeyNotFoundError(key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// if found in persistence unit, add it in cache
+	u.putInCache(key, buff, expiresAt)
+
+	return buff, nil
+}
+
+// getFromCache returns the cached value of the key. An expired entry is removed from the cache, and reported as missing.
+// This function should only be called under the (already acquired) u.lock
+func (u *Unit) getFromCache(key []byte) ([]byte, bool) {
+	v, ok := u.cacher.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	switch value := v.(type) {
+	case []byte:
+		return value, true
+	case *expiringCachedValue:
+		if isExpired(value.expiresAt, time.Now()) {
+			u.cacher.Remove(key)
+			return nil, false
 		}
 
-		// if found in persistence unit, add it in cache
-		u.cacher.Put(key, v, len(buff))
+		return value.value, true
+	default:
+		return nil, false
+	}
+}
+
+// hasInCache returns true if the key is cached (and not expired), without affecting the cache
+func (u *Unit) hasInCache(key []byte) bool {
+	v, ok := u.cacher.Peek(key)
+	if !ok {
+		return false
+	}
+
+	value, isExpiring := v.(*expiringCachedValue)
+	if isExpiring {
+		return !isExpired(value.expiresAt, time.Now())
 	}
 
-	return v.([]byte), nil
+	return true
+}
+
+// putInCache caches the value, along with its expiry (if any, a zero expiry meaning the entry never expires)
+// This function should only be called under the (already acquired) u.lock
+func (u *Unit) putInCache(key []byte, value []byte, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		u.cacher.Put(key, value, len(value))
+		return
+	}
+
+	u.cacher.Put(key, &expiringCachedValue{value: value, expiresAt: expiresAt}, len(value))
+}
+
+// getFromPersister returns the value of the key, along with its expiry (zero if the entry never expires)
+// This function should only be called under the (already acquired) u.lock
+func (u *Unit) getFromPersister(key []byte) ([]byte, time.Time, error) {
+	persister, ok := u.persister.(expiringEntriesPersister)
+	if ok {
+		return persister.GetWithExpiry(key)
+	}
+
+	buff, err := u.persister.Get(key)
+	return buff, time.Time{}, err
 }
 
 // GetFromEpoch will call the Get method as this storer doesn't handle epochs
@@ -194,6 +499,10 @@ func (u *Unit) GetFromEpoch(key []byte, _ uint32) ([]byte, error) {
 
 // GetBulkFromEpoch will call the Get method for all keys as this storer doesn't handle epochs
 func (u *Unit) GetBulkFromEpoch(keys [][]byte, _ uint32) ([]storageCore.KeyValuePair, error) {
+	if u == nil {
+		return nil, common.ErrNilStorageUnit
+	}
+
 	results := make([]storageCore.KeyValuePair, 0, len(keys))
 	for _, key := range keys {
 		value, err := u.Get(key)
@@ -210,18 +519,121 @@ func (u *Unit) GetBulkFromEpoch(keys [][]byte, _ uint32) ([]storageCore.KeyValue
 	return results, nil
 }
 
+// GetBulk returns the values of the provided keys, only for the keys found. The keys are first searched in the cache, then the
+// remaining ones are fetched from the persister at once (if the persister supports it, otherwise one by one). The values fetched
+// from the persister are added in the cache. The keys missing from the persister are not reported as an error (the returned error
+// signals an infrastructure failure, such as a closed persister).
+func (u *Unit) GetBulk(keys [][]byte) (map[string][]byte, error) {
+	if u == nil {
+		return nil, common.ErrNilStorageUnit
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	results := make(map[string][]byte, len(keys))
+	missingKeys := make([][]byte, 0)
+	for _, key := range keys {
+		buff, ok := u.getFromCache(key)
+		if ok {
+			results[string(key)] = buff
+			continue
+		}
+
+		if u.negativeCache != nil && u.negativeCache.has(key) {
+			continue
+		}
+
+		missingKeys = append(missingKeys, key)
+	}
+
+	if len(missingKeys) == 0 {
+		return results, nil
+	}
+
+	fetched, expiries, err := u.getMultiFromPersister(missingKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, key := range missingKeys {
+		buff, ok := fetched[string(key)]
+		if !ok {
+			// The fetch succeeded, thus the key is missing from the persister
+			if u.negativeCache != nil {
+				u.negativeCache.add(key)
+			}
+			continue
+		}
+
+		results[string(key)] = buff
+		u.putInCache(key, buff, expiries[string(key)])
+	}
+
+	return results, nil
+}
+
+// getMultiFromPersister returns the values of the keys found, along with the expiries of the entries that expire
+// This function should only be called under the (already acquired) u.lock
+func (u *Unit) getMultiFromPersister(keys [][]byte) (map[string][]byte, map[string]time.Time, error) {
+	_, isExpiring := u.persister.(expiringEntriesPersister)
+	if !isExpiring {
+		getter, ok := u.persister.(multiGetter)
+		if ok {
+			results, err := getter.GetMulti(keys)
+			return results, nil, err
+		}
+	}
+
+	results := make(map[string][]byte, len(keys))
+	expiries := make(map[string]time.Time)
+	for _, key := range keys {
+		buff, expiresAt, err := u.getFromPersister(key)
+		if common.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		results[string(key)] = buff
+		if !expiresAt.IsZero() {
+			expiries[string(key)] = expiresAt
+		}
+	}
+
+	return results, expiries, nil
+}
+
 // Has checks if the key is in the Unit.
 // It first checks the cache. If it is not found, it checks the db
 func (u *Unit) Has(key []byte) error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+
 	u.lock.RLock()
 	defer u.lock.RUnlock()
 
-	has := u.cacher.Has(key)
-	if has {
+	if u.hasInCache(key) {
 		return nil
 	}
 
-	return u.persister.Has(key)
+	if u.negativeCache != nil && u.negativeCache.has(key) {
+		return u.newKeyNotFoundError(key)
+	}
+
+	err := u.persister.Has(key)
+	if common.IsNotFoundError(err) {
+		return u.newKeyNotFoundError(key)
+	}
+
+	return err
+}
+
+// newKeyNotFoundError returns ErrKeyNotFound, wrapped along with the name of the unit and the key
+func (u *Unit) newKeyNotFoundError(key []byte) error {
+	return common.NewKeyNotFoundError(fmt.Sprintf("storage unit [%s]", u.name), key)
 }
 
 // SearchFirst will call the Get method as this storer doesn't handle epochs
@@ -236,9 +648,14 @@ func (u *Unit) RemoveFromCurrentEpoch(key []byte) error {
 
 // Remove removes the data associated to the given key from both cache and persistence medium
 func (u *Unit) Remove(key []byte) error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
+	u.markModifiedForWarmUps(key)
 	u.cacher.Remove(key)
 	err := u.persister.Remove(key)
 
@@ -247,15 +664,90 @@ func (u *Unit) Remove(key []byte) error {
 
 // ClearCache cleans up the entire cache
 func (u *Unit) ClearCache() {
+	if u == nil {
+		return
+	}
+
 	u.cacher.Clear()
+	u.clearNegativeCache()
+}
+
+// EnableNegativeCache enables the negative cache (disabled by default): the keys not found in the persister (by Get or GetBulk)
+// are recorded in a bounded set, for the given time-to-live. Within the TTL, Get and Has return ErrKeyNotFound
+// for such keys, without hitting the persister. A Put of a key invalidates its negative entry.
+// Only the "not found" errors of the persister (see common.IsNotFoundError) are recorded as misses; other errors
+// (e.g. a closed persister) are returned as they are, and the next lookups hit the persister again.
+func (u *Unit) EnableNegativeCache(capacity uint32, ttl time.Duration) error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+	if capacity == 0 {
+		return fmt.Errorf("%w: capacity of the negative cache is invalid", common.ErrInvalidConfig)
+	}
+	if ttl <= 0 {
+		return fmt.Errorf("%w: TTL of the negative cache is invalid", common.ErrInvalidConfig)
+	}
+
+	u.lock.Lock()
+	defer u.lock.Unlock()
+
+	u.negativeCache = newNegativeCache(int(capacity), ttl)
+	return nil
+}
+
+// NegativeCacheStats returns the usage statistics (e.g. the number of hits) of the negative cache, if enabled
+func (u *Unit) NegativeCacheStats() (NegativeCacheStats, error) {
+	if u == nil {
+		return NegativeCacheStats{}, common.ErrNilStorageUnit
+	}
+
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.negativeCache == nil {
+		return NegativeCacheStats{}, common.ErrNegativeCacheNotEnabled
+	}
+
+	return u.negativeCache.getStats(), nil
+}
+
+func (u *Unit) clearNegativeCache() {
+	u.lock.RLock()
+	defer u.lock.RUnlock()
+
+	if u.negativeCache != nil {
+		u.negativeCache.clear()
+	}
+}
+
+// CacheStats returns the usage statistics of the cacher, if the cacher is able to report them
+func (u *Unit) CacheStats() (types.CacheStats, error) {
+	if u == nil {
+		return types.CacheStats{}, common.ErrNilStorageUnit
+	}
+
+	statsHandler, ok := u.cacher.(types.CacheStatsHandler)
+	if !ok {
+		return types.CacheStats{}, common.ErrCacheStatsNotAvailable
+	}
+
+	return statsHandler.Stats(), nil
 }
 
 // DestroyUnit cleans up the cache, and the db
 func (u *Unit) DestroyUnit() error {
+	if u == nil {
+		return common.ErrNilStorageUnit
+	}
+
 	u.lock.Lock()
 	defer u.lock.Unlock()
 
 	u.cacher.Clear()
+	if u.negativeCache != nil {
+		u.negativeCache.clear()
+	}
+
 	return u.persister.Destroy()
 }
 
@@ -277,6 +769,7 @@ func NewStorageUnit(c types.Cacher, p types.Persister) (*Unit, error) {
 	sUnit := &Unit{
 		persister: p,
 		cacher:    c,
+		warmUps:   make(map[*CacheWarmUp]struct{}),
 	}
 
 	return sUnit, nil
@@ -294,7 +787,6 @@ func NewStorageUnitFromConf(cacheConf CacheConfig, dbConf DBConfig) (*Unit, erro
 	if dbConf.MaxBatchSize > int(cacheConf.Capacity) {
 		return nil, common.ErrCacheSizeIsLowerThanBatchSize
 	}
-
 	cache, err = NewCache(cacheConf)
 	if err != nil {
 		return nil, err
@@ -306,49 +798,70 @@ func NewStorageUnitFromConf(cacheConf CacheConfig, dbConf DBConfig) (*Unit, erro
 		BatchDelaySeconds: dbConf.BatchDelaySeconds,
 		MaxBatchSize:      dbConf.MaxBatchSize,
 		MaxOpenFiles:      dbConf.MaxOpenFiles,
+		LevelDBTuning: leveldb.TuningArgs{
+			BlockSizeKB:           dbConf.LevelDBBlockSizeKB,
+			BloomFilterBitsPerKey: dbConf.LevelDBBloomFilterBitsPerKey,
+			WriteBufferMB:         dbConf.LevelDBWriteBufferMB,
+		},
 	}
 	db, err = NewDB(argDB)
 	if err != nil {
 		return nil, err
 	}
 
-	return NewStorageUnit(cache, db)
+	if dbConf.WALEnabled {
+		db, err = newWALPersister(db, dbConf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if dbConf.ExpiringEntriesEnabled {
+		db, err = newExpiringPersister(db, dbConf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sUnit, err := NewStorageUnit(cache, db)
+	if err != nil {
+		return nil, err
+	}
+	sUnit.name = dbConf.FilePath
+
+	if cacheConf.NegativeCacheCapacity > 0 {
+		err = sUnit.EnableNegativeCache(cacheConf.NegativeCacheCapacity, cacheConf.NegativeCacheTTL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return sUnit, nil
 }
 
 // NewCache creates a new cache from a cache config
 func NewCache(config CacheConfig) (types.Cacher, error) {
+	config = config.withDefaults()
 	monitoring.MonitorNewCache(config.Name, config.SizeInBytes)
 
-	cacheType := config.Type
-	capacity := config.Capacity
-	shards := config.Shards
-	sizeInBytes := config.SizeInBytes
+	err := config.Verify()
+	if err != nil {
+		return nil, err
+	}
+
+	capacity := int(config.Capacity)
+	sizeInBytes := int64(config.SizeInBytes)
 
 	var cacher types.Cacher
-	var err error
-
-	switch cacheType {
+	switch config.Type {
 	case LRUCache:
-		if sizeInBytes != 0 {
-			return nil, common.ErrLRUCacheWithProvidedSize
-		}
-
-		cacher, err = lrucache.NewCache(int(capacity))
+		cacher, err = lrucache.NewCache(capacity)
 	case SizeLRUCache:
-		if sizeInBytes < minimumSizeForLRUCache {
-			return nil, fmt.Errorf("%w, provided %d, minimum %d",
-				common.ErrLRUCacheInvalidSize,
-				sizeInBytes,
-				minimumSizeForLRUCache,
-			)
-		}
-
-		cacher, err = lrucache.NewCacheWithSizeInBytes(int(capacity), int64(sizeInBytes))
+		cacher, err = lrucache.NewCacheWithSizeInBytes(capacity, sizeInBytes)
+	case TwoQueueCache:
+		cacher, err = lrucache.NewTwoQueueCacheWithSizeInBytes(capacity, sizeInBytes)
 	case FIFOShardedCache:
-		cacher, err = fifocache.NewShardedCache(int(capacity), int(shards))
-		if err != nil {
-			return nil, err
-		}
+		cacher, err = fifocache.NewShardedCacheWithSizeInBytes(capacity, int(config.Shards), int64(config.MaxSizeInBytes))
 		// add other implementations if required
 	default:
 		return nil, common.ErrNotSupportedCacheType
@@ -358,6 +871,13 @@ func NewCache(config CacheConfig) (types.Cacher, error) {
 		return nil, err
 	}
 
+	if config.AccessCountingEnabled {
+		inspectableCacher, ok := cacher.(types.InspectableCacher)
+		if ok {
+			inspectableCacher.SetAccessCountingEnabled(true)
+		}
+	}
+
 	return cacher, nil
 }
 
@@ -368,6 +888,32 @@ type ArgDB struct {
 	BatchDelaySeconds int
 	MaxBatchSize      int
 	MaxOpenFiles      int
+	LevelDBTuning     leveldb.TuningArgs
+}
+
+// NewStorerFromUnitConfig creates a storage unit from the provided configuration (see NewStorageUnitFromConf), wrapping it
+// into an access recording storer if the diagnostics flag is set
+func NewStorerFromUnitConfig(unitConf UnitConfig) (types.Storer, error) {
+	unit, err := NewStorageUnitFromConf(unitConf.CacheConf, unitConf.DBConf)
+	if err != nil {
+		return nil, err
+	}
+	if !unitConf.RecordAccesses {
+		return unit, nil
+	}
+
+	topK := unitConf.AccessRecordingTopK
+	if topK == 0 {
+		topK = defaultAccessRecordingTopK
+	}
+
+	recordingStorer, err := accessRecordingStorer.NewAccessRecordingStorer(unit, topK)
+	if err != nil {
+		_ = unit.Close()
+		return nil, err
+	}
+
+	return recordingStorer, nil
 }
 
 // NewDB creates a new database from database config
@@ -378,9 +924,9 @@ func NewDB(argDB ArgDB) (types.Persister, error) {
 	for i := 0; i < MaxRetriesToCreateDB; i++ {
 		switch argDB.DBType {
 		case LvlDB:
-			db, err = leveldb.NewDB(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles)
+			db, err = leveldb.NewDBWithTuning(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles, argDB.LevelDBTuning)
 		case LvlDBSerial:
-			db, err = leveldb.NewSerialDB(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles)
+			db, err = leveldb.NewSerialDBWithTuning(argDB.Path, argDB.BatchDelaySeconds, argDB.MaxBatchSize, argDB.MaxOpenFiles, argDB.LevelDBTuning)
 		case MemoryDB:
 			db = memorydb.New()
 		default:
@@ -414,3 +960,33 @@ func (h HasherType) NewHasher() (hashing.Hasher, error) {
 		return nil, common.ErrNotSupportedHashType
 	}
 }
+
+func newWALPersister(db types.Persister, dbConf DBConfig) (types.Persister, error) {
+	maxSegmentSizeInBytes := dbConf.WALMaxSegmentSizeInBytes
+	if maxSegmentSizeInBytes == 0 {
+		maxSegmentSizeInBytes = defaultWALMaxSegmentSizeInBytes
+	}
+
+	walDB, err := wal.NewWALPersister(db, filepath.Join(dbConf.FilePath, walDirectoryName), maxSegmentSizeInBytes)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return walDB, nil
+}
+
+func newExpiringPersister(db types.Persister, dbConf DBConfig) (types.Persister, error) {
+	cleanupIntervalInSeconds := dbConf.ExpiredEntriesCleanupIntervalInSeconds
+	if cleanupIntervalInSeconds == 0 {
+		cleanupIntervalInSeconds = defaultExpiredEntriesCleanupIntervalInSeconds
+	}
+
+	expiringDB, err := NewExpiringPersister(db, time.Duration(cleanupIntervalInSeconds)*time.Second)
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+
+	return expiringDB, nil
+}
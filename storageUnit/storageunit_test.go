@@ -1,15 +1,26 @@
 package storageUnit_test
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strconv"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/accessRecordingStorer"
 	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/leveldb"
 	"github.com/multiversx/mx-chain-storage-go/lrucache"
 	"github.com/multiversx/mx-chain-storage-go/memorydb"
 	"github.com/multiversx/mx-chain-storage-go/storageUnit"
+	"github.com/multiversx/mx-chain-storage-go/testscommon"
+	"github.com/multiversx/mx-chain-storage-go/types"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -141,7 +152,7 @@ func TestHasNotPresent(t *testing.T) {
 	err := s.Has(key)
 
 	assert.NotNil(t, err)
-	assert.Equal(t, err, common.ErrKeyNotFound)
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
 }
 
 func TestHasNotPresentCache(t *testing.T) {
@@ -253,6 +264,157 @@ func TestCreateCacheFromConfOK(t *testing.T) {
 	assert.NotNil(t, cacher, "valid cacher expected but got nil")
 }
 
+func TestCreateTwoQueueCacheFromConf(t *testing.T) {
+
+	cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.TwoQueueCache, Capacity: 10, Shards: 1, SizeInBytes: 0})
+	assert.True(t, errors.Is(err, common.ErrLRUCacheInvalidSize))
+	assert.Nil(t, cacher)
+
+	cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.TwoQueueCache, Capacity: 10, Shards: 1, SizeInBytes: 2048})
+	assert.Nil(t, err, "no error expected but got %s", err)
+	assert.NotNil(t, cacher, "valid cacher expected but got nil")
+}
+
+func TestCacheConfig_Verify(t *testing.T) {
+	t.Parallel()
+
+	validConfig := storageUnit.CacheConfig{
+		Type:                  storageUnit.FIFOShardedCache,
+		Capacity:              100,
+		Shards:                4,
+		NegativeCacheCapacity: 10,
+		NegativeCacheTTL:      time.Minute,
+	}
+	assert.Nil(t, validConfig.Verify())
+
+	invalidConfig := validConfig
+	invalidConfig.Type = "NotLRU"
+	assert.Equal(t, common.ErrNotSupportedCacheType, invalidConfig.Verify())
+
+	invalidConfig = validConfig
+	invalidConfig.Capacity = 0
+	err := invalidConfig.Verify()
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "config.Capacity")
+
+	invalidConfig = validConfig
+	invalidConfig.Shards = 0
+	err = invalidConfig.Verify()
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "config.Shards")
+
+	invalidConfig = validConfig
+	invalidConfig.Shards = 101
+	err = invalidConfig.Verify()
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "config.Shards")
+
+	invalidConfig = validConfig
+	invalidConfig.NegativeCacheTTL = 0
+	err = invalidConfig.Verify()
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "TTL of the negative cache")
+
+	invalidConfig = validConfig
+	invalidConfig.Type = storageUnit.LRUCache
+	invalidConfig.SizeInBytes = 2048
+	assert.Equal(t, common.ErrLRUCacheWithProvidedSize, invalidConfig.Verify())
+
+	invalidConfig = validConfig
+	invalidConfig.Type = storageUnit.SizeLRUCache
+	invalidConfig.SizeInBytes = 1
+	assert.ErrorIs(t, invalidConfig.Verify(), common.ErrLRUCacheInvalidSize)
+
+	invalidConfig = validConfig
+	invalidConfig.Type = storageUnit.SizeLRUCache
+	invalidConfig.SizeInBytes = 2048
+	invalidConfig.MaxSizeInBytes = 2048
+	err = invalidConfig.Verify()
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Contains(t, err.Error(), "config.MaxSizeInBytes")
+}
+
+func TestNewCache_InvalidConfig(t *testing.T) {
+	t.Parallel()
+
+	cacher, err := storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.LRUCache})
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Nil(t, cacher)
+
+	cacher, err = storageUnit.NewCache(storageUnit.CacheConfig{Type: storageUnit.FIFOShardedCache, Capacity: 10, Shards: 11})
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Nil(t, cacher)
+}
+
+func TestNewCache_AppliesDefaults(t *testing.T) {
+	t.Parallel()
+
+	// Shards is omitted: it defaults to 16, but no more than the capacity
+	config := storageUnit.CacheConfig{Type: storageUnit.FIFOShardedCache, Capacity: 100}
+	assert.ErrorIs(t, config.Verify(), common.ErrInvalidConfig)
+
+	cacher, err := storageUnit.NewCache(config)
+	assert.Nil(t, err)
+	assert.NotNil(t, cacher)
+
+	config.Capacity = 4
+	cacher, err = storageUnit.NewCache(config)
+	assert.Nil(t, err)
+	for i := 0; i < 10; i++ {
+		key := []byte(fmt.Sprintf("key-%d", i))
+		cacher.Put(key, key, len(key))
+	}
+	assert.True(t, cacher.Len() <= 4)
+}
+
+func TestNewCache_FIFOShardedWithSizeInBytes(t *testing.T) {
+	t.Parallel()
+
+	putUnsizedEntries := func(cacher types.Cacher) {
+		for i := 0; i < 10; i++ {
+			key := []byte(fmt.Sprintf("key-%d", i))
+			cacher.Put(key, key, 0)
+		}
+	}
+
+	t.Run("SizeInBytes is ignored, as before", func(t *testing.T) {
+		t.Parallel()
+
+		config := storageUnit.CacheConfig{Type: storageUnit.FIFOShardedCache, Capacity: 100, Shards: 1, SizeInBytes: 1024}
+		cacher, err := storageUnit.NewCache(config)
+		assert.Nil(t, err)
+
+		putUnsizedEntries(cacher)
+		assert.Equal(t, 10, cacher.Len())
+	})
+
+	t.Run("MaxSizeInBytes enables the budget in bytes", func(t *testing.T) {
+		t.Parallel()
+
+		config := storageUnit.CacheConfig{Type: storageUnit.FIFOShardedCache, Capacity: 100, Shards: 1, MaxSizeInBytes: 2048}
+		cacher, err := storageUnit.NewCache(config)
+		assert.Nil(t, err)
+
+		// The entries added without a size are accounted with a default size (of 1024 bytes)
+		putUnsizedEntries(cacher)
+		assert.Equal(t, 2, cacher.Len())
+	})
+}
+
+func TestNewCache_AccessCountingEnabled(t *testing.T) {
+	t.Parallel()
+
+	for _, enabled := range []bool{false, true} {
+		config := storageUnit.CacheConfig{Type: storageUnit.SizeLRUCache, Capacity: 10, SizeInBytes: 2048, AccessCountingEnabled: enabled}
+		cacher, err := storageUnit.NewCache(config)
+		assert.Nil(t, err)
+
+		_, _ = cacher.Get([]byte("key"))
+		counts := cacher.(types.InspectableCacher).GetAccessCounts()
+		assert.Equal(t, enabled, len(counts) == 1)
+	}
+}
+
 func TestCreateDBFromConfWrongType(t *testing.T) {
 	arg := storageUnit.ArgDB{
 		DBType:            "NotLvlDB",
@@ -365,6 +527,74 @@ func TestNewStorageUnit_FromConfLvlDBOk(t *testing.T) {
 	assert.Nil(t, err, "no error expected destroying the persister")
 }
 
+func TestNewStorageUnit_FromConfLvlDBWithWAL(t *testing.T) {
+	cacheConf := storageUnit.CacheConfig{
+		Capacity: 10,
+		Type:     storageUnit.LRUCache,
+	}
+	dbConf := storageUnit.DBConfig{
+		FilePath:          filepath.Join(t.TempDir(), "Blocks"),
+		Type:              storageUnit.LvlDB,
+		MaxBatchSize:      10,
+		BatchDelaySeconds: 100,
+		MaxOpenFiles:      10,
+		WALEnabled:        true,
+	}
+	storer, err := storageUnit.NewStorageUnitFromConf(cacheConf, dbConf)
+	assert.Nil(t, err)
+
+	// The write is still pending in the batch of the persister, but it is already in the log
+	err = storer.Put([]byte("key"), []byte("value"))
+	assert.Nil(t, err)
+	segments, err := os.ReadDir(filepath.Join(dbConf.FilePath, "WAL"))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(segments))
+	info, _ := segments[0].Info()
+	assert.True(t, info.Size() > 0)
+
+	err = storer.Close()
+	assert.Nil(t, err)
+
+	storer, err = storageUnit.NewStorageUnitFromConf(cacheConf, dbConf)
+	assert.Nil(t, err)
+	value, err := storer.Get([]byte("key"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("value"), value)
+	err = storer.DestroyUnit()
+	assert.Nil(t, err)
+}
+
+func TestNewStorerFromUnitConfig(t *testing.T) {
+	t.Parallel()
+
+	unitConf := storageUnit.UnitConfig{
+		CacheConf: storageUnit.CacheConfig{
+			Capacity: 10,
+			Type:     storageUnit.LRUCache,
+		},
+		DBConf: storageUnit.DBConfig{
+			Type: storageUnit.MemoryDB,
+		},
+	}
+	storer, err := storageUnit.NewStorerFromUnitConfig(unitConf)
+	assert.Nil(t, err)
+	_, isUnit := storer.(*storageUnit.Unit)
+	assert.True(t, isUnit)
+
+	unitConf.RecordAccesses = true
+	storer, err = storageUnit.NewStorerFromUnitConfig(unitConf)
+	assert.Nil(t, err)
+	recorder, isRecorder := storer.(interface {
+		Report() accessRecordingStorer.AccessReport
+	})
+	assert.True(t, isRecorder)
+
+	_ = storer.Put([]byte("key"), []byte("value"))
+	_, _ = storer.Get([]byte("key"))
+	assert.Equal(t, uint64(1), recorder.Report().NumReads)
+	assert.Equal(t, uint64(1), recorder.Report().NumWrites)
+}
+
 func TestNewStorageUnit_ShouldWorkLvlDB(t *testing.T) {
 	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
 		Capacity: 10,
@@ -451,3 +681,673 @@ func BenchmarkStorageUnit_GetWithDataNotBeingPresent(b *testing.B) {
 		logError(err)
 	}
 }
+
+func TestUnit_CacheStats(t *testing.T) {
+	t.Parallel()
+
+	s := initStorageUnit(t, 10)
+
+	_ = s.Put([]byte("key"), []byte("value"))
+	_, _ = s.Get([]byte("key"))
+	_, _ = s.Get([]byte("missing"))
+
+	stats, err := s.CacheStats()
+	assert.Nil(t, err)
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(1), stats.Puts)
+	assert.Equal(t, 1, stats.Len)
+}
+
+func TestUnit_NilUnitShouldNotPanic(t *testing.T) {
+	t.Parallel()
+
+	defer func() {
+		r := recover()
+		assert.Nil(t, r)
+	}()
+
+	var s *storageUnit.Unit
+	assert.True(t, s.IsInterfaceNil())
+
+	key := []byte("key")
+	assert.Equal(t, common.ErrNilStorageUnit, s.Put(key, []byte("value")))
+	assert.Equal(t, common.ErrNilStorageUnit, s.PutInEpoch(key, []byte("value"), 0))
+	assert.Equal(t, common.ErrNilStorageUnit, s.Has(key))
+	assert.Equal(t, common.ErrNilStorageUnit, s.Remove(key))
+	assert.Equal(t, common.ErrNilStorageUnit, s.RemoveFromCurrentEpoch(key))
+	assert.Equal(t, common.ErrNilStorageUnit, s.DestroyUnit())
+	assert.Equal(t, common.ErrNilStorageUnit, s.Close())
+
+	value, err := s.Get(key)
+	assert.Nil(t, value)
+	assert.Equal(t, common.ErrNilStorageUnit, err)
+
+	value, err = s.SearchFirst(key)
+	assert.Nil(t, value)
+	assert.Equal(t, common.ErrNilStorageUnit, err)
+
+	results, err := s.GetBulkFromEpoch([][]byte{key}, 0)
+	assert.Nil(t, results)
+	assert.Equal(t, common.ErrNilStorageUnit, err)
+
+	_, err = s.CacheStats()
+	assert.Equal(t, common.ErrNilStorageUnit, err)
+
+	assert.Equal(t, common.ErrNilStorageUnit, s.EnableNegativeCache(10, time.Second))
+	_, err = s.NegativeCacheStats()
+	assert.Equal(t, common.ErrNilStorageUnit, err)
+
+	s.ClearCache()
+	s.RangeKeys(func(key []byte, value []byte) bool {
+		assert.Fail(t, "should not have been called")
+		return true
+	})
+}
+
+func createStorageUnitWithCountingPersister(t *testing.T, numGetCalls *uint32) *storageUnit.Unit {
+	mdb := memorydb.New()
+	persister := &testscommon.PersisterStub{
+		PutCalled: mdb.Put,
+		GetCalled: func(key []byte) ([]byte, error) {
+			atomic.AddUint32(numGetCalls, 1)
+			return mdb.Get(key)
+		},
+		HasCalled: mdb.Has,
+	}
+
+	cache, _ := lrucache.NewCache(10)
+	s, err := storageUnit.NewStorageUnit(cache, persister)
+	assert.Nil(t, err)
+
+	return s
+}
+
+func TestUnit_NegativeCache(t *testing.T) {
+	t.Parallel()
+
+	t.Run("disabled by default", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+
+		_, err := s.Get([]byte("missing"))
+		assert.NotNil(t, err)
+		_, err = s.Get([]byte("missing"))
+		assert.NotNil(t, err)
+		assert.Equal(t, uint32(2), atomic.LoadUint32(&numGetCalls))
+
+		_, err = s.NegativeCacheStats()
+		assert.Equal(t, common.ErrNegativeCacheNotEnabled, err)
+	})
+
+	t.Run("invalid arguments", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		assert.ErrorIs(t, s.EnableNegativeCache(0, time.Second), common.ErrInvalidConfig)
+		assert.ErrorIs(t, s.EnableNegativeCache(10, 0), common.ErrInvalidConfig)
+	})
+
+	t.Run("missing keys do not hit the persister again", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+		err := s.EnableNegativeCache(10, time.Hour)
+		assert.Nil(t, err)
+
+		_, err = s.Get([]byte("missing"))
+		assert.NotNil(t, err)
+		assert.Equal(t, uint32(1), atomic.LoadUint32(&numGetCalls))
+
+		_, err = s.Get([]byte("missing"))
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
+		assert.ErrorIs(t, s.Has([]byte("missing")), common.ErrKeyNotFound)
+		assert.Equal(t, uint32(1), atomic.LoadUint32(&numGetCalls))
+
+		stats, err := s.NegativeCacheStats()
+		assert.Nil(t, err)
+		assert.Equal(t, uint64(2), stats.NumHits)
+		assert.Equal(t, uint64(1), stats.NumMisses)
+		assert.Equal(t, 1, stats.Len)
+	})
+
+	t.Run("errors other than not found are not recorded", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		expectedErr := errors.New("expected error")
+		persister := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				atomic.AddUint32(&numGetCalls, 1)
+				return nil, expectedErr
+			},
+		}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+		_ = s.EnableNegativeCache(10, time.Hour)
+
+		_, err := s.Get([]byte("key"))
+		assert.Equal(t, expectedErr, err)
+		_, err = s.Get([]byte("key"))
+		assert.Equal(t, expectedErr, err)
+		assert.Equal(t, uint32(2), atomic.LoadUint32(&numGetCalls))
+
+		stats, _ := s.NegativeCacheStats()
+		assert.Equal(t, 0, stats.Len)
+	})
+
+	t.Run("put invalidates the negative entry", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+		_ = s.EnableNegativeCache(10, time.Hour)
+
+		_, _ = s.Get([]byte("key"))
+		err := s.Put([]byte("key"), []byte("value"))
+		assert.Nil(t, err)
+
+		s.ClearCache()
+		value, err := s.Get([]byte("key"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value"), value)
+		assert.Equal(t, uint32(2), atomic.LoadUint32(&numGetCalls))
+	})
+
+	t.Run("entries expire", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+		_ = s.EnableNegativeCache(10, 50*time.Millisecond)
+
+		_, _ = s.Get([]byte("missing"))
+		time.Sleep(100 * time.Millisecond)
+		_, _ = s.Get([]byte("missing"))
+		assert.Equal(t, uint32(2), atomic.LoadUint32(&numGetCalls))
+	})
+
+	t.Run("capacity is bounded", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+		_ = s.EnableNegativeCache(2, time.Hour)
+
+		_, _ = s.Get([]byte("a"))
+		_, _ = s.Get([]byte("b"))
+		_, _ = s.Get([]byte("c"))
+
+		stats, _ := s.NegativeCacheStats()
+		assert.Equal(t, 2, stats.Len)
+
+		// "a" (the oldest) has been dropped
+		_, _ = s.Get([]byte("a"))
+		assert.Equal(t, uint32(4), atomic.LoadUint32(&numGetCalls))
+		_, _ = s.Get([]byte("c"))
+		assert.Equal(t, uint32(4), atomic.LoadUint32(&numGetCalls))
+	})
+}
+
+func TestNewStorageUnit_FromConfWithNegativeCache(t *testing.T) {
+	t.Parallel()
+
+	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
+		Capacity:              10,
+		Type:                  storageUnit.LRUCache,
+		NegativeCacheCapacity: 10,
+	}, storageUnit.DBConfig{
+		Type: storageUnit.MemoryDB,
+	})
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Nil(t, storer)
+
+	storer, err = storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
+		Capacity:              10,
+		Type:                  storageUnit.LRUCache,
+		NegativeCacheCapacity: 10,
+		NegativeCacheTTL:      time.Minute,
+	}, storageUnit.DBConfig{
+		Type: storageUnit.MemoryDB,
+	})
+	assert.Nil(t, err)
+
+	_, err = storer.NegativeCacheStats()
+	assert.Nil(t, err)
+}
+
+func TestUnit_KeyNotFoundErrorsHaveContext(t *testing.T) {
+	t.Parallel()
+
+	storer, err := storageUnit.NewStorageUnitFromConf(storageUnit.CacheConfig{
+		Capacity:              10,
+		Type:                  storageUnit.LRUCache,
+		NegativeCacheCapacity: 10,
+		NegativeCacheTTL:      time.Minute,
+	}, storageUnit.DBConfig{
+		FilePath: "Accounts",
+		Type:     storageUnit.MemoryDB,
+	})
+	assert.Nil(t, err)
+
+	checkError := func(err error) {
+		assert.ErrorIs(t, err, common.ErrKeyNotFound)
+		assert.True(t, common.IsNotFoundError(err))
+		assert.Contains(t, err.Error(), "key not found")
+		assert.Contains(t, err.Error(), "storage unit [Accounts]")
+		assert.Contains(t, err.Error(), hex.EncodeToString([]byte("missing")))
+	}
+
+	_, err = storer.Get([]byte("missing"))
+	checkError(err)
+	// From the negative cache
+	_, err = storer.Get([]byte("missing"))
+	checkError(err)
+	checkError(storer.Has([]byte("missing")))
+
+	// Legacy errors of the persisters (only mentioning "not found") are recognized, as well
+	cache, _ := lrucache.NewCache(10)
+	unit, _ := storageUnit.NewStorageUnit(cache, &testscommon.PersisterStub{
+		GetCalled: func(key []byte) ([]byte, error) {
+			return nil, errors.New("entry not found")
+		},
+	})
+	_, err = unit.Get([]byte("missing"))
+	assert.ErrorIs(t, err, common.ErrKeyNotFound)
+}
+
+type multiGetterPersisterStub struct {
+	testscommon.PersisterStub
+	GetMultiCalled func(keys [][]byte) (map[string][]byte, error)
+}
+
+// GetMulti -
+func (stub *multiGetterPersisterStub) GetMulti(keys [][]byte) (map[string][]byte, error) {
+	return stub.GetMultiCalled(keys)
+}
+
+func TestUnit_GetBulk(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		var s *storageUnit.Unit
+		results, err := s.GetBulk([][]byte{[]byte("a")})
+		assert.Nil(t, results)
+		assert.Equal(t, common.ErrNilStorageUnit, err)
+	})
+
+	t.Run("cache first, then a single multi get", func(t *testing.T) {
+		t.Parallel()
+
+		mdb := memorydb.New()
+		_ = mdb.Put([]byte("a"), []byte("value-a"))
+		_ = mdb.Put([]byte("b"), []byte("value-b"))
+
+		numGetMultiCalls := 0
+		var requestedKeys [][]byte
+		persister := &multiGetterPersisterStub{
+			GetMultiCalled: func(keys [][]byte) (map[string][]byte, error) {
+				numGetMultiCalls++
+				requestedKeys = keys
+				return mdb.GetMulti(keys)
+			},
+		}
+		persister.GetCalled = func(key []byte) ([]byte, error) {
+			assert.Fail(t, "should have not been called")
+			return nil, nil
+		}
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+		cache.Put([]byte("c"), []byte("value-c"), 7)
+
+		results, err := s.GetBulk([][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("missing")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{
+			"a": []byte("value-a"),
+			"b": []byte("value-b"),
+			"c": []byte("value-c"),
+		}, results)
+		assert.Equal(t, 1, numGetMultiCalls)
+		assert.Equal(t, [][]byte{[]byte("a"), []byte("b"), []byte("missing")}, requestedKeys)
+
+		// The fetched values are now cached
+		assert.True(t, cache.Has([]byte("a")))
+		assert.True(t, cache.Has([]byte("b")))
+
+		results, err = s.GetBulk([][]byte{[]byte("a"), []byte("b"), []byte("c")})
+		assert.Nil(t, err)
+		assert.Equal(t, 3, len(results))
+		assert.Equal(t, 1, numGetMultiCalls)
+	})
+
+	t.Run("persister without multi get should fall back to sequential gets", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+		_ = s.Put([]byte("a"), []byte("value-a"))
+		_ = s.Put([]byte("b"), []byte("value-b"))
+		s.ClearCache()
+
+		results, err := s.GetBulk([][]byte{[]byte("a"), []byte("missing"), []byte("b")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"a": []byte("value-a"), "b": []byte("value-b")}, results)
+		assert.Equal(t, uint32(3), atomic.LoadUint32(&numGetCalls))
+	})
+
+	t.Run("persister failure should error", func(t *testing.T) {
+		t.Parallel()
+
+		persister := &testscommon.PersisterStub{
+			GetCalled: func(key []byte) ([]byte, error) {
+				return nil, common.ErrDBIsClosed
+			},
+		}
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+		results, err := s.GetBulk([][]byte{[]byte("a")})
+		assert.Nil(t, results)
+		assert.Equal(t, common.ErrDBIsClosed, err)
+	})
+
+	t.Run("negative cache should be used", func(t *testing.T) {
+		t.Parallel()
+
+		numGetCalls := uint32(0)
+		s := createStorageUnitWithCountingPersister(t, &numGetCalls)
+		_ = s.EnableNegativeCache(10, time.Hour)
+
+		results, err := s.GetBulk([][]byte{[]byte("missing")})
+		assert.Nil(t, err)
+		assert.Empty(t, results)
+		assert.Equal(t, uint32(1), atomic.LoadUint32(&numGetCalls))
+
+		_, err = s.GetBulk([][]byte{[]byte("missing")})
+		assert.Nil(t, err)
+		assert.Equal(t, uint32(1), atomic.LoadUint32(&numGetCalls))
+	})
+}
+
+func TestNilStorer_GetBulk(t *testing.T) {
+	t.Parallel()
+
+	ns := storageUnit.NewNilStorer()
+	results, err := ns.GetBulk([][]byte{[]byte("a")})
+	assert.Nil(t, err)
+	assert.Empty(t, results)
+}
+
+type atomicBatchPersisterStub struct {
+	testscommon.PersisterStub
+	WriteBatchCalled func(entries []storageCore.KeyValuePair) error
+}
+
+// WriteBatch -
+func (stub *atomicBatchPersisterStub) WriteBatch(entries []storageCore.KeyValuePair) error {
+	return stub.WriteBatchCalled(entries)
+}
+
+func TestUnit_PutBatch(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		var s *storageUnit.Unit
+		assert.Equal(t, common.ErrNilStorageUnit, s.PutBatch(nil))
+	})
+
+	t.Run("persister without atomic batches should error", func(t *testing.T) {
+		t.Parallel()
+
+		cache, _ := lrucache.NewCache(10)
+		s, _ := storageUnit.NewStorageUnit(cache, &testscommon.PersisterStub{})
+
+		err := s.PutBatch([]storageCore.KeyValuePair{{Key: []byte("a"), Value: []byte("a")}})
+		assert.Equal(t, common.ErrAtomicBatchNotSupported, err)
+		assert.Equal(t, 0, cache.Len())
+	})
+
+	t.Run("should write the persister, then the cache", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		_ = s.Put([]byte("b"), []byte("old-b"))
+
+		err := s.PutBatch([]storageCore.KeyValuePair{
+			{Key: []byte("a"), Value: []byte("value-a")},
+			{Key: []byte("b"), Value: nil},
+			{Key: []byte("c"), Value: []byte("value-c")},
+		})
+		assert.Nil(t, err)
+
+		value, err := s.Get([]byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value-a"), value)
+		assert.NotNil(t, s.Has([]byte("b")))
+
+		// The values are found in the persister as well
+		s.ClearCache()
+		value, err = s.Get([]byte("c"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("value-c"), value)
+		assert.NotNil(t, s.Has([]byte("b")))
+	})
+
+	t.Run("failed write should not affect the cache", func(t *testing.T) {
+		t.Parallel()
+
+		expectedErr := errors.New("expected error")
+		persister := &atomicBatchPersisterStub{
+			WriteBatchCalled: func(entries []storageCore.KeyValuePair) error {
+				return expectedErr
+			},
+		}
+		cache, _ := lrucache.NewCache(10)
+		cache.Put([]byte("b"), []byte("old-b"), 5)
+		s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+		err := s.PutBatch([]storageCore.KeyValuePair{
+			{Key: []byte("a"), Value: []byte("value-a")},
+			{Key: []byte("b"), Value: nil},
+		})
+		assert.Equal(t, expectedErr, err)
+		assert.False(t, cache.Has([]byte("a")))
+		assert.True(t, cache.Has([]byte("b")))
+	})
+}
+
+func TestUnit_RangeKeysShouldIncludeThePendingEntries(t *testing.T) {
+	t.Parallel()
+
+	persister, err := leveldb.NewDB(t.TempDir(), 10, 3, 10)
+	assert.Nil(t, err)
+	defer func() {
+		_ = persister.Close()
+	}()
+
+	cache, _ := lrucache.NewCache(10)
+	s, _ := storageUnit.NewStorageUnit(cache, persister)
+
+	// Flushed (the batch is full)
+	_ = s.Put([]byte("a"), []byte("old-a"))
+	_ = s.Put([]byte("b"), []byte("b"))
+	_ = s.Put([]byte("c"), []byte("c"))
+	// Pending
+	_ = s.Put([]byte("a"), []byte("new-a"))
+	_ = s.Remove([]byte("b"))
+
+	numPersisted := 0
+	persister.RangeKeys(func(_ []byte, _ []byte) bool {
+		numPersisted++
+		return true
+	})
+	assert.Equal(t, 3, numPersisted)
+
+	yielded := make(map[string]string)
+	numCalls := 0
+	s.RangeKeys(func(key []byte, value []byte) bool {
+		yielded[string(key)] = string(value)
+		numCalls++
+		return true
+	})
+	assert.Equal(t, map[string]string{"a": "new-a", "c": "c"}, yielded)
+	assert.Equal(t, 2, numCalls)
+
+	// Early exit
+	numCalls = 0
+	s.RangeKeys(func(_ []byte, _ []byte) bool {
+		numCalls++
+		return false
+	})
+	assert.Equal(t, 1, numCalls)
+
+	// No panic on nil handler
+	s.RangeKeys(nil)
+}
+
+func createStorageUnitWithExpiringEntries(t *testing.T) (*storageUnit.Unit, types.Persister) {
+	db := memorydb.New()
+	persister, err := storageUnit.NewExpiringPersister(db, time.Hour)
+	assert.Nil(t, err)
+
+	cache, _ := lrucache.NewCache(10)
+	s, err := storageUnit.NewStorageUnit(cache, persister)
+	assert.Nil(t, err)
+
+	return s, db
+}
+
+func TestUnit_PutWithExpiry(t *testing.T) {
+	t.Parallel()
+
+	t.Run("nil unit should error", func(t *testing.T) {
+		t.Parallel()
+
+		var s *storageUnit.Unit
+		assert.Equal(t, common.ErrNilStorageUnit, s.PutWithExpiry([]byte("a"), []byte("a"), time.Minute))
+	})
+	t.Run("invalid TTL should error", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := createStorageUnitWithExpiringEntries(t)
+		assert.Equal(t, common.ErrInvalidTTL, s.PutWithExpiry([]byte("a"), []byte("a"), 0))
+	})
+	t.Run("persister without expiring entries should error", func(t *testing.T) {
+		t.Parallel()
+
+		s := initStorageUnit(t, 10)
+		err := s.PutWithExpiry([]byte("a"), []byte("a"), time.Minute)
+		assert.Equal(t, common.ErrExpiringEntriesNotSupported, err)
+		assert.NotNil(t, s.Has([]byte("a")))
+	})
+	t.Run("expired entries should be missing, also from the cache", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := createStorageUnitWithExpiringEntries(t)
+		_ = s.Put([]byte("plain"), []byte("aaa"))
+		err := s.PutWithExpiry([]byte("expiring"), []byte("bbb"), time.Millisecond*50)
+		assert.Nil(t, err)
+
+		value, err := s.Get([]byte("expiring"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("bbb"), value)
+		assert.Nil(t, s.Has([]byte("expiring")))
+
+		time.Sleep(time.Millisecond * 100)
+
+		_, err = s.Get([]byte("expiring"))
+		assert.True(t, common.IsNotFoundError(err))
+		assert.True(t, common.IsNotFoundError(s.Has([]byte("expiring"))))
+		values, err := s.GetBulk([][]byte{[]byte("plain"), []byte("expiring")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"plain": []byte("aaa")}, values)
+
+		value, err = s.Get([]byte("plain"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("aaa"), value)
+	})
+	t.Run("expiry should be honored for the entries fetched from the persister", func(t *testing.T) {
+		t.Parallel()
+
+		s, _ := createStorageUnitWithExpiringEntries(t)
+		_ = s.PutWithExpiry([]byte("a"), []byte("aaa"), time.Millisecond*50)
+		_ = s.PutWithExpiry([]byte("b"), []byte("bbb"), time.Millisecond*50)
+		s.ClearCache()
+
+		value, err := s.Get([]byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("aaa"), value)
+		values, err := s.GetBulk([][]byte{[]byte("b")})
+		assert.Nil(t, err)
+		assert.Equal(t, map[string][]byte{"b": []byte("bbb")}, values)
+
+		time.Sleep(time.Millisecond * 100)
+
+		_, err = s.Get([]byte("a"))
+		assert.True(t, common.IsNotFoundError(err))
+		values, err = s.GetBulk([][]byte{[]byte("b")})
+		assert.Nil(t, err)
+		assert.Empty(t, values)
+	})
+	t.Run("put should make the entry never expire", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := createStorageUnitWithExpiringEntries(t)
+		_ = s.PutWithExpiry([]byte("a"), []byte("old"), time.Millisecond*50)
+		_ = s.Put([]byte("a"), []byte("new"))
+
+		time.Sleep(time.Millisecond * 100)
+
+		value, err := s.Get([]byte("a"))
+		assert.Nil(t, err)
+		assert.Equal(t, []byte("new"), value)
+		s.ClearCache()
+		assert.Nil(t, s.Has([]byte("a")))
+		assert.Nil(t, db.Has([]byte("a")))
+	})
+	t.Run("expired entries should be removed from the persister", func(t *testing.T) {
+		t.Parallel()
+
+		s, db := createStorageUnitWithExpiringEntries(t)
+		_ = s.PutWithExpiry([]byte("a"), []byte("aaa"), time.Millisecond*10)
+		time.Sleep(time.Millisecond * 20)
+		_, _ = s.Get([]byte("a"))
+
+		assert.Eventually(t, func() bool {
+			return db.Has([]byte("a")) != nil
+		}, time.Second, time.Millisecond*10)
+	})
+}
+
+func TestNewStorageUnit_FromConfWithExpiringEntries(t *testing.T) {
+	t.Parallel()
+
+	cacheConf := storageUnit.CacheConfig{
+		Capacity: 10,
+		Type:     storageUnit.LRUCache,
+	}
+
+	storer, err := storageUnit.NewStorageUnitFromConf(cacheConf, storageUnit.DBConfig{
+		Type:                                   storageUnit.MemoryDB,
+		ExpiringEntriesEnabled:                 true,
+		ExpiredEntriesCleanupIntervalInSeconds: -1,
+	})
+	assert.ErrorIs(t, err, common.ErrInvalidConfig)
+	assert.Nil(t, storer)
+
+	storer, err = storageUnit.NewStorageUnitFromConf(cacheConf, storageUnit.DBConfig{
+		Type:                   storageUnit.MemoryDB,
+		ExpiringEntriesEnabled: true,
+	})
+	assert.Nil(t, err)
+	assert.Nil(t, storer.PutWithExpiry([]byte("a"), []byte("aaa"), time.Minute))
+	_ = storer.Close()
+}
@@ -0,0 +1,45 @@
+package testscommon
+
+// IteratorStub -
+type IteratorStub struct {
+	NextCalled  func() bool
+	KeyCalled   func() []byte
+	ValueCalled func() []byte
+	CloseCalled func() error
+}
+
+// Next -
+func (stub *IteratorStub) Next() bool {
+	if stub.NextCalled != nil {
+		return stub.NextCalled()
+	}
+
+	return false
+}
+
+// Key -
+func (stub *IteratorStub) Key() []byte {
+	if stub.KeyCalled != nil {
+		return stub.KeyCalled()
+	}
+
+	return nil
+}
+
+// Value -
+func (stub *IteratorStub) Value() []byte {
+	if stub.ValueCalled != nil {
+		return stub.ValueCalled()
+	}
+
+	return nil
+}
+
+// Close -
+func (stub *IteratorStub) Close() error {
+	if stub.CloseCalled != nil {
+		return stub.CloseCalled()
+	}
+
+	return nil
+}
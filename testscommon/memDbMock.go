@@ -4,7 +4,11 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
+
+	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 // MemDbMock represents the memory database storage. It holds a map of key value pairs
@@ -112,6 +116,42 @@ func (s *MemDbMock) RangeKeys(handler func(key []byte, value []byte) bool) {
 	}
 }
 
+// NewIterator returns an iterator over a sorted snapshot of the (key, value) pairs having the given prefix
+func (s *MemDbMock) NewIterator(prefix []byte) (types.Iterator, error) {
+	s.mutx.RLock()
+	keys := make([]string, 0, len(s.db))
+	values := make(map[string][]byte, len(s.db))
+	for k, v := range s.db {
+		if !strings.HasPrefix(k, string(prefix)) {
+			continue
+		}
+
+		keys = append(keys, k)
+		values[k] = v
+	}
+	s.mutx.RUnlock()
+
+	sort.Strings(keys)
+	index := -1
+
+	return &IteratorStub{
+		NextCalled: func() bool {
+			if index >= len(keys)-1 {
+				return false
+			}
+
+			index++
+			return true
+		},
+		KeyCalled: func() []byte {
+			return []byte(keys[index])
+		},
+		ValueCalled: func() []byte {
+			return values[keys[index]]
+		},
+	}, nil
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (s *MemDbMock) IsInterfaceNil() bool {
 	return s == nil
@@ -1,5 +1,9 @@
 package testscommon
 
+import (
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
 // PersisterStub -
 type PersisterStub struct {
 	PutCalled           func(key, val []byte) error
@@ -10,6 +14,7 @@ type PersisterStub struct {
 	DestroyCalled       func() error
 	DestroyClosedCalled func() error
 	RangeKeysCalled     func(handler func(key []byte, val []byte) bool)
+	NewIteratorCalled   func(prefix []byte) (types.Iterator, error)
 }
 
 // Put -
@@ -82,6 +87,15 @@ func (p *PersisterStub) RangeKeys(handler func(key []byte, val []byte) bool) {
 	}
 }
 
+// NewIterator -
+func (p *PersisterStub) NewIterator(prefix []byte) (types.Iterator, error) {
+	if p.NewIteratorCalled != nil {
+		return p.NewIteratorCalled(prefix)
+	}
+
+	return &IteratorStub{}, nil
+}
+
 // IsInterfaceNil -
 func (p *PersisterStub) IsInterfaceNil() bool {
 	return p == nil
@@ -0,0 +1,541 @@
+package tieredStorer
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var _ types.Storer = (*tieredStorer)(nil)
+
+var log = logger.GetOrCreate("storage/tieredStorer")
+
+const demotionInterval = time.Second
+const maxDemotionBatchSize = 1024
+
+// batchPutter defines a storer able to write more entries atomically (e.g. storageUnit.Unit)
+type batchPutter interface {
+	PutBatch(entries []storageCore.KeyValuePair) error
+}
+
+// TieredStorerStats holds the usage statistics of a tiered storer
+type TieredStorerStats struct {
+	HotNumEntries int
+	HotNumBytes   uint64
+	NumHotHits    uint64
+	NumColdHits   uint64
+	NumMisses     uint64
+	NumDemoted    uint64
+	NumPromoted   uint64
+}
+
+type hotEntry struct {
+	key       string
+	size      uint64
+	writtenAt time.Time
+}
+
+// tieredStorer composes a (fast) hot storer over a (larger, slower) cold storer, within a single logical unit.
+// The writes go to the hot tier, while a background pass demotes the entries older than a threshold (or exceeding the
+// byte budget of the hot tier) into the cold tier. The reads look up the hot tier, then the cold one.
+type tieredStorer struct {
+	hot          types.Storer
+	cold         types.Storer
+	demoteAfter  time.Duration
+	maxHotBytes  uint64
+	promoteOnHit atomic.Flag
+
+	// hotEntries tracks the entries of the hot tier, ordered (in hotOrder) by their write time, oldest first
+	hotEntries map[string]*list.Element
+	hotOrder   *list.List
+	hotBytes   uint64
+	// mutOperation serializes the writes, the removals and the demotions, so that a removed key is never resurrected
+	mutOperation sync.Mutex
+
+	// numRemovals is incremented by each removal, thus the promotions are able to detect a concurrent removal
+	numRemovals atomic.Counter
+	numHotHits  atomic.Counter
+	numColdHits atomic.Counter
+	numMisses   atomic.Counter
+	numDemoted  atomic.Counter
+	numPromoted atomic.Counter
+
+	getNow         func() time.Time
+	chDemotion     chan struct{}
+	cancelDemotion context.CancelFunc
+	demotionDone   chan struct{}
+	closeOnce      sync.Once
+}
+
+// NewTieredStorer creates a new tiered storer. The entries of the hot tier are demoted into the cold tier once older than
+// demoteAfter, or (oldest first) while the hot tier holds more than maxHotBytes. The entries already found in the hot tier
+// (e.g. after a restart) are considered freshly written. The demotion runs on a background goroutine, stopped by Close.
+func NewTieredStorer(hot types.Storer, cold types.Storer, demoteAfter time.Duration, maxHotBytes uint64) (*tieredStorer, error) {
+	if check.IfNil(hot) {
+		return nil, fmt.Errorf("%w: hot", common.ErrNilStorer)
+	}
+	if check.IfNil(cold) {
+		return nil, fmt.Errorf("%w: cold", common.ErrNilStorer)
+	}
+	if demoteAfter <= 0 {
+		return nil, fmt.Errorf("%w: demoteAfter is invalid", common.ErrInvalidConfig)
+	}
+	if maxHotBytes == 0 {
+		return nil, fmt.Errorf("%w: maxHotBytes is invalid", common.ErrInvalidConfig)
+	}
+
+	ts := &tieredStorer{
+		hot:          hot,
+		cold:         cold,
+		demoteAfter:  demoteAfter,
+		maxHotBytes:  maxHotBytes,
+		hotEntries:   make(map[string]*list.Element),
+		hotOrder:     list.New(),
+		getNow:       time.Now,
+		chDemotion:   make(chan struct{}, 1),
+		demotionDone: make(chan struct{}),
+	}
+
+	hot.RangeKeys(func(key []byte, value []byte) bool {
+		ts.trackNoLock(string(key), uint64(len(value)))
+		return true
+	})
+
+	var ctx context.Context
+	ctx, ts.cancelDemotion = context.WithCancel(context.Background())
+	go ts.demoteInBackground(ctx)
+
+	return ts, nil
+}
+
+// EnablePromotionOnHit makes Get copy into the hot tier the entries found in the cold tier
+func (ts *tieredStorer) EnablePromotionOnHit() {
+	ts.promoteOnHit.SetValue(true)
+}
+
+func (ts *tieredStorer) demoteInBackground(ctx context.Context) {
+	defer close(ts.demotionDone)
+
+	ticker := time.NewTicker(demotionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-ts.chDemotion:
+		case <-ctx.Done():
+			log.Debug("closing tieredStorer's demotion go routine...")
+			return
+		}
+
+		err := ts.Demote()
+		if err != nil {
+			log.Warn("tieredStorer: cannot demote entries", "error", err)
+		}
+	}
+}
+
+func (ts *tieredStorer) signalDemotion() {
+	select {
+	case ts.chDemotion <- struct{}{}:
+	default:
+	}
+}
+
+// Demote moves into the cold tier the entries of the hot tier older than the demotion threshold, as well as the oldest entries
+// exceeding the byte budget of the hot tier. The entries are demoted in batches: each batch is written into the cold tier
+// (atomically, if supported) before being removed from the hot tier, so that a crash in between leaves duplicates, but never loses data.
+func (ts *tieredStorer) Demote() error {
+	for {
+		numProcessed, err := ts.demoteBatch(maxDemotionBatchSize)
+		if err != nil {
+			return err
+		}
+		if numProcessed < maxDemotionBatchSize {
+			return nil
+		}
+	}
+}
+
+func (ts *tieredStorer) demoteBatch(maxBatchSize int) (int, error) {
+	ts.mutOperation.Lock()
+	defer ts.mutOperation.Unlock()
+
+	now := ts.getNow()
+	hotBytesAfterDemotion := ts.hotBytes
+	entries := make([]storageCore.KeyValuePair, 0)
+	staleKeys := make([]string, 0)
+
+	for element := ts.hotOrder.Front(); element != nil && len(entries)+len(staleKeys) < maxBatchSize; element = element.Next() {
+		entry := element.Value.(*hotEntry)
+		isExpired := now.Sub(entry.writtenAt) >= ts.demoteAfter
+		isOverBudget := hotBytesAfterDemotion > ts.maxHotBytes
+		if !isExpired && !isOverBudget {
+			break
+		}
+
+		hotBytesAfterDemotion -= entry.size
+
+		value, err := ts.hot.Get([]byte(entry.key))
+		if err != nil {
+			// Not held by the hot tier anymore
+			staleKeys = append(staleKeys, entry.key)
+			continue
+		}
+
+		entries = append(entries, storageCore.KeyValuePair{Key: []byte(entry.key), Value: value})
+	}
+
+	for _, key := range staleKeys {
+		ts.untrackNoLock(key)
+	}
+
+	if len(entries) == 0 {
+		return len(staleKeys), nil
+	}
+
+	err := ts.putInCold(entries)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, entry := range entries {
+		err = ts.hot.Remove(entry.Key)
+		if err != nil {
+			// The entry remains tracked, thus its demotion is retried on the next pass
+			return 0, err
+		}
+
+		ts.untrackNoLock(string(entry.Key))
+		ts.numDemoted.Increment()
+	}
+
+	return len(entries) + len(staleKeys), nil
+}
+
+func (ts *tieredStorer) putInCold(entries []storageCore.KeyValuePair) error {
+	putter, ok := ts.cold.(batchPutter)
+	if ok {
+		err := putter.PutBatch(entries)
+		if !errors.Is(err, common.ErrAtomicBatchNotSupported) {
+			return err
+		}
+	}
+
+	for _, entry := range entries {
+		err := ts.cold.Put(entry.Key, entry.Value)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// This function should only be used in critical section (ts.mutOperation), or before the storer is shared
+func (ts *tieredStorer) trackNoLock(key string, size uint64) {
+	element, ok := ts.hotEntries[key]
+	if ok {
+		entry := element.Value.(*hotEntry)
+		ts.hotBytes -= entry.size
+		entry.size = size
+		entry.writtenAt = ts.getNow()
+		ts.hotOrder.MoveToBack(element)
+	} else {
+		ts.hotEntries[key] = ts.hotOrder.PushBack(&hotEntry{key: key, size: size, writtenAt: ts.getNow()})
+	}
+
+	ts.hotBytes += size
+}
+
+// This function should only be used in critical section (ts.mutOperation)
+func (ts *tieredStorer) untrackNoLock(key string) {
+	element, ok := ts.hotEntries[key]
+	if !ok {
+		return
+	}
+
+	ts.hotBytes -= element.Value.(*hotEntry).size
+	ts.hotOrder.Remove(element)
+	delete(ts.hotEntries, key)
+}
+
+// Put adds data to the hot tier. If the byte budget of the hot tier is exceeded, a demotion is triggered (in background).
+func (ts *tieredStorer) Put(key, data []byte) error {
+	ts.mutOperation.Lock()
+	err := ts.hot.Put(key, data)
+	if err == nil {
+		ts.trackNoLock(string(key), uint64(len(data)))
+	}
+	isOverBudget := ts.hotBytes > ts.maxHotBytes
+	ts.mutOperation.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if isOverBudget {
+		ts.signalDemotion()
+	}
+
+	return nil
+}
+
+// PutInEpoch will call the Put method as this storer doesn't handle epochs
+func (ts *tieredStorer) PutInEpoch(key, data []byte, _ uint32) error {
+	return ts.Put(key, data)
+}
+
+// Get looks up the key in the hot tier, then in the cold tier. If promotion is enabled, the value found in the cold tier
+// is also added to the hot tier.
+func (ts *tieredStorer) Get(key []byte) ([]byte, error) {
+	value, err := ts.hot.Get(key)
+	if err == nil {
+		ts.numHotHits.Increment()
+		return value, nil
+	}
+
+	numRemovals := ts.numRemovals.Get()
+	value, err = ts.cold.Get(key)
+	if err != nil {
+		ts.numMisses.Increment()
+		return nil, err
+	}
+
+	ts.numColdHits.Increment()
+	if ts.promoteOnHit.IsSet() {
+		ts.promote(key, value, numRemovals)
+	}
+
+	return value, nil
+}
+
+func (ts *tieredStorer) promote(key []byte, value []byte, numRemovalsBeforeLookup int64) {
+	ts.mutOperation.Lock()
+	defer ts.mutOperation.Unlock()
+
+	// A concurrent removal must not be undone, while a concurrent write must not be overwritten
+	if ts.numRemovals.Get() != numRemovalsBeforeLookup {
+		return
+	}
+	if _, isHot := ts.hotEntries[string(key)]; isHot {
+		return
+	}
+
+	err := ts.hot.Put(key, value)
+	if err != nil {
+		log.Debug("tieredStorer: cannot promote entry", "key", key, "error", err)
+		return
+	}
+
+	ts.trackNoLock(string(key), uint64(len(value)))
+	ts.numPromoted.Increment()
+}
+
+// GetFromEpoch will call the Get method as this storer doesn't handle epochs
+func (ts *tieredStorer) GetFromEpoch(key []byte, _ uint32) ([]byte, error) {
+	return ts.Get(key)
+}
+
+// GetBulkFromEpoch will call the Get method for all keys as this storer doesn't handle epochs
+func (ts *tieredStorer) GetBulkFromEpoch(keys [][]byte, _ uint32) ([]storageCore.KeyValuePair, error) {
+	results := make([]storageCore.KeyValuePair, 0, len(keys))
+	for _, key := range keys {
+		value, err := ts.Get(key)
+		if err != nil {
+			log.Trace("tieredStorer: cannot get key", "key", key, "error", err)
+			continue
+		}
+
+		results = append(results, storageCore.KeyValuePair{Key: key, Value: value})
+	}
+
+	return results, nil
+}
+
+// GetBulk looks up the keys in the hot tier, then the missing ones in the cold tier. The keys found in none of the tiers
+// are absent from the result. The entries found in the cold tier are not promoted.
+func (ts *tieredStorer) GetBulk(keys [][]byte) (map[string][]byte, error) {
+	results, err := ts.hot.GetBulk(keys)
+	if err != nil {
+		return nil, err
+	}
+
+	missingKeys := make([][]byte, 0)
+	for _, key := range keys {
+		if _, ok := results[string(key)]; !ok {
+			missingKeys = append(missingKeys, key)
+		}
+	}
+	ts.numHotHits.Add(int64(len(keys) - len(missingKeys)))
+
+	if len(missingKeys) == 0 {
+		return results, nil
+	}
+
+	coldResults, err := ts.cold.GetBulk(missingKeys)
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range coldResults {
+		results[key] = value
+	}
+	ts.numColdHits.Add(int64(len(coldResults)))
+	ts.numMisses.Add(int64(len(missingKeys) - len(coldResults)))
+
+	return results, nil
+}
+
+// Has checks whether the key is held by any of the tiers
+func (ts *tieredStorer) Has(key []byte) error {
+	err := ts.hot.Has(key)
+	if err == nil {
+		return nil
+	}
+
+	return ts.cold.Has(key)
+}
+
+// SearchFirst searches the key in the hot tier, then in the cold tier
+func (ts *tieredStorer) SearchFirst(key []byte) ([]byte, error) {
+	value, err := ts.hot.SearchFirst(key)
+	if err == nil {
+		return value, nil
+	}
+
+	return ts.cold.SearchFirst(key)
+}
+
+// Remove removes the key from both tiers
+func (ts *tieredStorer) Remove(key []byte) error {
+	return ts.removeFromBothTiers(key, types.Storer.Remove)
+}
+
+// RemoveFromCurrentEpoch removes the key from both tiers, as this storer doesn't handle epochs
+func (ts *tieredStorer) RemoveFromCurrentEpoch(key []byte) error {
+	return ts.removeFromBothTiers(key, types.Storer.RemoveFromCurrentEpoch)
+}
+
+func (ts *tieredStorer) removeFromBothTiers(key []byte, remove func(storer types.Storer, key []byte) error) error {
+	ts.mutOperation.Lock()
+	defer ts.mutOperation.Unlock()
+
+	ts.numRemovals.Increment()
+
+	errHot := remove(ts.hot, key)
+	if errHot == nil {
+		ts.untrackNoLock(string(key))
+	}
+
+	errCold := remove(ts.cold, key)
+	if errHot != nil {
+		return errHot
+	}
+
+	return errCold
+}
+
+// ClearCache clears the caches of both tiers
+func (ts *tieredStorer) ClearCache() {
+	ts.hot.ClearCache()
+	ts.cold.ClearCache()
+}
+
+// GetOldestEpoch will return an error that signals that the oldest epoch fetching is not available
+func (ts *tieredStorer) GetOldestEpoch() (uint32, error) {
+	return 0, common.ErrOldestEpochNotAvailable
+}
+
+// RangeKeys iterates over the (key, value) pairs of the hot tier, then over the ones of the cold tier (skipping the keys already
+// yielded by the hot tier), until the handler returns false
+func (ts *tieredStorer) RangeKeys(handler func(key []byte, value []byte) bool) {
+	if handler == nil {
+		return
+	}
+
+	yieldedKeys := make(map[string]struct{})
+	shouldContinue := true
+	ts.hot.RangeKeys(func(key []byte, value []byte) bool {
+		yieldedKeys[string(key)] = struct{}{}
+		shouldContinue = handler(key, value)
+		return shouldContinue
+	})
+	if !shouldContinue {
+		return
+	}
+
+	ts.cold.RangeKeys(func(key []byte, value []byte) bool {
+		_, isYielded := yieldedKeys[string(key)]
+		if isYielded {
+			return true
+		}
+
+		return handler(key, value)
+	})
+}
+
+// GetStats returns the usage statistics of the tiers
+func (ts *tieredStorer) GetStats() TieredStorerStats {
+	ts.mutOperation.Lock()
+	hotNumEntries := len(ts.hotEntries)
+	hotNumBytes := ts.hotBytes
+	ts.mutOperation.Unlock()
+
+	return TieredStorerStats{
+		HotNumEntries: hotNumEntries,
+		HotNumBytes:   hotNumBytes,
+		NumHotHits:    ts.numHotHits.GetUint64(),
+		NumColdHits:   ts.numColdHits.GetUint64(),
+		NumMisses:     ts.numMisses.GetUint64(),
+		NumDemoted:    ts.numDemoted.GetUint64(),
+		NumPromoted:   ts.numPromoted.GetUint64(),
+	}
+}
+
+func (ts *tieredStorer) stopDemotion() {
+	ts.closeOnce.Do(func() {
+		ts.cancelDemotion()
+		<-ts.demotionDone
+	})
+}
+
+// DestroyUnit stops the demotion, then destroys both tiers
+func (ts *tieredStorer) DestroyUnit() error {
+	ts.stopDemotion()
+
+	errHot := ts.hot.DestroyUnit()
+	errCold := ts.cold.DestroyUnit()
+	if errHot != nil {
+		return errHot
+	}
+
+	return errCold
+}
+
+// Close stops the demotion, then closes both tiers
+func (ts *tieredStorer) Close() error {
+	ts.stopDemotion()
+
+	errHot := ts.hot.Close()
+	errCold := ts.cold.Close()
+	if errHot != nil {
+		return errHot
+	}
+
+	return errCold
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (ts *tieredStorer) IsInterfaceNil() bool {
+	return ts == nil
+}
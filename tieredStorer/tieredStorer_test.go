@@ -0,0 +1,318 @@
+package tieredStorer_test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/multiversx/mx-chain-storage-go/memorydb"
+	"github.com/multiversx/mx-chain-storage-go/storageUnit"
+	"github.com/multiversx/mx-chain-storage-go/testscommon"
+	"github.com/multiversx/mx-chain-storage-go/tieredStorer"
+	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func createUnit(persister types.Persister) *storageUnit.Unit {
+	cache, _ := lrucache.NewCache(10)
+	unit, _ := storageUnit.NewStorageUnit(cache, persister)
+	return unit
+}
+
+func createTiers() (*storageUnit.Unit, *storageUnit.Unit) {
+	return createUnit(memorydb.New()), createUnit(memorydb.New())
+}
+
+func TestNewTieredStorer(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+
+	ts, err := tieredStorer.NewTieredStorer(nil, cold, time.Minute, 1024)
+	assert.True(t, check.IfNil(ts))
+	assert.True(t, errors.Is(err, common.ErrNilStorer))
+
+	ts, err = tieredStorer.NewTieredStorer(hot, nil, time.Minute, 1024)
+	assert.True(t, check.IfNil(ts))
+	assert.True(t, errors.Is(err, common.ErrNilStorer))
+
+	ts, err = tieredStorer.NewTieredStorer(hot, cold, 0, 1024)
+	assert.True(t, check.IfNil(ts))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	ts, err = tieredStorer.NewTieredStorer(hot, cold, time.Minute, 0)
+	assert.True(t, check.IfNil(ts))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	ts, err = tieredStorer.NewTieredStorer(hot, cold, time.Minute, 1024)
+	assert.False(t, check.IfNil(ts))
+	assert.Nil(t, err)
+	assert.Nil(t, ts.Close())
+}
+
+func TestTieredStorer_PutAndGet(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	_ = cold.Put([]byte("old"), []byte("cold value"))
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Minute, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+
+	err := ts.Put([]byte("new"), []byte("hot value"))
+	assert.Nil(t, err)
+	assert.Nil(t, hot.Has([]byte("new")))
+	assert.NotNil(t, cold.Has([]byte("new")))
+
+	value, err := ts.Get([]byte("new"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("hot value"), value)
+
+	value, err = ts.Get([]byte("old"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("cold value"), value)
+	// Not promoted
+	assert.NotNil(t, hot.Has([]byte("old")))
+
+	_, err = ts.Get([]byte("missing"))
+	assert.NotNil(t, err)
+
+	assert.Nil(t, ts.Has([]byte("new")))
+	assert.Nil(t, ts.Has([]byte("old")))
+	assert.NotNil(t, ts.Has([]byte("missing")))
+
+	values, err := ts.GetBulk([][]byte{[]byte("new"), []byte("old"), []byte("missing")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"new": []byte("hot value"), "old": []byte("cold value")}, values)
+
+	stats := ts.GetStats()
+	assert.Equal(t, 1, stats.HotNumEntries)
+	assert.Equal(t, uint64(len("hot value")), stats.HotNumBytes)
+	assert.Equal(t, uint64(2), stats.NumHotHits)
+	assert.Equal(t, uint64(2), stats.NumColdHits)
+	assert.Equal(t, uint64(2), stats.NumMisses)
+}
+
+func TestTieredStorer_GetPromotesOnHit(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	_ = cold.Put([]byte("old"), []byte("cold value"))
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Minute, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+	ts.EnablePromotionOnHit()
+
+	value, err := ts.Get([]byte("old"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("cold value"), value)
+	assert.Nil(t, hot.Has([]byte("old")))
+
+	value, err = ts.Get([]byte("old"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("cold value"), value)
+
+	stats := ts.GetStats()
+	assert.Equal(t, uint64(1), stats.NumPromoted)
+	assert.Equal(t, uint64(1), stats.NumColdHits)
+	assert.Equal(t, uint64(1), stats.NumHotHits)
+	assert.Equal(t, 1, stats.HotNumEntries)
+}
+
+func TestTieredStorer_RemoveHitsBothTiers(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	_ = cold.Put([]byte("a"), []byte("old a"))
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Minute, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+
+	_ = ts.Put([]byte("a"), []byte("new a"))
+	err := ts.Remove([]byte("a"))
+	assert.Nil(t, err)
+
+	assert.NotNil(t, hot.Has([]byte("a")))
+	assert.NotNil(t, cold.Has([]byte("a")))
+	_, err = ts.Get([]byte("a"))
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, ts.GetStats().HotNumEntries)
+}
+
+func TestTieredStorer_DemoteOldEntries(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, 50*time.Millisecond, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+
+	_ = ts.Put([]byte("a"), []byte("aaaa"))
+	_ = ts.Put([]byte("b"), []byte("bbbb"))
+	time.Sleep(100 * time.Millisecond)
+	_ = ts.Put([]byte("c"), []byte("cccc"))
+
+	err := ts.Demote()
+	assert.Nil(t, err)
+
+	assert.NotNil(t, hot.Has([]byte("a")))
+	assert.NotNil(t, hot.Has([]byte("b")))
+	assert.Nil(t, hot.Has([]byte("c")))
+	assert.Nil(t, cold.Has([]byte("a")))
+	assert.Nil(t, cold.Has([]byte("b")))
+	assert.NotNil(t, cold.Has([]byte("c")))
+
+	for _, key := range []string{"a", "b", "c"} {
+		value, errGet := ts.Get([]byte(key))
+		assert.Nil(t, errGet)
+		assert.Equal(t, []byte(key+key+key+key), value)
+	}
+
+	stats := ts.GetStats()
+	assert.Equal(t, uint64(2), stats.NumDemoted)
+	assert.Equal(t, 1, stats.HotNumEntries)
+	assert.Equal(t, uint64(4), stats.HotNumBytes)
+}
+
+func TestTieredStorer_DemoteBeyondHotByteBudget(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Hour, 10)
+	defer func() {
+		_ = ts.Close()
+	}()
+
+	_ = ts.Put([]byte("a"), []byte("aaaa"))
+	_ = ts.Put([]byte("b"), []byte("bbbb"))
+	_ = ts.Put([]byte("c"), []byte("cccc"))
+
+	// The budget is exceeded, thus a demotion is triggered in background
+	assert.Eventually(t, func() bool {
+		return ts.GetStats().NumDemoted == 1
+	}, time.Second, 5*time.Millisecond)
+
+	assert.Nil(t, cold.Has([]byte("a")))
+	assert.NotNil(t, hot.Has([]byte("a")))
+	assert.Nil(t, hot.Has([]byte("b")))
+	assert.Nil(t, hot.Has([]byte("c")))
+	assert.Equal(t, uint64(8), ts.GetStats().HotNumBytes)
+}
+
+func TestTieredStorer_DemotionIsCrashSafe(t *testing.T) {
+	t.Parallel()
+
+	hot := createUnit(memorydb.New())
+	errCold := errors.New("cold tier failure")
+	cold := createUnit(&testscommon.PersisterStub{
+		PutCalled: func(key, val []byte) error {
+			return errCold
+		},
+	})
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Millisecond, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+
+	_ = ts.Put([]byte("a"), []byte("aaaa"))
+	time.Sleep(10 * time.Millisecond)
+
+	err := ts.Demote()
+	assert.Equal(t, errCold, err)
+
+	// The entry is not removed from the hot tier, since it could not be written into the cold one
+	value, err := ts.Get([]byte("a"))
+	assert.Nil(t, err)
+	assert.Equal(t, []byte("aaaa"), value)
+	assert.Equal(t, 1, ts.GetStats().HotNumEntries)
+	assert.Equal(t, uint64(0), ts.GetStats().NumDemoted)
+}
+
+func TestTieredStorer_TracksPreExistingHotEntries(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	_ = hot.Put([]byte("a"), []byte("aaaa"))
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Millisecond, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+
+	assert.Equal(t, 1, ts.GetStats().HotNumEntries)
+	time.Sleep(10 * time.Millisecond)
+
+	err := ts.Demote()
+	assert.Nil(t, err)
+	assert.Nil(t, cold.Has([]byte("a")))
+	assert.NotNil(t, hot.Has([]byte("a")))
+}
+
+func TestTieredStorer_RangeKeys(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	_ = cold.Put([]byte("a"), []byte("old a"))
+	_ = cold.Put([]byte("b"), []byte("b"))
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Minute, 1024)
+	defer func() {
+		_ = ts.Close()
+	}()
+	_ = ts.Put([]byte("a"), []byte("new a"))
+
+	yielded := make(map[string]string)
+	ts.RangeKeys(func(key []byte, value []byte) bool {
+		_, isDuplicate := yielded[string(key)]
+		assert.False(t, isDuplicate)
+		yielded[string(key)] = string(value)
+		return true
+	})
+	assert.Equal(t, map[string]string{"a": "new a", "b": "b"}, yielded)
+}
+
+func TestTieredStorer_ConcurrentOperations(t *testing.T) {
+	t.Parallel()
+
+	hot, cold := createTiers()
+	ts, _ := tieredStorer.NewTieredStorer(hot, cold, time.Millisecond, 64)
+	defer func() {
+		_ = ts.Close()
+	}()
+	ts.EnablePromotionOnHit()
+
+	numOperations := 1000
+	wg := sync.WaitGroup{}
+	wg.Add(numOperations)
+	for i := 0; i < numOperations; i++ {
+		go func(idx int) {
+			defer wg.Done()
+
+			key := []byte(fmt.Sprintf("key-%d", idx%50))
+			switch idx % 5 {
+			case 0:
+				_ = ts.Remove(key)
+			case 1:
+				_ = ts.Demote()
+			default:
+				_ = ts.Put(key, key)
+				_, _ = ts.Get(key)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	err := ts.Demote()
+	assert.Nil(t, err)
+	ts.RangeKeys(func(key []byte, value []byte) bool {
+		assert.Equal(t, key, value)
+		return true
+	})
+}
@@ -30,3 +30,9 @@ func (tc *timeCacher) NumRegisteredHandlers() int {
 
 	return len(tc.mapDataHandlers)
 }
+
+// Sweep -
+func (tc *timeCacher) Sweep() {
+	evicted := tc.timeCache.sweepReturningEvicted()
+	tc.callEvictionHandlers(evicted)
+}
@@ -1,16 +1,25 @@
 package timecache
 
 import (
+	"context"
+	"sync"
 	"time"
 
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
 	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 // TimeCache can retain an amount of string keys for a defined period of time
 // sweeping (clean-up) is triggered each time a new item is added or a key is present in the time cache
 // This data structure is concurrent safe.
 type TimeCache struct {
-	timeCache *timeCacheCore
+	timeCache          *timeCacheCore
+	numSweptInLastPass atomic.Counter
+
+	mutAutoSweep    sync.Mutex
+	cancelAutoSweep func()
+	autoSweepDone   chan struct{}
 }
 
 // NewTimeCache creates a new time cache data structure instance
@@ -20,6 +29,42 @@ func NewTimeCache(defaultSpan time.Duration) *TimeCache {
 	}
 }
 
+// NewTimeCacheWithAutoSweep creates a new time cache data structure instance, which sweeps itself periodically
+// (on a background goroutine, stopped by Close). The automatic sweeping processes the entries in chunks,
+// thus it does not block the other operations for the whole pass.
+func NewTimeCacheWithAutoSweep(defaultSpan time.Duration, sweepInterval time.Duration) (*TimeCache, error) {
+	if sweepInterval <= 0 {
+		return nil, common.ErrInvalidSweepInterval
+	}
+
+	tc := NewTimeCache(defaultSpan)
+	tc.autoSweepDone = make(chan struct{})
+
+	var ctx context.Context
+	ctx, tc.cancelAutoSweep = context.WithCancel(context.Background())
+	go tc.autoSweep(ctx, sweepInterval)
+
+	return tc, nil
+}
+
+func (tc *TimeCache) autoSweep(ctx context.Context, sweepInterval time.Duration) {
+	defer close(tc.autoSweepDone)
+
+	ticker := time.NewTicker(sweepInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			numSwept := tc.timeCache.sweepInChunks(sweepChunkSize)
+			tc.numSweptInLastPass.Set(int64(numSwept))
+		case <-ctx.Done():
+			log.Debug("closing TimeCache's sweep go routine...")
+			return
+		}
+	}
+}
+
 // Add will store the key in the time cache
 // Double adding the key is permitted. It will replace the data, if existing. It does not trigger sweep.
 func (tc *TimeCache) Add(key string) error {
@@ -38,6 +83,7 @@ func (tc *TimeCache) add(key string, duration time.Duration) error {
 		timestamp: time.Now(),
 		span:      duration,
 	}
+	tc.timeCache.stats.RecordPut()
 	return nil
 }
 
@@ -56,10 +102,43 @@ func (tc *TimeCache) Upsert(key string, duration time.Duration) error {
 	return err
 }
 
+// UpsertWithSpan will add the key with the provided span, if not exists
+// If the record exists, its remaining span is extended to (at least) the provided span. A longer remaining span is kept as it is.
+func (tc *TimeCache) UpsertWithSpan(key string, span time.Duration) error {
+	return tc.timeCache.upsertWithSpan(key, span)
+}
+
+// TTL returns the remaining span of the key. It returns an error if the key is not found (or has expired).
+func (tc *TimeCache) TTL(key string) (time.Duration, error) {
+	return tc.timeCache.ttl(key)
+}
+
 // Sweep starts from the oldest element and will search each element if it is still valid to be kept. Sweep ends when
 // it finds an element that is still valid
 func (tc *TimeCache) Sweep() {
-	tc.timeCache.sweep()
+	numSwept := tc.timeCache.sweep()
+	tc.numSweptInLastPass.Set(int64(numSwept))
+}
+
+// NumSweptInLastPass returns the number of entries removed by the last sweep pass (either manual or automatic)
+func (tc *TimeCache) NumSweptInLastPass() int {
+	return int(tc.numSweptInLastPass.Get())
+}
+
+// Close stops the automatic sweeping (if any) and waits for the sweeping goroutine to exit. Calling Close multiple times is allowed.
+func (tc *TimeCache) Close() error {
+	tc.mutAutoSweep.Lock()
+	defer tc.mutAutoSweep.Unlock()
+
+	if tc.cancelAutoSweep == nil {
+		return nil
+	}
+
+	tc.cancelAutoSweep()
+	<-tc.autoSweepDone
+	tc.cancelAutoSweep = nil
+
+	return nil
 }
 
 // Has returns if the key is still found in the time cache
@@ -72,6 +151,17 @@ func (tc *TimeCache) Len() int {
 	return tc.timeCache.len()
 }
 
+// Stats returns the usage statistics of the time cache. Hits and misses are recorded by Has, while
+// the entries removed by Sweep are accounted as evictions.
+func (tc *TimeCache) Stats() types.CacheStats {
+	return tc.timeCache.getStats()
+}
+
+// ResetStats resets the usage statistics of the time cache
+func (tc *TimeCache) ResetStats() {
+	tc.timeCache.stats.Reset()
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (tc *TimeCache) IsInterfaceNil() bool {
 	return tc == nil
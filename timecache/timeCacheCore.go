@@ -1,10 +1,14 @@
 package timecache
 
 import (
+	"container/heap"
+	"sort"
 	"sync"
 	"time"
 
+	"github.com/multiversx/mx-chain-storage-go/cacheStats"
 	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 type entry struct {
@@ -13,10 +17,53 @@ type entry struct {
 	value     interface{}
 }
 
+func (e *entry) remainingSpan(now time.Time) time.Duration {
+	return e.span - now.Sub(e.timestamp)
+}
+
+type evictedEntry struct {
+	key   string
+	value interface{}
+}
+
+type expiryCandidate struct {
+	key    string
+	expiry time.Time
+}
+
+// expiryCandidates is a max-heap of entries, by expiry (the entry expiring last is at the top)
+type expiryCandidates []expiryCandidate
+
+func (candidates expiryCandidates) Len() int {
+	return len(candidates)
+}
+
+func (candidates expiryCandidates) Less(i, j int) bool {
+	return candidates[i].expiry.After(candidates[j].expiry)
+}
+
+func (candidates expiryCandidates) Swap(i, j int) {
+	candidates[i], candidates[j] = candidates[j], candidates[i]
+}
+
+// Push is required by heap.Interface
+func (candidates *expiryCandidates) Push(x interface{}) {
+	*candidates = append(*candidates, x.(expiryCandidate))
+}
+
+// Pop is required by heap.Interface
+func (candidates *expiryCandidates) Pop() interface{} {
+	old := *candidates
+	last := old[len(old)-1]
+	*candidates = old[:len(old)-1]
+	return last
+}
+
 type timeCacheCore struct {
 	*sync.RWMutex
 	data        map[string]*entry
 	defaultSpan time.Duration
+	stats       *cacheStats.StatsCollector
 }
 
 func newTimeCacheCore(defaultSpan time.Duration) *timeCacheCore {
@@ -24,6 +71,7 @@ func newTimeCacheCore(defaultSpan time.Duration) *timeCacheCore {
 		RWMutex:     &sync.RWMutex{},
 		data:        make(map[string]*entry),
 		defaultSpan: defaultSpan,
+		stats:       cacheStats.NewStatsCollector(),
 	}
 }
 
@@ -54,9 +102,58 @@ func (tcc *timeCacheCore) upsert(key string, value interface{}, duration time.Du
 		span:      duration,
 		value:     value,
 	}
+	tcc.stats.RecordPut()
 	return found, nil
 }
 
+// upsertWithSpan will add the key and provided duration if not exists
+// If the record exists, its remaining span is extended to the provided duration, but never shortened
+// It also operates on the locker so the call is concurrent safe
+func (tcc *timeCacheCore) upsertWithSpan(key string, duration time.Duration) error {
+	if len(key) == 0 {
+		return common.ErrEmptyKey
+	}
+
+	tcc.Lock()
+	defer tcc.Unlock()
+
+	now := time.Now()
+	existing, found := tcc.data[key]
+	if found {
+		if existing.remainingSpan(now) < duration {
+			existing.timestamp = now
+			existing.span = duration
+		}
+
+		return nil
+	}
+
+	tcc.data[key] = &entry{
+		timestamp: now,
+		span:      duration,
+	}
+	tcc.stats.RecordPut()
+	return nil
+}
+
+// ttl returns the remaining span of the key, or an error if the key is missing (or expired, but not swept yet)
+func (tcc *timeCacheCore) ttl(key string) (time.Duration, error) {
+	tcc.RLock()
+	defer tcc.RUnlock()
+
+	existing, found := tcc.data[key]
+	if !found {
+		return 0, common.ErrKeyNotFound
+	}
+
+	remaining := existing.remainingSpan(time.Now())
+	if remaining <= 0 {
+		return 0, common.ErrKeyNotFound
+	}
+
+	return remaining, nil
+}
+
 // put will add the key, value and provided duration, overriding values if the data already existed
 // It also operates on the locker so the call is concurrent safe
 func (tcc *timeCacheCore) put(key string, value interface{}, duration time.Duration) error {
@@ -72,6 +169,7 @@ func (tcc *timeCacheCore) put(key string, value interface{}, duration time.Durat
 		span:      duration,
 		value:     value,
 	}
+	tcc.stats.RecordPut()
 	return nil
 }
 
@@ -95,21 +193,101 @@ func (tcc *timeCacheCore) hasOrAdd(key string, value interface{}, duration time.
 		span:      duration,
 		value:     value,
 	}
+	tcc.stats.RecordPut()
 	return false, true, nil
 }
 
 // sweep iterates over all contained elements checking if the element is still valid to be kept
-// It also operates on the locker so the call is concurrent safe
-func (tcc *timeCacheCore) sweep() {
+// It also operates on the locker so the call is concurrent safe. It returns the number of removed entries.
+func (tcc *timeCacheCore) sweep() int {
+	return len(tcc.sweepReturningEvicted())
+}
+
+// sweepReturningEvicted is similar to sweep, but it returns the removed entries
+func (tcc *timeCacheCore) sweepReturningEvicted() []evictedEntry {
 	tcc.Lock()
 	defer tcc.Unlock()
 
+	evicted := make([]evictedEntry, 0)
 	for key, element := range tcc.data {
 		isOldElement := time.Since(element.timestamp) > element.span
 		if isOldElement {
 			delete(tcc.data, key)
+			evicted = append(evicted, evictedEntry{key: key, value: element.value})
+		}
+	}
+
+	tcc.stats.RecordEvictions(len(evicted))
+	return evicted
+}
+
+// evictClosestToExpiry removes the entries closest to expiry, until the number of entries does not exceed "maxSize"
+// The entries to be removed are selected in a single pass over the map (using a bounded heap), no matter how many they are.
+// It also operates on the locker so the call is concurrent safe. It returns the removed entries (the closest to expiry first).
+func (tcc *timeCacheCore) evictClosestToExpiry(maxSize int) []evictedEntry {
+	tcc.Lock()
+	defer tcc.Unlock()
+
+	numExcess := len(tcc.data) - maxSize
+	if numExcess <= 0 {
+		return make([]evictedEntry, 0)
+	}
+
+	candidates := make(expiryCandidates, 0, numExcess)
+	for key, element := range tcc.data {
+		expiry := element.timestamp.Add(element.span)
+		if len(candidates) < numExcess {
+			heap.Push(&candidates, expiryCandidate{key: key, expiry: expiry})
+			continue
+		}
+		if expiry.Before(candidates[0].expiry) {
+			candidates[0] = expiryCandidate{key: key, expiry: expiry}
+			heap.Fix(&candidates, 0)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].expiry.Before(candidates[j].expiry)
+	})
+
+	evicted := make([]evictedEntry, 0, len(candidates))
+	for _, candidate := range candidates {
+		evicted = append(evicted, evictedEntry{key: candidate.key, value: tcc.data[candidate.key].value})
+		delete(tcc.data, candidate.key)
+	}
+
+	tcc.stats.RecordEvictions(len(evicted))
+	return evicted
+}
+
+// sweepInChunks is similar to sweep, but it does not hold the (write) lock for the whole pass: the lock is released
+// (then acquired again) after each chunk of visited entries, while the iteration over the map carries on. The entries added
+// in the meantime may or may not be visited, and the ones removed in the meantime are not visited anymore (as for any map
+// modified during an iteration). An entry is only removed if it is still held by the cache (e.g. not replaced, and the cache
+// not cleared in the meantime). It returns the number of removed entries.
+func (tcc *timeCacheCore) sweepInChunks(chunkSize int) int {
+	tcc.Lock()
+	defer tcc.Unlock()
+
+	data := tcc.data
+	numEvicted := 0
+	numVisited := 0
+	for key, element := range data {
+		isOldElement := time.Since(element.timestamp) > element.span
+		if isOldElement && tcc.data[key] == element {
+			delete(tcc.data, key)
+			numEvicted++
+		}
+
+		numVisited++
+		if numVisited%chunkSize == 0 {
+			tcc.Unlock()
+			tcc.Lock()
 		}
 	}
+
+	tcc.stats.RecordEvictions(numEvicted)
+	return numEvicted
 }
 
 // has returns if the key is still found in the time cache
@@ -118,6 +296,7 @@ func (tcc *timeCacheCore) has(key string) bool {
 	defer tcc.RUnlock()
 
 	_, ok := tcc.data[key]
+	tcc.stats.RecordLookup(ok)
 
 	return ok
 }
@@ -137,3 +316,8 @@ func (tcc *timeCacheCore) clear() {
 	tcc.data = make(map[string]*entry)
 	tcc.Unlock()
 }
+
+// getStats returns the usage statistics of the time cache. The swept entries are accounted as evictions
+func (tcc *timeCacheCore) getStats() types.CacheStats {
+	return tcc.stats.Stats(tcc.len(), 0)
+}
@@ -48,3 +48,29 @@ func TestTimeCacheCore_ConcurrentOperations(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestTimeCacheCore_EvictClosestToExpiry(t *testing.T) {
+	t.Parallel()
+
+	tcc := newTimeCacheCore(time.Hour)
+	assert.Empty(t, tcc.evictClosestToExpiry(10))
+
+	// The span of "key<i>" is "i" minutes, added in a shuffled order
+	for _, i := range []int{7, 3, 9, 0, 5, 1, 8, 2, 6, 4} {
+		_ = tcc.put(fmt.Sprintf("key%d", i), i, time.Duration(i)*time.Minute)
+	}
+
+	evicted := tcc.evictClosestToExpiry(6)
+	assert.Equal(t, []evictedEntry{
+		{key: "key0", value: 0},
+		{key: "key1", value: 1},
+		{key: "key2", value: 2},
+		{key: "key3", value: 3},
+	}, evicted)
+	assert.Equal(t, 6, tcc.len())
+	assert.False(t, tcc.has("key3"))
+	assert.True(t, tcc.has("key4"))
+	assert.Equal(t, uint64(4), tcc.getStats().Evictions)
+
+	assert.Empty(t, tcc.evictClosestToExpiry(6))
+}
@@ -245,3 +245,230 @@ func TestTimeCache_IsInterfaceNil(t *testing.T) {
 
 	assert.True(t, check.IfNil(tc))
 }
+
+func TestTimeCache_StatsAndResetStats(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Minute)
+
+	_ = tc.Add("a")
+	_ = tc.AddWithSpan("b", time.Nanosecond)
+	_ = tc.Upsert("c", time.Minute)
+	_ = tc.Upsert("c", time.Minute)
+	time.Sleep(time.Millisecond)
+	tc.Sweep()
+
+	require.True(t, tc.Has("a"))
+	require.False(t, tc.Has("b"))
+
+	stats := tc.Stats()
+	require.Equal(t, uint64(1), stats.Hits)
+	require.Equal(t, uint64(1), stats.Misses)
+	require.Equal(t, uint64(3), stats.Puts)
+	require.Equal(t, uint64(1), stats.Evictions)
+	require.Equal(t, 2, stats.Len)
+
+	tc.ResetStats()
+	stats = tc.Stats()
+	require.Equal(t, uint64(0), stats.Hits)
+	require.Equal(t, uint64(0), stats.Puts)
+	require.Equal(t, uint64(0), stats.Evictions)
+	require.Equal(t, 2, stats.Len)
+}
+
+// ------- UpsertWithSpan and TTL
+
+func TestTimeCache_TTL(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Second)
+
+	ttl, err := tc.TTL("missing")
+	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.Equal(t, time.Duration(0), ttl)
+
+	_ = tc.AddWithSpan("key", time.Minute)
+	ttl, err = tc.TTL("key")
+	assert.Nil(t, err)
+	assert.True(t, ttl > 59*time.Second && ttl <= time.Minute)
+
+	// Simulate the passing of time
+	recovered, _ := tc.Value("key")
+	recovered.timestamp = recovered.timestamp.Add(-40 * time.Second)
+	ttl, err = tc.TTL("key")
+	assert.Nil(t, err)
+	assert.True(t, ttl > 19*time.Second && ttl <= 20*time.Second)
+
+	// Expired, but not swept yet
+	recovered.timestamp = recovered.timestamp.Add(-time.Minute)
+	ttl, err = tc.TTL("key")
+	assert.Equal(t, common.ErrKeyNotFound, err)
+	assert.Equal(t, time.Duration(0), ttl)
+}
+
+func TestTimeCache_UpsertWithSpan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("empty key should err", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		err := tc.UpsertWithSpan("", time.Minute)
+		assert.Equal(t, common.ErrEmptyKey, err)
+	})
+
+	t.Run("should add if missing", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		err := tc.UpsertWithSpan("key", time.Minute)
+		assert.Nil(t, err)
+
+		recovered, ok := tc.Value("key")
+		require.True(t, ok)
+		assert.Equal(t, time.Minute, recovered.span)
+	})
+
+	t.Run("should extend a shorter remaining span", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		_ = tc.UpsertWithSpan("key", time.Hour)
+		recovered, _ := tc.Value("key")
+		recovered.timestamp = recovered.timestamp.Add(-59 * time.Minute)
+
+		err := tc.UpsertWithSpan("key", 10*time.Minute)
+		assert.Nil(t, err)
+
+		ttl, err := tc.TTL("key")
+		assert.Nil(t, err)
+		assert.True(t, ttl > 9*time.Minute && ttl <= 10*time.Minute)
+	})
+
+	t.Run("should not shorten a longer remaining span", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Second)
+		_ = tc.UpsertWithSpan("key", time.Hour)
+		recovered, _ := tc.Value("key")
+		recovered.timestamp = recovered.timestamp.Add(-30 * time.Minute)
+		timestampBefore := recovered.timestamp
+
+		err := tc.UpsertWithSpan("key", 10*time.Minute)
+		assert.Nil(t, err)
+
+		recovered, _ = tc.Value("key")
+		assert.Equal(t, time.Hour, recovered.span)
+		assert.Equal(t, timestampBefore, recovered.timestamp)
+	})
+
+	t.Run("sweep should honor the span of each key", func(t *testing.T) {
+		t.Parallel()
+
+		tc := NewTimeCache(time.Hour)
+		_ = tc.UpsertWithSpan("short", time.Millisecond)
+		_ = tc.UpsertWithSpan("long", time.Hour)
+		_ = tc.Add("default")
+
+		time.Sleep(10 * time.Millisecond)
+		tc.Sweep()
+
+		assert.False(t, tc.Has("short"))
+		assert.True(t, tc.Has("long"))
+		assert.True(t, tc.Has("default"))
+	})
+}
+
+// ------- Automatic sweeping
+
+func TestNewTimeCacheWithAutoSweep(t *testing.T) {
+	t.Parallel()
+
+	t.Run("invalid sweep interval should err", func(t *testing.T) {
+		t.Parallel()
+
+		tc, err := NewTimeCacheWithAutoSweep(time.Second, 0)
+		assert.Nil(t, tc)
+		assert.Equal(t, common.ErrInvalidSweepInterval, err)
+	})
+
+	t.Run("should sweep periodically", func(t *testing.T) {
+		t.Parallel()
+
+		tc, err := NewTimeCacheWithAutoSweep(time.Hour, 10*time.Millisecond)
+		require.Nil(t, err)
+		defer func() {
+			_ = tc.Close()
+		}()
+
+		for i := 0; i < 3*sweepChunkSize/2; i++ {
+			_ = tc.AddWithSpan(fmt.Sprintf("short-%d", i), time.Millisecond)
+		}
+		_ = tc.Add("long")
+
+		require.Eventually(t, func() bool {
+			return tc.Len() == 1
+		}, time.Second, time.Millisecond)
+
+		assert.True(t, tc.Has("long"))
+		assert.Equal(t, uint64(3*sweepChunkSize/2), tc.Stats().Evictions)
+	})
+
+	t.Run("close should stop the sweeping", func(t *testing.T) {
+		t.Parallel()
+
+		tc, _ := NewTimeCacheWithAutoSweep(time.Hour, 10*time.Millisecond)
+		err := tc.Close()
+		assert.Nil(t, err)
+		err = tc.Close()
+		assert.Nil(t, err)
+
+		_ = tc.AddWithSpan("short", time.Millisecond)
+		time.Sleep(50 * time.Millisecond)
+		assert.Equal(t, 1, tc.Len())
+	})
+}
+
+func TestTimeCache_NumSweptInLastPass(t *testing.T) {
+	t.Parallel()
+
+	tc := NewTimeCache(time.Hour)
+	assert.Equal(t, 0, tc.NumSweptInLastPass())
+
+	_ = tc.AddWithSpan("a", time.Millisecond)
+	_ = tc.AddWithSpan("b", time.Millisecond)
+	_ = tc.Add("c")
+	time.Sleep(10 * time.Millisecond)
+
+	tc.Sweep()
+	assert.Equal(t, 2, tc.NumSweptInLastPass())
+
+	tc.Sweep()
+	assert.Equal(t, 0, tc.NumSweptInLastPass())
+
+	// Close is allowed (no-op) for the time caches without automatic sweeping
+	assert.Nil(t, tc.Close())
+}
+
+func TestTimeCacheCore_SweepInChunksConcurrentWithAdd(t *testing.T) {
+	t.Parallel()
+
+	tcc := newTimeCacheCore(time.Hour)
+	for i := 0; i < 5000; i++ {
+		_ = tcc.put(fmt.Sprintf("expired-%d", i), nil, -time.Second)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			_ = tcc.put(fmt.Sprintf("fresh-%d", i), nil, time.Hour)
+		}
+	}()
+
+	numSwept := tcc.sweepInChunks(100)
+	<-done
+
+	assert.Equal(t, 5000, numSwept)
+	assert.Equal(t, 1000, tcc.len())
+}
@@ -8,26 +8,34 @@ import (
 
 	logger "github.com/multiversx/mx-chain-logger-go"
 	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
 )
 
 var log = logger.GetOrCreate("storage/timecache")
 
 const minDuration = time.Second
+const sweepChunkSize = 1000
 
 // ArgTimeCacher is the argument used to create a new timeCacher instance
 type ArgTimeCacher struct {
 	DefaultSpan time.Duration
 	CacheExpiry time.Duration
+	// MaxSize caps the number of entries (zero means no cap). When exceeded, the entries closest to expiry are evicted.
+	MaxSize int
 }
 
 // timeCacher implements a time cacher with automatic sweeping mechanism
 type timeCacher struct {
 	timeCache   *timeCacheCore
 	cacheExpiry time.Duration
+	maxSize     int
 	cancelFunc  func()
 
 	mutAddedDataHandlers sync.RWMutex
 	mapDataHandlers      map[string]func(key []byte, value interface{})
+
+	mutEvictionHandlers sync.RWMutex
+	evictionHandlers    []func(key []byte, value interface{})
 }
 
 // NewTimeCacher creates a new timeCacher
@@ -40,6 +48,7 @@ func NewTimeCacher(arg ArgTimeCacher) (*timeCacher, error) {
 	tc := &timeCacher{
 		timeCache:       newTimeCacheCore(arg.DefaultSpan),
 		cacheExpiry:     arg.CacheExpiry,
+		maxSize:         arg.MaxSize,
 		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
 	}
 
@@ -57,6 +66,9 @@ func checkArg(arg ArgTimeCacher) error {
 	if arg.CacheExpiry < minDuration {
 		return common.ErrInvalidCacheExpiry
 	}
+	if arg.MaxSize < 0 {
+		return common.ErrCacheSizeInvalid
+	}
 
 	return nil
 }
@@ -71,7 +83,8 @@ func (tc *timeCacher) startSweeping(ctx context.Context) {
 
 		select {
 		case <-timer.C:
-			tc.timeCache.sweep()
+			evicted := tc.timeCache.sweepReturningEvicted()
+			tc.callEvictionHandlers(evicted)
 		case <-ctx.Done():
 			log.Info("closing mapTimeCacher's sweep go routine...")
 			return
@@ -84,7 +97,9 @@ func (tc *timeCacher) Clear() {
 	tc.timeCache.clear()
 }
 
-// Put adds a value to the cache. It will always return false since the eviction did not occur
+// Put adds a value to the cache. It returns true if an eviction occurred (due to the max size being exceeded).
+// Since the max size is enforced after the addition (and not atomically with it), the eviction might have been caused
+// by the concurrent additions of other goroutines, as well; the evicted entries might include the ones added by them.
 func (tc *timeCacher) Put(key []byte, value interface{}, _ int) (evicted bool) {
 	err := tc.timeCache.put(string(key), value, tc.timeCache.defaultSpan)
 	if err != nil {
@@ -94,7 +109,19 @@ func (tc *timeCacher) Put(key []byte, value interface{}, _ int) (evicted bool) {
 
 	tc.callAddedDataHandlers(key, value)
 
-	return false
+	return tc.evictIfMaxSizeExceeded()
+}
+
+// evictIfMaxSizeExceeded evicts the entries closest to expiry, if the max size is exceeded. It returns true if any eviction occurred.
+func (tc *timeCacher) evictIfMaxSizeExceeded() bool {
+	if tc.maxSize == 0 || tc.timeCache.len() <= tc.maxSize {
+		return false
+	}
+
+	evicted := tc.timeCache.evictClosestToExpiry(tc.maxSize)
+	tc.callEvictionHandlers(evicted)
+
+	return len(evicted) > 0
 }
 
 // Get returns a key's value from the cache
@@ -103,6 +130,7 @@ func (tc *timeCacher) Get(key []byte) (interface{}, bool) {
 	defer tc.timeCache.RUnlock()
 
 	v, ok := tc.timeCache.data[string(key)]
+	tc.timeCache.stats.RecordLookup(ok)
 	if !ok {
 		return nil, ok
 	}
@@ -132,6 +160,7 @@ func (tc *timeCacher) HasOrAdd(key []byte, value interface{}, _ int) (has, added
 
 	if !has {
 		tc.callAddedDataHandlers(key, value)
+		tc.evictIfMaxSizeExceeded()
 	}
 
 	return
@@ -176,7 +205,11 @@ func (tc *timeCacher) SizeInBytesContained() uint64 {
 
 // MaxSize returns the maximum number of items which can be stored in cache.
 func (tc *timeCacher) MaxSize() int {
-	return math.MaxInt32
+	if tc.maxSize == 0 {
+		return math.MaxInt32
+	}
+
+	return tc.maxSize
 }
 
 // RegisterHandler registers a new handler to be called when a new data is added
@@ -198,6 +231,36 @@ func (tc *timeCacher) UnRegisterHandler(id string) {
 	tc.mutAddedDataHandlers.Unlock()
 }
 
+// RegisterEvictionHandler registers a new handler to be called when an entry is evicted, either by the sweeping go routine
+// (since expired) or due to the max size being exceeded. The handlers are not called under the lock of the cache.
+func (tc *timeCacher) RegisterEvictionHandler(handler func(key []byte, value interface{})) {
+	if handler == nil {
+		log.Error("attempt to register a nil eviction handler to a cacher object")
+		return
+	}
+
+	tc.mutEvictionHandlers.Lock()
+	tc.evictionHandlers = append(tc.evictionHandlers, handler)
+	tc.mutEvictionHandlers.Unlock()
+}
+
+func (tc *timeCacher) callEvictionHandlers(evicted []evictedEntry) {
+	if len(evicted) == 0 {
+		return
+	}
+
+	tc.mutEvictionHandlers.RLock()
+	handlers := make([]func(key []byte, value interface{}), len(tc.evictionHandlers))
+	copy(handlers, tc.evictionHandlers)
+	tc.mutEvictionHandlers.RUnlock()
+
+	for _, entry := range evicted {
+		for _, handler := range handlers {
+			handler([]byte(entry.key), entry.value)
+		}
+	}
+}
+
 func (tc *timeCacher) callAddedDataHandlers(key []byte, value interface{}) {
 	tc.mutAddedDataHandlers.RLock()
 	for _, handler := range tc.mapDataHandlers {
@@ -206,6 +269,17 @@ func (tc *timeCacher) callAddedDataHandlers(key []byte, value interface{}) {
 	tc.mutAddedDataHandlers.RUnlock()
 }
 
+// Stats returns the usage statistics of the cache. Hits and misses are recorded by Get and Has, while
+// the entries removed by the sweeping go routine are accounted as evictions.
+func (tc *timeCacher) Stats() types.CacheStats {
+	return tc.timeCache.getStats()
+}
+
+// ResetStats resets the usage statistics of the cache
+func (tc *timeCacher) ResetStats() {
+	tc.timeCache.stats.Reset()
+}
+
 // Close will close the internal sweep go routine
 func (tc *timeCacher) Close() error {
 	if tc.cancelFunc != nil {
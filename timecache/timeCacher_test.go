@@ -54,6 +54,15 @@ func TestNewTimeCache(t *testing.T) {
 		assert.Nil(t, cacher)
 		assert.Equal(t, common.ErrInvalidCacheExpiry, err)
 	})
+	t.Run("invalid MaxSize should error", func(t *testing.T) {
+		t.Parallel()
+
+		arg := createArgTimeCacher()
+		arg.MaxSize = -1
+		cacher, err := timecache.NewTimeCacher(arg)
+		assert.Nil(t, cacher)
+		assert.Equal(t, common.ErrCacheSizeInvalid, err)
+	})
 	t.Run("should work", func(t *testing.T) {
 		t.Parallel()
 
@@ -361,6 +370,90 @@ func TestTimeCacher_MaxSize(t *testing.T) {
 	cacher, _ := timecache.NewTimeCacher(createArgTimeCacher())
 	assert.False(t, cacher.IsInterfaceNil())
 	assert.Equal(t, math.MaxInt32, cacher.MaxSize())
+
+	arg := createArgTimeCacher()
+	arg.MaxSize = 3
+	cacher, _ = timecache.NewTimeCacher(arg)
+	assert.Equal(t, 3, cacher.MaxSize())
+}
+
+func TestTimeCacher_MaxSizeShouldEvictClosestToExpiry(t *testing.T) {
+	t.Parallel()
+
+	arg := createArgTimeCacher()
+	arg.MaxSize = 3
+	cacher, _ := timecache.NewTimeCacher(arg)
+	defer func() {
+		_ = cacher.Close()
+	}()
+
+	evictedKeys := make([]string, 0)
+	cacher.RegisterEvictionHandler(func(key []byte, value interface{}) {
+		assert.Equal(t, "v"+string(key[1:]), value)
+		evictedKeys = append(evictedKeys, string(key))
+	})
+
+	for i := 0; i < 3; i++ {
+		evicted := cacher.Put([]byte(fmt.Sprintf("k%d", i)), fmt.Sprintf("v%d", i), 0)
+		assert.False(t, evicted)
+		time.Sleep(time.Millisecond)
+	}
+
+	evicted := cacher.Put([]byte("k3"), "v3", 0)
+	assert.True(t, evicted)
+	_, _ = cacher.HasOrAdd([]byte("k4"), "v4", 0)
+
+	assert.Equal(t, 3, cacher.Len())
+	assert.Equal(t, []string{"k0", "k1"}, evictedKeys)
+	assert.False(t, cacher.Has([]byte("k0")))
+	assert.False(t, cacher.Has([]byte("k1")))
+	assert.True(t, cacher.Has([]byte("k4")))
+	assert.Equal(t, uint64(2), cacher.Stats().Evictions)
+}
+
+func TestTimeCacher_RegisterEvictionHandler(t *testing.T) {
+	t.Parallel()
+
+	arg := createArgTimeCacher()
+	arg.DefaultSpan = time.Second
+	cacher, _ := timecache.NewTimeCacher(arg)
+	defer func() {
+		_ = cacher.Close()
+	}()
+
+	// nil handlers are ignored
+	cacher.RegisterEvictionHandler(nil)
+
+	mut := sync.Mutex{}
+	evictedA := make(map[string]interface{})
+	evictedB := make(map[string]interface{})
+	cacher.RegisterEvictionHandler(func(key []byte, value interface{}) {
+		// Should not be called under the lock of the cache
+		_ = cacher.Len()
+
+		mut.Lock()
+		evictedA[string(key)] = value
+		mut.Unlock()
+	})
+	cacher.RegisterEvictionHandler(func(key []byte, value interface{}) {
+		mut.Lock()
+		evictedB[string(key)] = value
+		mut.Unlock()
+	})
+
+	cacher.Put([]byte("a"), "va", 0)
+	cacher.Put([]byte("b"), "vb", 0)
+
+	time.Sleep(arg.DefaultSpan + 100*time.Millisecond)
+	cacher.Sweep()
+
+	expected := map[string]interface{}{"a": "va", "b": "vb"}
+	mut.Lock()
+	assert.Equal(t, expected, evictedA)
+	assert.Equal(t, expected, evictedB)
+	mut.Unlock()
+	assert.Equal(t, 0, cacher.Len())
+	assert.Empty(t, cacher.Keys())
 }
 
 func TestTimeCacher_ConcurrentOperations(t *testing.T) {
@@ -421,3 +514,32 @@ func createKeyByteSlice(index int) []byte {
 func createValueByteSlice(index int) []byte {
 	return []byte(fmt.Sprintf("value%d", index))
 }
+
+func TestTimeCacher_StatsAndResetStats(t *testing.T) {
+	t.Parallel()
+
+	tc, _ := timecache.NewTimeCacher(createArgTimeCacher())
+
+	tc.Put([]byte("a"), "a", 0)
+	_, _ = tc.HasOrAdd([]byte("a"), "a", 0)
+	_, _ = tc.HasOrAdd([]byte("b"), "b", 0)
+
+	_, ok := tc.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.False(t, tc.Has([]byte("c")))
+
+	stats := tc.Stats()
+	assert.Equal(t, uint64(1), stats.Hits)
+	assert.Equal(t, uint64(1), stats.Misses)
+	assert.Equal(t, uint64(2), stats.Puts)
+	assert.Equal(t, 2, stats.Len)
+
+	tc.ResetStats()
+	stats = tc.Stats()
+	assert.Equal(t, uint64(0), stats.Hits)
+	assert.Equal(t, uint64(0), stats.Misses)
+	assert.Equal(t, uint64(0), stats.Puts)
+	assert.Equal(t, 2, stats.Len)
+
+	_ = tc.Close()
+}
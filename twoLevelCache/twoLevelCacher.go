@@ -0,0 +1,259 @@
+package twoLevelCache
+
+import (
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var _ types.Cacher = (*twoLevelCacher)(nil)
+
+var log = logger.GetOrCreate("storage/twoLevelCache")
+
+// twoLevelCacher composes a small (hot) L1 cacher over a larger L2 cacher, in order to reduce the lock contention on the latter.
+// The L2 cacher holds all the entries, while the L1 cacher holds (subject to its own eviction policy) the recently written or promoted ones.
+type twoLevelCacher struct {
+	l1           types.Cacher
+	l2           types.Cacher
+	promoteOnHit bool
+
+	// The operations which alter the entries increment numMutationsStarted before altering the levels, and numMutationsEnded
+	// afterwards, thus the promotions are able to detect a concurrent alteration (and not leave a stale or removed value in L1)
+	numMutationsStarted atomic.Counter
+	numMutationsEnded   atomic.Counter
+
+	mutAddedDataHandlers sync.RWMutex
+	mapDataHandlers      map[string]func(key []byte, value interface{})
+}
+
+// NewTwoLevelCacher creates a new two-level cacher. If promoteOnHit is set, the entries found in L2 (by Get) are also added to L1.
+func NewTwoLevelCacher(l1 types.Cacher, l2 types.Cacher, promoteOnHit bool) (*twoLevelCacher, error) {
+	if check.IfNil(l1) {
+		return nil, common.ErrNilCacher
+	}
+	if check.IfNil(l2) {
+		return nil, common.ErrNilCacher
+	}
+
+	return &twoLevelCacher{
+		l1:              l1,
+		l2:              l2,
+		promoteOnHit:    promoteOnHit,
+		mapDataHandlers: make(map[string]func(key []byte, value interface{})),
+	}, nil
+}
+
+// Clear clears both levels
+func (c *twoLevelCacher) Clear() {
+	c.beginMutation()
+	defer c.endMutation()
+
+	c.l2.Clear()
+	c.l1.Clear()
+}
+
+// Put adds a value to both levels. Returns true if an eviction occurred in L2 (evictions from L1 do not lose any data).
+func (c *twoLevelCacher) Put(key []byte, value interface{}, sizeInBytes int) (evicted bool) {
+	c.beginMutation()
+	has, added := c.l2.HasOrAdd(key, value, sizeInBytes)
+	if has {
+		evicted = c.l2.Put(key, value, sizeInBytes)
+	}
+
+	c.l1.Put(key, value, sizeInBytes)
+	c.endMutation()
+
+	if added {
+		c.callAddedDataHandlers(key, value)
+	}
+
+	return evicted
+}
+
+// Get looks up the key in L1, then in L2. If promotion is enabled, the value found in L2 is added to L1.
+func (c *twoLevelCacher) Get(key []byte) (value interface{}, ok bool) {
+	value, ok = c.l1.Get(key)
+	if ok {
+		return value, true
+	}
+
+	numMutationsBefore, isMutationInProgress := c.getMutationsState()
+
+	value, ok = c.l2.Get(key)
+	if !ok {
+		return nil, false
+	}
+
+	if c.promoteOnHit && !isMutationInProgress {
+		c.promote(key, value, numMutationsBefore)
+	}
+
+	return value, true
+}
+
+// promote adds the value (read from L2) to L1, unless L1 already holds the key. If a mutation has started meanwhile,
+// the (possibly stale or removed) promoted value is dropped from L1: the next Get falls back to L2, which holds the up-to-date value.
+// A mutation starting after the check alters L1 after the promotion, thus it overrides (or removes) the promoted value, as well.
+// The promotion is only attempted if no mutation was in progress when reading from L2 (see getMutationsState).
+func (c *twoLevelCacher) promote(key []byte, value interface{}, numMutationsBefore int64) {
+	_, added := c.l1.HasOrAdd(key, value, 0)
+	if !added {
+		return
+	}
+
+	isStale := c.numMutationsStarted.Get() != numMutationsBefore
+	if isStale {
+		c.l1.Remove(key)
+	}
+}
+
+func (c *twoLevelCacher) beginMutation() {
+	c.numMutationsStarted.Increment()
+}
+
+func (c *twoLevelCacher) endMutation() {
+	c.numMutationsEnded.Increment()
+}
+
+// getMutationsState returns the number of started mutations, and whether any of them is still in progress.
+// The started mutations are read first: a mutation starting in between is detected by the subsequent check in promote.
+func (c *twoLevelCacher) getMutationsState() (int64, bool) {
+	numStarted := c.numMutationsStarted.Get()
+	numEnded := c.numMutationsEnded.Get()
+
+	return numStarted, numEnded != numStarted
+}
+
+// Has checks if the key is in any of the levels
+func (c *twoLevelCacher) Has(key []byte) bool {
+	return c.l1.Has(key) || c.l2.Has(key)
+}
+
+// Peek looks up the key in L1, then in L2, without promoting it
+func (c *twoLevelCacher) Peek(key []byte) (value interface{}, ok bool) {
+	value, ok = c.l1.Peek(key)
+	if ok {
+		return value, true
+	}
+
+	return c.l2.Peek(key)
+}
+
+// HasOrAdd checks if the key is in any of the levels, and if not, adds the value to both levels
+func (c *twoLevelCacher) HasOrAdd(key []byte, value interface{}, sizeInBytes int) (has, added bool) {
+	if c.l1.Has(key) {
+		return true, false
+	}
+
+	c.beginMutation()
+	has, added = c.l2.HasOrAdd(key, value, sizeInBytes)
+	if added {
+		c.l1.Put(key, value, sizeInBytes)
+	}
+	c.endMutation()
+
+	if !added {
+		return has, added
+	}
+
+	c.callAddedDataHandlers(key, value)
+
+	return has, added
+}
+
+// Remove removes the key from both levels
+func (c *twoLevelCacher) Remove(key []byte) {
+	c.beginMutation()
+	defer c.endMutation()
+
+	c.l2.Remove(key)
+	c.l1.Remove(key)
+}
+
+// Keys returns the (deduplicated) keys of both levels: the keys of L2, followed by the ones held only by L1
+func (c *twoLevelCacher) Keys() [][]byte {
+	keysL2 := c.l2.Keys()
+	keysL1 := c.l1.Keys()
+
+	seen := make(map[string]struct{}, len(keysL2))
+	keys := make([][]byte, 0, len(keysL2)+len(keysL1))
+
+	for _, key := range keysL2 {
+		seen[string(key)] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	for _, key := range keysL1 {
+		_, ok := seen[string(key)]
+		if ok {
+			continue
+		}
+
+		seen[string(key)] = struct{}{}
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Len returns the number of distinct keys held by the two levels
+func (c *twoLevelCacher) Len() int {
+	return len(c.Keys())
+}
+
+// SizeInBytesContained returns the size in bytes of the elements contained in L2
+func (c *twoLevelCacher) SizeInBytesContained() uint64 {
+	return c.l2.SizeInBytesContained()
+}
+
+// MaxSize returns the maximum number of items which can be stored in L2
+func (c *twoLevelCacher) MaxSize() int {
+	return c.l2.MaxSize()
+}
+
+// RegisterHandler registers a new handler to be called when a new key is added
+func (c *twoLevelCacher) RegisterHandler(handler func(key []byte, value interface{}), id string) {
+	if handler == nil {
+		log.Error("attempt to register a nil handler to a cacher object")
+		return
+	}
+
+	c.mutAddedDataHandlers.Lock()
+	c.mapDataHandlers[id] = handler
+	c.mutAddedDataHandlers.Unlock()
+}
+
+// UnRegisterHandler removes the handler from the list
+func (c *twoLevelCacher) UnRegisterHandler(id string) {
+	c.mutAddedDataHandlers.Lock()
+	delete(c.mapDataHandlers, id)
+	c.mutAddedDataHandlers.Unlock()
+}
+
+func (c *twoLevelCacher) callAddedDataHandlers(key []byte, value interface{}) {
+	c.mutAddedDataHandlers.RLock()
+	for _, handler := range c.mapDataHandlers {
+		go handler(key, value)
+	}
+	c.mutAddedDataHandlers.RUnlock()
+}
+
+// Close closes both levels
+func (c *twoLevelCacher) Close() error {
+	errL1 := c.l1.Close()
+	errL2 := c.l2.Close()
+	if errL1 != nil {
+		return errL1
+	}
+
+	return errL2
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (c *twoLevelCacher) IsInterfaceNil() bool {
+	return c == nil
+}
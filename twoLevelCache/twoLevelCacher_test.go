@@ -0,0 +1,269 @@
+package twoLevelCache_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/lrucache"
+	"github.com/multiversx/mx-chain-storage-go/twoLevelCache"
+	"github.com/multiversx/mx-chain-storage-go/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func createLevels(sizeL1 int, sizeL2 int) (types.Cacher, types.Cacher) {
+	l1, _ := lrucache.NewCache(sizeL1)
+	l2, _ := lrucache.NewCache(sizeL2)
+
+	return l1, l2
+}
+
+func TestNewTwoLevelCacher(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+
+	c, err := twoLevelCache.NewTwoLevelCacher(nil, l2, false)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrNilCacher, err)
+
+	c, err = twoLevelCache.NewTwoLevelCacher(l1, nil, false)
+	assert.True(t, check.IfNil(c))
+	assert.Equal(t, common.ErrNilCacher, err)
+
+	c, err = twoLevelCache.NewTwoLevelCacher(l1, l2, false)
+	assert.False(t, check.IfNil(c))
+	assert.Nil(t, err)
+}
+
+func TestTwoLevelCacher_PutGetShouldWork(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, false)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Put([]byte("c"), "c", 0)
+
+	assert.Equal(t, 2, l1.Len())
+	assert.Equal(t, 3, l2.Len())
+	assert.False(t, l1.Has([]byte("a")))
+
+	value, ok := c.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	assert.False(t, l1.Has([]byte("a")))
+
+	c.Put([]byte("c"), "c-updated", 0)
+	value, ok = c.Get([]byte("c"))
+	assert.True(t, ok)
+	assert.Equal(t, "c-updated", value)
+	value, _ = l2.Get([]byte("c"))
+	assert.Equal(t, "c-updated", value)
+
+	_, ok = c.Get([]byte("missing"))
+	assert.False(t, ok)
+}
+
+func TestTwoLevelCacher_GetShouldPromoteOnHit(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, true)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Put([]byte("c"), "c", 0)
+	assert.False(t, l1.Has([]byte("a")))
+
+	value, ok := c.Get([]byte("a"))
+	assert.True(t, ok)
+	assert.Equal(t, "a", value)
+	assert.True(t, l1.Has([]byte("a")))
+
+	// Peek does not promote
+	value, ok = c.Peek([]byte("b"))
+	assert.True(t, ok)
+	assert.Equal(t, "b", value)
+	assert.False(t, l1.Has([]byte("b")))
+}
+
+func TestTwoLevelCacher_RemoveAndClearShouldApplyToBothLevels(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, false)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+
+	c.Remove([]byte("a"))
+	assert.False(t, c.Has([]byte("a")))
+	assert.False(t, l1.Has([]byte("a")))
+	assert.False(t, l2.Has([]byte("a")))
+
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+	assert.Equal(t, 0, l1.Len())
+	assert.Equal(t, 0, l2.Len())
+}
+
+func TestTwoLevelCacher_KeysAndLenShouldDeduplicate(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, false)
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("b"), "b", 0)
+	c.Put([]byte("c"), "c", 0)
+	// Only held by L1
+	l1.Put([]byte("d"), "d", 0)
+
+	assert.Equal(t, 4, c.Len())
+	assert.ElementsMatch(t, [][]byte{[]byte("a"), []byte("b"), []byte("c"), []byte("d")}, c.Keys())
+}
+
+func TestTwoLevelCacher_HasOrAdd(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, false)
+
+	has, added := c.HasOrAdd([]byte("a"), "a", 0)
+	assert.False(t, has)
+	assert.True(t, added)
+	assert.True(t, l1.Has([]byte("a")))
+	assert.True(t, l2.Has([]byte("a")))
+
+	has, added = c.HasOrAdd([]byte("a"), "a-other", 0)
+	assert.True(t, has)
+	assert.False(t, added)
+
+	value, _ := c.Get([]byte("a"))
+	assert.Equal(t, "a", value)
+}
+
+func TestTwoLevelCacher_AddedDataHandlersShouldBeCalledOncePerNewKey(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(2, 10)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, true)
+
+	mut := sync.Mutex{}
+	numCalls := make(map[string]int)
+	c.RegisterHandler(func(key []byte, value interface{}) {
+		mut.Lock()
+		numCalls[string(key)]++
+		mut.Unlock()
+	}, "id")
+
+	c.Put([]byte("a"), "a", 0)
+	c.Put([]byte("a"), "a-updated", 0)
+	_, _ = c.HasOrAdd([]byte("a"), "a-other", 0)
+	_, _ = c.HasOrAdd([]byte("b"), "b", 0)
+	c.Put([]byte("c"), "c", 0)
+	c.Put([]byte("d"), "d", 0)
+	_, _ = c.Get([]byte("b"))
+
+	time.Sleep(100 * time.Millisecond)
+
+	mut.Lock()
+	assert.Equal(t, map[string]int{"a": 1, "b": 1, "c": 1, "d": 1}, numCalls)
+	mut.Unlock()
+}
+
+func TestTwoLevelCacher_ConcurrentPromotionsShouldNotLeaveStaleValues(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(100, 1000)
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, l2, true)
+
+	key := []byte("key")
+	numIterations := 1000
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < numIterations; i++ {
+			c.Put(key, i, 0)
+			l1.Remove(key)
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < numIterations; i++ {
+			_, _ = c.Get(key)
+		}
+	}()
+
+	wg.Wait()
+
+	// A value promoted in L1 must never be older than the one in L2
+	valueL2, _ := l2.Peek(key)
+	valueL1, ok := l1.Peek(key)
+	if ok {
+		assert.Equal(t, valueL2, valueL1)
+	}
+}
+
+// interleavingCacher wraps a cacher, in order to interleave a Get with a concurrent Remove (see the test below)
+type interleavingCacher struct {
+	types.Cacher
+	removeStarted chan struct{}
+	getDone       chan struct{}
+	removeDone    chan struct{}
+}
+
+func (c *interleavingCacher) Get(key []byte) (interface{}, bool) {
+	value, ok := c.Cacher.Get(key)
+	close(c.getDone)
+	<-c.removeDone
+
+	return value, ok
+}
+
+func (c *interleavingCacher) Remove(key []byte) {
+	close(c.removeStarted)
+	<-c.getDone
+	c.Cacher.Remove(key)
+}
+
+func TestTwoLevelCacher_ConcurrentGetAndRemoveShouldNotRestoreRemovedKeys(t *testing.T) {
+	t.Parallel()
+
+	l1, l2 := createLevels(100, 1000)
+	key := []byte("key")
+	_, _ = l2.HasOrAdd(key, "value", 0)
+
+	interleavingL2 := &interleavingCacher{
+		Cacher:        l2,
+		removeStarted: make(chan struct{}),
+		getDone:       make(chan struct{}),
+		removeDone:    make(chan struct{}),
+	}
+	c, _ := twoLevelCache.NewTwoLevelCacher(l1, interleavingL2, true)
+
+	// The Get reads L2 after the Remove has started, but before the key is actually removed
+	go func() {
+		c.Remove(key)
+		close(interleavingL2.removeDone)
+	}()
+
+	<-interleavingL2.removeStarted
+	value, ok := c.Get(key)
+	assert.True(t, ok)
+	assert.Equal(t, "value", value)
+
+	// The removed key stays gone
+	assert.False(t, l1.Has(key))
+	assert.False(t, l2.Has(key))
+	assert.False(t, c.Has(key))
+}
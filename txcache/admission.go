@@ -0,0 +1,55 @@
+package txcache
+
+// isUnderAdmissionPressure checks whether the cache is filled above the admission pressure ratio
+func (cache *TxCache) isUnderAdmissionPressure() bool {
+	return cache.isAboveFillRatio(cache.config.getAdmissionPressureRatio())
+}
+
+// shouldRejectDueToLowFee checks whether the transaction should be rejected because, while the cache is under pressure,
+// its sender would still remain below the configured minimum cumulative fee (even after adding the transaction)
+func (cache *TxCache) shouldRejectDueToLowFee(tx *WrappedTransaction) bool {
+	if !cache.config.isFeeAdmissionEnabled() {
+		return false
+	}
+	if !cache.isUnderAdmissionPressure() {
+		return false
+	}
+
+	totalFee := computeTxFee(tx)
+	listForSender, ok := cache.txListBySender.getListForSender(string(tx.Tx.GetSndAddr()))
+	if ok {
+		totalFee = addSaturating(totalFee, listForSender.getTotalFee())
+	}
+
+	return totalFee < cache.config.MinTotalFeePerSenderUnderPressure
+}
+
+// computeTxFee returns the precomputed fee of a transaction, if available, or its maximum fee (gas limit * gas price), otherwise.
+// The maximum fee saturates at math.MaxUint64.
+func computeTxFee(tx *WrappedTransaction) uint64 {
+	if tx.PrecomputedFee > 0 {
+		return tx.PrecomputedFee
+	}
+
+	return mulSaturating(tx.Tx.GetGasLimit(), tx.Tx.GetGasPrice())
+}
+
+// shouldRejectDueToSenderBytes checks whether the transaction should be rejected because it would bring its sender
+// above the configured maximum number of bytes (only if the "reject" policy is configured)
+// This function should only be used in critical section (cache.mutTxOperation)
+func (cache *TxCache) shouldRejectDueToSenderBytes(tx *WrappedTransaction) bool {
+	if !cache.config.RejectTxsAboveSenderBytesThreshold {
+		return false
+	}
+	// Duplicates are handled as usual
+	if _, isKnown := cache.txByHash.getTx(string(tx.TxHash)); isKnown {
+		return false
+	}
+
+	listForSender, ok := cache.txListBySender.getListForSender(string(tx.Tx.GetSndAddr()))
+	if !ok {
+		return tx.Size > int64(cache.config.NumBytesPerSenderThreshold)
+	}
+
+	return listForSender.wouldExceedMaxNumBytes(tx)
+}
@@ -0,0 +1,227 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func createConfigForFeeAdmission(minTotalFee uint64) ConfigSourceMe {
+	return ConfigSourceMe{
+		Name:                              "untitled",
+		NumChunks:                         16,
+		EvictionEnabled:                   true,
+		CountThreshold:                    10,
+		CountPerSenderThreshold:           math.MaxUint32,
+		NumBytesThreshold:                 maxNumBytesUpperBound,
+		NumBytesPerSenderThreshold:        maxNumBytesPerSenderUpperBound,
+		NumSendersToPreemptivelyEvict:     1,
+		MinTotalFeePerSenderUnderPressure: minTotalFee,
+	}
+}
+
+func TestTxCache_AddTx_FeeAdmission(t *testing.T) {
+	minTotalFee := uint64(100_000 * oneBillion)
+	txGasHandler, _ := dummyParams()
+
+	t.Run("not under pressure, low-fee senders are accepted", func(t *testing.T) {
+		cache, err := NewTxCache(createConfigForFeeAdmission(minTotalFee), txGasHandler)
+		require.Nil(t, err)
+
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+		require.False(t, cache.isUnderAdmissionPressure())
+	})
+
+	t.Run("under pressure, low-fee senders are rejected", func(t *testing.T) {
+		cache, err := NewTxCache(createConfigForFeeAdmission(minTotalFee), txGasHandler)
+		require.Nil(t, err)
+
+		for index := 0; index < 11; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), 1), sender, 1, 128, 100_000, oneBillion))
+		}
+
+		require.Equal(t, uint64(11), cache.CountTx())
+		require.True(t, cache.isUnderAdmissionPressure())
+
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50_000, oneBillion))
+		require.False(t, ok)
+		require.False(t, added)
+		_, found := cache.GetByTxHash([]byte("hash-alice-1"))
+		require.False(t, found)
+
+		ok, added = cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 128, 100_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+	})
+
+	t.Run("under pressure, the existing transactions of the sender are accounted", func(t *testing.T) {
+		cache, err := NewTxCache(createConfigForFeeAdmission(minTotalFee), txGasHandler)
+		require.Nil(t, err)
+
+		cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 60_000, oneBillion))
+
+		for index := 0; index < 10; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), 1), sender, 1, 128, 100_000, oneBillion))
+		}
+
+		require.True(t, cache.isUnderAdmissionPressure())
+
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 50_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+	})
+
+	t.Run("under pressure, with respect to the high-water ratio", func(t *testing.T) {
+		config := createConfigForFeeAdmission(minTotalFee)
+		config.CountThreshold = 100
+		config.EvictionHighWaterRatio = 0.1
+		config.EvictionLowWaterRatio = 0.05
+
+		cache, err := NewTxCache(config, txGasHandler)
+		require.Nil(t, err)
+
+		for index := 0; index < 10; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), 1), sender, 1, 128, 100_000, oneBillion))
+		}
+
+		require.False(t, cache.isUnderAdmissionPressure())
+		ok, _ := cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50_000, oneBillion))
+		require.True(t, ok)
+	})
+
+	t.Run("under pressure, the fees saturate instead of overflowing", func(t *testing.T) {
+		cache, err := NewTxCache(createConfigForFeeAdmission(math.MaxUint64), txGasHandler)
+		require.Nil(t, err)
+
+		// gasLimit * gasPrice = 2^65 (thus, above math.MaxUint64)
+		cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 1<<33, 1<<32))
+
+		for index := 0; index < 10; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), 1), sender, 1, 128, 100_000, oneBillion))
+		}
+
+		require.True(t, cache.isUnderAdmissionPressure())
+
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 1<<33, 1<<32))
+		require.True(t, ok)
+		require.True(t, added)
+
+		ok, added = cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 128, 100_000, oneBillion))
+		require.False(t, ok)
+		require.False(t, added)
+	})
+
+	t.Run("feature disabled", func(t *testing.T) {
+		cache, err := NewTxCache(createConfigForFeeAdmission(0), txGasHandler)
+		require.Nil(t, err)
+
+		for index := 0; index < 11; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), 1), sender, 1, 128, 100_000, oneBillion))
+		}
+
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+	})
+}
+
+func TestTxCache_AddTx_SenderBytesThreshold(t *testing.T) {
+	txGasHandler, _ := dummyParams()
+
+	createConfig := func(reject bool) ConfigSourceMe {
+		return ConfigSourceMe{
+			Name:                               "untitled",
+			NumChunks:                          16,
+			CountThreshold:                     math.MaxUint32,
+			CountPerSenderThreshold:            math.MaxUint32,
+			NumBytesThreshold:                  maxNumBytesUpperBound,
+			NumBytesPerSenderThreshold:         1024,
+			NumSendersToPreemptivelyEvict:      1,
+			RejectTxsAboveSenderBytesThreshold: reject,
+		}
+	}
+
+	requireTotalBytesOfSender := func(t *testing.T, cache *TxCache, sender string, expected int64) {
+		listForSender, ok := cache.txListBySender.getListForSender(sender)
+		require.True(t, ok)
+		require.Equal(t, expected, listForSender.totalBytes.Get())
+		require.LessOrEqual(t, expected, int64(1024))
+	}
+
+	t.Run("evict policy", func(t *testing.T) {
+		cache, err := NewTxCache(createConfig(false), txGasHandler)
+		require.Nil(t, err)
+
+		cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 512, 50_000, oneBillion))
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 512, 50_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+		requireTotalBytesOfSender(t, cache, "alice", 1024)
+
+		// Above the cap (by 129 bytes): the highest nonce is evicted
+		ok, added = cache.AddTx(createTxWithParams([]byte("hash-alice-3"), "alice", 3, 129, 50_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+		requireTotalBytesOfSender(t, cache, "alice", 1024)
+		require.Equal(t, []string{"hash-alice-1", "hash-alice-2"}, cache.getHashesForSender("alice"))
+		_, ok = cache.GetByTxHash([]byte("hash-alice-3"))
+		require.False(t, ok)
+	})
+
+	t.Run("reject policy", func(t *testing.T) {
+		cache, err := NewTxCache(createConfig(true), txGasHandler)
+		require.Nil(t, err)
+
+		cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 512, 50_000, oneBillion))
+		ok, added := cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 512, 50_000, oneBillion))
+		require.True(t, ok)
+		require.True(t, added)
+		requireTotalBytesOfSender(t, cache, "alice", 1024)
+
+		// Above the cap (by 129 bytes): the transaction is rejected
+		ok, added = cache.AddTx(createTxWithParams([]byte("hash-alice-0"), "alice", 0, 129, 50_000, oneBillion))
+		require.False(t, ok)
+		require.False(t, added)
+		requireTotalBytesOfSender(t, cache, "alice", 1024)
+		require.Equal(t, []string{"hash-alice-1", "hash-alice-2"}, cache.getHashesForSender("alice"))
+		_, ok = cache.GetByTxHash([]byte("hash-alice-0"))
+		require.False(t, ok)
+		require.Equal(t, uint64(2), cache.CountTx())
+
+		// Duplicates are still handled as usual
+		ok, added = cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 512, 50_000, oneBillion))
+		require.True(t, ok)
+		require.False(t, added)
+
+		// New senders above the cap are rejected, as well
+		ok, _ = cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 1025, 50_000, oneBillion))
+		require.False(t, ok)
+		require.Equal(t, uint64(2), cache.CountTx())
+	})
+}
+
+func Test_computeTxFee(t *testing.T) {
+	tx := createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50_000, oneBillion)
+	require.Equal(t, uint64(50_000*oneBillion), computeTxFee(tx))
+
+	tx.PrecomputedFee = 42
+	require.Equal(t, uint64(42), computeTxFee(tx))
+
+	// gasLimit * gasPrice = 2^65 (thus, above math.MaxUint64)
+	tx = createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 1<<33, 1<<32)
+	require.Equal(t, uint64(math.MaxUint64), computeTxFee(tx))
+
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+	list.AddTx(tx, txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("hash-alice-3"), "alice", 3, 128, 50_000, oneBillion), txGasHandler, txFeeHelper)
+	require.Equal(t, uint64(math.MaxUint64), list.getTotalFee())
+}
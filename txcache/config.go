@@ -3,6 +3,7 @@ package txcache
 import (
 	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/multiversx/mx-chain-storage-go/common"
 )
@@ -17,6 +18,8 @@ const maxNumBytesPerSenderLowerBound = maxNumItemsPerSenderLowerBound * 1
 const maxNumBytesPerSenderUpperBound = 33_554_432 // 32 MB
 const numTxsToPreemptivelyEvictLowerBound = 1
 const numSendersToPreemptivelyEvictLowerBound = 1
+const numScoreChunksLowerBound = 10
+const numScoreChunksUpperBound = 10_000
 
 // ConfigSourceMe holds cache configuration
 type ConfigSourceMe struct {
@@ -28,11 +31,45 @@ type ConfigSourceMe struct {
 	CountThreshold                uint32
 	CountPerSenderThreshold       uint32
 	NumSendersToPreemptivelyEvict uint32
+	ScoreRefreshInterval          time.Duration
+	// NumScoreChunks is the number of buckets in which the senders are grouped by score (defaults to 100).
+	// For very large pools, more buckets lead to a finer ordering of the senders.
+	NumScoreChunks uint32
+	// NonceIndexEnabled enables, for each sender, an index of the transactions by nonce (at the expense of extra memory)
+	NonceIndexEnabled bool
+	// EvictionHighWaterRatio enables the proactive eviction: once the fill ratio of the cache (with respect to NumBytesThreshold
+	// and CountThreshold) crosses it, the cache is shrunk (in background) down to EvictionLowWaterRatio. Zero disables the feature.
+	EvictionHighWaterRatio float64
+	EvictionLowWaterRatio  float64
+	// MaxHeapBytes enables the eviction driven by memory pressure: if the heap in use exceeds it, the lowest-scored
+	// senders are evicted (see Start). Zero disables the feature.
+	MaxHeapBytes uint64
+	// MemoryPressureCheckInterval is the period of the heap checks (defaults to 5 seconds)
+	MemoryPressureCheckInterval time.Duration
+	// MinTotalFeePerSenderUnderPressure enables the fee-based admission: while the cache is under pressure (above the
+	// high-water fill ratio, if configured, or at full capacity, otherwise), a transaction is rejected if the cumulative fee
+	// (the precomputed fee or gas limit * gas price) of its sender, including the new transaction, is still below it. Zero disables the feature.
+	// It only applies if EvictionEnabled is set.
+	MinTotalFeePerSenderUnderPressure uint64
+	// RejectTxsAboveSenderBytesThreshold changes the policy applied when a transaction would bring its sender above
+	// NumBytesPerSenderThreshold: the transaction is rejected, instead of evicting the highest nonces of the sender.
+	RejectTxsAboveSenderBytesThreshold bool
+	// EvictLowestFeeTxsOfSender changes the policy applied when a sender exceeds its thresholds: its lowest-fee transactions
+	// are evicted first (without introducing nonce gaps, see txListForSender.RemoveLowestFeeTxs), instead of its highest nonces.
+	EvictLowestFeeTxsOfSender bool
+	// UseLowestNonceAsImplicitAccountNonce changes how the nonce gaps of a sender are detected while its account nonce hasn't been
+	// notified (see TxCache.NotifyAccountNonce): the lowest nonce of its transactions serves as the account nonce, instead of
+	// skipping the detection. An explicit notification always takes precedence.
+	UseLowestNonceAsImplicitAccountNonce bool
 }
 
 type senderConstraints struct {
-	maxNumTxs   uint32
-	maxNumBytes uint32
+	maxNumTxs                    uint32
+	maxNumBytes                  uint32
+	useNonceIndex                bool
+	rejectAboveMaxNumBytes       bool
+	evictLowestFeeFirst          bool
+	useLowestNonceAsAccountNonce bool
 }
 
 // TODO: Upon further analysis and brainstorming, add some sensible minimum accepted values for the appropriate fields.
@@ -49,6 +86,15 @@ func (config *ConfigSourceMe) verify() error {
 	if config.CountPerSenderThreshold < maxNumItemsPerSenderLowerBound {
 		return fmt.Errorf("%w: config.CountPerSenderThreshold is invalid", common.ErrInvalidConfig)
 	}
+	if config.NumScoreChunks != 0 && (config.NumScoreChunks < numScoreChunksLowerBound || config.NumScoreChunks > numScoreChunksUpperBound) {
+		return fmt.Errorf("%w: config.NumScoreChunks is invalid", common.ErrInvalidConfig)
+	}
+	if config.ScoreRefreshInterval < 0 {
+		return fmt.Errorf("%w: config.ScoreRefreshInterval is invalid", common.ErrInvalidConfig)
+	}
+	if config.MemoryPressureCheckInterval < 0 {
+		return fmt.Errorf("%w: config.MemoryPressureCheckInterval is invalid", common.ErrInvalidConfig)
+	}
 	if config.EvictionEnabled {
 		if config.NumBytesThreshold < maxNumBytesLowerBound || config.NumBytesThreshold > maxNumBytesUpperBound {
 			return fmt.Errorf("%w: config.NumBytesThreshold is invalid", common.ErrInvalidConfig)
@@ -59,15 +105,69 @@ func (config *ConfigSourceMe) verify() error {
 		if config.NumSendersToPreemptivelyEvict < numSendersToPreemptivelyEvictLowerBound {
 			return fmt.Errorf("%w: config.NumSendersToPreemptivelyEvict is invalid", common.ErrInvalidConfig)
 		}
+		if config.EvictionHighWaterRatio != 0 || config.EvictionLowWaterRatio != 0 {
+			if config.EvictionHighWaterRatio <= 0 || config.EvictionHighWaterRatio > 1 {
+				return fmt.Errorf("%w: config.EvictionHighWaterRatio is invalid", common.ErrInvalidConfig)
+			}
+			if config.EvictionLowWaterRatio <= 0 || config.EvictionLowWaterRatio >= config.EvictionHighWaterRatio {
+				return fmt.Errorf("%w: config.EvictionLowWaterRatio is invalid", common.ErrInvalidConfig)
+			}
+		}
 	}
 
 	return nil
 }
 
+func (config *ConfigSourceMe) isFillRatioEvictionEnabled() bool {
+	return config.EvictionEnabled && config.EvictionHighWaterRatio > 0
+}
+
+func (config *ConfigSourceMe) isFeeAdmissionEnabled() bool {
+	return config.EvictionEnabled && config.MinTotalFeePerSenderUnderPressure > 0
+}
+
+// getAdmissionPressureRatio returns the fill ratio above which the cache is considered to be under pressure
+func (config *ConfigSourceMe) getAdmissionPressureRatio() float64 {
+	if config.isFillRatioEvictionEnabled() {
+		return config.EvictionHighWaterRatio
+	}
+
+	return 1
+}
+
+// withDefaults returns a copy of the configuration, having the defaults applied for the optional fields left unset
+func (config *ConfigSourceMe) withDefaults() ConfigSourceMe {
+	configWithDefaults := *config
+	configWithDefaults.NumScoreChunks = config.getNumScoreChunks()
+	configWithDefaults.MemoryPressureCheckInterval = config.getMemoryPressureCheckInterval()
+
+	return configWithDefaults
+}
+
+func (config *ConfigSourceMe) getNumScoreChunks() uint32 {
+	if config.NumScoreChunks == 0 {
+		return defaultNumScoreChunks
+	}
+
+	return config.NumScoreChunks
+}
+
+func (config *ConfigSourceMe) getMemoryPressureCheckInterval() time.Duration {
+	if config.MemoryPressureCheckInterval == 0 {
+		return defaultMemoryPressureCheckInterval
+	}
+
+	return config.MemoryPressureCheckInterval
+}
+
 func (config *ConfigSourceMe) getSenderConstraints() senderConstraints {
 	return senderConstraints{
-		maxNumBytes: config.NumBytesPerSenderThreshold,
-		maxNumTxs:   config.CountPerSenderThreshold,
+		maxNumBytes:                  config.NumBytesPerSenderThreshold,
+		maxNumTxs:                    config.CountPerSenderThreshold,
+		useNonceIndex:                config.NonceIndexEnabled,
+		rejectAboveMaxNumBytes:       config.RejectTxsAboveSenderBytesThreshold,
+		evictLowestFeeFirst:          config.EvictLowestFeeTxsOfSender,
+		useLowestNonceAsAccountNonce: config.UseLowestNonceAsImplicitAccountNonce,
 	}
 }
 
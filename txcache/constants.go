@@ -1,5 +1,7 @@
 package txcache
 
+import "time"
+
 const estimatedNumOfSweepableSendersPerSelection = 100
 
 const senderGracePeriodLowerBound = 2
@@ -7,3 +9,20 @@ const senderGracePeriodLowerBound = 2
 const senderGracePeriodUpperBound = 2
 
 const numEvictedTxsToDisplay = 3
+
+const numTopSendersInDebugState = 10
+
+const defaultNumScoreChunks = uint32(100)
+
+const defaultMemoryPressureCheckInterval = 5 * time.Second
+
+const reputationLowerBound = -10
+
+const reputationUpperBound = 10
+
+const reputationBoostOnInclusion = 1
+
+const reputationPenaltyOnMiss = 1
+
+// reputationScoreStep is the fraction of the score range added to (or subtracted from) the score for each reputation point
+const reputationScoreStep = 0.01
@@ -0,0 +1,107 @@
+package txcache
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// TxCacheDebugState is a comprehensive snapshot of the internal state of the cache, useful for tests and debugging
+type TxCacheDebugState struct {
+	SenderCount         uint64
+	TxCount             uint64
+	NumBytes            int
+	EvictedCount        uint64
+	SelectionCycleCount uint64
+	LastEvictionTime    time.Time
+	LastSelectionTime   time.Time
+	TopSenders          []SenderDebugInfo
+}
+
+// SenderDebugInfo holds debugging information about a sender
+type SenderDebugInfo struct {
+	Address             []byte
+	Score               uint32
+	NumTxs              uint64
+	NumBytes            int64
+	AccountNonce        uint64
+	AccountNonceKnown   bool
+	LowestTxNonce       uint64
+	NumFailedSelections int64
+}
+
+// DebugState returns a snapshot of the internal state of the cache. "EvictedCount" counts the transactions evicted
+// due to the capacity constraints (including the per-sender ones) or the memory pressure. "TopSenders" holds
+// (at most) the highest-scored senders.
+func (cache *TxCache) DebugState() TxCacheDebugState {
+	return TxCacheDebugState{
+		SenderCount:         cache.CountSenders(),
+		TxCount:             cache.CountTx(),
+		NumBytes:            cache.NumBytes(),
+		EvictedCount:        cache.numEvictedTxs.GetUint64(),
+		SelectionCycleCount: cache.numSelections.GetUint64(),
+		LastEvictionTime:    timestampToTime(cache.lastEvictionTimestamp.Get()),
+		LastSelectionTime:   timestampToTime(cache.lastSelectionTimestamp.Get()),
+		TopSenders:          cache.getTopSendersDebugInfo(numTopSendersInDebugState),
+	}
+}
+
+// Dump returns the transactions of the cache as a JSON array, in the order of ExportSorted (see WrappedTransaction.MarshalJSON)
+func (cache *TxCache) Dump() ([]byte, error) {
+	txs := make([]*WrappedTransaction, 0, cache.CountTx())
+	for _, listForSender := range cache.txListBySender.getSnapshotDescending() {
+		txs = append(txs, listForSender.getTxs()...)
+	}
+
+	return json.Marshal(txs)
+}
+
+func (cache *TxCache) getTopSendersDebugInfo(maxNumSenders int) []SenderDebugInfo {
+	snapshot := cache.txListBySender.getSnapshotDescending()
+	if len(snapshot) > maxNumSenders {
+		snapshot = snapshot[:maxNumSenders]
+	}
+
+	result := make([]SenderDebugInfo, 0, len(snapshot))
+	for _, listForSender := range snapshot {
+		info := SenderDebugInfo{
+			Address:             []byte(listForSender.sender),
+			Score:               listForSender.getLastComputedScore(),
+			NumTxs:              listForSender.countTxWithLock(),
+			NumBytes:            listForSender.totalBytes.Get(),
+			AccountNonce:        listForSender.accountNonce.Get(),
+			AccountNonceKnown:   listForSender.accountNonceKnown.IsSet(),
+			NumFailedSelections: listForSender.numFailedSelections.Get(),
+		}
+
+		lowestTx := listForSender.getLowestNonceTx()
+		if lowestTx != nil {
+			info.LowestTxNonce = lowestTx.Tx.GetNonce()
+		}
+
+		result = append(result, info)
+	}
+
+	return result
+}
+
+func (cache *TxCache) recordEviction(numTxs uint32) {
+	if numTxs == 0 {
+		return
+	}
+
+	cache.numEvictedTxs.Add(int64(numTxs))
+	cache.lastEvictionTimestamp.Set(cache.getNow().UnixNano())
+}
+
+func (cache *TxCache) recordSelection() {
+	cache.numSelections.Increment()
+	cache.lastSelectionTimestamp.Set(cache.getNow().UnixNano())
+}
+
+func timestampToTime(timestamp int64) time.Time {
+	if timestamp == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(0, timestamp)
+}
@@ -0,0 +1,87 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCache_DebugState(t *testing.T) {
+	t.Run("empty cache", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		state := cache.DebugState()
+		require.Equal(t, uint64(0), state.SenderCount)
+		require.Equal(t, uint64(0), state.TxCount)
+		require.Equal(t, uint64(0), state.EvictedCount)
+		require.Equal(t, uint64(0), state.SelectionCycleCount)
+		require.True(t, state.LastEvictionTime.IsZero())
+		require.True(t, state.LastSelectionTime.IsZero())
+		require.Empty(t, state.TopSenders)
+	})
+
+	t.Run("with transactions and selections", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		now := time.Unix(1_000_000, 0)
+		cache.getNow = func() time.Time {
+			return now
+		}
+
+		cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50000, oneBillion))
+		cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 50000, oneBillion))
+		cache.AddTx(createTxWithParams([]byte("hash-bob-5"), "bob", 5, 128, 50000, uint64(1.5*oneBillion)))
+		cache.NotifyAccountNonce([]byte("bob"), 5)
+
+		_ = cache.SelectTransactionsWithBandwidth(10, 10, math.MaxUint64)
+		_ = cache.SelectTransactionsWFQ(10)
+
+		state := cache.DebugState()
+		require.Equal(t, uint64(2), state.SenderCount)
+		require.Equal(t, uint64(3), state.TxCount)
+		require.Equal(t, 3*128, state.NumBytes)
+		require.Equal(t, uint64(0), state.EvictedCount)
+		require.Equal(t, uint64(2), state.SelectionCycleCount)
+		require.Equal(t, now, state.LastSelectionTime)
+		require.True(t, state.LastEvictionTime.IsZero())
+
+		require.Len(t, state.TopSenders, 2)
+		require.Equal(t, []byte("bob"), state.TopSenders[0].Address)
+		require.Equal(t, uint64(1), state.TopSenders[0].NumTxs)
+		require.Equal(t, int64(128), state.TopSenders[0].NumBytes)
+		require.Equal(t, uint64(5), state.TopSenders[0].AccountNonce)
+		require.True(t, state.TopSenders[0].AccountNonceKnown)
+		require.Equal(t, uint64(5), state.TopSenders[0].LowestTxNonce)
+		require.Equal(t, []byte("alice"), state.TopSenders[1].Address)
+		require.Equal(t, uint64(2), state.TopSenders[1].NumTxs)
+		require.Equal(t, uint64(1), state.TopSenders[1].LowestTxNonce)
+		require.Greater(t, state.TopSenders[0].Score, state.TopSenders[1].Score)
+	})
+
+	t.Run("at most the top senders are reported", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		addManyTransactionsWithUniformDistribution(cache, 2*numTopSendersInDebugState, 1)
+
+		state := cache.DebugState()
+		require.Equal(t, uint64(2*numTopSendersInDebugState), state.SenderCount)
+		require.Len(t, state.TopSenders, numTopSendersInDebugState)
+	})
+
+	t.Run("with evictions", func(t *testing.T) {
+		cache := newCacheToTest(maxNumBytesPerSenderUpperBound, 2)
+		now := time.Unix(1_000_000, 0)
+		cache.getNow = func() time.Time {
+			return now
+		}
+
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+
+		state := cache.DebugState()
+		require.Equal(t, uint64(2), state.TxCount)
+		require.Equal(t, uint64(1), state.EvictedCount)
+		require.Equal(t, now, state.LastEvictionTime)
+	})
+}
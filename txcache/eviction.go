@@ -32,6 +32,55 @@ func (cache *TxCache) doEviction() {
 	journal.passOneNumSteps, journal.passOneNumTxs, journal.passOneNumSenders = cache.evictSendersInLoop()
 	journal.evictionPerformed = true
 	cache.evictionJournal = journal
+	cache.recordEviction(journal.passOneNumTxs)
+
+	cache.monitorEvictionEnd(stopWatch)
+	cache.destroySnapshotOfSenders()
+}
+
+// triggerBackgroundEvictionIfAboveHighWater schedules an eviction (down to the low-water fill ratio) on a separate goroutine,
+// if the high-water fill ratio has been crossed. At most one background eviction is scheduled at a time.
+func (cache *TxCache) triggerBackgroundEvictionIfAboveHighWater() {
+	if !cache.config.isFillRatioEvictionEnabled() {
+		return
+	}
+	if cache.isEvictionInProgress.IsSet() {
+		return
+	}
+	if !cache.isAboveHighWater() {
+		return
+	}
+
+	isAlreadyDue := cache.isBackgroundEvictionDue.SetReturningPrevious()
+	if isAlreadyDue {
+		return
+	}
+
+	cache.runInBackground(cache.doBackgroundEviction)
+}
+
+// doBackgroundEviction evicts senders (and their transactions) until the fill ratio drops under the low-water ratio
+func (cache *TxCache) doBackgroundEviction() {
+	defer cache.isBackgroundEvictionDue.Reset()
+
+	cache.evictionMutex.Lock()
+	defer cache.evictionMutex.Unlock()
+
+	_ = cache.isEvictionInProgress.SetReturningPrevious()
+	defer cache.isEvictionInProgress.Reset()
+
+	if !cache.isAboveHighWater() {
+		return
+	}
+
+	stopWatch := cache.monitorEvictionStart()
+	cache.makeSnapshotOfSenders()
+
+	journal := evictionJournal{}
+	journal.passOneNumSteps, journal.passOneNumTxs, journal.passOneNumSenders = cache.evictSendersWhile(cache.isAboveLowWater)
+	journal.evictionPerformed = true
+	cache.evictionJournal = journal
+	cache.recordEviction(journal.passOneNumTxs)
 
 	cache.monitorEvictionEnd(stopWatch)
 	cache.destroySnapshotOfSenders()
@@ -49,6 +98,25 @@ func (cache *TxCache) isCapacityExceeded() bool {
 	return cache.areThereTooManyBytes() || cache.areThereTooManySenders() || cache.areThereTooManyTxs()
 }
 
+func (cache *TxCache) isAboveHighWater() bool {
+	return cache.isAboveFillRatio(cache.config.EvictionHighWaterRatio)
+}
+
+func (cache *TxCache) isAboveLowWater() bool {
+	return cache.isAboveFillRatio(cache.config.EvictionLowWaterRatio)
+}
+
+// isAboveFillRatio checks the number of bytes, senders and transactions against the given fraction of the thresholds
+func (cache *TxCache) isAboveFillRatio(ratio float64) bool {
+	maxNumBytes := ratio * float64(cache.config.NumBytesThreshold)
+	maxCount := ratio * float64(cache.config.CountThreshold)
+
+	tooManyBytes := float64(cache.NumBytes()) > maxNumBytes
+	tooManySenders := float64(cache.CountSenders()) > maxCount
+	tooManyTxs := float64(cache.CountTx()) > maxCount
+	return tooManyBytes || tooManySenders || tooManyTxs
+}
+
 func (cache *TxCache) areThereTooManyBytes() bool {
 	numBytes := cache.NumBytes()
 	tooManyBytes := numBytes > int(cache.config.NumBytesThreshold)
@@ -4,6 +4,7 @@ import (
 	"math"
 	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 )
@@ -269,8 +270,11 @@ func Test_AddWithEviction_UniformDistribution_25000x10(t *testing.T) {
 	addManyTransactionsWithUniformDistribution(cache, numSenders, numTxsPerSender)
 
 	// Sometimes (due to map iteration non-determinism), more eviction happens - one more step of 100 senders.
-	require.LessOrEqual(t, uint32(cache.CountTx()), config.CountThreshold)
-	require.GreaterOrEqual(t, uint32(cache.CountTx()), config.CountThreshold-config.NumSendersToPreemptivelyEvict*uint32(numTxsPerSender))
+	state := cache.DebugState()
+	require.LessOrEqual(t, uint32(state.TxCount), config.CountThreshold)
+	require.GreaterOrEqual(t, uint32(state.TxCount), config.CountThreshold-config.NumSendersToPreemptivelyEvict*uint32(numTxsPerSender))
+	require.Equal(t, uint64(numSenders*numTxsPerSender), state.TxCount+state.EvictedCount)
+	require.False(t, state.LastEvictionTime.IsZero())
 }
 
 func Test_EvictSendersAndTheirTxs_Concurrently(t *testing.T) {
@@ -305,3 +309,103 @@ func Test_EvictSendersAndTheirTxs_Concurrently(t *testing.T) {
 
 	wg.Wait()
 }
+
+func TestEviction_BackgroundEvictionAboveHighWater(t *testing.T) {
+	t.Run("because of count", func(t *testing.T) {
+		config := ConfigSourceMe{
+			Name:                          "untitled",
+			NumChunks:                     16,
+			EvictionEnabled:               true,
+			CountThreshold:                100,
+			CountPerSenderThreshold:       math.MaxUint32,
+			NumBytesThreshold:             maxNumBytesUpperBound,
+			NumBytesPerSenderThreshold:    maxNumBytesPerSenderUpperBound,
+			NumSendersToPreemptivelyEvict: 5,
+			EvictionHighWaterRatio:        0.9,
+			EvictionLowWaterRatio:         0.75,
+		}
+
+		txGasHandler, _ := dummyParams()
+		cache, err := NewTxCache(config, txGasHandler)
+		require.Nil(t, err)
+
+		// Up to the high-water mark, nothing is evicted
+		for index := 0; index < 90; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTx([]byte{byte(index)}, sender, uint64(1)))
+		}
+
+		require.Equal(t, uint64(90), cache.CountTx())
+		require.False(t, cache.isBackgroundEvictionDue.IsSet())
+
+		// Crossing the high-water mark
+		cache.AddTx(createTx([]byte{byte(90)}, string(createFakeSenderAddress(90)), uint64(1)))
+
+		require.Eventually(t, func() bool {
+			return cache.CountTx() <= 75 && !cache.isBackgroundEvictionDue.IsSet()
+		}, time.Second, time.Millisecond)
+
+		// Evicted in batches of 5 senders, thus approximately down to the low-water mark
+		require.GreaterOrEqual(t, cache.CountTx(), uint64(70))
+		require.Equal(t, cache.CountTx(), cache.CountSenders())
+		require.True(t, cache.evictionJournal.evictionPerformed)
+	})
+
+	t.Run("because of size", func(t *testing.T) {
+		numBytesPerTx := uint32(1000)
+
+		config := ConfigSourceMe{
+			Name:                          "untitled",
+			NumChunks:                     16,
+			EvictionEnabled:               true,
+			CountThreshold:                math.MaxUint32,
+			CountPerSenderThreshold:       math.MaxUint32,
+			NumBytesThreshold:             numBytesPerTx * 100,
+			NumBytesPerSenderThreshold:    maxNumBytesPerSenderUpperBound,
+			NumSendersToPreemptivelyEvict: 1,
+			EvictionHighWaterRatio:        0.9,
+			EvictionLowWaterRatio:         0.75,
+		}
+
+		txGasHandler, _ := dummyParams()
+		cache, err := NewTxCache(config, txGasHandler)
+		require.Nil(t, err)
+
+		for index := 0; index < 91; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTxWithParams([]byte{byte(index)}, sender, uint64(1), uint64(numBytesPerTx), 10000, 100*oneBillion))
+		}
+
+		require.Eventually(t, func() bool {
+			return cache.NumBytes() <= int(numBytesPerTx*75) && !cache.isBackgroundEvictionDue.IsSet()
+		}, time.Second, time.Millisecond)
+
+		require.Equal(t, uint64(75), cache.CountTx())
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		config := ConfigSourceMe{
+			Name:                          "untitled",
+			NumChunks:                     16,
+			EvictionEnabled:               true,
+			CountThreshold:                100,
+			CountPerSenderThreshold:       math.MaxUint32,
+			NumBytesThreshold:             maxNumBytesUpperBound,
+			NumBytesPerSenderThreshold:    maxNumBytesPerSenderUpperBound,
+			NumSendersToPreemptivelyEvict: 5,
+		}
+
+		txGasHandler, _ := dummyParams()
+		cache, err := NewTxCache(config, txGasHandler)
+		require.Nil(t, err)
+
+		for index := 0; index < 100; index++ {
+			sender := string(createFakeSenderAddress(index))
+			cache.AddTx(createTx([]byte{byte(index)}, sender, uint64(1)))
+		}
+
+		time.Sleep(50 * time.Millisecond)
+		require.Equal(t, uint64(100), cache.CountTx())
+		require.False(t, cache.evictionJournal.evictionPerformed)
+	})
+}
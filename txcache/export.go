@@ -0,0 +1,94 @@
+package txcache
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/multiversx/mx-chain-storage-go/common"
+)
+
+const exportLengthPrefixSize = 4
+
+// protoMarshalizable is implemented by the (generated) protobuf transaction types
+type protoMarshalizable interface {
+	Marshal() ([]byte, error)
+}
+
+// ExportSorted writes the transactions of the cache to the provided writer, to be streamed to a syncing peer.
+// The senders are iterated in descending order of their score, and the transactions of each sender in nonce order.
+// Each transaction is written in protobuf format, prefixed by its length (4 bytes, big endian).
+// The (optional) progressFn is called every batchSize transactions, with the number of transactions exported so far.
+func (cache *TxCache) ExportSorted(w io.Writer, batchSize int, progressFn func(count int)) error {
+	lengthPrefix := make([]byte, exportLengthPrefixSize)
+
+	return cache.exportSortedWith(w, batchSize, progressFn, func(tx *WrappedTransaction) error {
+		return exportTx(w, tx, lengthPrefix)
+	})
+}
+
+// ExportSortedAsJSON writes the transactions of the cache to the provided writer, in the order of ExportSorted, for debugging purposes.
+// Each transaction is written as a JSON object (see WrappedTransaction.MarshalJSON) on its own line.
+// The (optional) progressFn is called every batchSize transactions, with the number of transactions exported so far.
+func (cache *TxCache) ExportSortedAsJSON(w io.Writer, batchSize int, progressFn func(count int)) error {
+	var encoder *json.Encoder
+	if w != nil {
+		encoder = json.NewEncoder(w)
+	}
+
+	return cache.exportSortedWith(w, batchSize, progressFn, func(tx *WrappedTransaction) error {
+		return encoder.Encode(tx)
+	})
+}
+
+func (cache *TxCache) exportSortedWith(w io.Writer, batchSize int, progressFn func(count int), exportFn func(tx *WrappedTransaction) error) error {
+	if cache == nil {
+		return common.ErrNilCacher
+	}
+	if w == nil {
+		return common.ErrNilWriter
+	}
+	if batchSize <= 0 {
+		return common.ErrInvalidBatchSize
+	}
+
+	count := 0
+	for _, listForSender := range cache.txListBySender.getSnapshotDescending() {
+		for _, tx := range listForSender.getTxs() {
+			err := exportFn(tx)
+			if err != nil {
+				return err
+			}
+
+			count++
+			if progressFn != nil && count%batchSize == 0 {
+				progressFn(count)
+			}
+		}
+	}
+
+	return nil
+}
+
+func exportTx(w io.Writer, tx *WrappedTransaction, lengthPrefix []byte) error {
+	marshalizable, ok := tx.Tx.(protoMarshalizable)
+	if !ok {
+		return fmt.Errorf("%w: transaction %x", common.ErrNotProtoMarshalizable, tx.TxHash)
+	}
+
+	buff, err := marshalizable.Marshal()
+	if err != nil {
+		return err
+	}
+
+	binary.BigEndian.PutUint32(lengthPrefix, uint32(len(buff)))
+
+	_, err = w.Write(lengthPrefix)
+	if err != nil {
+		return err
+	}
+
+	_, err = w.Write(buff)
+	return err
+}
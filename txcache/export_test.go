@@ -0,0 +1,174 @@
+package txcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/data"
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/stretchr/testify/require"
+)
+
+type nonMarshalizableTx struct {
+	data.TransactionHandler
+}
+
+func TestTxCache_ExportSorted(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		err := cache.ExportSorted(nil, 1, nil)
+		require.Equal(t, common.ErrNilWriter, err)
+
+		err = cache.ExportSorted(&bytes.Buffer{}, 0, nil)
+		require.Equal(t, common.ErrInvalidBatchSize, err)
+
+		var nilCache *TxCache
+		err = nilCache.ExportSorted(&bytes.Buffer{}, 1, nil)
+		require.Equal(t, common.ErrNilCacher, err)
+	})
+
+	t.Run("should write senders by score, transactions by nonce", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-bob-7"), "bob", 7))
+		cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+		cache.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+
+		progress := make([]int, 0)
+		buffer := &bytes.Buffer{}
+		err := cache.ExportSorted(buffer, 2, func(count int) {
+			progress = append(progress, count)
+		})
+		require.Nil(t, err)
+		require.Equal(t, []int{2, 4}, progress)
+
+		actual := make([]*transaction.Transaction, 0)
+		for buffer.Len() > 0 {
+			length := binary.BigEndian.Uint32(buffer.Next(exportLengthPrefixSize))
+			tx := &transaction.Transaction{}
+			err = tx.Unmarshal(buffer.Next(int(length)))
+			require.Nil(t, err)
+
+			actual = append(actual, tx)
+		}
+		require.Len(t, actual, 5)
+
+		// Senders are contiguous and in descending order of their score, transactions are in nonce order
+		exportedSenders := make([]string, 0)
+		for i, tx := range actual {
+			isNewSender := i == 0 || !bytes.Equal(actual[i-1].SndAddr, tx.SndAddr)
+			if isNewSender {
+				require.NotContains(t, exportedSenders, string(tx.SndAddr))
+				exportedSenders = append(exportedSenders, string(tx.SndAddr))
+				continue
+			}
+
+			require.Less(t, actual[i-1].Nonce, tx.Nonce)
+		}
+
+		require.Len(t, exportedSenders, 2)
+		scoreFirst := getSenderScoreToTest(t, cache, exportedSenders[0])
+		scoreSecond := getSenderScoreToTest(t, cache, exportedSenders[1])
+		require.GreaterOrEqual(t, scoreFirst, scoreSecond)
+	})
+
+	t.Run("should error on non-marshalizable transaction", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		tx := createTx([]byte("hash-alice-1"), "alice", 1)
+		tx.Tx = &nonMarshalizableTx{TransactionHandler: tx.Tx}
+		cache.AddTx(tx)
+
+		err := cache.ExportSorted(&bytes.Buffer{}, 1, nil)
+		require.True(t, errors.Is(err, common.ErrNotProtoMarshalizable))
+	})
+}
+
+func TestTxCache_ExportSortedAsJSON(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		err := cache.ExportSortedAsJSON(nil, 1, nil)
+		require.Equal(t, common.ErrNilWriter, err)
+
+		err = cache.ExportSortedAsJSON(&bytes.Buffer{}, 0, nil)
+		require.Equal(t, common.ErrInvalidBatchSize, err)
+	})
+
+	t.Run("should write a JSON object per line, in the order of ExportSorted", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		// Bob (paying more) has a higher score than Alice, thus the order of the senders is deterministic
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTxWithParams([]byte("hash-bob-7"), "bob", 7, 128, 50_000, 10*oneBillion))
+		require.Greater(t, cache.getScoreOfSender("bob"), cache.getScoreOfSender("alice"))
+
+		progress := make([]int, 0)
+		buffer := &bytes.Buffer{}
+		err := cache.ExportSortedAsJSON(buffer, 1, func(count int) {
+			progress = append(progress, count)
+		})
+		require.Nil(t, err)
+		require.Equal(t, []int{1, 2, 3}, progress)
+
+		lines := strings.Split(strings.TrimSpace(buffer.String()), "\n")
+		require.Len(t, lines, 3)
+
+		exportedHashes := make([]string, 0, len(lines))
+		for _, line := range lines {
+			tx := &WrappedTransaction{}
+			err = json.Unmarshal([]byte(line), tx)
+			require.Nil(t, err)
+			exportedHashes = append(exportedHashes, string(tx.TxHash))
+		}
+
+		require.Equal(t, []string{"hash-bob-7", "hash-alice-1", "hash-alice-2"}, exportedHashes)
+		require.Equal(t, exportedHashes, dumpHashesToTest(t, cache))
+	})
+}
+
+func TestTxCache_Dump(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	buff, err := cache.Dump()
+	require.Nil(t, err)
+	require.Equal(t, "[]", string(buff))
+
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-bob-7"), "bob", 7))
+	cache.AddTx(createTx([]byte("hash-bob-8"), "bob", 8))
+
+	require.ElementsMatch(t, []string{"hash-alice-1", "hash-bob-7", "hash-bob-8"}, dumpHashesToTest(t, cache))
+}
+
+func dumpHashesToTest(t *testing.T, cache *TxCache) []string {
+	buff, err := cache.Dump()
+	require.Nil(t, err)
+
+	dumped := make([]*WrappedTransaction, 0)
+	err = json.Unmarshal(buff, &dumped)
+	require.Nil(t, err)
+
+	hashes := make([]string, 0, len(dumped))
+	for _, tx := range dumped {
+		hashes = append(hashes, string(tx.TxHash))
+	}
+
+	return hashes
+}
+
+func getSenderScoreToTest(t *testing.T, cache *TxCache, sender string) uint32 {
+	listForSender, ok := cache.txListBySender.getListForSender(sender)
+	require.True(t, ok)
+
+	return listForSender.getLastComputedScore()
+}
@@ -0,0 +1,75 @@
+package txcache
+
+import (
+	"math"
+	"sort"
+)
+
+// GetFeePercentile returns the p-th percentile (nearest-rank method) of the gas prices of the transactions in the cache.
+// The percentile is expected in the interval [0, 100] (values outside the interval are clamped). For an empty cache, it returns 0.
+func (cache *TxCache) GetFeePercentile(percentile float64) uint64 {
+	gasPrices := cache.getGasPricesSortedAscending()
+	if len(gasPrices) == 0 {
+		return 0
+	}
+
+	percentile = math.Max(0, math.Min(100, percentile))
+	rank := int(math.Ceil(percentile / 100 * float64(len(gasPrices))))
+	if rank < 1 {
+		rank = 1
+	}
+
+	return gasPrices[rank-1]
+}
+
+// GetMinFeeForInclusion estimates the minimum gas price needed by a transaction in order to rank within the
+// top "positionInBlock" transactions of a block bounded by "gasLimit". The transactions in the cache are ranked by gas price
+// (descending) and, out of those that fit in the block, the gas price of the last one is returned.
+// If the block would not be filled by the transactions in the cache, the minimum gas price is returned.
+// Note: the ranking does not account for the per-sender nonce ordering or the scores used by the selection.
+func (cache *TxCache) GetMinFeeForInclusion(positionInBlock int, gasLimit uint64) uint64 {
+	minGasPrice := cache.txListBySender.txGasHandler.MinGasPrice()
+	if positionInBlock <= 0 || gasLimit == 0 {
+		return minGasPrice
+	}
+
+	txs := make([]*WrappedTransaction, 0, cache.CountTx())
+	cache.ForEachTransaction(func(_ []byte, tx *WrappedTransaction) {
+		txs = append(txs, tx)
+	})
+
+	sort.Slice(txs, func(i, j int) bool {
+		return txs[i].Tx.GetGasPrice() > txs[j].Tx.GetGasPrice()
+	})
+
+	numIncluded := 0
+	accumulatedGas := uint64(0)
+	lastIncludedGasPrice := minGasPrice
+	for _, tx := range txs {
+		accumulatedGas += tx.Tx.GetGasLimit()
+		if accumulatedGas > gasLimit {
+			return lastIncludedGasPrice
+		}
+
+		numIncluded++
+		lastIncludedGasPrice = tx.Tx.GetGasPrice()
+		if numIncluded == positionInBlock {
+			return lastIncludedGasPrice
+		}
+	}
+
+	return minGasPrice
+}
+
+func (cache *TxCache) getGasPricesSortedAscending() []uint64 {
+	gasPrices := make([]uint64, 0, cache.CountTx())
+	cache.ForEachTransaction(func(_ []byte, tx *WrappedTransaction) {
+		gasPrices = append(gasPrices, tx.Tx.GetGasPrice())
+	})
+
+	sort.Slice(gasPrices, func(i, j int) bool {
+		return gasPrices[i] < gasPrices[j]
+	})
+
+	return gasPrices
+}
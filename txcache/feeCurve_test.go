@@ -0,0 +1,80 @@
+package txcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func addTxsWithGasPrices(cache *TxCache, gasLimit uint64, gasPrices ...uint64) {
+	for index, gasPrice := range gasPrices {
+		sender := string(createFakeSenderAddress(index))
+		cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), 1), sender, 1, 128, gasLimit, gasPrice))
+	}
+}
+
+func TestTxCache_GetFeePercentile(t *testing.T) {
+	t.Run("empty cache", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		require.Equal(t, uint64(0), cache.GetFeePercentile(50))
+	})
+
+	t.Run("with transactions", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		// Added in arbitrary order, on purpose
+		addTxsWithGasPrices(cache, 50_000, 7*oneBillion, 1*oneBillion, 10*oneBillion, 4*oneBillion, 2*oneBillion, 9*oneBillion, 3*oneBillion, 6*oneBillion, 5*oneBillion, 8*oneBillion)
+
+		require.Equal(t, uint64(1*oneBillion), cache.GetFeePercentile(0))
+		require.Equal(t, uint64(1*oneBillion), cache.GetFeePercentile(10))
+		require.Equal(t, uint64(3*oneBillion), cache.GetFeePercentile(25))
+		require.Equal(t, uint64(5*oneBillion), cache.GetFeePercentile(50))
+		require.Equal(t, uint64(9*oneBillion), cache.GetFeePercentile(90))
+		require.Equal(t, uint64(10*oneBillion), cache.GetFeePercentile(100))
+	})
+
+	t.Run("out of range percentiles are clamped", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		addTxsWithGasPrices(cache, 50_000, 1*oneBillion, 2*oneBillion, 3*oneBillion)
+
+		require.Equal(t, uint64(1*oneBillion), cache.GetFeePercentile(-5))
+		require.Equal(t, uint64(3*oneBillion), cache.GetFeePercentile(150))
+	})
+}
+
+func TestTxCache_GetMinFeeForInclusion(t *testing.T) {
+	minGasPrice := uint64(oneBillion)
+
+	t.Run("bad arguments", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		addTxsWithGasPrices(cache, 50_000, 2*oneBillion)
+
+		require.Equal(t, minGasPrice, cache.GetMinFeeForInclusion(0, 1_000_000))
+		require.Equal(t, minGasPrice, cache.GetMinFeeForInclusion(1, 0))
+	})
+
+	t.Run("empty cache", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		require.Equal(t, minGasPrice, cache.GetMinFeeForInclusion(10, 1_000_000))
+	})
+
+	t.Run("bounded by position", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		addTxsWithGasPrices(cache, 50_000, 5*oneBillion, 1*oneBillion, 4*oneBillion, 2*oneBillion, 3*oneBillion)
+
+		require.Equal(t, uint64(5*oneBillion), cache.GetMinFeeForInclusion(1, 1_000_000))
+		require.Equal(t, uint64(3*oneBillion), cache.GetMinFeeForInclusion(3, 1_000_000))
+		require.Equal(t, uint64(1*oneBillion), cache.GetMinFeeForInclusion(5, 1_000_000))
+		// Block not filled
+		require.Equal(t, minGasPrice, cache.GetMinFeeForInclusion(6, 1_000_000))
+	})
+
+	t.Run("bounded by gas limit", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		addTxsWithGasPrices(cache, 50_000, 5*oneBillion, 1*oneBillion, 4*oneBillion, 2*oneBillion, 3*oneBillion)
+
+		// Only two transactions fit
+		require.Equal(t, uint64(4*oneBillion), cache.GetMinFeeForInclusion(5, 120_000))
+		// Not even one transaction fits
+		require.Equal(t, minGasPrice, cache.GetMinFeeForInclusion(5, 40_000))
+	})
+}
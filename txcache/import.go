@@ -0,0 +1,144 @@
+package txcache
+
+import (
+	"encoding/binary"
+	"io"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-storage-go/common"
+)
+
+// maxImportRecordSize guards against allocating huge buffers when reading a corrupted stream
+const maxImportRecordSize = maxNumBytesPerSenderUpperBound
+
+// TrustLevel defines how much a source of imported transactions is trusted
+type TrustLevel int
+
+const (
+	// TrustLevelFull means that the source is trusted to provide valid data, but the signatures are still verified
+	TrustLevelFull TrustLevel = iota
+	// TrustLevelNone means that the transactions are added without any verification (dangerous, for testing only)
+	TrustLevelNone
+)
+
+// SetTxImportHandler sets the handler used to wrap and verify the imported transactions (see ImportFromPeer)
+func (cache *TxCache) SetTxImportHandler(handler TxImportHandler) error {
+	if cache == nil {
+		return common.ErrNilCacher
+	}
+	if check.IfNil(handler) {
+		return common.ErrNilTxImportHandler
+	}
+
+	cache.mutTxImportHandler.Lock()
+	cache.txImportHandler = handler
+	cache.mutTxImportHandler.Unlock()
+
+	return nil
+}
+
+func (cache *TxCache) getTxImportHandler() TxImportHandler {
+	cache.mutTxImportHandler.RLock()
+	defer cache.mutTxImportHandler.RUnlock()
+
+	return cache.txImportHandler
+}
+
+// ImportFromPeer adds to the cache the transactions read from the provided reader, as written by ExportSorted.
+// For TrustLevelFull, the signature of each transaction is verified (using the handler set by SetTxImportHandler).
+// It returns the number of added, skipped (already present) and invalid transactions. An error is returned
+// if the stream cannot be read (e.g. it is truncated or corrupted), along with the counts so far.
+func (cache *TxCache) ImportFromPeer(r io.Reader, trustLevel TrustLevel) (imported, skipped, invalid int, err error) {
+	if cache == nil {
+		return 0, 0, 0, common.ErrNilCacher
+	}
+	if r == nil {
+		return 0, 0, 0, common.ErrNilReader
+	}
+	if trustLevel != TrustLevelFull && trustLevel != TrustLevelNone {
+		return 0, 0, 0, common.ErrInvalidTrustLevel
+	}
+
+	handler := cache.getTxImportHandler()
+	if check.IfNil(handler) {
+		return 0, 0, 0, common.ErrNilTxImportHandler
+	}
+
+	lengthPrefix := make([]byte, exportLengthPrefixSize)
+	for {
+		buff, errRead := readImportRecord(r, lengthPrefix)
+		if errRead == io.EOF {
+			return imported, skipped, invalid, nil
+		}
+		if errRead != nil {
+			return imported, skipped, invalid, errRead
+		}
+
+		tx, errImport := cache.prepareImportedTx(buff, handler, trustLevel)
+		if errImport != nil {
+			log.Trace("TxCache.ImportFromPeer: invalid transaction", "name", cache.name, "err", errImport)
+			invalid++
+			continue
+		}
+
+		_, isPresent := cache.GetByTxHash(tx.TxHash)
+		if isPresent {
+			skipped++
+			continue
+		}
+
+		_, added := cache.AddTx(tx)
+		if added {
+			imported++
+		} else {
+			skipped++
+		}
+	}
+}
+
+// readImportRecord returns io.EOF only if the stream ends on a record boundary
+func readImportRecord(r io.Reader, lengthPrefix []byte) ([]byte, error) {
+	_, err := io.ReadFull(r, lengthPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	length := binary.BigEndian.Uint32(lengthPrefix)
+	if length > maxImportRecordSize {
+		return nil, common.ErrImportRecordTooLarge
+	}
+
+	buff := make([]byte, length)
+	_, err = io.ReadFull(r, buff)
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+
+	return buff, err
+}
+
+func (cache *TxCache) prepareImportedTx(buff []byte, handler TxImportHandler, trustLevel TrustLevel) (*WrappedTransaction, error) {
+	tx := &transaction.Transaction{}
+	err := tx.Unmarshal(buff)
+	if err != nil {
+		return nil, err
+	}
+
+	if trustLevel == TrustLevelFull {
+		err = handler.VerifyTx(tx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	wrappedTx, err := handler.WrapTx(tx)
+	if err != nil {
+		return nil, err
+	}
+	if wrappedTx == nil || check.IfNil(wrappedTx.Tx) {
+		return nil, common.ErrNilTransaction
+	}
+
+	return wrappedTx, nil
+}
@@ -0,0 +1,167 @@
+package txcache
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/stretchr/testify/require"
+)
+
+var errInvalidSignature = errors.New("invalid signature")
+
+type txImportHandlerStub struct {
+	numVerified int
+}
+
+func (stub *txImportHandlerStub) WrapTx(tx *transaction.Transaction) (*WrappedTransaction, error) {
+	return &WrappedTransaction{
+		Tx:     tx,
+		TxHash: []byte(fmt.Sprintf("hash-%s-%d", tx.SndAddr, tx.Nonce)),
+		Size:   int64(estimatedSizeOfBoundedTxFields),
+	}, nil
+}
+
+func (stub *txImportHandlerStub) VerifyTx(tx *transaction.Transaction) error {
+	stub.numVerified++
+	if bytes.Equal(tx.Signature, []byte("bad")) {
+		return errInvalidSignature
+	}
+
+	return nil
+}
+
+func (stub *txImportHandlerStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+func TestTxCache_ImportFromPeer(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		_, _, _, err := cache.ImportFromPeer(nil, TrustLevelFull)
+		require.Equal(t, common.ErrNilReader, err)
+
+		_, _, _, err = cache.ImportFromPeer(&bytes.Buffer{}, TrustLevel(42))
+		require.Equal(t, common.ErrInvalidTrustLevel, err)
+
+		_, _, _, err = cache.ImportFromPeer(&bytes.Buffer{}, TrustLevelFull)
+		require.Equal(t, common.ErrNilTxImportHandler, err)
+
+		err = cache.SetTxImportHandler(nil)
+		require.Equal(t, common.ErrNilTxImportHandler, err)
+
+		var nilCache *TxCache
+		err = nilCache.SetTxImportHandler(&txImportHandlerStub{})
+		require.Equal(t, common.ErrNilCacher, err)
+
+		_, _, _, err = nilCache.ImportFromPeer(&bytes.Buffer{}, TrustLevelNone)
+		require.Equal(t, common.ErrNilCacher, err)
+	})
+
+	t.Run("should import what ExportSorted writes", func(t *testing.T) {
+		source := newUnconstrainedCacheToTest()
+		source.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		source.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		source.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+
+		buffer := &bytes.Buffer{}
+		err := source.ExportSorted(buffer, 1, nil)
+		require.Nil(t, err)
+
+		destination := newUnconstrainedCacheToTest()
+		handler := &txImportHandlerStub{}
+		_ = destination.SetTxImportHandler(handler)
+		destination.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+
+		imported, skipped, invalid, err := destination.ImportFromPeer(buffer, TrustLevelFull)
+		require.Nil(t, err)
+		require.Equal(t, 2, imported)
+		require.Equal(t, 1, skipped)
+		require.Equal(t, 0, invalid)
+		require.Equal(t, 3, handler.numVerified)
+		require.Equal(t, uint64(3), destination.CountTx())
+		require.ElementsMatch(t, source.Keys(), destination.Keys())
+	})
+
+	t.Run("should verify signatures depending on the trust level", func(t *testing.T) {
+		records := writeImportRecords(t,
+			&transaction.Transaction{SndAddr: []byte("alice"), Nonce: 1, Signature: []byte("good")},
+			&transaction.Transaction{SndAddr: []byte("alice"), Nonce: 2, Signature: []byte("bad")},
+		)
+
+		cache := newUnconstrainedCacheToTest()
+		handler := &txImportHandlerStub{}
+		_ = cache.SetTxImportHandler(handler)
+
+		imported, skipped, invalid, err := cache.ImportFromPeer(bytes.NewReader(records), TrustLevelFull)
+		require.Nil(t, err)
+		require.Equal(t, 1, imported)
+		require.Equal(t, 0, skipped)
+		require.Equal(t, 1, invalid)
+
+		cache = newUnconstrainedCacheToTest()
+		handler = &txImportHandlerStub{}
+		_ = cache.SetTxImportHandler(handler)
+
+		imported, skipped, invalid, err = cache.ImportFromPeer(bytes.NewReader(records), TrustLevelNone)
+		require.Nil(t, err)
+		require.Equal(t, 2, imported)
+		require.Equal(t, 0, skipped)
+		require.Equal(t, 0, invalid)
+		require.Equal(t, 0, handler.numVerified)
+	})
+
+	t.Run("should count undecodable records as invalid", func(t *testing.T) {
+		records := writeImportRecords(t, &transaction.Transaction{SndAddr: []byte("alice"), Nonce: 1})
+		garbage := []byte{0, 0, 0, 3, 0xff, 0xff, 0xff}
+		records = append(garbage, records...)
+
+		cache := newUnconstrainedCacheToTest()
+		_ = cache.SetTxImportHandler(&txImportHandlerStub{})
+
+		imported, _, invalid, err := cache.ImportFromPeer(bytes.NewReader(records), TrustLevelNone)
+		require.Nil(t, err)
+		require.Equal(t, 1, imported)
+		require.Equal(t, 1, invalid)
+	})
+
+	t.Run("should error on truncated or corrupted stream", func(t *testing.T) {
+		records := writeImportRecords(t,
+			&transaction.Transaction{SndAddr: []byte("alice"), Nonce: 1},
+			&transaction.Transaction{SndAddr: []byte("alice"), Nonce: 2},
+		)
+
+		cache := newUnconstrainedCacheToTest()
+		_ = cache.SetTxImportHandler(&txImportHandlerStub{})
+
+		imported, _, _, err := cache.ImportFromPeer(bytes.NewReader(records[:len(records)-1]), TrustLevelNone)
+		require.Equal(t, io.ErrUnexpectedEOF, err)
+		require.Equal(t, 1, imported)
+
+		tooLarge := []byte{0xff, 0xff, 0xff, 0xff}
+		_, _, _, err = cache.ImportFromPeer(bytes.NewReader(tooLarge), TrustLevelNone)
+		require.Equal(t, common.ErrImportRecordTooLarge, err)
+	})
+}
+
+func writeImportRecords(t *testing.T, txs ...*transaction.Transaction) []byte {
+	buffer := &bytes.Buffer{}
+	lengthPrefix := make([]byte, exportLengthPrefixSize)
+
+	for _, tx := range txs {
+		buff, err := tx.Marshal()
+		require.Nil(t, err)
+
+		binary.BigEndian.PutUint32(lengthPrefix, uint32(len(buff)))
+		buffer.Write(lengthPrefix)
+		buffer.Write(buff)
+	}
+
+	return buffer.Bytes()
+}
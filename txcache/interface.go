@@ -2,6 +2,7 @@ package txcache
 
 import (
 	"github.com/multiversx/mx-chain-core-go/data"
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
 )
 
 type scoreComputer interface {
@@ -21,3 +22,24 @@ type TxGasHandler interface {
 
 // ForEachTransaction is an iterator callback
 type ForEachTransaction func(txHash []byte, value *WrappedTransaction)
+
+// ForEachSender is an iterator callback over the senders in the cache; the iteration stops when it returns false
+type ForEachSender func(sender []byte, numTxs uint64, numBytes uint64) bool
+
+// SenderPredicate decides whether a sender (along with its transactions) should be removed from the cache
+type SenderPredicate func(sender []byte, numTxs uint64, numBytes uint64) bool
+
+// RandomSource provides the randomness of the weighted sampling of senders (e.g. *rand.Rand)
+type RandomSource interface {
+	// Int63n returns a non-negative pseudo-random number in [0, n)
+	Int63n(n int64) int64
+}
+
+// TxImportHandler prepares the transactions imported from a peer (see TxCache.ImportFromPeer)
+type TxImportHandler interface {
+	// WrapTx computes the hash, the size and the shard of an imported transaction
+	WrapTx(tx *transaction.Transaction) (*WrappedTransaction, error)
+	// VerifyTx verifies the signature of an imported transaction
+	VerifyTx(tx *transaction.Transaction) error
+	IsInterfaceNil() bool
+}
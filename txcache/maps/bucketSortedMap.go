@@ -1,9 +1,13 @@
 package maps
 
 import (
+	"sort"
 	"sync"
 )
 
+// HashFunc computes the hash of a key, used to assign the key to a chunk (and to a score chunk)
+type HashFunc func(key string) uint32
+
 // BucketSortedMap is
 type BucketSortedMap struct {
 	mutex        sync.RWMutex
@@ -12,27 +16,56 @@ type BucketSortedMap struct {
 	maxScore     uint32
 	chunks       []*MapChunk
 	scoreChunks  []*MapChunk
+	hashFunc     HashFunc
+
+	// mutScoreMapping guards the mapping of the scores to the score chunks (changed by RebalanceScoreChunks)
+	mutScoreMapping  sync.RWMutex
+	scoreChunkRanges []scoreChunkRange
 }
 
 // MapChunk is
 type MapChunk struct {
 	items map[string]BucketSortedMapItem
-	mutex sync.RWMutex
+	// scores holds the scores of the items (only for the score chunks)
+	scores map[string]uint32
+	index  uint32
+	mutex  sync.RWMutex
+}
+
+// scoreChunkRange holds the (consecutive) score chunks of a score
+type scoreChunkRange struct {
+	first uint32
+	last  uint32
+}
+
+func (scoreRange scoreChunkRange) contains(chunkIndex uint32) bool {
+	return chunkIndex >= scoreRange.first && chunkIndex <= scoreRange.last
+}
+
+// pickChunkIndex spreads the items of a score evenly among the score chunks of the score
+func (scoreRange scoreChunkRange) pickChunkIndex(keyHash uint32) uint32 {
+	width := scoreRange.last - scoreRange.first + 1
+	return scoreRange.first + keyHash%width
 }
 
-// NewBucketSortedMap creates a new map.
-func NewBucketSortedMap(nChunks uint32, nScoreChunks uint32) *BucketSortedMap {
+// NewBucketSortedMap creates a new map. The hash function routes the keys to the chunks; if nil, FNV-1 (32 bits) is used.
+// Callers with knowledge about the distribution of the keys (e.g. having a common prefix) can provide a function which spreads them more uniformly.
+func NewBucketSortedMap(nChunks uint32, nScoreChunks uint32, hashFunc HashFunc) *BucketSortedMap {
 	if nChunks == 0 {
 		nChunks = 1
 	}
 	if nScoreChunks == 0 {
 		nScoreChunks = 1
 	}
+	if hashFunc == nil {
+		hashFunc = fnv32Hash
+	}
 
 	sortedMap := BucketSortedMap{
 		nChunks:      nChunks,
 		nScoreChunks: nScoreChunks,
 		maxScore:     nScoreChunks - 1,
+		hashFunc:     hashFunc,
 	}
 
 	sortedMap.initializeChunks()
@@ -56,9 +89,17 @@ func (sortedMap *BucketSortedMap) initializeChunks() {
 
 	for i := uint32(0); i < sortedMap.nScoreChunks; i++ {
 		sortedMap.scoreChunks[i] = &MapChunk{
-			items: make(map[string]BucketSortedMapItem),
+			items:  make(map[string]BucketSortedMapItem),
+			scores: make(map[string]uint32),
+			index:  i,
 		}
 	}
+
+	// Initially, each score has its own score chunk
+	sortedMap.scoreChunkRanges = make([]scoreChunkRange, sortedMap.maxScore+1)
+	for score := range sortedMap.scoreChunkRanges {
+		sortedMap.scoreChunkRanges[score] = scoreChunkRange{first: uint32(score), last: uint32(score)}
+	}
 }
 
 // Set puts the item in the map
@@ -68,18 +109,113 @@ func (sortedMap *BucketSortedMap) Set(item BucketSortedMapItem) {
 	chunk.setItem(item)
 }
 
-// NotifyScoreChange moves or adds the item to the corresponding score chunk
-func (sortedMap *BucketSortedMap) NotifyScoreChange(item BucketSortedMapItem, newScore uint32) {
+// NotifyScoreChange moves or adds the item to the corresponding score chunk.
+// If the item isn't (anymore) held by the map, e.g. it has been removed concurrently, this is a no-op and returns false,
+// so that a removed item is never resurrected in the score chunks.
+func (sortedMap *BucketSortedMap) NotifyScoreChange(item BucketSortedMapItem, newScore uint32) bool {
+	if !sortedMap.holdsItem(item) {
+		return false
+	}
+
 	if newScore > sortedMap.maxScore {
 		newScore = sortedMap.maxScore
 	}
 
-	newScoreChunk := sortedMap.getScoreChunks()[newScore]
-	if newScoreChunk != item.GetScoreChunk() {
+	sortedMap.mutScoreMapping.RLock()
+	defer sortedMap.mutScoreMapping.RUnlock()
+
+	scoreChunks := sortedMap.getScoreChunks()
+	scoreRange := sortedMap.scoreChunkRanges[newScore]
+	currentScoreChunk := item.GetScoreChunk()
+	isInRange := currentScoreChunk != nil &&
+		scoreRange.contains(currentScoreChunk.index) &&
+		scoreChunks[currentScoreChunk.index] == currentScoreChunk
+
+	if isInRange {
+		currentScoreChunk.setItemWithScore(item, newScore)
+	} else {
 		removeFromScoreChunk(item)
-		newScoreChunk.setItem(item)
+		newScoreChunk := scoreChunks[scoreRange.pickChunkIndex(sortedMap.hashFunc(item.GetKey()))]
+		newScoreChunk.setItemWithScore(item, newScore)
 		item.SetScoreChunk(newScoreChunk)
 	}
+
+	// The item might have been removed while being moved (and the removal might have missed the new score chunk)
+	if !sortedMap.holdsItem(item) {
+		removeFromScoreChunk(item)
+		item.SetScoreChunk(nil)
+		return false
+	}
+
+	return true
+}
+
+// holdsItem returns true if the given item (not just an item with the same key) is held by the map
+func (sortedMap *BucketSortedMap) holdsItem(item BucketSortedMapItem) bool {
+	heldItem, ok := sortedMap.Get(item.GetKey())
+	return ok && heldItem == item
+}
+
+// SwapScoreChunks exchanges the score chunks of the two items. Both score chunks are locked during the operation,
+// so that readers never observe a state where the items are in the same chunk or missing from the score chunks.
+// Returns false (and does nothing) if any of the items isn't held in a score chunk of the map.
+func (sortedMap *BucketSortedMap) SwapScoreChunks(first BucketSortedMapItem, second BucketSortedMapItem) bool {
+	firstScoreChunk := first.GetScoreChunk()
+	secondScoreChunk := second.GetScoreChunk()
+	if firstScoreChunk == nil || secondScoreChunk == nil {
+		return false
+	}
+	if firstScoreChunk == secondScoreChunk {
+		return true
+	}
+
+	sortedMap.mutScoreMapping.RLock()
+	defer sortedMap.mutScoreMapping.RUnlock()
+
+	firstIndex, secondIndex := -1, -1
+	for i, chunk := range sortedMap.getScoreChunks() {
+		if chunk == firstScoreChunk {
+			firstIndex = i
+		}
+		if chunk == secondScoreChunk {
+			secondIndex = i
+		}
+	}
+	if firstIndex < 0 || secondIndex < 0 {
+		return false
+	}
+
+	// Always lock in the order of the score, to avoid deadlocks
+	if firstIndex < secondIndex {
+		firstScoreChunk.mutex.Lock()
+		secondScoreChunk.mutex.Lock()
+	} else {
+		secondScoreChunk.mutex.Lock()
+		firstScoreChunk.mutex.Lock()
+	}
+	defer firstScoreChunk.mutex.Unlock()
+	defer secondScoreChunk.mutex.Unlock()
+
+	firstKey := first.GetKey()
+	secondKey := second.GetKey()
+
+	// Each item takes the score (as known by the map) of the other one, so that the scores remain consistent with the chunks
+	firstScore := firstScoreChunk.scores[firstKey]
+	secondScore := secondScoreChunk.scores[secondKey]
+
+	delete(firstScoreChunk.items, firstKey)
+	delete(firstScoreChunk.scores, firstKey)
+	delete(secondScoreChunk.items, secondKey)
+	delete(secondScoreChunk.scores, secondKey)
+	firstScoreChunk.items[secondKey] = second
+	firstScoreChunk.scores[secondKey] = firstScore
+	secondScoreChunk.items[firstKey] = first
+	secondScoreChunk.scores[firstKey] = secondScore
+
+	first.SetScoreChunk(secondScoreChunk)
+	second.SetScoreChunk(firstScoreChunk)
+
+	return true
 }
 
 func removeFromScoreChunk(item BucketSortedMapItem) {
@@ -112,7 +248,9 @@ func (sortedMap *BucketSortedMap) Remove(key string) (interface{}, bool) {
 	chunk := sortedMap.getChunk(key)
 	item := chunk.removeItemByKey(key)
 	if item != nil {
+		sortedMap.mutScoreMapping.RLock()
 		removeFromScoreChunk(item)
+		sortedMap.mutScoreMapping.RUnlock()
 	}
 
 	return item, item != nil
@@ -122,7 +260,7 @@ func (sortedMap *BucketSortedMap) Remove(key string) (interface{}, bool) {
 func (sortedMap *BucketSortedMap) getChunk(key string) *MapChunk {
 	sortedMap.mutex.RLock()
 	defer sortedMap.mutex.RUnlock()
-	return sortedMap.chunks[fnv32Hash(key)%sortedMap.nChunks]
+	return sortedMap.chunks[sortedMap.hashFunc(key)%sortedMap.nChunks]
 }
 
 // fnv32Hash implements https://en.wikipedia.org/wiki/Fowler–Noll–Vo_hash_function for 32 bits
@@ -138,11 +276,113 @@ func fnv32Hash(key string) uint32 {
 
 // Clear clears the map
 func (sortedMap *BucketSortedMap) Clear() {
+	sortedMap.mutScoreMapping.Lock()
+	defer sortedMap.mutScoreMapping.Unlock()
+
 	// There is no need to explicitly remove each item for each chunk
 	// The garbage collector will remove the data from memory
 	sortedMap.initializeChunks()
 }
 
+// RebalanceScoreChunks recomputes the mapping of the scores to the score chunks, based on the current distribution of the scores
+// (quantile-based), so that the sorted items are spread evenly among the score chunks. The items are reassigned accordingly,
+// and the order is preserved: the items of a score chunk never have a higher score than the items of the next score chunks
+// (a score might span several consecutive score chunks, while several scores might share a score chunk, thus be iterated in no particular order).
+// The items added later (or whose score changes) follow the new mapping.
+// This is an expensive maintenance operation: all the score chunks are locked while the items are reassigned.
+func (sortedMap *BucketSortedMap) RebalanceScoreChunks() {
+	sortedMap.mutScoreMapping.Lock()
+	defer sortedMap.mutScoreMapping.Unlock()
+
+	scoreChunks := sortedMap.getScoreChunks()
+	for _, chunk := range scoreChunks {
+		chunk.mutex.Lock()
+	}
+	defer func() {
+		for _, chunk := range scoreChunks {
+			chunk.mutex.Unlock()
+		}
+	}()
+
+	items := make([]scoredItem, 0)
+	for _, chunk := range scoreChunks {
+		for key, item := range chunk.items {
+			// Skip the items removed in the meantime (their removal from the score chunks is pending)
+			if sortedMap.holdsItem(item) {
+				items = append(items, scoredItem{item: item, score: chunk.scores[key]})
+			}
+		}
+
+		chunk.items = make(map[string]BucketSortedMapItem)
+		chunk.scores = make(map[string]uint32)
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].score != items[j].score {
+			return items[i].score < items[j].score
+		}
+		return items[i].item.GetKey() < items[j].item.GetKey()
+	})
+
+	sortedMap.scoreChunkRanges = computeScoreChunkRanges(items, sortedMap.maxScore, sortedMap.nScoreChunks)
+
+	for position, scored := range items {
+		chunk := scoreChunks[computeQuantileChunkIndex(position, len(items), sortedMap.nScoreChunks)]
+		key := scored.item.GetKey()
+		chunk.items[key] = scored.item
+		chunk.scores[key] = scored.score
+		scored.item.SetScoreChunk(chunk)
+	}
+}
+
+type scoredItem struct {
+	item  BucketSortedMapItem
+	score uint32
+}
+
+// computeQuantileChunkIndex returns the score chunk of the item at the given position (within the items sorted by score)
+func computeQuantileChunkIndex(position int, numItems int, nScoreChunks uint32) uint32 {
+	index := uint64(position) * uint64(nScoreChunks) / uint64(numItems)
+	if index >= uint64(nScoreChunks) {
+		return nScoreChunks - 1
+	}
+
+	return uint32(index)
+}
+
+// computeScoreChunkRanges computes the (non-overlapping, ascending) ranges of score chunks for each score,
+// given the items sorted by score. The scores without items get the score chunk at their quantile.
+func computeScoreChunkRanges(sortedItems []scoredItem, maxScore uint32, nScoreChunks uint32) []scoreChunkRange {
+	ranges := make([]scoreChunkRange, maxScore+1)
+	numItems := len(sortedItems)
+
+	if numItems == 0 {
+		for score := range ranges {
+			ranges[score] = scoreChunkRange{first: uint32(score), last: uint32(score)}
+		}
+		return ranges
+	}
+
+	position := 0
+	for score := range ranges {
+		numItemsOfScore := 0
+		for position+numItemsOfScore < numItems && sortedItems[position+numItemsOfScore].score == uint32(score) {
+			numItemsOfScore++
+		}
+
+		first := computeQuantileChunkIndex(position, numItems, nScoreChunks)
+		last := first
+		if numItemsOfScore > 0 {
+			last = computeQuantileChunkIndex(position+numItemsOfScore-1, numItems, nScoreChunks)
+		}
+
+		ranges[score] = scoreChunkRange{first: first, last: last}
+		position += numItemsOfScore
+	}
+
+	return ranges
+}
+
 // Count returns the number of elements within the map
 func (sortedMap *BucketSortedMap) Count() uint32 {
 	count := uint32(0)
@@ -179,6 +419,28 @@ func (sortedMap *BucketSortedMap) ScoreChunksCounts() []uint32 {
 	return counts
 }
 
+// ChunkCount holds the number of items within a chunk
+type ChunkCount struct {
+	ChunkIndex uint32
+	Count      uint32
+}
+
+// ScoreChunksHistogram returns the number of elements by score chunk (one entry for each chunk, including the empty ones),
+// along with the total number of sorted elements. The total is the sum of the returned counts.
+func (sortedMap *BucketSortedMap) ScoreChunksHistogram() ([]ChunkCount, uint32) {
+	scoreChunks := sortedMap.getScoreChunks()
+	histogram := make([]ChunkCount, len(scoreChunks))
+	total := uint32(0)
+
+	for i, chunk := range scoreChunks {
+		count := chunk.countItems()
+		histogram[i] = ChunkCount{ChunkIndex: uint32(i), Count: count}
+		total += count
+	}
+
+	return histogram, total
+}
+
 // SortedMapIterCb is an iterator callback
 type SortedMapIterCb func(key string, value BucketSortedMapItem)
 
@@ -251,6 +513,28 @@ func (sortedMap *BucketSortedMap) IterCbSortedDescending(callback SortedMapIterC
 	}
 }
 
+// SortedMapWeakIterCb is an iterator callback; iteration stops when it returns false
+type SortedMapWeakIterCb func(key string, value BucketSortedMapItem) bool
+
+// IterCbSortedAscendingWeakly iterates over the sorted elements in the map, score chunk by score chunk, without holding any lock
+// while the callback is invoked. Each score chunk is read-locked only for the time needed to copy its items.
+// The iteration is weakly consistent: under concurrent mutation, an item that changes its score chunk during the iteration
+// might be missed or visited twice, and items added or removed during the iteration might (or might not) be visited.
+// Useful for long-running diagnostics, which do not require a consistent snapshot.
+func (sortedMap *BucketSortedMap) IterCbSortedAscendingWeakly(callback SortedMapWeakIterCb) {
+	if callback == nil {
+		return
+	}
+
+	for _, chunk := range sortedMap.getScoreChunks() {
+		for _, item := range chunk.getItemsSnapshot() {
+			if !callback(item.GetKey(), item) {
+				return
+			}
+		}
+	}
+}
+
 // Keys returns all keys as []string
 func (sortedMap *BucketSortedMap) Keys() []string {
 	count := sortedMap.Count()
@@ -295,6 +579,7 @@ func (chunk *MapChunk) removeItem(item BucketSortedMapItem) {
 
 	key := item.GetKey()
 	delete(chunk.items, key)
+	delete(chunk.scores, key)
 }
 
 func (chunk *MapChunk) removeItemByKey(key string) BucketSortedMapItem {
@@ -314,6 +599,15 @@ func (chunk *MapChunk) setItem(item BucketSortedMapItem) {
 	chunk.items[key] = item
 }
 
+func (chunk *MapChunk) setItemWithScore(item BucketSortedMapItem, score uint32) {
+	chunk.mutex.Lock()
+	defer chunk.mutex.Unlock()
+
+	key := item.GetKey()
+	chunk.items[key] = item
+	chunk.scores[key] = score
+}
+
 func (chunk *MapChunk) countItems() uint32 {
 	chunk.mutex.RLock()
 	defer chunk.mutex.RUnlock()
@@ -330,6 +624,18 @@ func (chunk *MapChunk) forEachItem(callback SortedMapIterCb) {
 	}
 }
 
+func (chunk *MapChunk) getItemsSnapshot() []BucketSortedMapItem {
+	chunk.mutex.RLock()
+	defer chunk.mutex.RUnlock()
+
+	items := make([]BucketSortedMapItem, 0, len(chunk.items))
+	for _, item := range chunk.items {
+		items = append(items, item)
+	}
+
+	return items
+}
+
 func (chunk *MapChunk) appendKeys(keysAccumulator []string) []string {
 	chunk.mutex.RLock()
 	defer chunk.mutex.RUnlock()
@@ -340,3 +646,8 @@ func (chunk *MapChunk) appendKeys(keysAccumulator []string) []string {
 
 	return keysAccumulator
 }
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (sortedMap *BucketSortedMap) IsInterfaceNil() bool {
+	return sortedMap == nil
+}
@@ -2,6 +2,7 @@ package maps
 
 import (
 	"fmt"
+	"math"
 	"sync"
 	"testing"
 
@@ -66,20 +67,52 @@ func simulateMutationThatChangesScore(myMap *BucketSortedMap, key string) {
 }
 
 func TestNewBucketSortedMap(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 	require.Equal(t, uint32(4), myMap.nChunks)
 	require.Equal(t, 4, len(myMap.chunks))
 	require.Equal(t, uint32(100), myMap.nScoreChunks)
 	require.Equal(t, 100, len(myMap.scoreChunks))
 
 	// 1 is minimum number of chunks
-	myMap = NewBucketSortedMap(0, 0)
+	myMap = NewBucketSortedMap(0, 0, nil)
 	require.Equal(t, uint32(1), myMap.nChunks)
 	require.Equal(t, uint32(1), myMap.nScoreChunks)
 }
 
+func TestBucketSortedMap_HashFunc(t *testing.T) {
+	// Keys sharing a long prefix, distinguished by their last byte
+	keys := []string{"shard-0-sender-0", "shard-0-sender-1", "shard-0-sender-2", "shard-0-sender-3"}
+	lastByteHash := func(key string) uint32 {
+		return uint32(key[len(key)-1])
+	}
+
+	myMap := NewBucketSortedMap(4, 100, lastByteHash)
+	for _, key := range keys {
+		myMap.Set(newDummyItem(key))
+	}
+
+	// Spread uniformly, by the provided function
+	for i, chunk := range myMap.getChunks() {
+		require.Len(t, chunk.items, 1)
+		for key := range chunk.items {
+			require.Equal(t, uint32(i), lastByteHash(key)%4)
+		}
+	}
+
+	for _, key := range keys {
+		_, ok := myMap.Get(key)
+		require.True(t, ok)
+	}
+
+	// The default is FNV-1
+	myMap = NewBucketSortedMap(4, 100, nil)
+	myMap.Set(newDummyItem(keys[0]))
+	_, ok := myMap.chunks[fnv32Hash(keys[0])%4].items[keys[0]]
+	require.True(t, ok)
+}
+
 func TestBucketSortedMap_Count(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 	myMap.Set(newScoredDummyItem("a", 0))
 	myMap.Set(newScoredDummyItem("b", 1))
 	myMap.Set(newScoredDummyItem("c", 2))
@@ -107,7 +140,7 @@ func TestBucketSortedMap_Count(t *testing.T) {
 }
 
 func TestBucketSortedMap_Keys(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 	myMap.Set(newDummyItem("a"))
 	myMap.Set(newDummyItem("b"))
 	myMap.Set(newDummyItem("c"))
@@ -121,7 +154,7 @@ func TestBucketSortedMap_Keys(t *testing.T) {
 }
 
 func TestBucketSortedMap_KeysSorted(t *testing.T) {
-	myMap := NewBucketSortedMap(1, 4)
+	myMap := NewBucketSortedMap(1, 4, nil)
 
 	myMap.Set(newScoredDummyItem("d", 3))
 	myMap.Set(newScoredDummyItem("a", 0))
@@ -150,7 +183,7 @@ func TestBucketSortedMap_KeysSorted(t *testing.T) {
 }
 
 func TestBucketSortedMap_ItemMovesOnNotifyScoreChange(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 
 	a := newScoredDummyItem("a", 1)
 	b := newScoredDummyItem("b", 42)
@@ -172,8 +205,307 @@ func TestBucketSortedMap_ItemMovesOnNotifyScoreChange(t *testing.T) {
 	require.Equal(t, myMap.scoreChunks[43], b.GetScoreChunk())
 }
 
+func TestBucketSortedMap_NotifyScoreChangeOnRemovedItem(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 100, nil)
+
+	a := newScoredDummyItem("a", 15)
+	myMap.Set(a)
+	require.True(t, myMap.NotifyScoreChange(a, 15))
+	require.Equal(t, uint32(1), myMap.CountSorted())
+
+	_, _ = myMap.Remove("a")
+	require.False(t, myMap.NotifyScoreChange(a, 42))
+	require.Equal(t, uint32(0), myMap.Count())
+	require.Equal(t, uint32(0), myMap.CountSorted())
+
+	// An item never added to the map isn't added to the score chunks, either
+	require.False(t, myMap.NotifyScoreChange(newScoredDummyItem("b", 3), 3))
+	require.Equal(t, uint32(0), myMap.CountSorted())
+
+	// Another item, with the same key, doesn't make the stale one eligible for score changes
+	aPrime := newScoredDummyItem("a", 7)
+	myMap.Set(aPrime)
+	require.True(t, myMap.NotifyScoreChange(aPrime, 7))
+	require.False(t, myMap.NotifyScoreChange(a, 42))
+	require.Equal(t, []BucketSortedMapItem{aPrime}, myMap.GetSnapshotAscending())
+}
+
+func TestBucketSortedMap_NotifyScoreChangeConcurrentWithRemove(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		myMap := NewBucketSortedMap(4, 16, nil)
+		a := newScoredDummyItem("a", 0)
+		myMap.Set(a)
+		myMap.NotifyScoreChange(a, 0)
+
+		var wg sync.WaitGroup
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			for score := uint32(1); score < 16; score++ {
+				myMap.NotifyScoreChange(a, score)
+			}
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			_, _ = myMap.Remove("a")
+		}()
+
+		wg.Wait()
+
+		require.Equal(t, uint32(0), myMap.CountSorted())
+	}
+}
+
+func TestBucketSortedMap_SwapScoreChunks(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 100, nil)
+
+	a := newScoredDummyItem("a", 1)
+	b := newScoredDummyItem("b", 42)
+	c := newScoredDummyItem("c", 42)
+	notSorted := newDummyItem("d")
+	myMap.Set(a)
+	myMap.Set(b)
+	myMap.Set(c)
+	myMap.Set(notSorted)
+	simulateMutationThatChangesScore(myMap, "a")
+	simulateMutationThatChangesScore(myMap, "b")
+	simulateMutationThatChangesScore(myMap, "c")
+
+	ok := myMap.SwapScoreChunks(a, b)
+	require.True(t, ok)
+	require.Equal(t, myMap.scoreChunks[42], a.GetScoreChunk())
+	require.Equal(t, myMap.scoreChunks[1], b.GetScoreChunk())
+	require.Equal(t, []uint32{1, 2}, []uint32{myMap.ScoreChunksCounts()[1], myMap.ScoreChunksCounts()[42]})
+	require.Equal(t, uint32(3), myMap.CountSorted())
+
+	// Swapping back
+	ok = myMap.SwapScoreChunks(b, a)
+	require.True(t, ok)
+	require.Equal(t, myMap.scoreChunks[1], a.GetScoreChunk())
+	require.Equal(t, myMap.scoreChunks[42], b.GetScoreChunk())
+
+	// Same chunk
+	ok = myMap.SwapScoreChunks(b, c)
+	require.True(t, ok)
+	require.Equal(t, myMap.scoreChunks[42], b.GetScoreChunk())
+	require.Equal(t, myMap.scoreChunks[42], c.GetScoreChunk())
+
+	// Item not in a score chunk
+	ok = myMap.SwapScoreChunks(a, notSorted)
+	require.False(t, ok)
+	require.Equal(t, myMap.scoreChunks[1], a.GetScoreChunk())
+	require.Nil(t, notSorted.GetScoreChunk())
+	require.Equal(t, uint32(3), myMap.CountSorted())
+}
+
+func TestBucketSortedMap_SwapScoreChunksConcurrentWithRead(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 100, nil)
+
+	a := newScoredDummyItem("a", 1)
+	b := newScoredDummyItem("b", 42)
+	myMap.Set(a)
+	myMap.Set(b)
+	simulateMutationThatChangesScore(myMap, "a")
+	simulateMutationThatChangesScore(myMap, "b")
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 1000; j++ {
+			require.True(t, myMap.SwapScoreChunks(a, b))
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for j := 0; j < 1000; j++ {
+			_, ok := myMap.Get("a")
+			require.True(t, ok)
+			_, ok = myMap.Get("b")
+			require.True(t, ok)
+
+			snapshot := myMap.GetSnapshotAscending()
+			require.Len(t, snapshot, 2)
+			require.NotEqual(t, snapshot[0].GetKey(), snapshot[1].GetKey())
+		}
+	}()
+
+	wg.Wait()
+
+	// An even number of swaps leaves the items in their initial chunks
+	require.Equal(t, myMap.scoreChunks[1], a.GetScoreChunk())
+	require.Equal(t, myMap.scoreChunks[42], b.GetScoreChunk())
+}
+
+func TestBucketSortedMap_ScoreChunksHistogram(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 10, nil)
+
+	histogram, total := myMap.ScoreChunksHistogram()
+	require.Len(t, histogram, 10)
+	require.Equal(t, uint32(0), total)
+
+	for i := 0; i < 100; i++ {
+		key := fmt.Sprintf("item%d", i)
+		myMap.Set(newScoredDummyItem(key, uint32(i%7)))
+		simulateMutationThatChangesScore(myMap, key)
+	}
+
+	histogram, total = myMap.ScoreChunksHistogram()
+	require.Len(t, histogram, 10)
+	require.Equal(t, uint32(100), total)
+
+	sum := uint32(0)
+	for i, pair := range histogram {
+		require.Equal(t, uint32(i), pair.ChunkIndex)
+		sum += pair.Count
+	}
+	require.Equal(t, total, sum)
+	require.Equal(t, uint32(15), histogram[0].Count)
+	require.Equal(t, uint32(14), histogram[6].Count)
+	require.Equal(t, uint32(0), histogram[7].Count)
+}
+
+func TestBucketSortedMap_RebalanceScoreChunks(t *testing.T) {
+	t.Run("empty map", func(t *testing.T) {
+		myMap := NewBucketSortedMap(4, 10, nil)
+		myMap.RebalanceScoreChunks()
+
+		item := newScoredDummyItem("a", 3)
+		myMap.Set(item)
+		simulateMutationThatChangesScore(myMap, "a")
+		require.Equal(t, myMap.scoreChunks[3], item.GetScoreChunk())
+	})
+
+	t.Run("skewed scores", func(t *testing.T) {
+		myMap := NewBucketSortedMap(4, 10, nil)
+
+		// 90 items with score 1, 5 items with score 2, 5 items with score 8
+		for i := 0; i < 100; i++ {
+			score := uint32(1)
+			if i >= 90 {
+				score = 2
+			}
+			if i >= 95 {
+				score = 8
+			}
+
+			key := fmt.Sprintf("item%d", i)
+			myMap.Set(newScoredDummyItem(key, score))
+			simulateMutationThatChangesScore(myMap, key)
+		}
+
+		require.Equal(t, uint32(90), maxOfCounts(myMap.ScoreChunksCounts()))
+
+		myMap.RebalanceScoreChunks()
+
+		require.Equal(t, uint32(100), myMap.CountSorted())
+		require.LessOrEqual(t, maxOfCounts(myMap.ScoreChunksCounts()), uint32(10))
+		requireScoreChunksOrdered(t, myMap)
+
+		// Items added (or whose score changes) afterwards follow the new mapping
+		for i := 100; i < 200; i++ {
+			key := fmt.Sprintf("item%d", i)
+			myMap.Set(newScoredDummyItem(key, uint32(i%10)))
+			simulateMutationThatChangesScore(myMap, key)
+		}
+
+		require.Equal(t, uint32(200), myMap.CountSorted())
+		requireScoreChunksOrdered(t, myMap)
+		require.Len(t, myMap.GetSnapshotAscending(), 200)
+	})
+
+	t.Run("removed items are not resurrected", func(t *testing.T) {
+		myMap := NewBucketSortedMap(4, 10, nil)
+		for i := 0; i < 10; i++ {
+			key := fmt.Sprintf("item%d", i)
+			myMap.Set(newScoredDummyItem(key, 0))
+			simulateMutationThatChangesScore(myMap, key)
+		}
+
+		myMap.Remove("item0")
+		myMap.RebalanceScoreChunks()
+		require.Equal(t, uint32(9), myMap.CountSorted())
+
+		myMap.Clear()
+		myMap.Set(newScoredDummyItem("a", 5))
+		simulateMutationThatChangesScore(myMap, "a")
+		require.Equal(t, uint32(1), myMap.ScoreChunksCounts()[5])
+	})
+
+	t.Run("concurrent with mutations", func(t *testing.T) {
+		myMap := NewBucketSortedMap(4, 10, nil)
+
+		wg := sync.WaitGroup{}
+		for i := 0; i < 1000; i++ {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+
+				key := fmt.Sprintf("item%d", i%100)
+				switch i % 10 {
+				case 0:
+					myMap.RebalanceScoreChunks()
+				case 1:
+					myMap.Remove(key)
+				default:
+					myMap.Set(newScoredDummyItem(key, uint32(i%3)))
+					simulateMutationThatChangesScore(myMap, key)
+				}
+			}(i)
+		}
+		wg.Wait()
+
+		myMap.RebalanceScoreChunks()
+		require.Equal(t, myMap.Count(), myMap.CountSorted())
+		requireScoreChunksOrdered(t, myMap)
+	})
+}
+
+func maxOfCounts(counts []uint32) uint32 {
+	maxCount := uint32(0)
+	for _, count := range counts {
+		if count > maxCount {
+			maxCount = count
+		}
+	}
+
+	return maxCount
+}
+
+func requireScoreChunksOrdered(t *testing.T, myMap *BucketSortedMap) {
+	highestScoreSoFar := uint32(0)
+	for _, chunk := range myMap.scoreChunks {
+		chunk.mutex.RLock()
+		lowest, highest := uint32(math.MaxUint32), uint32(0)
+		for _, score := range chunk.scores {
+			if score < lowest {
+				lowest = score
+			}
+			if score > highest {
+				highest = score
+			}
+		}
+		chunk.mutex.RUnlock()
+
+		if len(chunk.scores) == 0 {
+			continue
+		}
+
+		require.GreaterOrEqual(t, lowest, highestScoreSoFar)
+		highestScoreSoFar = highest
+	}
+}
+
 func TestBucketSortedMap_Has(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 	myMap.Set(newDummyItem("a"))
 	myMap.Set(newDummyItem("b"))
 
@@ -183,7 +515,7 @@ func TestBucketSortedMap_Has(t *testing.T) {
 }
 
 func TestBucketSortedMap_Remove(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 	myMap.Set(newDummyItem("a"))
 	myMap.Set(newDummyItem("b"))
 
@@ -197,7 +529,7 @@ func TestBucketSortedMap_Remove(t *testing.T) {
 }
 
 func TestBucketSortedMap_Clear(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 	myMap.Set(newDummyItem("a"))
 	myMap.Set(newDummyItem("b"))
 
@@ -208,7 +540,7 @@ func TestBucketSortedMap_Clear(t *testing.T) {
 }
 
 func TestBucketSortedMap_IterCb(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 
 	myMap.Set(newScoredDummyItem("a", 15))
 	myMap.Set(newScoredDummyItem("b", 101))
@@ -236,8 +568,109 @@ func TestBucketSortedMap_IterCb(t *testing.T) {
 	require.Equal(t, 0, i+1)
 }
 
+func TestBucketSortedMap_IterCbSortedAscendingWeakly(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 100, nil)
+
+	myMap.Set(newScoredDummyItem("a", 15))
+	myMap.Set(newScoredDummyItem("b", 101))
+	myMap.Set(newScoredDummyItem("c", 3))
+	simulateMutationThatChangesScore(myMap, "a")
+	simulateMutationThatChangesScore(myMap, "b")
+	simulateMutationThatChangesScore(myMap, "c")
+
+	visited := make([]string, 0)
+	myMap.IterCbSortedAscendingWeakly(func(key string, value BucketSortedMapItem) bool {
+		visited = append(visited, key)
+		return true
+	})
+	require.Equal(t, []string{"c", "a", "b"}, visited)
+
+	// Stops when the callback returns false
+	visited = make([]string, 0)
+	myMap.IterCbSortedAscendingWeakly(func(key string, value BucketSortedMapItem) bool {
+		visited = append(visited, key)
+		return len(visited) < 2
+	})
+	require.Equal(t, []string{"c", "a"}, visited)
+
+	// Nil callback is ignored
+	myMap.IterCbSortedAscendingWeakly(nil)
+}
+
+func TestBucketSortedMap_IterCbSortedAscendingWeakly_VisitsStableSetWhenNoMutation(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 100, nil)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		myMap.Set(newScoredDummyItem(key, uint32(i%100)))
+		simulateMutationThatChangesScore(myMap, key)
+	}
+
+	collect := func() map[string]struct{} {
+		visited := make(map[string]struct{})
+		myMap.IterCbSortedAscendingWeakly(func(key string, value BucketSortedMapItem) bool {
+			_, alreadyVisited := visited[key]
+			require.False(t, alreadyVisited)
+			visited[key] = struct{}{}
+			return true
+		})
+		return visited
+	}
+
+	first := collect()
+	second := collect()
+	require.Len(t, first, 1000)
+	require.Equal(t, first, second)
+
+	for _, item := range myMap.GetSnapshotAscending() {
+		require.Contains(t, first, item.GetKey())
+	}
+}
+
+func TestBucketSortedMap_IterCbSortedAscendingWeakly_NoPanicIfConcurrentMutation(t *testing.T) {
+	myMap := NewBucketSortedMap(4, 100, nil)
+
+	for i := 0; i < 1000; i++ {
+		key := fmt.Sprintf("item-%d", i)
+		myMap.Set(newScoredDummyItem(key, uint32(i%100)))
+		simulateMutationThatChangesScore(myMap, key)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			myMap.IterCbSortedAscendingWeakly(func(key string, value BucketSortedMapItem) bool {
+				return true
+			})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			key := fmt.Sprintf("item-%d", i)
+			item, ok := myMap.Get(key)
+			if ok {
+				itemAsDummy := item.(*dummyItem)
+				itemAsDummy.score.Set(uint32((i + 50) % 100))
+				itemAsDummy.simulateMutationThatChangesScore(myMap)
+			}
+			if i%3 == 0 {
+				myMap.Remove(key)
+			}
+		}
+	}()
+
+	wg.Wait()
+}
+
 func TestBucketSortedMap_GetSnapshotAscending(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 
 	snapshot := myMap.GetSnapshotAscending()
 	require.Equal(t, []BucketSortedMapItem{}, snapshot)
@@ -259,7 +692,7 @@ func TestBucketSortedMap_GetSnapshotAscending(t *testing.T) {
 }
 
 func TestBucketSortedMap_GetSnapshotDescending(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 100)
+	myMap := NewBucketSortedMap(4, 100, nil)
 
 	snapshot := myMap.GetSnapshotDescending()
 	require.Equal(t, []BucketSortedMapItem{}, snapshot)
@@ -287,7 +720,7 @@ func TestBucketSortedMap_AddManyItems(t *testing.T) {
 	numItemsInScoreChunkPerGoroutine := numItemsPerGoroutine / numScoreChunks
 	numItemsInScoreChunk := numItemsInScoreChunkPerGoroutine * numGoroutines
 
-	myMap := NewBucketSortedMap(16, uint32(numScoreChunks))
+	myMap := NewBucketSortedMap(16, uint32(numScoreChunks), nil)
 
 	var waitGroup sync.WaitGroup
 	waitGroup.Add(numGoroutines)
@@ -318,7 +751,7 @@ func TestBucketSortedMap_AddManyItems(t *testing.T) {
 func TestBucketSortedMap_ClearConcurrentWithRead(t *testing.T) {
 	numChunks := uint32(4)
 	numScoreChunks := uint32(4)
-	myMap := NewBucketSortedMap(numChunks, numScoreChunks)
+	myMap := NewBucketSortedMap(numChunks, numScoreChunks, nil)
 
 	var wg sync.WaitGroup
 	wg.Add(2)
@@ -357,7 +790,7 @@ func TestBucketSortedMap_ClearConcurrentWithRead(t *testing.T) {
 }
 
 func TestBucketSortedMap_ClearConcurrentWithWrite(t *testing.T) {
-	myMap := NewBucketSortedMap(4, 4)
+	myMap := NewBucketSortedMap(4, 4, nil)
 
 	var wg sync.WaitGroup
 	wg.Add(2)
@@ -388,7 +821,7 @@ func TestBucketSortedMap_NoForgottenItemsOnConcurrentScoreChanges(t *testing.T)
 	// This test helped us to find a memory leak occuring on concurrent score changes (concurrent movements across buckets)
 
 	for i := 0; i < 1000; i++ {
-		myMap := NewBucketSortedMap(16, 16)
+		myMap := NewBucketSortedMap(16, 16, nil)
 		a := newScoredDummyItem("a", 0)
 		myMap.Set(a)
 		simulateMutationThatChangesScore(myMap, "a")
@@ -419,3 +852,11 @@ func TestBucketSortedMap_NoForgottenItemsOnConcurrentScoreChanges(t *testing.T)
 		require.Equal(t, uint32(0), myMap.Count())
 	}
 }
+
+func TestBucketSortedMap_IsInterfaceNil(t *testing.T) {
+	var myMap *BucketSortedMap
+	require.True(t, myMap.IsInterfaceNil())
+
+	myMap = NewBucketSortedMap(4, 100, nil)
+	require.False(t, myMap.IsInterfaceNil())
+}
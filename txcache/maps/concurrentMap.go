@@ -178,3 +178,8 @@ func (m *ConcurrentMap) getChunks() []*concurrentMapChunk {
 	defer m.mutex.RUnlock()
 	return m.chunks
 }
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (m *ConcurrentMap) IsInterfaceNil() bool {
+	return m == nil
+}
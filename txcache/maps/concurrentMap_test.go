@@ -158,3 +158,11 @@ func TestConcurrentMap_IterCb(t *testing.T) {
 
 	require.Equal(t, 3, i)
 }
+
+func TestConcurrentMap_IsInterfaceNil(t *testing.T) {
+	var myMap *ConcurrentMap
+	require.True(t, myMap.IsInterfaceNil())
+
+	myMap = NewConcurrentMap(4)
+	require.False(t, myMap.IsInterfaceNil())
+}
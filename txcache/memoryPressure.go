@@ -0,0 +1,118 @@
+package txcache
+
+import (
+	"context"
+	"runtime"
+	"time"
+)
+
+func readHeapInUse() uint64 {
+	var memStats runtime.MemStats
+	runtime.ReadMemStats(&memStats)
+	return memStats.HeapInuse
+}
+
+// RemoveSendersByPredicate removes the senders (along with their transactions) for which the predicate holds.
+// The senders are visited in the ascending order of their score (lowest-scored senders first).
+// It returns the number of removed transactions and senders.
+func (cache *TxCache) RemoveSendersByPredicate(predicate SenderPredicate) (numTxs uint32, numSenders uint32) {
+	if predicate == nil {
+		return 0, 0
+	}
+
+	snapshot := cache.txListBySender.getSnapshotAscending()
+	listsToRemove := make([]*txListForSender, 0)
+
+	for _, txList := range snapshot {
+		numBytes := txList.totalBytes.GetUint64()
+		if predicate([]byte(txList.sender), txList.countTxWithLock(), numBytes) {
+			listsToRemove = append(listsToRemove, txList)
+		}
+	}
+
+	return cache.evictSendersAndTheirTxs(listsToRemove)
+}
+
+// evictOnMemoryPressure removes the lowest-scored senders if the heap in use exceeds "MaxHeapBytes".
+// Since the heap only shrinks after a garbage collection, the removal stops once the (accounted) size of the removed transactions
+// covers the excess. If the pressure persists, further senders are removed at the next check.
+func (cache *TxCache) evictOnMemoryPressure() (numTxs uint32, numSenders uint32) {
+	heapInUse := cache.heapInUseProvider()
+	if heapInUse <= cache.config.MaxHeapBytes {
+		return 0, 0
+	}
+
+	cache.evictionMutex.Lock()
+	defer cache.evictionMutex.Unlock()
+
+	_ = cache.isEvictionInProgress.SetReturningPrevious()
+	defer cache.isEvictionInProgress.Reset()
+
+	excess := heapInUse - cache.config.MaxHeapBytes
+	log.Debug("TxCache: memory pressure detected", "name", cache.name, "heapInUse", heapInUse, "maxHeapBytes", cache.config.MaxHeapBytes)
+
+	numBytesRemoved := uint64(0)
+	numTxs, numSenders = cache.RemoveSendersByPredicate(func(_ []byte, _ uint64, numBytes uint64) bool {
+		if numBytesRemoved >= excess {
+			return false
+		}
+
+		numBytesRemoved += numBytes
+		return true
+	})
+	cache.recordEviction(numTxs)
+
+	log.Debug("TxCache: memory pressure eviction ended", "name", cache.name, "numTxs", numTxs, "numSenders", numSenders, "numBytes", numBytesRemoved)
+	return numTxs, numSenders
+}
+
+func (cache *TxCache) startMemoryMonitoring() {
+	if cache.config.MaxHeapBytes == 0 {
+		return
+	}
+
+	cache.mutMemoryMonitoring.Lock()
+	defer cache.mutMemoryMonitoring.Unlock()
+
+	if cache.cancelMemoryMonitoring != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, cache.cancelMemoryMonitoring = context.WithCancel(context.Background())
+	cache.memoryMonitoringDone = make(chan struct{})
+
+	ticks, stopTicker := cache.memoryTickerFactory(cache.config.getMemoryPressureCheckInterval())
+	go cache.monitorMemoryLoop(ctx, ticks, stopTicker, cache.memoryMonitoringDone)
+}
+
+func (cache *TxCache) monitorMemoryLoop(ctx context.Context, ticks <-chan time.Time, stopTicker func(), done chan struct{}) {
+	defer close(done)
+	defer stopTicker()
+
+	for {
+		select {
+		case <-ticks:
+			_, _ = cache.evictOnMemoryPressure()
+		case <-ctx.Done():
+			log.Debug("TxCache: closing the memory monitoring goroutine", "name", cache.name)
+			return
+		}
+	}
+}
+
+// stopMemoryMonitoring stops the background goroutine (if running) and waits for it to exit
+func (cache *TxCache) stopMemoryMonitoring() {
+	cache.mutMemoryMonitoring.Lock()
+	defer cache.mutMemoryMonitoring.Unlock()
+
+	if cache.cancelMemoryMonitoring == nil {
+		return
+	}
+
+	cache.cancelMemoryMonitoring()
+	<-cache.memoryMonitoringDone
+
+	cache.cancelMemoryMonitoring = nil
+	cache.memoryMonitoringDone = nil
+}
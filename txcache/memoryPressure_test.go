@@ -0,0 +1,128 @@
+package txcache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newCacheWithMemoryPressureToTest(maxHeapBytes uint64, heapInUse *uint64) *TxCache {
+	cache := newUnconstrainedCacheToTest()
+	cache.config.MaxHeapBytes = maxHeapBytes
+	cache.heapInUseProvider = func() uint64 {
+		return atomic.LoadUint64(heapInUse)
+	}
+
+	// alice has the lowest score, carol has the highest one
+	cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 1000, 50000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 1000, 50000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 1000, 50000, uint64(1.2*oneBillion)))
+	cache.AddTx(createTxWithParams([]byte("hash-carol-1"), "carol", 1, 1000, 50000, uint64(1.5*oneBillion)))
+
+	return cache
+}
+
+func TestTxCache_RemoveSendersByPredicate(t *testing.T) {
+	heapInUse := uint64(0)
+	cache := newCacheWithMemoryPressureToTest(0, &heapInUse)
+
+	numTxs, numSenders := cache.RemoveSendersByPredicate(nil)
+	require.Equal(t, uint32(0), numTxs)
+	require.Equal(t, uint32(0), numSenders)
+
+	visited := make([]string, 0)
+	numTxs, numSenders = cache.RemoveSendersByPredicate(func(sender []byte, numTxs uint64, numBytes uint64) bool {
+		visited = append(visited, string(sender))
+		return numTxs == 1
+	})
+	require.Equal(t, []string{"alice", "bob", "carol"}, visited)
+	require.Equal(t, uint32(2), numTxs)
+	require.Equal(t, uint32(2), numSenders)
+	require.Equal(t, uint64(2), cache.CountTx())
+	require.Equal(t, uint64(1), cache.CountSenders())
+	require.NotNil(t, cache.getListForSender("alice"))
+}
+
+func TestTxCache_EvictOnMemoryPressure(t *testing.T) {
+	t.Run("no pressure", func(t *testing.T) {
+		heapInUse := uint64(10_000)
+		cache := newCacheWithMemoryPressureToTest(10_000, &heapInUse)
+
+		numTxs, numSenders := cache.evictOnMemoryPressure()
+		require.Equal(t, uint32(0), numTxs)
+		require.Equal(t, uint32(0), numSenders)
+		require.Equal(t, uint64(4), cache.CountTx())
+	})
+
+	t.Run("should evict the lowest-scored senders, to cover the excess", func(t *testing.T) {
+		heapInUse := uint64(12_500)
+		cache := newCacheWithMemoryPressureToTest(10_000, &heapInUse)
+
+		// alice (2000 bytes) and bob (1000 bytes) cover the excess of 2500 bytes
+		numTxs, numSenders := cache.evictOnMemoryPressure()
+		require.Equal(t, uint32(3), numTxs)
+		require.Equal(t, uint32(2), numSenders)
+		require.Equal(t, uint64(1), cache.CountTx())
+
+		_, ok := cache.GetByTxHash([]byte("hash-carol-1"))
+		require.True(t, ok)
+		require.False(t, cache.isEvictionInProgress.IsSet())
+	})
+}
+
+func TestTxCache_StartShouldMonitorMemoryPeriodically(t *testing.T) {
+	heapInUse := uint64(0)
+	cache := newCacheWithMemoryPressureToTest(10_000, &heapInUse)
+	ticker := newFakeTicker()
+	cache.memoryTickerFactory = ticker.factory
+
+	cache.Start()
+	require.NotNil(t, cache.cancelMemoryMonitoring)
+	require.Nil(t, cache.cancelScoreRefresh)
+
+	ticker.tick()
+	require.Equal(t, uint64(4), cache.CountTx())
+
+	atomic.StoreUint64(&heapInUse, 10_001)
+	ticker.tick()
+	require.Eventually(t, func() bool {
+		return cache.CountTx() == 2
+	}, time.Second, time.Millisecond)
+	require.Equal(t, uint64(2), cache.CountSenders())
+
+	// Pressure relieved, nothing else is evicted (the second tick is only accepted after the first one is handled)
+	atomic.StoreUint64(&heapInUse, 0)
+	ticker.tick()
+	ticker.tick()
+	require.Equal(t, uint64(2), cache.CountTx())
+
+	err := cache.Close()
+	require.Nil(t, err)
+	require.True(t, ticker.isStopped())
+	require.Nil(t, cache.cancelMemoryMonitoring)
+
+	select {
+	case ticker.ticks <- time.Now():
+		require.Fail(t, "the loop should have been stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestTxCache_StartShouldNotMonitorMemoryWhenNotConfigured(t *testing.T) {
+	heapInUse := uint64(0)
+	cache := newCacheWithMemoryPressureToTest(0, &heapInUse)
+
+	cache.Start()
+	require.Nil(t, cache.cancelMemoryMonitoring)
+	require.Nil(t, cache.Close())
+}
+
+func TestConfigSourceMe_GetMemoryPressureCheckInterval(t *testing.T) {
+	config := ConfigSourceMe{}
+	require.Equal(t, defaultMemoryPressureCheckInterval, config.getMemoryPressureCheckInterval())
+
+	config.MemoryPressureCheckInterval = time.Second
+	require.Equal(t, time.Second, config.getMemoryPressureCheckInterval())
+}
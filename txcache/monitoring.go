@@ -3,10 +3,12 @@ package txcache
 import (
 	"encoding/hex"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/multiversx/mx-chain-core-go/core"
 	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/txcache/maps"
 )
 
 var log = logger.GetOrCreate("txcache")
@@ -105,6 +107,51 @@ func (cache *TxCache) displaySendersHistogram() {
 	log.Debug("TxCache.sendersHistogram:", "chunks", backingMap.ChunksCounts(), "scoreChunks", backingMap.ScoreChunksCounts())
 }
 
+// GetScoreChunksHistogram returns the number of senders in each score chunk, along with the total number of senders in score chunks
+// The output is suitable for emitting gauges (one per score chunk).
+func (cache *TxCache) GetScoreChunksHistogram() ([]maps.ChunkCount, uint32) {
+	return cache.txListBySender.backingMap.ScoreChunksHistogram()
+}
+
+// GetTransactionsSortedGlobally returns all the transactions in the cache, sorted by the score of their sender (descending),
+// then by sender and then by nonce. The score taken into account is the last computed one.
+// This is an expensive operation (it copies and sorts the whole content of the cache), meant for debugging and offline analysis only.
+func (cache *TxCache) GetTransactionsSortedGlobally() []*WrappedTransaction {
+	type senderWithScore struct {
+		listForSender *txListForSender
+		score         uint32
+	}
+
+	snapshot := cache.txListBySender.getSnapshotDescending()
+	senders := make([]senderWithScore, 0, len(snapshot))
+	for _, listForSender := range snapshot {
+		senders = append(senders, senderWithScore{
+			listForSender: listForSender,
+			score:         listForSender.getLastComputedScore(),
+		})
+	}
+
+	sort.SliceStable(senders, func(i, j int) bool {
+		if senders[i].score != senders[j].score {
+			return senders[i].score > senders[j].score
+		}
+
+		return senders[i].listForSender.sender < senders[j].listForSender.sender
+	})
+
+	result := make([]*WrappedTransaction, 0, cache.CountTx())
+	for _, sender := range senders {
+		txs := sender.listForSender.getTxs()
+		sort.SliceStable(txs, func(i, j int) bool {
+			return txs[i].Tx.GetNonce() < txs[j].Tx.GetNonce()
+		})
+
+		result = append(result, txs...)
+	}
+
+	return result
+}
+
 // evictionJournal keeps a short journal about the eviction process
 // This is useful for debugging and reasoning about the eviction
 type evictionJournal struct {
@@ -0,0 +1,146 @@
+package txcache
+
+import (
+	"container/list"
+)
+
+// nonceSkipListMaxLevel bounds the number of levels of the skip list; with a promotion probability of 1/4,
+// it is enough for 4^16 nonce groups
+const nonceSkipListMaxLevel = 16
+
+// nonceSkipListSeed is the (arbitrary, non-zero) initial state of the generator of levels
+const nonceSkipListSeed = 0x9E3779B97F4A7C15
+
+// nonceSkipList holds the nonce groups of a sender's list: for each nonce, the first (highest priority) element having it.
+// The groups are sorted by nonce, and are searched, added and removed in (expected) logarithmic time.
+// It is not concurrent safe; it is guarded by the mutex of the sender's list.
+type nonceSkipList struct {
+	head       nonceSkipListNode
+	level      int
+	length     int
+	levelState uint64
+}
+
+type nonceSkipListNode struct {
+	nonce   uint64
+	element *list.Element
+	next    []*nonceSkipListNode
+}
+
+func newNonceSkipList() *nonceSkipList {
+	return &nonceSkipList{
+		head: nonceSkipListNode{
+			next: make([]*nonceSkipListNode, nonceSkipListMaxLevel),
+		},
+		level:      1,
+		levelState: nonceSkipListSeed,
+	}
+}
+
+// ceiling returns the first element of the group having the lowest nonce greater than or equal to the given one (nil if missing)
+func (skipList *nonceSkipList) ceiling(nonce uint64) *list.Element {
+	node := skipList.seek(nonce, nil)
+	if node == nil {
+		return nil
+	}
+
+	return node.element
+}
+
+// get returns the first element of the group having the given nonce (nil if missing)
+func (skipList *nonceSkipList) get(nonce uint64) *list.Element {
+	node := skipList.seek(nonce, nil)
+	if node == nil || node.nonce != nonce {
+		return nil
+	}
+
+	return node.element
+}
+
+// set records the first element of the group having the given nonce, adding the group if necessary
+func (skipList *nonceSkipList) set(nonce uint64, element *list.Element) {
+	var predecessors [nonceSkipListMaxLevel]*nonceSkipListNode
+
+	node := skipList.seek(nonce, &predecessors)
+	if node != nil && node.nonce == nonce {
+		node.element = element
+		return
+	}
+
+	level := skipList.randomLevel()
+	for ; skipList.level < level; skipList.level++ {
+		predecessors[skipList.level] = &skipList.head
+	}
+
+	newNode := &nonceSkipListNode{
+		nonce:   nonce,
+		element: element,
+		next:    make([]*nonceSkipListNode, level),
+	}
+	for i := 0; i < level; i++ {
+		newNode.next[i] = predecessors[i].next[i]
+		predecessors[i].next[i] = newNode
+	}
+
+	skipList.length++
+}
+
+// remove removes the group having the given nonce (if any)
+func (skipList *nonceSkipList) remove(nonce uint64) {
+	var predecessors [nonceSkipListMaxLevel]*nonceSkipListNode
+
+	node := skipList.seek(nonce, &predecessors)
+	if node == nil || node.nonce != nonce {
+		return
+	}
+
+	for i := 0; i < len(node.next); i++ {
+		predecessors[i].next[i] = node.next[i]
+	}
+	for skipList.level > 1 && skipList.head.next[skipList.level-1] == nil {
+		skipList.level--
+	}
+
+	skipList.length--
+}
+
+// forEach calls the provided function for each group, in nonce order
+func (skipList *nonceSkipList) forEach(fn func(nonce uint64, element *list.Element)) {
+	for node := skipList.head.next[0]; node != nil; node = node.next[0] {
+		fn(node.nonce, node.element)
+	}
+}
+
+// seek returns the node having the lowest nonce greater than or equal to the given one (nil if missing).
+// If requested, it also records, for each level, the last node having a lower nonce.
+func (skipList *nonceSkipList) seek(nonce uint64, predecessors *[nonceSkipListMaxLevel]*nonceSkipListNode) *nonceSkipListNode {
+	node := &skipList.head
+	for i := skipList.level - 1; i >= 0; i-- {
+		for node.next[i] != nil && node.next[i].nonce < nonce {
+			node = node.next[i]
+		}
+
+		if predecessors != nil {
+			predecessors[i] = node
+		}
+	}
+
+	return node.next[0]
+}
+
+// randomLevel returns the level of a new node: each level above the first is reached with a probability of 1/4.
+// The levels are drawn from a xorshift generator, cheap and good enough for balancing the list.
+func (skipList *nonceSkipList) randomLevel() int {
+	skipList.levelState ^= skipList.levelState << 13
+	skipList.levelState ^= skipList.levelState >> 7
+	skipList.levelState ^= skipList.levelState << 17
+
+	bits := skipList.levelState
+	level := 1
+	for level < nonceSkipListMaxLevel && bits&3 == 0 {
+		level++
+		bits >>= 2
+	}
+
+	return level
+}
@@ -0,0 +1,100 @@
+package txcache
+
+import (
+	"container/list"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNonceSkipList_SetGetRemove(t *testing.T) {
+	skipList := newNonceSkipList()
+	items := list.New()
+	a, b, c := items.PushBack("a"), items.PushBack("b"), items.PushBack("c")
+
+	require.Nil(t, skipList.get(1))
+	require.Nil(t, skipList.ceiling(0))
+
+	skipList.set(5, a)
+	skipList.set(3, b)
+	require.Equal(t, 2, skipList.length)
+	require.Equal(t, a, skipList.get(5))
+	require.Equal(t, b, skipList.get(3))
+	require.Nil(t, skipList.get(4))
+
+	// Replacing the element of an existing group does not add a group
+	skipList.set(5, c)
+	require.Equal(t, 2, skipList.length)
+	require.Equal(t, c, skipList.get(5))
+
+	require.Equal(t, b, skipList.ceiling(0))
+	require.Equal(t, b, skipList.ceiling(3))
+	require.Equal(t, c, skipList.ceiling(4))
+	require.Nil(t, skipList.ceiling(6))
+
+	skipList.remove(4)
+	require.Equal(t, 2, skipList.length)
+
+	skipList.remove(3)
+	require.Equal(t, 1, skipList.length)
+	require.Nil(t, skipList.get(3))
+	require.Equal(t, c, skipList.ceiling(0))
+
+	skipList.remove(5)
+	require.Equal(t, 0, skipList.length)
+	require.Equal(t, 1, skipList.level)
+	require.Nil(t, skipList.ceiling(0))
+}
+
+func TestNonceSkipList_MatchesSortedNonces(t *testing.T) {
+	skipList := newNonceSkipList()
+	items := list.New()
+	expected := make(map[uint64]*list.Element)
+	random := rand.New(rand.NewSource(42))
+
+	for i := 0; i < 20000; i++ {
+		nonce := uint64(random.Intn(5000))
+		if random.Intn(3) == 0 {
+			skipList.remove(nonce)
+			delete(expected, nonce)
+			continue
+		}
+
+		element := items.PushBack(nonce)
+		skipList.set(nonce, element)
+		expected[nonce] = element
+	}
+
+	expectedNonces := make([]uint64, 0, len(expected))
+	for nonce := range expected {
+		expectedNonces = append(expectedNonces, nonce)
+	}
+	sort.Slice(expectedNonces, func(i, j int) bool {
+		return expectedNonces[i] < expectedNonces[j]
+	})
+
+	actualNonces := make([]uint64, 0, len(expected))
+	skipList.forEach(func(nonce uint64, element *list.Element) {
+		require.Equal(t, expected[nonce], element)
+		actualNonces = append(actualNonces, nonce)
+	})
+
+	require.Equal(t, expectedNonces, actualNonces)
+	require.Equal(t, len(expected), skipList.length)
+	require.Greater(t, skipList.level, 1)
+
+	for nonce := uint64(0); nonce <= 5000; nonce++ {
+		require.Equal(t, expected[nonce], skipList.get(nonce))
+
+		position := sort.Search(len(expectedNonces), func(i int) bool {
+			return expectedNonces[i] >= nonce
+		})
+		if position == len(expectedNonces) {
+			require.Nil(t, skipList.ceiling(nonce))
+		} else {
+			require.Equal(t, expected[expectedNonces[position]], skipList.ceiling(nonce))
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package txcache
+
+import (
+	"sync/atomic"
+)
+
+const numRecentSenders = 8
+
+// recentSendersSnapshot is an immutable set of the most recently looked-up senders (the most recent first)
+type recentSendersSnapshot struct {
+	lists [numRecentSenders]*txListForSender
+}
+
+// recentSendersCache is a tiny cache of the most recently looked-up senders, which can be read without locks.
+// It holds an immutable snapshot, replaced as a whole (copy-on-write) on each change:
+//   - a lookup only caches a sender if the snapshot has not changed since the lookup started (compare-and-swap),
+//   - a removal always replaces the snapshot, even if the sender is not cached,
+//
+// so that a sender concurrently removed from the map cannot be left in the cache.
+type recentSendersCache struct {
+	snapshot atomic.Value
+}
+
+func newRecentSendersCache() *recentSendersCache {
+	cache := &recentSendersCache{}
+	cache.snapshot.Store(&recentSendersSnapshot{})
+
+	return cache
+}
+
+// get returns the list of the sender (if cached), along with the snapshot it has been looked up in
+func (cache *recentSendersCache) get(sender string) (*txListForSender, *recentSendersSnapshot, bool) {
+	snapshot := cache.snapshot.Load().(*recentSendersSnapshot)
+	for _, listForSender := range snapshot.lists {
+		if listForSender == nil {
+			break
+		}
+		if listForSender.sender == sender {
+			return listForSender, snapshot, true
+		}
+	}
+
+	return nil, snapshot, false
+}
+
+// tryAdd caches the list (evicting the oldest one), unless the snapshot has changed since the lookup of the sender
+func (cache *recentSendersCache) tryAdd(observed *recentSendersSnapshot, listForSender *txListForSender) {
+	newSnapshot := &recentSendersSnapshot{}
+	newSnapshot.lists[0] = listForSender
+	copy(newSnapshot.lists[1:], observed.lists[:numRecentSenders-1])
+
+	cache.snapshot.CompareAndSwap(observed, newSnapshot)
+}
+
+// remove evicts the sender from the cache. Should be called after removing the sender from the map.
+func (cache *recentSendersCache) remove(sender string) {
+	for {
+		observed := cache.snapshot.Load().(*recentSendersSnapshot)
+		newSnapshot := &recentSendersSnapshot{}
+		numLists := 0
+		for _, listForSender := range observed.lists {
+			if listForSender != nil && listForSender.sender != sender {
+				newSnapshot.lists[numLists] = listForSender
+				numLists++
+			}
+		}
+
+		if cache.snapshot.CompareAndSwap(observed, newSnapshot) {
+			return
+		}
+	}
+}
+
+func (cache *recentSendersCache) clear() {
+	cache.snapshot.Store(&recentSendersSnapshot{})
+}
@@ -0,0 +1,36 @@
+package txcache
+
+// NotifyBlockCommitted removes the transactions included in a committed block and adjusts the reputation of the senders,
+// which is taken into account by the score: the senders whose transactions were included get a slight boost (reliable senders),
+// while the senders which were selected, but whose transactions failed ("missedSenderAddrs"), get a slight penalty.
+// The reputation is bounded, and it is kept as long as the sender is held by the cache.
+func (cache *TxCache) NotifyBlockCommitted(includedTxHashes [][]byte, missedSenderAddrs [][]byte) {
+	includedSenders := make(map[string]struct{})
+
+	for _, txHash := range includedTxHashes {
+		tx, ok := cache.GetByTxHash(txHash)
+		if !ok {
+			continue
+		}
+
+		includedSenders[string(tx.Tx.GetSndAddr())] = struct{}{}
+		_ = cache.RemoveTxByHash(txHash)
+	}
+
+	for sender := range includedSenders {
+		cache.adjustSenderReputation(sender, reputationBoostOnInclusion)
+	}
+
+	for _, sender := range missedSenderAddrs {
+		cache.adjustSenderReputation(string(sender), -reputationPenaltyOnMiss)
+	}
+}
+
+func (cache *TxCache) adjustSenderReputation(sender string, delta int64) {
+	listForSender, ok := cache.txListBySender.getListForSender(sender)
+	if !ok {
+		return
+	}
+
+	listForSender.adjustReputation(delta)
+}
@@ -0,0 +1,82 @@
+package txcache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func addTxsForReputationTest(cache *TxCache, sender string, nonces ...uint64) {
+	for _, nonce := range nonces {
+		hash := createFakeTxHash([]byte(sender), int(nonce))
+		cache.AddTx(createTxWithParams(hash, sender, nonce, 128, 100000, oneBillion*3/2))
+	}
+}
+
+func TestTxCache_NotifyBlockCommitted_RemovesIncludedTxsAndBoostsTheirSenders(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	addTxsForReputationTest(cache, "alice", 1, 2, 3)
+	// Same transactions as alice, after the commit
+	addTxsForReputationTest(cache, "carol", 2, 3)
+
+	cache.NotifyBlockCommitted([][]byte{createFakeTxHash([]byte("alice"), 1), []byte("unknown")}, nil)
+
+	_, ok := cache.GetByTxHash(createFakeTxHash([]byte("alice"), 1))
+	require.False(t, ok)
+	require.Equal(t, uint64(4), cache.CountTx())
+
+	require.Equal(t, int64(1), cache.getListForSender("alice").reputation.Get())
+	require.Equal(t, cache.getScoreOfSender("carol")+1, cache.getScoreOfSender("alice"))
+	require.Equal(t, cache.getScoreOfSender("alice"), cache.getListForSender("alice").getLastComputedScore())
+}
+
+func TestTxCache_NotifyBlockCommitted_PenalizesMissedSenders(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	addTxsForReputationTest(cache, "alice", 1, 2, 3)
+	addTxsForReputationTest(cache, "bob", 1, 2, 3)
+	require.Equal(t, cache.getScoreOfSender("alice"), cache.getScoreOfSender("bob"))
+
+	cache.NotifyBlockCommitted(nil, [][]byte{[]byte("bob"), []byte("unknown")})
+
+	require.Equal(t, int64(-1), cache.getListForSender("bob").reputation.Get())
+	require.Equal(t, cache.getScoreOfSender("alice")-1, cache.getScoreOfSender("bob"))
+	require.Equal(t, cache.getScoreOfSender("bob"), cache.getListForSender("bob").getLastComputedScore())
+
+	// The senders are ordered by their (adjusted) score
+	snapshot := cache.txListBySender.getSnapshotDescending()
+	require.Equal(t, "alice", snapshot[0].sender)
+	require.Equal(t, "bob", snapshot[1].sender)
+}
+
+func TestTxCache_NotifyBlockCommitted_ReputationIsBounded(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	addTxsForReputationTest(cache, "alice", 1, 2, 3)
+	addTxsForReputationTest(cache, "bob", 1, 2, 3)
+
+	for i := 0; i < 3*reputationUpperBound; i++ {
+		cache.NotifyBlockCommitted(nil, [][]byte{[]byte("alice")})
+	}
+	require.Equal(t, int64(reputationLowerBound), cache.getListForSender("alice").reputation.Get())
+
+	for i := 0; i < 3*reputationUpperBound; i++ {
+		cache.adjustSenderReputation("bob", reputationBoostOnInclusion)
+	}
+	require.Equal(t, int64(reputationUpperBound), cache.getListForSender("bob").reputation.Get())
+}
+
+func TestDefaultScoreComputer_applyReputation(t *testing.T) {
+	_, txFeeHelper := dummyParams()
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
+
+	require.Equal(t, float64(0), computer.applyReputation(0, 5))
+	require.Equal(t, float64(42), computer.applyReputation(42, 0))
+	require.InDelta(t, float64(47), computer.applyReputation(42, 5), 0.0001)
+	require.InDelta(t, float64(32), computer.applyReputation(42, -10), 0.0001)
+	require.Equal(t, float64(0), computer.applyReputation(3, -10))
+
+	computer = newDefaultScoreComputer(txFeeHelper, 1000)
+	require.InDelta(t, float64(470), computer.applyReputation(420, 5), 0.0001)
+}
@@ -0,0 +1,56 @@
+package txcache
+
+import (
+	"bytes"
+	"sort"
+)
+
+// SampleSendersWeighted picks (at most) "count" distinct senders, at random, with probability proportional to their score.
+// Senders with a zero score have a small chance to be picked, as well (their weight is non-zero), so that they do not starve.
+// For a given content of the cache, the output only depends on the provided source of randomness.
+func (cache *TxCache) SampleSendersWeighted(count int, rng RandomSource) [][]byte {
+	if count <= 0 || rng == nil {
+		return make([][]byte, 0)
+	}
+
+	candidates := cache.txListBySender.getSnapshotAscending()
+	// The senders having the same score are held in no particular order, thus sort them (for a deterministic outcome)
+	sort.Slice(candidates, func(i, j int) bool {
+		return bytes.Compare([]byte(candidates[i].sender), []byte(candidates[j].sender)) < 0
+	})
+
+	weights := make([]int64, len(candidates))
+	totalWeight := int64(0)
+	for i, txList := range candidates {
+		weights[i] = int64(cache.txListBySender.normalizeScore(txList.getLastComputedScore())) + 1
+		totalWeight += weights[i]
+	}
+
+	result := make([][]byte, 0, count)
+
+	for len(result) < count && len(candidates) > 0 {
+		index := pickWeightedIndex(weights, rng.Int63n(totalWeight))
+		result = append(result, []byte(candidates[index].sender))
+
+		// Sampling without replacement
+		totalWeight -= weights[index]
+		lastIndex := len(candidates) - 1
+		candidates[index], weights[index] = candidates[lastIndex], weights[lastIndex]
+		candidates, weights = candidates[:lastIndex], weights[:lastIndex]
+	}
+
+	return result
+}
+
+// pickWeightedIndex returns the index of the weight covering the given point of the cumulative distribution
+func pickWeightedIndex(weights []int64, point int64) int {
+	for i, weight := range weights {
+		if point < weight {
+			return i
+		}
+
+		point -= weight
+	}
+
+	return len(weights) - 1
+}
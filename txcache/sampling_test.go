@@ -0,0 +1,70 @@
+package txcache
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCache_SampleSendersWeighted(t *testing.T) {
+	t.Run("invalid arguments", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+
+		require.Empty(t, cache.SampleSendersWeighted(0, rand.New(rand.NewSource(42))))
+		require.Empty(t, cache.SampleSendersWeighted(-1, rand.New(rand.NewSource(42))))
+		require.Empty(t, cache.SampleSendersWeighted(1, nil))
+	})
+
+	t.Run("empty cache", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		require.Empty(t, cache.SampleSendersWeighted(3, rand.New(rand.NewSource(42))))
+	})
+
+	t.Run("should pick distinct senders", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-bob-1"), "bob", 1))
+		cache.AddTx(createTx([]byte("hash-carol-1"), "carol", 1))
+
+		sampled := cache.SampleSendersWeighted(10, rand.New(rand.NewSource(42)))
+		require.Len(t, sampled, 3)
+		require.ElementsMatch(t, [][]byte{[]byte("alice"), []byte("bob"), []byte("carol")}, sampled)
+	})
+
+	t.Run("should be deterministic, given the source of randomness", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		for i := 0; i < 20; i++ {
+			sender := string(createFakeSenderAddress(i))
+			cache.AddTx(createTx(createFakeTxHash([]byte(sender), 1), sender, 1))
+		}
+
+		first := cache.SampleSendersWeighted(5, rand.New(rand.NewSource(7)))
+		second := cache.SampleSendersWeighted(5, rand.New(rand.NewSource(7)))
+		require.Len(t, first, 5)
+		require.Equal(t, first, second)
+	})
+
+	t.Run("higher-score senders should be sampled more often", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50000, 3*oneBillion))
+		cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 128, 50000, oneBillion))
+		require.Greater(t, cache.getScoreOfSender("alice"), cache.getScoreOfSender("bob"))
+
+		rng := rand.New(rand.NewSource(42))
+		numPicks := make(map[string]int)
+		for i := 0; i < 10000; i++ {
+			sampled := cache.SampleSendersWeighted(1, rng)
+			require.Len(t, sampled, 1)
+			numPicks[string(sampled[0])]++
+		}
+
+		weightAlice := float64(cache.getScoreOfSender("alice")) + 1
+		weightBob := float64(cache.getScoreOfSender("bob")) + 1
+		expectedShareOfAlice := weightAlice / (weightAlice + weightBob)
+
+		require.Greater(t, numPicks["alice"], numPicks["bob"])
+		require.InDelta(t, expectedShareOfAlice, float64(numPicks["alice"])/10000, 0.02)
+	})
+}
@@ -0,0 +1,26 @@
+package txcache
+
+import (
+	"math"
+	"math/bits"
+)
+
+// addSaturating returns a + b, or math.MaxUint64 if the sum overflows
+func addSaturating(a uint64, b uint64) uint64 {
+	sum, carry := bits.Add64(a, b, 0)
+	if carry != 0 {
+		return math.MaxUint64
+	}
+
+	return sum
+}
+
+// mulSaturating returns a * b, or math.MaxUint64 if the product overflows
+func mulSaturating(a uint64, b uint64) uint64 {
+	high, low := bits.Mul64(a, b)
+	if high != 0 {
+		return math.MaxUint64
+	}
+
+	return low
+}
@@ -0,0 +1,25 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_addSaturating(t *testing.T) {
+	require.Equal(t, uint64(0), addSaturating(0, 0))
+	require.Equal(t, uint64(42), addSaturating(40, 2))
+	require.Equal(t, uint64(math.MaxUint64), addSaturating(math.MaxUint64-1, 1))
+	require.Equal(t, uint64(math.MaxUint64), addSaturating(math.MaxUint64-1, 2))
+	require.Equal(t, uint64(math.MaxUint64), addSaturating(math.MaxUint64, math.MaxUint64))
+}
+
+func Test_mulSaturating(t *testing.T) {
+	require.Equal(t, uint64(0), mulSaturating(0, math.MaxUint64))
+	require.Equal(t, uint64(42), mulSaturating(6, 7))
+	require.Equal(t, uint64(math.MaxUint64), mulSaturating(math.MaxUint64, 1))
+	require.Equal(t, uint64(math.MaxUint64), mulSaturating(math.MaxUint64/2+1, 2))
+	require.Equal(t, uint64(math.MaxUint64), mulSaturating(math.MaxUint64, math.MaxUint64))
+	require.Equal(t, uint64(math.MaxUint64-1), mulSaturating(math.MaxUint64/2, 2))
+}
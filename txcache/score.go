@@ -12,30 +12,45 @@ type senderScoreParams struct {
 	// Fee score is normalized
 	feeScore uint64
 	gas      uint64
+	// Reputation is in the interval [reputationLowerBound, reputationUpperBound], see TxCache.NotifyBlockCommitted
+	reputation int64
 }
 
 type defaultScoreComputer struct {
-	txFeeHelper feeHelper
-	ppuDivider  uint64
+	txFeeHelper    feeHelper
+	ppuDivider     uint64
+	numScoreChunks uint32
 }
 
-func newDefaultScoreComputer(txFeeHelper feeHelper) *defaultScoreComputer {
+func newDefaultScoreComputer(txFeeHelper feeHelper, numScoreChunks uint32) *defaultScoreComputer {
 	ppuScoreDivider := txFeeHelper.minGasPriceFactor()
 	ppuScoreDivider = ppuScoreDivider * ppuScoreDivider * ppuScoreDivider
 
 	return &defaultScoreComputer{
-		txFeeHelper: txFeeHelper,
-		ppuDivider:  ppuScoreDivider,
+		txFeeHelper:    txFeeHelper,
+		ppuDivider:     ppuScoreDivider,
+		numScoreChunks: numScoreChunks,
 	}
 }
 
-// computeScore computes the score of the sender, as an integer 0-100
+// computeScore computes the score of the sender, as an integer 0-numScoreChunks
 func (computer *defaultScoreComputer) computeScore(scoreParams senderScoreParams) uint32 {
 	rawScore := computer.computeRawScore(scoreParams)
-	truncatedScore := uint32(rawScore)
+	adjustedScore := computer.applyReputation(rawScore, scoreParams.reputation)
+	truncatedScore := uint32(adjustedScore)
 	return truncatedScore
 }
 
+// applyReputation shifts the score (if defined) by a small fraction of the score range, for each reputation point
+func (computer *defaultScoreComputer) applyReputation(score float64, reputation int64) float64 {
+	if score == 0 || reputation == 0 {
+		return score
+	}
+
+	adjustedScore := score + float64(reputation)*reputationScoreStep*float64(computer.numScoreChunks)
+	return math.Max(0, adjustedScore)
+}
+
 // TODO (optimization): switch to integer operations (as opposed to float operations).
 func (computer *defaultScoreComputer) computeRawScore(params senderScoreParams) float64 {
 	allParamsDefined := params.feeScore > 0 && params.gas > 0 && params.count > 0
@@ -62,6 +77,6 @@ func (computer *defaultScoreComputer) computeRawScore(params senderScoreParams)
 	// and then subtract 0.5, since we only deal with positive scores,
 	// and then we multiply by 2, to have full [0..1] range.
 	asymptoticScore := (1/(1+math.Exp(-rawScore)) - 0.5) * 2
-	score := asymptoticScore * float64(numberOfScoreChunks)
+	score := asymptoticScore * float64(computer.numScoreChunks)
 	return score
 }
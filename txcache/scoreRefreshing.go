@@ -0,0 +1,78 @@
+package txcache
+
+import (
+	"context"
+	"time"
+)
+
+// tickerFactory creates a source of ticks (and a function to stop it), for the given interval
+type tickerFactory func(interval time.Duration) (<-chan time.Time, func())
+
+func newTimeTicker(interval time.Duration) (<-chan time.Time, func()) {
+	ticker := time.NewTicker(interval)
+	return ticker.C, ticker.Stop
+}
+
+// RecomputeAllScores recomputes the scores of all senders, and moves them to the corresponding score chunks
+func (cache *TxCache) RecomputeAllScores() {
+	cache.txListBySender.recomputeAllScores()
+}
+
+// Start starts the background goroutines of the cache: the one which periodically recomputes the scores of the senders
+// (if "ScoreRefreshInterval" is configured) and the one which evicts senders under memory pressure (if "MaxHeapBytes" is configured).
+// A goroutine which is already running is not started again.
+func (cache *TxCache) Start() {
+	cache.startScoreRefresh()
+	cache.startMemoryMonitoring()
+}
+
+func (cache *TxCache) startScoreRefresh() {
+	if cache.config.ScoreRefreshInterval == 0 {
+		return
+	}
+
+	cache.mutScoreRefresh.Lock()
+	defer cache.mutScoreRefresh.Unlock()
+
+	if cache.cancelScoreRefresh != nil {
+		return
+	}
+
+	var ctx context.Context
+	ctx, cache.cancelScoreRefresh = context.WithCancel(context.Background())
+	cache.scoreRefreshDone = make(chan struct{})
+
+	ticks, stopTicker := cache.scoreRefreshTickerFactory(cache.config.ScoreRefreshInterval)
+	go cache.refreshScoresLoop(ctx, ticks, stopTicker, cache.scoreRefreshDone)
+}
+
+func (cache *TxCache) refreshScoresLoop(ctx context.Context, ticks <-chan time.Time, stopTicker func(), done chan struct{}) {
+	defer close(done)
+	defer stopTicker()
+
+	for {
+		select {
+		case <-ticks:
+			cache.RecomputeAllScores()
+		case <-ctx.Done():
+			log.Debug("TxCache: closing the score refresh goroutine", "name", cache.name)
+			return
+		}
+	}
+}
+
+// stopScoreRefresh stops the background goroutine (if running) and waits for it to exit
+func (cache *TxCache) stopScoreRefresh() {
+	cache.mutScoreRefresh.Lock()
+	defer cache.mutScoreRefresh.Unlock()
+
+	if cache.cancelScoreRefresh == nil {
+		return
+	}
+
+	cache.cancelScoreRefresh()
+	<-cache.scoreRefreshDone
+
+	cache.cancelScoreRefresh = nil
+	cache.scoreRefreshDone = nil
+}
@@ -0,0 +1,157 @@
+package txcache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingScoreComputer struct {
+	numCalls uint32
+	score    uint32
+}
+
+func (computer *countingScoreComputer) computeScore(_ senderScoreParams) uint32 {
+	atomic.AddUint32(&computer.numCalls, 1)
+	return atomic.LoadUint32(&computer.score)
+}
+
+func (computer *countingScoreComputer) getNumCalls() uint32 {
+	return atomic.LoadUint32(&computer.numCalls)
+}
+
+type fakeTicker struct {
+	ticks   chan time.Time
+	stopped uint32
+}
+
+func newFakeTicker() *fakeTicker {
+	return &fakeTicker{
+		ticks: make(chan time.Time),
+	}
+}
+
+func (ticker *fakeTicker) factory(_ time.Duration) (<-chan time.Time, func()) {
+	return ticker.ticks, func() {
+		atomic.StoreUint32(&ticker.stopped, 1)
+	}
+}
+
+func (ticker *fakeTicker) tick() {
+	ticker.ticks <- time.Now()
+}
+
+func (ticker *fakeTicker) isStopped() bool {
+	return atomic.LoadUint32(&ticker.stopped) == 1
+}
+
+func newCacheWithScoreRefreshToTest(interval time.Duration) (*TxCache, *countingScoreComputer) {
+	cache := newUnconstrainedCacheToTest()
+	cache.config.ScoreRefreshInterval = interval
+
+	computer := &countingScoreComputer{}
+	cache.txListBySender.scoreComputer = computer
+
+	return cache, computer
+}
+
+func TestTxCache_RecomputeAllScores(t *testing.T) {
+	cache, computer := newCacheWithScoreRefreshToTest(0)
+
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-bob-1"), "bob", 1))
+	require.Equal(t, uint32(2), computer.getNumCalls())
+
+	atomic.StoreUint32(&computer.score, 42)
+	cache.RecomputeAllScores()
+	require.Equal(t, uint32(4), computer.getNumCalls())
+	require.Equal(t, uint32(42), cache.getListForSender("alice").getLastComputedScore())
+	require.Equal(t, uint32(42), cache.getListForSender("bob").getLastComputedScore())
+	require.Equal(t, 2, len(cache.txListBySender.backingMap.GetSnapshotDescending()))
+}
+
+func TestTxCache_StartShouldRefreshScoresPeriodically(t *testing.T) {
+	cache, computer := newCacheWithScoreRefreshToTest(time.Hour)
+	ticker := newFakeTicker()
+	cache.scoreRefreshTickerFactory = ticker.factory
+
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	require.Equal(t, uint32(1), computer.getNumCalls())
+
+	cache.Start()
+	atomic.StoreUint32(&computer.score, 7)
+
+	ticker.tick()
+	ticker.tick()
+	// The third tick is only accepted after the second refresh is done
+	ticker.tick()
+	require.GreaterOrEqual(t, computer.getNumCalls(), uint32(3))
+	require.Equal(t, uint32(7), cache.getListForSender("alice").getLastComputedScore())
+
+	err := cache.Close()
+	require.Nil(t, err)
+	require.True(t, ticker.isStopped())
+
+	numCallsAfterClose := computer.getNumCalls()
+	select {
+	case ticker.ticks <- time.Now():
+		require.Fail(t, "the loop should have been stopped")
+	case <-time.After(50 * time.Millisecond):
+	}
+	require.Equal(t, numCallsAfterClose, computer.getNumCalls())
+}
+
+func TestTxCache_StartWithRealTicker(t *testing.T) {
+	cache, computer := newCacheWithScoreRefreshToTest(time.Millisecond)
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+
+	cache.Start()
+	require.Eventually(t, func() bool {
+		return computer.getNumCalls() > 3
+	}, time.Second, time.Millisecond)
+
+	err := cache.Close()
+	require.Nil(t, err)
+
+	numCallsAfterClose := computer.getNumCalls()
+	time.Sleep(20 * time.Millisecond)
+	require.Equal(t, numCallsAfterClose, computer.getNumCalls())
+}
+
+func TestTxCache_StartShouldDoNothingWhenIntervalNotConfigured(t *testing.T) {
+	cache, _ := newCacheWithScoreRefreshToTest(0)
+	ticker := newFakeTicker()
+	cache.scoreRefreshTickerFactory = ticker.factory
+
+	cache.Start()
+	require.Nil(t, cache.cancelScoreRefresh)
+
+	err := cache.Close()
+	require.Nil(t, err)
+}
+
+func TestTxCache_StartAndCloseMultipleTimes(t *testing.T) {
+	cache, _ := newCacheWithScoreRefreshToTest(time.Hour)
+
+	cache.Start()
+	firstDone := cache.scoreRefreshDone
+	cache.Start()
+	require.True(t, firstDone == cache.scoreRefreshDone)
+
+	require.Nil(t, cache.Close())
+	require.Nil(t, cache.Close())
+	require.Nil(t, cache.cancelScoreRefresh)
+
+	select {
+	case <-firstDone:
+	default:
+		require.Fail(t, "the loop should have exited")
+	}
+
+	// Can be restarted after Close
+	cache.Start()
+	require.NotNil(t, cache.cancelScoreRefresh)
+	require.Nil(t, cache.Close())
+}
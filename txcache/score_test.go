@@ -10,7 +10,7 @@ import (
 
 func TestDefaultScoreComputer_computeRawScore(t *testing.T) {
 	_, txFeeHelper := dummyParamsWithGasPrice(oneBillion)
-	computer := newDefaultScoreComputer(txFeeHelper)
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
 
 	// 50k moveGas, 100Bil minPrice -> normalizedFee 8940
 	score := computer.computeRawScore(senderScoreParams{count: 1, feeScore: 18000, gas: 100000})
@@ -32,9 +32,20 @@ func TestDefaultScoreComputer_computeRawScore(t *testing.T) {
 	assert.InDelta(t, float64(1.4129614707), score, delta)
 }
 
+func TestDefaultScoreComputer_computeRawScoreWithNumScoreChunks(t *testing.T) {
+	_, txFeeHelper := dummyParamsWithGasPrice(oneBillion)
+	computer := newDefaultScoreComputer(txFeeHelper, 1000)
+
+	score := computer.computeRawScore(senderScoreParams{count: 1, feeScore: 18000, gas: 100000})
+	assert.InDelta(t, float64(168.753739025), score, delta*10)
+
+	score = computer.computeRawScore(senderScoreParams{count: 1000, feeScore: 18000000, gas: 100000000})
+	assert.InDelta(t, float64(18.520698299), score, delta*10)
+}
+
 func BenchmarkScoreComputer_computeRawScore(b *testing.B) {
 	_, txFeeHelper := dummyParams()
-	computer := newDefaultScoreComputer(txFeeHelper)
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
 
 	for i := 0; i < b.N; i++ {
 		for j := uint64(0); j < 10000000; j++ {
@@ -45,7 +56,7 @@ func BenchmarkScoreComputer_computeRawScore(b *testing.B) {
 
 func TestDefaultScoreComputer_computeRawScoreOfTxListForSender(t *testing.T) {
 	txGasHandler, txFeeHelper := dummyParamsWithGasPrice(oneBillion)
-	computer := newDefaultScoreComputer(txFeeHelper)
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
 	list := newUnconstrainedListToTest()
 
 	list.AddTx(createTxWithParams([]byte("a"), ".", 1, 1000, 50000, oneBillion), txGasHandler, txFeeHelper)
@@ -62,9 +73,34 @@ func TestDefaultScoreComputer_computeRawScoreOfTxListForSender(t *testing.T) {
 	require.InDelta(t, float64(12.4595615805), rawScore, delta)
 }
 
+func TestDefaultScoreComputer_computeRawScoreOfTxListForSender_WithPrecomputedFee(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParamsWithGasPrice(oneBillion)
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
+
+	listEstimated := newUnconstrainedListToTest()
+	listEstimated.AddTx(createTxWithParams([]byte("a"), ".", 1, 1000, 50000, oneBillion), txGasHandler, txFeeHelper)
+
+	listPrecomputed := newUnconstrainedListToTest()
+	tx := createTxWithParams([]byte("a"), ".", 1, 1000, 50000, oneBillion)
+	tx.PrecomputedFee = 2 * 50000 * oneBillion
+	listPrecomputed.AddTx(tx, txGasHandler, txFeeHelper)
+
+	shift := txFeeHelper.gasLimitShift() + txFeeHelper.gasPriceShift()
+	require.Equal(t, int64(tx.PrecomputedFee>>shift), listPrecomputed.totalFeeScore.Get())
+	require.Greater(t, listPrecomputed.totalFeeScore.Get(), listEstimated.totalFeeScore.Get())
+
+	scoreEstimated := computer.computeScore(listEstimated.getScoreParams())
+	scorePrecomputed := computer.computeScore(listPrecomputed.getScoreParams())
+	require.Greater(t, scorePrecomputed, scoreEstimated)
+
+	// Removal subtracts the precomputed value
+	listPrecomputed.RemoveTx(tx)
+	require.Equal(t, int64(0), listPrecomputed.totalFeeScore.Get())
+}
+
 func TestDefaultScoreComputer_scoreFluctuatesDeterministicallyWhileTxListForSenderMutates(t *testing.T) {
 	txGasHandler, txFeeHelper := dummyParamsWithGasPrice(oneBillion)
-	computer := newDefaultScoreComputer(txFeeHelper)
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
 	list := newUnconstrainedListToTest()
 
 	A := createTxWithParams([]byte("A"), ".", 1, 1000, 200000, oneBillion)
@@ -105,7 +141,7 @@ func TestDefaultScoreComputer_scoreFluctuatesDeterministicallyWhileTxListForSend
 
 func TestDefaultScoreComputer_DifferentSenders(t *testing.T) {
 	txGasHandler, txFeeHelper := dummyParamsWithGasPrice(oneBillion)
-	computer := newDefaultScoreComputer(txFeeHelper)
+	computer := newDefaultScoreComputer(txFeeHelper, defaultNumScoreChunks)
 
 	A := createTxWithParams([]byte("a"), "a", 1, 128, 50000, oneBillion)                // min value normal tx
 	B := createTxWithParams([]byte("b"), "b", 1, 128, 50000, uint64(1.5*oneBillion))    // 50% higher value normal tx
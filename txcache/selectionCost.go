@@ -0,0 +1,80 @@
+package txcache
+
+// SelectionCostEstimate holds the estimated cost of a selection (see TxCache.EstimateSelectionCost)
+type SelectionCostEstimate struct {
+	NumSenders int
+	NumTxs     int
+	TotalGas   uint64
+}
+
+// EstimateSelectionCost estimates how many senders and transactions would be visited by a selection in order to fill the given gas budget,
+// without copying the transactions and without altering the state of the senders (as the actual selection does).
+// The senders are visited in the descending order of their score, and the transactions of each sender in the order of their nonces
+// (up to the first nonce gap), as in a selection with unbounded batch size and bandwidth per sender.
+// The visit stops at the first transaction that does not fit in the remaining budget.
+// Senders having the same score are visited in an arbitrary order (by the selection, as well), thus, in their case, the estimate is approximate.
+// Useful for the adaptive block building, in order to decide whether to attempt a selection.
+func (cache *TxCache) EstimateSelectionCost(gasBudget uint64) SelectionCostEstimate {
+	estimate := SelectionCostEstimate{}
+
+	for _, txList := range cache.getSendersEligibleForSelection() {
+		numTxs, gas, isBudgetExhausted := txList.estimateSelectionCost(gasBudget - estimate.TotalGas)
+		if numTxs > 0 {
+			estimate.NumSenders++
+			estimate.NumTxs += numTxs
+			estimate.TotalGas += gas
+		}
+
+		if isBudgetExhausted {
+			break
+		}
+	}
+
+	return estimate
+}
+
+// estimateSelectionCost returns the number of transactions (and their gas) that would be selected from the sender,
+// given the remaining gas budget, and whether the budget is exhausted. The state of the sender is not altered.
+func (listForSender *txListForSender) estimateSelectionCost(remainingGas uint64) (int, uint64, bool) {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	maxNumTxs := listForSender.items.Len()
+	if listForSender.hasInitialGap() {
+		if !listForSender.isInGracePeriodOnNextSelection() {
+			return 0, 0, false
+		}
+		maxNumTxs = 1
+	}
+
+	numTxs := 0
+	gas := uint64(0)
+	previousNonce := uint64(0)
+
+	for element := listForSender.items.Front(); element != nil && numTxs < maxNumTxs; element = element.Next() {
+		value := element.Value.(*WrappedTransaction)
+		txNonce := value.Tx.GetNonce()
+		if previousNonce > 0 && txNonce > previousNonce+1 {
+			break
+		}
+
+		gasLimit := value.Tx.GetGasLimit()
+		if gasLimit > remainingGas-gas {
+			return numTxs, gas, true
+		}
+
+		numTxs++
+		gas += gasLimit
+		previousNonce = txNonce
+	}
+
+	return numTxs, gas, false
+}
+
+// isInGracePeriodOnNextSelection tells whether the sender (having an initial gap) would be in the grace period
+// at the next selection, which increments the number of failed selections before checking the grace period
+// (see verifyInitialGapOnSelectionStart)
+func (listForSender *txListForSender) isInGracePeriodOnNextSelection() bool {
+	numFailedSelections := listForSender.numFailedSelections.Get() + 1
+	return numFailedSelections >= senderGracePeriodLowerBound && numFailedSelections <= senderGracePeriodUpperBound
+}
@@ -0,0 +1,108 @@
+package txcache
+
+import (
+	"fmt"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCache_EstimateSelectionCost_EmptyCache(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	require.Equal(t, SelectionCostEstimate{}, cache.EstimateSelectionCost(math.MaxUint64))
+	require.Equal(t, SelectionCostEstimate{}, cache.EstimateSelectionCost(0))
+}
+
+func TestTxCache_EstimateSelectionCost_StopsAtBudget(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 100000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 100000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-alice-3"), "alice", 3, 128, 100000, oneBillion))
+
+	require.Equal(t, SelectionCostEstimate{}, cache.EstimateSelectionCost(99999))
+	require.Equal(t, SelectionCostEstimate{NumSenders: 1, NumTxs: 1, TotalGas: 100000}, cache.EstimateSelectionCost(100000))
+	require.Equal(t, SelectionCostEstimate{NumSenders: 1, NumTxs: 2, TotalGas: 200000}, cache.EstimateSelectionCost(299999))
+	require.Equal(t, SelectionCostEstimate{NumSenders: 1, NumTxs: 3, TotalGas: 300000}, cache.EstimateSelectionCost(math.MaxUint64))
+}
+
+func TestTxCache_EstimateSelectionCost_DoesNotAlterTheSenders(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("hash-alice-5"), "alice", 5))
+	cache.NotifyAccountNonce([]byte("alice"), 1)
+	listForAlice, _ := cache.txListBySender.getListForSender("alice")
+
+	for i := 0; i < 10; i++ {
+		_ = cache.EstimateSelectionCost(math.MaxUint64)
+	}
+
+	require.Equal(t, int64(0), listForAlice.numFailedSelections.Get())
+	require.False(t, listForAlice.sweepable.IsSet())
+}
+
+func TestTxCache_EstimateSelectionCost_MatchesActualSelection(t *testing.T) {
+	for _, gasBudget := range []uint64{0, 50000, 1000000, 2500000, 4000000, math.MaxUint64} {
+		t.Run(fmt.Sprintf("budget %d", gasBudget), func(t *testing.T) {
+			cache := createCacheForSelectionCostEstimation()
+
+			// The initial gaps are handled by the estimation as by the selection, across the selections (grace period)
+			for selection := 0; selection < 4; selection++ {
+				estimate := cache.EstimateSelectionCost(gasBudget)
+				selected := cache.doSelectTransactions(math.MaxInt16, math.MaxInt16, math.MaxUint64)
+
+				require.Equal(t, countSelectedWithinBudget(selected, gasBudget), estimate)
+			}
+		})
+	}
+}
+
+// createCacheForSelectionCostEstimation creates a cache where the senders have distinct scores (thus, a deterministic order in the selection)
+func createCacheForSelectionCostEstimation() *TxCache {
+	cache := newUnconstrainedCacheToTest()
+
+	for senderTag := 0; senderTag < 10; senderTag++ {
+		sender := string(createFakeSenderAddress(senderTag))
+		gasPrice := oneBillion + uint64(senderTag)*oneBillion/2
+
+		for nonce := 1; nonce <= 5; nonce++ {
+			// Some senders have a middle gap
+			if senderTag%5 == 0 && nonce == 3 {
+				continue
+			}
+
+			gasLimit := uint64(50000 + 10000*senderTag + 1000*nonce)
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte(sender), nonce), sender, uint64(nonce), 128, gasLimit, gasPrice))
+		}
+
+		// Some senders have an initial gap
+		if senderTag%7 == 0 {
+			cache.NotifyAccountNonce([]byte(sender), 0)
+		} else {
+			cache.NotifyAccountNonce([]byte(sender), 1)
+		}
+	}
+
+	return cache
+}
+
+func countSelectedWithinBudget(selected []*WrappedTransaction, gasBudget uint64) SelectionCostEstimate {
+	estimate := SelectionCostEstimate{}
+	senders := make(map[string]struct{})
+
+	for _, tx := range selected {
+		gasLimit := tx.Tx.GetGasLimit()
+		if gasLimit > gasBudget-estimate.TotalGas {
+			break
+		}
+
+		senders[string(tx.Tx.GetSndAddr())] = struct{}{}
+		estimate.NumTxs++
+		estimate.TotalGas += gasLimit
+	}
+
+	estimate.NumSenders = len(senders)
+	return estimate
+}
@@ -0,0 +1,98 @@
+package txcache
+
+// wfqSenderQueue holds the state of a sender during a weighted fair queuing selection
+type wfqSenderQueue struct {
+	txs     []*WrappedTransaction
+	quantum uint64
+	deficit uint64
+}
+
+// SelectTransactionsWFQ selects at most "maxCount" transactions, using Deficit Round Robin (a form of weighted fair queuing).
+// The fee budget of a sender is the total fee of its selectable transactions. The deficit counter of each sender
+// is initialized to its fee budget. In each round, the counter is incremented by the quantum of the sender: its fee budget,
+// weighted by its score. Then, the sender gives transactions (in nonce order) as long as its deficit covers their cost.
+// The cost of a transaction is its fee, multiplied by the length of the longest queue, so that the initial deficit covers
+// at most one transaction of an average fee, instead of the whole queue (which would degrade the selection to the order
+// of the scores). Thus, the fees selected from each sender are proportional to its fee budget, weighted by its score.
+// The arithmetic on the fees saturates at math.MaxUint64.
+// The nonce gaps are handled as in SelectTransactionsWithBandwidth.
+// Unlike SelectTransactionsWithBandwidth, the selection does not alter the state of the senders (e.g. with respect to sweeping).
+func (cache *TxCache) SelectTransactionsWFQ(maxCount int) []*WrappedTransaction {
+	if maxCount <= 0 {
+		return make([]*WrappedTransaction, 0)
+	}
+
+	queues, numRounds := cache.createWFQSenderQueues()
+	result := make([]*WrappedTransaction, 0, maxCount)
+
+	for len(result) < maxCount && len(queues) > 0 {
+		activeQueues := queues[:0]
+
+		for _, queue := range queues {
+			queue.deficit = addSaturating(queue.deficit, queue.quantum)
+
+			for len(queue.txs) > 0 && len(result) < maxCount {
+				cost := mulSaturating(computeWFQCost(queue.txs[0]), numRounds)
+				if cost > queue.deficit {
+					break
+				}
+
+				queue.deficit -= cost
+				result = append(result, queue.txs[0])
+				queue.txs = queue.txs[1:]
+			}
+
+			if len(queue.txs) > 0 {
+				activeQueues = append(activeQueues, queue)
+			}
+		}
+
+		queues = activeQueues
+	}
+
+	cache.recordSelection()
+	return result
+}
+
+// createWFQSenderQueues returns the queues of the senders (in descending order of their score), along with the length of the longest queue.
+// The deficit counters are initialized to the fee budgets, and the quanta are the fee budgets weighted by the scores.
+func (cache *TxCache) createWFQSenderQueues() ([]*wfqSenderQueue, uint64) {
+	snapshotOfSenders := cache.getSendersEligibleForSelection()
+	queues := make([]*wfqSenderQueue, 0, len(snapshotOfSenders))
+	numRounds := uint64(1)
+
+	for _, txList := range snapshotOfSenders {
+		txs := txList.getSelectableTxs()
+		if len(txs) == 0 {
+			continue
+		}
+
+		budget := uint64(0)
+		for _, tx := range txs {
+			budget = addSaturating(budget, computeWFQCost(tx))
+		}
+
+		if uint64(len(txs)) > numRounds {
+			numRounds = uint64(len(txs))
+		}
+
+		weight := uint64(cache.txListBySender.normalizeScore(txList.getLastComputedScore())) + 1
+		queues = append(queues, &wfqSenderQueue{
+			txs:     txs,
+			quantum: mulSaturating(budget, weight),
+			deficit: budget,
+		})
+	}
+
+	return queues, numRounds
+}
+
+// computeWFQCost returns the fee of the transaction (at least 1, so that the transactions without a fee are accounted, as well)
+func computeWFQCost(tx *WrappedTransaction) uint64 {
+	fee := computeTxFee(tx)
+	if fee == 0 {
+		return 1
+	}
+
+	return fee
+}
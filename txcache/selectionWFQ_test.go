@@ -0,0 +1,136 @@
+package txcache
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxCache_SelectTransactionsWFQ(t *testing.T) {
+	t.Run("invalid max count", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+
+		require.Empty(t, cache.SelectTransactionsWFQ(0))
+		require.Empty(t, cache.SelectTransactionsWFQ(-1))
+	})
+
+	t.Run("empty cache", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+		require.Empty(t, cache.SelectTransactionsWFQ(10))
+	})
+
+	t.Run("should select in proportion to the score, preserving the nonce order", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		for nonce := uint64(1); nonce <= 100; nonce++ {
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte("alice"), int(nonce)), "alice", nonce, 128, 50000, uint64(1.5*oneBillion)))
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte("bob"), int(nonce)), "bob", nonce, 128, 50000, oneBillion))
+		}
+
+		weightAlice := int(cache.getScoreOfSender("alice")) + 1
+		weightBob := int(cache.getScoreOfSender("bob")) + 1
+		require.Greater(t, weightAlice, weightBob)
+
+		// Exactly one round: the initial deficits (the fee budgets) cover one transaction each, then the quanta
+		// (the fee budgets, weighted by the scores) cover as many transactions as the weights.
+		selected := cache.SelectTransactionsWFQ(weightAlice + weightBob + 2)
+		require.Len(t, selected, weightAlice+weightBob+2)
+
+		noncesBySender := make(map[string][]uint64)
+		for _, tx := range selected {
+			sender := string(tx.Tx.GetSndAddr())
+			noncesBySender[sender] = append(noncesBySender[sender], tx.Tx.GetNonce())
+		}
+
+		require.Len(t, noncesBySender["alice"], weightAlice+1)
+		require.Len(t, noncesBySender["bob"], weightBob+1)
+		for _, nonces := range noncesBySender {
+			for i, nonce := range nonces {
+				require.Equal(t, uint64(i+1), nonce)
+			}
+		}
+
+		// Eventually, all transactions are selected
+		selected = cache.SelectTransactionsWFQ(1000)
+		require.Len(t, selected, 200)
+	})
+
+	t.Run("cost is the fee", func(t *testing.T) {
+		tx := createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50_000, oneBillion)
+		require.Equal(t, uint64(50_000*oneBillion), computeWFQCost(tx))
+
+		tx.PrecomputedFee = 3 * 50_000 * oneBillion
+		require.Equal(t, uint64(3*50_000*oneBillion), computeWFQCost(tx))
+
+		tx = createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 50_000, 0)
+		require.Equal(t, uint64(1), computeWFQCost(tx))
+	})
+
+	t.Run("should select fees in proportion to the fee budgets, weighted by the score", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		for nonce := uint64(1); nonce <= 200; nonce++ {
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte("alice"), int(nonce)), "alice", nonce, 128, 50_000, oneBillion))
+		}
+		for nonce := uint64(1); nonce <= 100; nonce++ {
+			cache.AddTx(createTxWithParams(createFakeTxHash([]byte("bob"), int(nonce)), "bob", nonce, 128, 50_000, oneBillion))
+		}
+
+		budgetAlice := float64(200 * 50_000 * oneBillion)
+		budgetBob := float64(100 * 50_000 * oneBillion)
+		weightAlice := float64(cache.getScoreOfSender("alice") + 1)
+		weightBob := float64(cache.getScoreOfSender("bob") + 1)
+
+		// Neither of the senders is drained
+		selected := cache.SelectTransactionsWFQ(90)
+		require.Len(t, selected, 90)
+
+		feeBySender := make(map[string]float64)
+		for _, tx := range selected {
+			feeBySender[string(tx.Tx.GetSndAddr())] += float64(computeTxFee(tx))
+		}
+
+		expectedRatio := (budgetAlice * weightAlice) / (budgetBob * weightBob)
+		actualRatio := feeBySender["alice"] / feeBySender["bob"]
+		require.InEpsilon(t, expectedRatio, actualRatio, 0.1)
+	})
+
+	t.Run("should not overflow when the fees are huge", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		for nonce := uint64(1); nonce <= 10; nonce++ {
+			txAlice := createTx(createFakeTxHash([]byte("alice"), int(nonce)), "alice", nonce)
+			txAlice.PrecomputedFee = math.MaxUint64
+			cache.AddTx(txAlice)
+
+			txBob := createTx(createFakeTxHash([]byte("bob"), int(nonce)), "bob", nonce)
+			txBob.PrecomputedFee = math.MaxUint64 / 3
+			cache.AddTx(txBob)
+		}
+
+		selected := cache.SelectTransactionsWFQ(100)
+		require.Len(t, selected, 20)
+	})
+
+	t.Run("should handle nonce gaps", func(t *testing.T) {
+		cache := newUnconstrainedCacheToTest()
+
+		cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+		cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+		cache.AddTx(createTx([]byte("hash-alice-4"), "alice", 4))
+		cache.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+		cache.AddTx(createTx([]byte("hash-bob-6"), "bob", 6))
+		cache.NotifyAccountNonce([]byte("bob"), 3)
+
+		selected := cache.SelectTransactionsWFQ(10)
+		hashes := make([]string, 0, len(selected))
+		for _, tx := range selected {
+			hashes = append(hashes, string(tx.TxHash))
+		}
+
+		// Bob has an initial gap (and is not in the grace period), Alice has a middle gap
+		require.Equal(t, []string{"hash-alice-1", "hash-alice-2"}, hashes)
+	})
+}
@@ -1,7 +1,12 @@
 package txcache
 
 import (
+	"bytes"
+	"context"
+	"math"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/multiversx/mx-chain-core-go/core/atomic"
 	"github.com/multiversx/mx-chain-core-go/core/check"
@@ -22,6 +27,7 @@ type TxCache struct {
 	evictionJournal           evictionJournal
 	evictionSnapshotOfSenders []*txListForSender
 	isEvictionInProgress      atomic.Flag
+	isBackgroundEvictionDue   atomic.Flag
 	numSendersSelected        atomic.Counter
 	numSendersWithInitialGap  atomic.Counter
 	numSendersWithMiddleGap   atomic.Counter
@@ -29,6 +35,24 @@ type TxCache struct {
 	sweepingMutex             sync.Mutex
 	sweepingListOfSenders     []*txListForSender
 	mutTxOperation            sync.Mutex
+	scoreRefreshTickerFactory tickerFactory
+	cancelScoreRefresh        context.CancelFunc
+	scoreRefreshDone          chan struct{}
+	mutScoreRefresh           sync.Mutex
+	memoryTickerFactory       tickerFactory
+	heapInUseProvider         func() uint64
+	cancelMemoryMonitoring    context.CancelFunc
+	memoryMonitoringDone      chan struct{}
+	mutMemoryMonitoring       sync.Mutex
+	getNow                    func() time.Time
+	numEvictedTxs             atomic.Counter
+	numSelections             atomic.Counter
+	lastEvictionTimestamp     atomic.Counter
+	lastSelectionTimestamp    atomic.Counter
+	txImportHandler           TxImportHandler
+	mutTxImportHandler        sync.RWMutex
+	backgroundTasks           sync.WaitGroup
+	mutBackgroundTasks        sync.RWMutex
 }
 
 // NewTxCache creates a new transaction cache
@@ -46,16 +70,22 @@ func NewTxCache(config ConfigSourceMe, txGasHandler TxGasHandler) (*TxCache, err
 
 	// Note: for simplicity, we use the same "numChunks" for both internal concurrent maps
 	numChunks := config.NumChunks
+	numScoreChunks := config.getNumScoreChunks()
 	senderConstraintsObj := config.getSenderConstraints()
 	txFeeHelper := newFeeComputationHelper(txGasHandler.MinGasPrice(), txGasHandler.MinGasLimit(), txGasHandler.MinGasPriceForProcessing())
-	scoreComputerObj := newDefaultScoreComputer(txFeeHelper)
+	scoreComputerObj := newDefaultScoreComputer(txFeeHelper, numScoreChunks)
 
 	txCache := &TxCache{
 		name:            config.Name,
-		txListBySender:  newTxListBySenderMap(numChunks, senderConstraintsObj, scoreComputerObj, txGasHandler, txFeeHelper),
+		txListBySender:  newTxListBySenderMap(numChunks, numScoreChunks, senderConstraintsObj, scoreComputerObj, txGasHandler, txFeeHelper),
 		txByHash:        newTxByHashMap(numChunks),
 		config:          config,
 		evictionJournal: evictionJournal{},
+
+		scoreRefreshTickerFactory: newTimeTicker,
+		memoryTickerFactory:       newTimeTicker,
+		heapInUseProvider:         readHeapInUse,
+		getNow:                    time.Now,
 	}
 
 	txCache.initSweepable()
@@ -63,10 +93,33 @@ func NewTxCache(config ConfigSourceMe, txGasHandler TxGasHandler) (*TxCache, err
 }
 
 // AddTx adds a transaction in the cache
-// Eviction happens if maximum capacity is reached
+// Eviction happens if maximum capacity is reached (or, if configured, in background, when the high-water fill ratio is crossed)
+// If configured, while the cache is under pressure, transactions of senders with a low cumulative fee are rejected
+// Invalid transactions (e.g. having the maximum nonce) are rejected, as well; see TryAddTx, which reports the error.
 func (cache *TxCache) AddTx(tx *WrappedTransaction) (ok bool, added bool) {
+	ok, added, err := cache.TryAddTx(tx)
+	if err != nil {
+		log.Trace("TxCache.AddTx(): rejected", "name", cache.name, "tx", tx.TxHash, "sender", tx.Tx.GetSndAddr(), "err", err)
+	}
+
+	return ok, added
+}
+
+// TryAddTx adds a transaction in the cache, just like AddTx does, but it also returns an error if the transaction is invalid
+// (e.g. common.ErrNonceOverflow, if it has the maximum nonce). The rejections due to the limits of the cache are not errors.
+func (cache *TxCache) TryAddTx(tx *WrappedTransaction) (ok bool, added bool, err error) {
 	if tx == nil || check.IfNil(tx.Tx) {
-		return false, false
+		return false, false, nil
+	}
+
+	if tx.Tx.GetNonce() == math.MaxUint64 {
+		// Checked early, so that the sender is not registered (nor transactions evicted) for nothing
+		return false, false, common.ErrNonceOverflow
+	}
+
+	if cache.shouldRejectDueToLowFee(tx) {
+		log.Trace("TxCache.AddTx(): rejected due to low fee of sender", "name", cache.name, "tx", tx.TxHash, "sender", tx.Tx.GetSndAddr())
+		return false, false, nil
 	}
 
 	if cache.config.EvictionEnabled {
@@ -74,8 +127,23 @@ func (cache *TxCache) AddTx(tx *WrappedTransaction) (ok bool, added bool) {
 	}
 
 	cache.mutTxOperation.Lock()
+	if cache.shouldRejectDueToSenderBytes(tx) {
+		cache.mutTxOperation.Unlock()
+		log.Trace("TxCache.AddTx(): rejected due to the bytes limit of sender", "name", cache.name, "tx", tx.TxHash, "sender", tx.Tx.GetSndAddr())
+		return false, false, nil
+	}
+	if tx.ReceivedAt.IsZero() {
+		tx.ReceivedAt = cache.getNow()
+	}
 	addedInByHash := cache.txByHash.addTx(tx)
-	addedInBySender, evicted := cache.txListBySender.addTx(tx)
+	addedInBySender, evicted, err := cache.txListBySender.addTx(tx)
+	if err != nil {
+		if addedInByHash {
+			cache.txByHash.removeTx(string(tx.TxHash))
+		}
+		cache.mutTxOperation.Unlock()
+		return false, false, err
+	}
 	cache.mutTxOperation.Unlock()
 	if addedInByHash != addedInBySender {
 		// This can happen  when two go-routines concur to add the same transaction:
@@ -88,12 +156,22 @@ func (cache *TxCache) AddTx(tx *WrappedTransaction) (ok bool, added bool) {
 
 	if len(evicted) > 0 {
 		cache.monitorEvictionWrtSenderLimit(tx.Tx.GetSndAddr(), evicted)
+		cache.recordEviction(uint32(len(evicted)))
 		cache.txByHash.RemoveTxsBulk(evicted)
 	}
 
+	if cache.config.EvictionEnabled {
+		cache.triggerBackgroundEvictionIfAboveHighWater()
+	}
+
 	// The return value "added" is true even if transaction added, but then removed due to limits be sender.
 	// This it to ensure that onAdded() notification is triggered.
-	return true, addedInByHash || addedInBySender
+	return true, addedInByHash || addedInBySender, nil
+}
+
+// GetConfig returns (a copy of) the effective configuration of the cache, having the defaults applied for the optional fields left unset
+func (cache *TxCache) GetConfig() ConfigSourceMe {
+	return cache.config.withDefaults()
 }
 
 // GetByTxHash gets the transaction by hash
@@ -107,7 +185,7 @@ func (cache *TxCache) GetByTxHash(txHash []byte) (*WrappedTransaction, bool) {
 // Each sender gets the chance to give at least bandwidthPerSender gas worth of transactions, unless "numRequested" limit is reached before iterating over all senders
 func (cache *TxCache) SelectTransactionsWithBandwidth(numRequested int, batchSizePerSender int, bandwidthPerSender uint64) []*WrappedTransaction {
 	result := cache.doSelectTransactions(numRequested, batchSizePerSender, bandwidthPerSender)
-	go cache.doAfterSelection()
+	cache.runInBackground(cache.doAfterSelection)
 	return result
 }
 
@@ -124,7 +202,7 @@ func (cache *TxCache) doSelectTransactions(numRequested int, batchSizePerSender
 		copiedInThisPass := 0
 
 		for _, txList := range snapshotOfSenders {
-			batchSizeWithScoreCoefficient := batchSizePerSender * int(txList.getLastComputedScore()+1)
+			batchSizeWithScoreCoefficient := batchSizePerSender * int(cache.txListBySender.normalizeScore(txList.getLastComputedScore())+1)
 			// Reset happens on first pass only
 			isFirstBatch := pass == 0
 			journal := txList.selectBatchTo(isFirstBatch, result[resultFillIndex:], batchSizeWithScoreCoefficient, bandwidthPerSender)
@@ -152,6 +230,34 @@ func (cache *TxCache) doSelectTransactions(numRequested int, batchSizePerSender
 
 	result = result[:resultFillIndex]
 	cache.monitorSelectionEnd(result, stopWatch)
+	cache.recordSelection()
+	return result
+}
+
+// GetFirstNTransactions returns (at most) the first "n" transactions that a selection would pick, as a lightweight preview
+// (e.g. for fee estimation). It performs a single pass over the senders (in the order of selection), each sender contributing
+// its selectable transactions (see getSelectableTxs). As SelectTransactionsWFQ, it does not alter the state of the senders,
+// thus the preview does not influence the subsequent selections.
+func (cache *TxCache) GetFirstNTransactions(n int) []*WrappedTransaction {
+	if n <= 0 {
+		return make([]*WrappedTransaction, 0)
+	}
+
+	result := make([]*WrappedTransaction, 0, n)
+
+	for _, txList := range cache.getSendersEligibleForSelection() {
+		txs := txList.getSelectableTxs()
+		numMissing := n - len(result)
+		if len(txs) > numMissing {
+			txs = txs[:numMissing]
+		}
+
+		result = append(result, txs...)
+		if len(result) == n {
+			break
+		}
+	}
+
 	return result
 }
 
@@ -220,6 +326,38 @@ func (cache *TxCache) ForEachTransaction(function ForEachTransaction) {
 	cache.txByHash.forEach(function)
 }
 
+// ForEachSenderWeakly iterates over the senders in the cache (lowest-scored senders first), holding no global lock.
+// Meant for long-running diagnostics: unlike the snapshot-based operations, the iteration is weakly consistent -
+// under concurrent additions, removals or score changes, a sender might be missed or visited more than once.
+// The iteration stops when the callback returns false.
+func (cache *TxCache) ForEachSenderWeakly(function ForEachSender) {
+	if function == nil {
+		return
+	}
+
+	cache.txListBySender.forEachSenderAscendingWeakly(func(listForSender *txListForSender) bool {
+		return function([]byte(listForSender.sender), listForSender.countTxWithLock(), listForSender.totalBytes.GetUint64())
+	})
+}
+
+// GetAllSenderHashes returns, for each sender, the hashes of its transactions (ordered by nonce)
+// Useful for the mempool synchronization, where peers exchange the sets of hashes, then only fetch the missing transactions.
+func (cache *TxCache) GetAllSenderHashes() map[string][][]byte {
+	snapshot := cache.txListBySender.getSnapshotAscending()
+	result := make(map[string][][]byte, len(snapshot))
+
+	for _, listForSender := range snapshot {
+		txHashes := listForSender.getTxHashes()
+		if len(txHashes) == 0 {
+			continue
+		}
+
+		result[listForSender.sender] = txHashes
+	}
+
+	return result
+}
+
 // GetTransactionsPoolForSender returns the list of transaction hashes for the sender
 func (cache *TxCache) GetTransactionsPoolForSender(sender string) []*WrappedTransaction {
 	listForSender, ok := cache.txListBySender.getListForSender(sender)
@@ -244,6 +382,31 @@ func (cache *TxCache) Clear() {
 	cache.mutTxOperation.Unlock()
 }
 
+// DrainAll removes all the transactions from the cache and returns them (sorted by sender, then by nonce), in a single operation
+// with respect to the additions and removals: each transaction is either drained, or it remains in the cache (if added afterwards).
+// Useful for draining the mempool on shutdown.
+func (cache *TxCache) DrainAll() []*WrappedTransaction {
+	cache.mutTxOperation.Lock()
+	drained := make([]*WrappedTransaction, 0, cache.txByHash.counter.Get())
+	cache.txByHash.forEach(func(_ []byte, tx *WrappedTransaction) {
+		drained = append(drained, tx)
+	})
+	cache.txListBySender.clear()
+	cache.txByHash.clear()
+	cache.mutTxOperation.Unlock()
+
+	sort.Slice(drained, func(i, j int) bool {
+		senderComparison := bytes.Compare(drained[i].Tx.GetSndAddr(), drained[j].Tx.GetSndAddr())
+		if senderComparison != 0 {
+			return senderComparison < 0
+		}
+
+		return drained[i].Tx.GetNonce() < drained[j].Tx.GetNonce()
+	})
+
+	return drained
+}
+
 // Put is not implemented
 func (cache *TxCache) Put(_ []byte, _ interface{}, _ int) (evicted bool) {
 	log.Error("TxCache.Put is not implemented")
@@ -314,15 +477,91 @@ func (cache *TxCache) NotifyAccountNonce(accountKey []byte, nonce uint64) {
 	cache.txListBySender.notifyAccountNonce(accountKey, nonce)
 }
 
+// GetPendingNonce returns the nonce following the highest contiguous nonce (reachable from the account nonce) of the sender's transactions
+// If the cache holds no transaction of the sender having the account nonce, the account nonce is returned.
+func (cache *TxCache) GetPendingNonce(sender []byte, accountNonce uint64) (uint64, error) {
+	if len(sender) == 0 {
+		return 0, common.ErrEmptyKey
+	}
+
+	listForSender, ok := cache.txListBySender.getListForSender(string(sender))
+	if !ok {
+		return accountNonce, nil
+	}
+
+	highestNonce, ok := listForSender.GetHighestContiguousNonce(accountNonce)
+	if !ok {
+		return accountNonce, nil
+	}
+
+	return highestNonce + 1, nil
+}
+
+// SenderWithGap holds a sender whose transactions are broken by a nonce gap (see TxCache.GetSendersWithPendingGap)
+type SenderWithGap struct {
+	Sender       []byte
+	AccountNonce uint64
+	MissingNonce uint64
+}
+
+// GetSendersWithPendingGap returns the senders whose transactions, starting at the (last notified) account nonce, are broken by a nonce gap,
+// along with the first missing nonce. Such senders are stuck (partially or completely) until the missing transaction arrives.
+// The senders whose account nonce hasn't been notified are not reported, unless the lowest nonce of their transactions serves
+// as their account nonce (see ConfigSourceMe.UseLowestNonceAsImplicitAccountNonce).
+func (cache *TxCache) GetSendersWithPendingGap() []SenderWithGap {
+	snapshot := cache.txListBySender.getSnapshotAscending()
+	result := make([]SenderWithGap, 0)
+
+	for _, listForSender := range snapshot {
+		accountNonce, missingNonce, hasGap := listForSender.getFirstMissingNonce()
+		if !hasGap {
+			continue
+		}
+
+		result = append(result, SenderWithGap{
+			Sender:       []byte(listForSender.sender),
+			AccountNonce: accountNonce,
+			MissingNonce: missingNonce,
+		})
+	}
+
+	return result
+}
+
 // ImmunizeTxsAgainstEviction does nothing for this type of cache
 func (cache *TxCache) ImmunizeTxsAgainstEviction(_ [][]byte) {
 }
 
-// Close does nothing for this cacher implementation
+// Close stops the background goroutines of the cache (if any) and waits for them to exit, including the short-lived ones
+// (e.g. the background eviction or the sweeping after a selection). It is safe to call it multiple times, or before Start.
+// The cache can be started again afterwards.
 func (cache *TxCache) Close() error {
+	cache.stopScoreRefresh()
+	cache.stopMemoryMonitoring()
+	cache.waitForBackgroundTasks()
 	return nil
 }
 
+// runInBackground runs the (short-lived) task on a separate goroutine, tracked so that Close can wait for it
+func (cache *TxCache) runInBackground(task func()) {
+	cache.mutBackgroundTasks.RLock()
+	cache.backgroundTasks.Add(1)
+	cache.mutBackgroundTasks.RUnlock()
+
+	go func() {
+		defer cache.backgroundTasks.Done()
+		task()
+	}()
+}
+
+func (cache *TxCache) waitForBackgroundTasks() {
+	// No task is scheduled while waiting
+	cache.mutBackgroundTasks.Lock()
+	defer cache.mutBackgroundTasks.Unlock()
+
+	cache.backgroundTasks.Wait()
+}
+
 // IsInterfaceNil returns true if there is no value under the interface
 func (cache *TxCache) IsInterfaceNil() bool {
 	return cache == nil
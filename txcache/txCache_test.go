@@ -57,6 +57,22 @@ func Test_NewTxCache(t *testing.T) {
 	badConfig.CountPerSenderThreshold = 0
 	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.CountPerSenderThreshold", txGasHandler)
 
+	badConfig = config
+	badConfig.ScoreRefreshInterval = -1
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.ScoreRefreshInterval", txGasHandler)
+
+	badConfig = config
+	badConfig.MemoryPressureCheckInterval = -1
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.MemoryPressureCheckInterval", txGasHandler)
+
+	badConfig = config
+	badConfig.NumScoreChunks = numScoreChunksLowerBound - 1
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.NumScoreChunks", txGasHandler)
+
+	badConfig = config
+	badConfig.NumScoreChunks = numScoreChunksUpperBound + 1
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.NumScoreChunks", txGasHandler)
+
 	badConfig = config
 	cache, err = NewTxCache(config, nil)
 	require.Nil(t, cache)
@@ -73,6 +89,31 @@ func Test_NewTxCache(t *testing.T) {
 	badConfig = withEvictionConfig
 	badConfig.NumSendersToPreemptivelyEvict = 0
 	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.NumSendersToPreemptivelyEvict", txGasHandler)
+
+	badConfig = withEvictionConfig
+	badConfig.EvictionHighWaterRatio = 1.1
+	badConfig.EvictionLowWaterRatio = 0.75
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.EvictionHighWaterRatio", txGasHandler)
+
+	badConfig = withEvictionConfig
+	badConfig.EvictionLowWaterRatio = 0.75
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.EvictionHighWaterRatio", txGasHandler)
+
+	badConfig = withEvictionConfig
+	badConfig.EvictionHighWaterRatio = 0.9
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.EvictionLowWaterRatio", txGasHandler)
+
+	badConfig = withEvictionConfig
+	badConfig.EvictionHighWaterRatio = 0.9
+	badConfig.EvictionLowWaterRatio = 0.9
+	requireErrorOnNewTxCache(t, badConfig, common.ErrInvalidConfig, "config.EvictionLowWaterRatio", txGasHandler)
+
+	goodConfig := withEvictionConfig
+	goodConfig.EvictionHighWaterRatio = 0.9
+	goodConfig.EvictionLowWaterRatio = 0.75
+	cache, err = NewTxCache(goodConfig, txGasHandler)
+	require.Nil(t, err)
+	require.NotNil(t, cache)
 }
 
 func requireErrorOnNewTxCache(t *testing.T, config ConfigSourceMe, errExpected error, errPartialMessage string, txGasHandler TxGasHandler) {
@@ -82,6 +123,38 @@ func requireErrorOnNewTxCache(t *testing.T, config ConfigSourceMe, errExpected e
 	require.Contains(t, errReceived.Error(), errPartialMessage)
 }
 
+func Test_GetConfig(t *testing.T) {
+	config := ConfigSourceMe{
+		Name:                       "test",
+		NumChunks:                  16,
+		NumBytesPerSenderThreshold: maxNumBytesPerSenderUpperBound,
+		CountPerSenderThreshold:    math.MaxUint32,
+	}
+	txGasHandler, _ := dummyParams()
+
+	cache, err := NewTxCache(config, txGasHandler)
+	require.Nil(t, err)
+
+	effectiveConfig := cache.GetConfig()
+	require.Equal(t, "test", effectiveConfig.Name)
+	require.Equal(t, uint32(16), effectiveConfig.NumChunks)
+	require.Equal(t, defaultNumScoreChunks, effectiveConfig.NumScoreChunks)
+	require.Equal(t, defaultMemoryPressureCheckInterval, effectiveConfig.MemoryPressureCheckInterval)
+
+	// The returned value is a copy
+	effectiveConfig.NumChunks = 1
+	effectiveConfig.NumScoreChunks = 1
+	require.Equal(t, uint32(16), cache.GetConfig().NumChunks)
+	require.Equal(t, defaultNumScoreChunks, cache.GetConfig().NumScoreChunks)
+	require.Equal(t, uint32(16), cache.config.NumChunks)
+
+	// Explicit values are kept
+	config.NumScoreChunks = 42
+	cache, err = NewTxCache(config, txGasHandler)
+	require.Nil(t, err)
+	require.Equal(t, uint32(42), cache.GetConfig().NumScoreChunks)
+}
+
 func Test_AddTx(t *testing.T) {
 	cache := newUnconstrainedCacheToTest()
 
@@ -117,6 +190,30 @@ func Test_AddNilTx_DoesNothing(t *testing.T) {
 	require.Nil(t, foundTx)
 }
 
+func Test_AddTx_RejectsMaxNonce(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	ok, added := cache.AddTx(createTx([]byte("hash-alice-max"), "alice", math.MaxUint64))
+	require.False(t, ok)
+	require.False(t, added)
+	require.Equal(t, uint64(0), cache.CountTx())
+
+	foundTx, ok := cache.GetByTxHash([]byte("hash-alice-max"))
+	require.False(t, ok)
+	require.Nil(t, foundTx)
+
+	ok, added, err := cache.TryAddTx(createTx([]byte("hash-alice-max"), "alice", math.MaxUint64))
+	require.False(t, ok)
+	require.False(t, added)
+	require.Equal(t, common.ErrNonceOverflow, err)
+	require.Equal(t, uint64(0), cache.CountSenders())
+
+	ok, added, err = cache.TryAddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	require.True(t, ok)
+	require.True(t, added)
+	require.Nil(t, err)
+}
+
 func Test_AddTx_AppliesSizeConstraintsPerSenderForNumTransactions(t *testing.T) {
 	cache := newCacheToTest(maxNumBytesPerSenderUpperBound, 3)
 
@@ -245,6 +342,93 @@ func Test_Clear(t *testing.T) {
 	require.Equal(t, uint64(0), cache.CountTx())
 }
 
+func Test_DrainAll(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("hash-bob-7"), "bob", 7))
+	cache.AddTx(createTx([]byte("hash-alice-42"), "alice", 42))
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+
+	drained := cache.DrainAll()
+	require.Len(t, drained, 3)
+	require.Equal(t, []byte("hash-alice-1"), drained[0].TxHash)
+	require.Equal(t, []byte("hash-alice-42"), drained[1].TxHash)
+	require.Equal(t, []byte("hash-bob-7"), drained[2].TxHash)
+
+	require.Equal(t, uint64(0), cache.CountTx())
+	require.Equal(t, uint64(0), cache.CountSenders())
+	require.Equal(t, 0, cache.NumBytes())
+	require.Empty(t, cache.DrainAll())
+}
+
+func Test_DrainAll_ConcurrentWithAdditions(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	numAdders := 4
+	numTxsPerAdder := 1000
+
+	mutDrained := sync.Mutex{}
+	drainedHashes := make(map[string]int)
+	recordDrained := func(txs []*WrappedTransaction) {
+		mutDrained.Lock()
+		for _, tx := range txs {
+			drainedHashes[string(tx.TxHash)]++
+		}
+		mutDrained.Unlock()
+	}
+
+	addersDone := make(chan struct{})
+	wgAdders := sync.WaitGroup{}
+	wgAdders.Add(numAdders)
+	for i := 0; i < numAdders; i++ {
+		go func(adder int) {
+			defer wgAdders.Done()
+
+			sender := string(createFakeSenderAddress(adder))
+			for nonce := 1; nonce <= numTxsPerAdder; nonce++ {
+				hash := createFakeTxHash([]byte(sender), nonce)
+				cache.AddTx(createTx(hash, sender, uint64(nonce)))
+			}
+		}(i)
+	}
+
+	wgDrainer := sync.WaitGroup{}
+	wgDrainer.Add(1)
+	go func() {
+		defer wgDrainer.Done()
+
+		for {
+			select {
+			case <-addersDone:
+				return
+			default:
+				recordDrained(cache.DrainAll())
+			}
+		}
+	}()
+
+	wgAdders.Wait()
+	close(addersDone)
+	wgDrainer.Wait()
+
+	remaining := make(map[string]struct{})
+	cache.ForEachTransaction(func(txHash []byte, _ *WrappedTransaction) {
+		remaining[string(txHash)] = struct{}{}
+	})
+
+	// Each transaction is either drained (exactly once) or still present, never both or neither
+	for adder := 0; adder < numAdders; adder++ {
+		sender := createFakeSenderAddress(adder)
+		for nonce := 1; nonce <= numTxsPerAdder; nonce++ {
+			hash := string(createFakeTxHash(sender, nonce))
+			_, isRemaining := remaining[hash]
+			numDrained := drainedHashes[hash]
+
+			require.True(t, (numDrained == 1) != isRemaining, "tx %s drained %d times, remaining: %v", hash, numDrained, isRemaining)
+		}
+	}
+}
+
 func Test_ForEachTransaction(t *testing.T) {
 	cache := newUnconstrainedCacheToTest()
 
@@ -298,6 +482,338 @@ func Test_GetTransactionsPoolForSender(t *testing.T) {
 	require.Equal(t, expectedTxs, txs)
 }
 
+func Test_AddTx_SetsReceivedAt(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+	now := time.Unix(1_000_000, 0)
+	cache.getNow = func() time.Time {
+		return now
+	}
+
+	// Not set by the wrapper
+	txAlice := createTx([]byte("hash-alice-1"), "alice", 1)
+	cache.AddTx(txAlice)
+
+	// Set by the wrapper
+	receivedAt := now.Add(-time.Minute)
+	txBob := createTx([]byte("hash-bob-1"), "bob", 1)
+	txBob.ReceivedAt = receivedAt
+	cache.AddTx(txBob)
+
+	foundTx, ok := cache.GetByTxHash([]byte("hash-alice-1"))
+	require.True(t, ok)
+	require.Equal(t, now, foundTx.ReceivedAt)
+	require.Equal(t, time.Duration(0), foundTx.Age(cache.getNow()))
+
+	foundTx, ok = cache.GetByTxHash([]byte("hash-bob-1"))
+	require.True(t, ok)
+	require.Equal(t, receivedAt, foundTx.ReceivedAt)
+	require.Equal(t, time.Minute, foundTx.Age(cache.getNow()))
+
+	// Age increases over time
+	now = now.Add(time.Hour)
+	require.Equal(t, time.Hour, txAlice.Age(cache.getNow()))
+	require.Equal(t, time.Hour+time.Minute, txBob.Age(cache.getNow()))
+
+	// Removing and adding back the transaction preserves the moment of receipt
+	cache.RemoveTxByHash([]byte("hash-alice-1"))
+	_, ok = cache.GetByTxHash([]byte("hash-alice-1"))
+	require.False(t, ok)
+
+	cache.AddTx(txAlice)
+	foundTx, ok = cache.GetByTxHash([]byte("hash-alice-1"))
+	require.True(t, ok)
+	require.Equal(t, now.Add(-time.Hour), foundTx.ReceivedAt)
+}
+
+func Test_GetAllSenderHashes(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+	require.Empty(t, cache.GetAllSenderHashes())
+
+	cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+	cache.AddTx(createTx([]byte("hash-bob-3"), "bob", 3))
+	cache.AddTx(createTx([]byte("hash-bob-4"), "bob", 4))
+
+	expected := map[string][][]byte{
+		"alice": {[]byte("hash-alice-1"), []byte("hash-alice-2")},
+		"bob":   {[]byte("hash-bob-3"), []byte("hash-bob-4"), []byte("hash-bob-5")},
+	}
+	require.Equal(t, expected, cache.GetAllSenderHashes())
+
+	cache.RemoveTxByHash([]byte("hash-alice-1"))
+	cache.RemoveTxByHash([]byte("hash-alice-2"))
+	cache.RemoveTxByHash([]byte("hash-bob-4"))
+
+	expected = map[string][][]byte{
+		"bob": {[]byte("hash-bob-3"), []byte("hash-bob-5")},
+	}
+	require.Equal(t, expected, cache.GetAllSenderHashes())
+}
+
+func Test_ForEachSenderWeakly(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+	cache.ForEachSenderWeakly(nil)
+
+	numSenders := 100
+	addManyTransactionsWithUniformDistribution(cache, numSenders, 3)
+
+	collect := func() map[string]uint64 {
+		visited := make(map[string]uint64)
+		cache.ForEachSenderWeakly(func(sender []byte, numTxs uint64, _ uint64) bool {
+			visited[string(sender)] = numTxs
+			return true
+		})
+		return visited
+	}
+
+	// Without concurrent mutations, the iteration eventually visits a stable set (all the senders)
+	visited := collect()
+	require.Len(t, visited, numSenders)
+	require.Equal(t, visited, collect())
+	for _, numTxs := range visited {
+		require.Equal(t, uint64(3), numTxs)
+	}
+
+	// The iteration stops when the callback returns false
+	numVisited := 0
+	cache.ForEachSenderWeakly(func(_ []byte, _ uint64, _ uint64) bool {
+		numVisited++
+		return numVisited < 10
+	})
+	require.Equal(t, 10, numVisited)
+}
+
+func Test_ForEachSenderWeakly_NoPanicIfConcurrentMutation(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+	addManyTransactionsWithUniformDistribution(cache, 100, 10)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			cache.ForEachSenderWeakly(func(_ []byte, _ uint64, _ uint64) bool {
+				return true
+			})
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			sender := createFakeSenderAddress(i)
+			cache.AddTx(createTx(createFakeTxHash(sender, 1000), string(sender), 1000))
+			cache.RemoveTxByHash(createFakeTxHash(sender, 1))
+		}
+	}()
+
+	wg.Wait()
+}
+
+func Test_GetPendingNonce(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("hash-alice-5"), "alice", 5))
+	cache.AddTx(createTx([]byte("hash-alice-6"), "alice", 6))
+	cache.AddTx(createTx([]byte("hash-alice-6-bis"), "alice", 6))
+	cache.AddTx(createTx([]byte("hash-alice-7"), "alice", 7))
+	cache.AddTx(createTx([]byte("hash-alice-9"), "alice", 9))
+
+	nonce, err := cache.GetPendingNonce([]byte("alice"), 5)
+	require.Nil(t, err)
+	require.Equal(t, uint64(8), nonce)
+
+	nonce, err = cache.GetPendingNonce([]byte("alice"), 7)
+	require.Nil(t, err)
+	require.Equal(t, uint64(8), nonce)
+
+	// No transaction at the account nonce
+	nonce, err = cache.GetPendingNonce([]byte("alice"), 4)
+	require.Nil(t, err)
+	require.Equal(t, uint64(4), nonce)
+
+	nonce, err = cache.GetPendingNonce([]byte("alice"), 8)
+	require.Nil(t, err)
+	require.Equal(t, uint64(8), nonce)
+
+	nonce, err = cache.GetPendingNonce([]byte("alice"), 9)
+	require.Nil(t, err)
+	require.Equal(t, uint64(10), nonce)
+
+	// Unknown sender
+	nonce, err = cache.GetPendingNonce([]byte("bob"), 42)
+	require.Nil(t, err)
+	require.Equal(t, uint64(42), nonce)
+
+	nonce, err = cache.GetPendingNonce(nil, 42)
+	require.Equal(t, common.ErrEmptyKey, err)
+	require.Equal(t, uint64(0), nonce)
+}
+
+func Test_GetSendersWithPendingGap(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	// Contiguous (with a duplicate, and with an already executed nonce)
+	cache.AddTx(createTx([]byte("hash-alice-4"), "alice", 4))
+	cache.AddTx(createTx([]byte("hash-alice-5"), "alice", 5))
+	cache.AddTx(createTx([]byte("hash-alice-6"), "alice", 6))
+	cache.AddTx(createTx([]byte("hash-alice-6-bis"), "alice", 6))
+	cache.AddTx(createTx([]byte("hash-alice-7"), "alice", 7))
+	cache.NotifyAccountNonce([]byte("alice"), 5)
+
+	// Initial gap
+	cache.AddTx(createTx([]byte("hash-bob-7"), "bob", 7))
+	cache.AddTx(createTx([]byte("hash-bob-8"), "bob", 8))
+	cache.NotifyAccountNonce([]byte("bob"), 5)
+
+	// Middle gap
+	cache.AddTx(createTx([]byte("hash-carol-1"), "carol", 1))
+	cache.AddTx(createTx([]byte("hash-carol-2"), "carol", 2))
+	cache.AddTx(createTx([]byte("hash-carol-2-bis"), "carol", 2))
+	cache.AddTx(createTx([]byte("hash-carol-4"), "carol", 4))
+	cache.NotifyAccountNonce([]byte("carol"), 1)
+
+	// Gapped, but the account nonce is not known
+	cache.AddTx(createTx([]byte("hash-dave-3"), "dave", 3))
+	cache.AddTx(createTx([]byte("hash-dave-5"), "dave", 5))
+
+	// All transactions already executed
+	cache.AddTx(createTx([]byte("hash-eve-1"), "eve", 1))
+	cache.NotifyAccountNonce([]byte("eve"), 2)
+
+	sendersWithGap := cache.GetSendersWithPendingGap()
+	require.ElementsMatch(t, []SenderWithGap{
+		{Sender: []byte("bob"), AccountNonce: 5, MissingNonce: 5},
+		{Sender: []byte("carol"), AccountNonce: 1, MissingNonce: 3},
+	}, sendersWithGap)
+
+	// The gap of bob is filled
+	cache.AddTx(createTx([]byte("hash-bob-5"), "bob", 5))
+	cache.AddTx(createTx([]byte("hash-bob-6"), "bob", 6))
+	sendersWithGap = cache.GetSendersWithPendingGap()
+	require.Equal(t, []SenderWithGap{{Sender: []byte("carol"), AccountNonce: 1, MissingNonce: 3}}, sendersWithGap)
+
+	// No sender
+	require.Equal(t, []SenderWithGap{}, newUnconstrainedCacheToTest().GetSendersWithPendingGap())
+}
+
+func Test_GetSendersWithPendingGap_WithImplicitAccountNonce(t *testing.T) {
+	txGasHandler, _ := dummyParams()
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                                 "test",
+		NumChunks:                            16,
+		NumBytesPerSenderThreshold:           maxNumBytesPerSenderUpperBound,
+		CountPerSenderThreshold:              math.MaxUint32,
+		UseLowestNonceAsImplicitAccountNonce: true,
+	}, txGasHandler)
+	require.Nil(t, err)
+
+	// Contiguous, not notified
+	cache.AddTx(createTx([]byte("hash-alice-4"), "alice", 4))
+	cache.AddTx(createTx([]byte("hash-alice-5"), "alice", 5))
+
+	// Middle gap, not notified
+	cache.AddTx(createTx([]byte("hash-dave-3"), "dave", 3))
+	cache.AddTx(createTx([]byte("hash-dave-5"), "dave", 5))
+
+	// Contiguous, but with an initial gap with respect to the notified account nonce
+	cache.AddTx(createTx([]byte("hash-bob-7"), "bob", 7))
+	cache.AddTx(createTx([]byte("hash-bob-8"), "bob", 8))
+	cache.NotifyAccountNonce([]byte("bob"), 5)
+
+	sendersWithGap := cache.GetSendersWithPendingGap()
+	require.ElementsMatch(t, []SenderWithGap{
+		{Sender: []byte("dave"), AccountNonce: 3, MissingNonce: 4},
+		{Sender: []byte("bob"), AccountNonce: 5, MissingNonce: 5},
+	}, sendersWithGap)
+
+	// The explicit notification takes precedence
+	cache.NotifyAccountNonce([]byte("alice"), 3)
+	cache.NotifyAccountNonce([]byte("dave"), 2)
+	sendersWithGap = cache.GetSendersWithPendingGap()
+	require.ElementsMatch(t, []SenderWithGap{
+		{Sender: []byte("alice"), AccountNonce: 3, MissingNonce: 3},
+		{Sender: []byte("dave"), AccountNonce: 2, MissingNonce: 2},
+		{Sender: []byte("bob"), AccountNonce: 5, MissingNonce: 5},
+	}, sendersWithGap)
+
+	// Without the option, the senders not notified are not reported
+	cache = newUnconstrainedCacheToTest()
+	cache.AddTx(createTx([]byte("hash-dave-3"), "dave", 3))
+	cache.AddTx(createTx([]byte("hash-dave-5"), "dave", 5))
+	require.Equal(t, []SenderWithGap{}, cache.GetSendersWithPendingGap())
+}
+
+func Test_GetScoreChunksHistogram(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-bob-1"), "bob", 1))
+	cache.AddTx(createTx([]byte("hash-carol-1"), "carol", 1))
+
+	histogram, total := cache.GetScoreChunksHistogram()
+	require.Len(t, histogram, int(defaultNumScoreChunks))
+	require.Equal(t, uint32(3), total)
+}
+
+func Test_NumScoreChunks(t *testing.T) {
+	txGasHandler, _ := dummyParams()
+	cache, err := NewTxCache(ConfigSourceMe{
+		Name:                       "test",
+		NumChunks:                  16,
+		NumBytesPerSenderThreshold: maxNumBytesPerSenderUpperBound,
+		CountPerSenderThreshold:    math.MaxUint32,
+		NumScoreChunks:             1000,
+	}, txGasHandler)
+	require.Nil(t, err)
+
+	cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 128, 50000, uint64(1.2*oneBillion)))
+
+	histogram, total := cache.GetScoreChunksHistogram()
+	require.Len(t, histogram, 1000)
+	require.Equal(t, uint32(2), total)
+
+	// The scores range over the configured number of chunks, but are normalized for selection
+	reference := newUnconstrainedCacheToTest()
+	reference.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50000, oneBillion))
+	reference.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 128, 50000, uint64(1.2*oneBillion)))
+
+	for _, sender := range []string{"alice", "bob"} {
+		score := cache.getScoreOfSender(sender)
+		referenceScore := reference.getScoreOfSender(sender)
+		require.Greater(t, score, referenceScore)
+		require.Equal(t, referenceScore, cache.txListBySender.normalizeScore(score))
+	}
+}
+
+func Test_GetTransactionsSortedGlobally(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+	require.Empty(t, cache.GetTransactionsSortedGlobally())
+
+	cache.AddTx(createTxWithParams([]byte("hash-bob-2"), "bob", 2, 128, 50000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-alice-2"), "alice", 2, 128, 50000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-carol-1"), "carol", 1, 128, 50000, uint64(1.5*oneBillion)))
+	cache.AddTx(createTxWithParams([]byte("hash-bob-1"), "bob", 1, 128, 50000, oneBillion))
+	cache.AddTx(createTxWithParams([]byte("hash-alice-1"), "alice", 1, 128, 50000, oneBillion))
+
+	// Alice and Bob have the same score, lower than Carol's
+	require.Equal(t, cache.getScoreOfSender("alice"), cache.getScoreOfSender("bob"))
+	require.Greater(t, cache.getScoreOfSender("carol"), cache.getScoreOfSender("alice"))
+
+	sorted := cache.GetTransactionsSortedGlobally()
+	hashes := make([]string, 0, len(sorted))
+	for _, tx := range sorted {
+		hashes = append(hashes, string(tx.TxHash))
+	}
+
+	require.Equal(t, []string{"hash-carol-1", "hash-alice-1", "hash-alice-2", "hash-bob-1", "hash-bob-2"}, hashes)
+}
+
 func Test_SelectTransactions_Dummy(t *testing.T) {
 	cache := newUnconstrainedCacheToTest()
 
@@ -352,6 +868,94 @@ func Test_SelectTransactions_BreaksAtNonceGaps(t *testing.T) {
 	require.Len(t, sorted, numSelected)
 }
 
+func Test_SelectTransactionsWithBandwidth_ShouldNotSelectTwiceWithinRound(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	senders := []string{"alice", "bob", "carol"}
+	for _, sender := range senders {
+		for nonce := uint64(1); nonce <= 5; nonce++ {
+			cache.AddTx(createTx([]byte(fmt.Sprintf("hash-%s-%d", sender, nonce)), sender, nonce))
+		}
+	}
+
+	requireSelectedOnce := func(selection []*WrappedTransaction) {
+		require.Len(t, selection, 15)
+
+		hashes := make(map[string]struct{})
+		for _, tx := range selection {
+			hashes[string(tx.TxHash)] = struct{}{}
+		}
+		require.Len(t, hashes, 15)
+	}
+
+	// Many passes (one transaction per sender, per pass), within the same round
+	requireSelectedOnce(cache.SelectTransactionsWithBandwidth(100, 1, math.MaxUint64))
+
+	// A new selection round starts with cleared marks
+	requireSelectedOnce(cache.SelectTransactionsWithBandwidth(100, 1, math.MaxUint64))
+}
+
+func Test_GetFirstNTransactions(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-alice-2"), "alice", 2))
+	cache.AddTx(createTx([]byte("hash-alice-3"), "alice", 3))
+	cache.AddTx(createTx([]byte("hash-alice-5"), "alice", 5))
+	cache.AddTx(createTx([]byte("hash-bob-42"), "bob", 42))
+	cache.AddTx(createTx([]byte("hash-bob-44"), "bob", 44))
+	cache.AddTx(createTx([]byte("hash-carol-7"), "carol", 7))
+	cache.AddTx(createTx([]byte("hash-carol-8"), "carol", 8))
+
+	require.Len(t, cache.GetFirstNTransactions(0), 0)
+	require.Len(t, cache.GetFirstNTransactions(-1), 0)
+	require.Len(t, cache.GetFirstNTransactions(4), 4)
+
+	// Breaks at nonce gaps
+	preview := cache.GetFirstNTransactions(100)
+	require.Len(t, preview, 3+1+2)
+
+	nonces := make(map[string]uint64)
+	for _, tx := range preview {
+		sender := string(tx.Tx.GetSndAddr())
+		require.Less(t, nonces[sender], tx.Tx.GetNonce())
+		nonces[sender] = tx.Tx.GetNonce()
+	}
+
+	// The selection is not affected by the preview
+	selection := cache.SelectTransactionsWithBandwidth(100, 100, math.MaxUint64)
+	require.ElementsMatch(t, preview, selection)
+}
+
+func Test_GetFirstNTransactions_DoesNotAlterSelectionState(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	cache.AddTx(createTx([]byte("hash-alice-1"), "alice", 1))
+	cache.AddTx(createTx([]byte("hash-bob-42"), "bob", 42))
+	cache.AddTx(createTx([]byte("hash-bob-43"), "bob", 43))
+	cache.NotifyAccountNonce([]byte("bob"), 40)
+
+	listForBob, ok := cache.txListBySender.getListForSender("bob")
+	require.True(t, ok)
+
+	// "bob" has an initial gap
+	for i := 0; i < 5; i++ {
+		preview := cache.GetFirstNTransactions(10)
+		require.Len(t, preview, 1)
+		require.Equal(t, []byte("hash-alice-1"), preview[0].TxHash)
+	}
+	require.Equal(t, uint64(0), listForBob.numFailedSelections.GetUint64())
+	require.Equal(t, 0, listForBob.copySnapshot.Len())
+
+	// The selections (and not the previews) count towards the grace period
+	_ = cache.SelectTransactionsWithBandwidth(10, 10, math.MaxUint64)
+	_ = cache.SelectTransactionsWithBandwidth(10, 10, math.MaxUint64)
+	require.Equal(t, uint64(2), listForBob.numFailedSelections.GetUint64())
+	preview := cache.GetFirstNTransactions(10)
+	require.Len(t, preview, 2)
+	require.Equal(t, uint64(2), listForBob.numFailedSelections.GetUint64())
+}
+
 func Test_SelectTransactions(t *testing.T) {
 	cache := newUnconstrainedCacheToTest()
 
@@ -463,6 +1067,63 @@ func Test_NotImplementedFunctions(t *testing.T) {
 	require.Nil(t, err)
 }
 
+func TestTxCache_CloseIsIdempotent(t *testing.T) {
+	cache, _ := newCacheWithScoreRefreshToTest(time.Hour)
+	cache.config.MaxHeapBytes = math.MaxUint64
+
+	// Before Start
+	require.NotPanics(t, func() {
+		require.Nil(t, cache.Close())
+		require.Nil(t, cache.Close())
+	})
+
+	cache.Start()
+	require.NotPanics(t, func() {
+		require.Nil(t, cache.Close())
+		require.Nil(t, cache.Close())
+	})
+	require.Nil(t, cache.cancelScoreRefresh)
+	require.Nil(t, cache.cancelMemoryMonitoring)
+}
+
+func TestTxCache_CloseWaitsForBackgroundTasks(t *testing.T) {
+	cache := newUnconstrainedCacheToTest()
+
+	proceed := make(chan struct{})
+	taskDone := make(chan struct{})
+	cache.runInBackground(func() {
+		<-proceed
+		close(taskDone)
+	})
+
+	closeDone := make(chan struct{})
+	go func() {
+		_ = cache.Close()
+		close(closeDone)
+	}()
+
+	select {
+	case <-closeDone:
+		require.Fail(t, "Close should wait for the background task")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(proceed)
+
+	select {
+	case <-closeDone:
+	case <-time.After(time.Second):
+		require.Fail(t, "Close should have returned")
+	}
+
+	// The task has ended before Close returned
+	select {
+	case <-taskDone:
+	default:
+		require.Fail(t, "the background task should have ended")
+	}
+}
+
 func Test_IsInterfaceNil(t *testing.T) {
 	cache := newUnconstrainedCacheToTest()
 	require.False(t, check.IfNil(cache))
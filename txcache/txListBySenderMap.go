@@ -4,43 +4,48 @@ import (
 	"sync"
 
 	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/txcache/maps"
 )
 
-const numberOfScoreChunks = uint32(100)
-
 // txListBySenderMap is a map-like structure for holding and accessing transactions by sender
 type txListBySenderMap struct {
 	backingMap        *maps.BucketSortedMap
+	numScoreChunks    uint32
 	senderConstraints senderConstraints
 	counter           atomic.Counter
 	scoreComputer     scoreComputer
 	txGasHandler      TxGasHandler
 	txFeeHelper       feeHelper
 	mutex             sync.Mutex
+	// recentSenders allows the lookups of the most active senders to skip the (locking) backing map
+	recentSenders *recentSendersCache
 }
 
 // newTxListBySenderMap creates a new instance of TxListBySenderMap
 func newTxListBySenderMap(
 	nChunksHint uint32,
+	numScoreChunks uint32,
 	senderConstraints senderConstraints,
 	scoreComputer scoreComputer,
 	txGasHandler TxGasHandler,
 	txFeeHelper feeHelper,
 ) *txListBySenderMap {
-	backingMap := maps.NewBucketSortedMap(nChunksHint, numberOfScoreChunks)
+	backingMap := maps.NewBucketSortedMap(nChunksHint, numScoreChunks, nil)
 
 	return &txListBySenderMap{
 		backingMap:        backingMap,
+		numScoreChunks:    numScoreChunks,
 		senderConstraints: senderConstraints,
 		scoreComputer:     scoreComputer,
 		txGasHandler:      txGasHandler,
 		txFeeHelper:       txFeeHelper,
+		recentSenders:     newRecentSendersCache(),
 	}
 }
 
 // addTx adds a transaction in the map, in the corresponding list (selected by its sender)
-func (txMap *txListBySenderMap) addTx(tx *WrappedTransaction) (bool, [][]byte) {
+func (txMap *txListBySenderMap) addTx(tx *WrappedTransaction) (bool, [][]byte, error) {
 	sender := string(tx.Tx.GetSndAddr())
 	listForSender := txMap.getOrAddListForSender(sender)
 	return listForSender.AddTx(tx, txMap.txGasHandler, txMap.txFeeHelper)
@@ -64,13 +69,20 @@ func (txMap *txListBySenderMap) getOrAddListForSender(sender string) *txListForS
 	return txMap.addSender(sender)
 }
 
+// getListForSender looks up the sender in the cache of recent senders first (without locking), then in the backing map
 func (txMap *txListBySenderMap) getListForSender(sender string) (*txListForSender, bool) {
+	listForSender, observedRecentSenders, ok := txMap.recentSenders.get(sender)
+	if ok {
+		return listForSender, true
+	}
+
 	listForSenderUntyped, ok := txMap.backingMap.Get(sender)
 	if !ok {
 		return nil, false
 	}
 
-	listForSender := listForSenderUntyped.(*txListForSender)
+	listForSender = listForSenderUntyped.(*txListForSender)
+	txMap.recentSenders.tryAdd(observedRecentSenders, listForSender)
 	return listForSender, true
 }
 
@@ -113,6 +125,7 @@ func (txMap *txListBySenderMap) removeTx(tx *WrappedTransaction) bool {
 
 func (txMap *txListBySenderMap) removeSender(sender string) bool {
 	_, removed := txMap.backingMap.Remove(sender)
+	txMap.recentSenders.remove(sender)
 	if removed {
 		txMap.counter.Decrement()
 	}
@@ -120,6 +133,48 @@ func (txMap *txListBySenderMap) removeSender(sender string) bool {
 	return removed
 }
 
+// DetachSender atomically removes the list of the sender from the map and returns it. The detached list keeps all its transactions,
+// thus it can be attached to another map (see AttachSender).
+func (txMap *txListBySenderMap) DetachSender(sender string) (*txListForSender, bool) {
+	txMap.mutex.Lock()
+	defer txMap.mutex.Unlock()
+
+	item, removed := txMap.backingMap.Remove(sender)
+	txMap.recentSenders.remove(sender)
+	if !removed {
+		return nil, false
+	}
+
+	txMap.counter.Decrement()
+
+	listForSender := item.(*txListForSender)
+	listForSender.SetScoreChunk(nil)
+
+	return listForSender, true
+}
+
+// AttachSender adds a list detached from another map (see DetachSender). From now on, the list is subject to the
+// constraints of this map (the size constraints being applied on the next addition) and its score is computed by this map.
+func (txMap *txListBySenderMap) AttachSender(listForSender *txListForSender) error {
+	if listForSender == nil {
+		return common.ErrNilTxListForSender
+	}
+
+	txMap.mutex.Lock()
+	defer txMap.mutex.Unlock()
+
+	if txMap.backingMap.Has(listForSender.sender) {
+		return common.ErrItemAlreadyInCache
+	}
+
+	listForSender.rebind(&txMap.senderConstraints, txMap.notifyScoreChange)
+	txMap.backingMap.Set(listForSender)
+	txMap.counter.Increment()
+	listForSender.recomputeScore()
+
+	return nil
+}
+
 // RemoveSendersBulk removes senders, in bulk
 func (txMap *txListBySenderMap) RemoveSendersBulk(senders []string) uint32 {
 	numRemoved := uint32(0)
@@ -143,6 +198,17 @@ func (txMap *txListBySenderMap) notifyAccountNonce(accountKey []byte, nonce uint
 	listForSender.notifyAccountNonce(nonce)
 }
 
+// GetSenderNonce returns the last account nonce notified for the given sender.
+// The returned bool is false if the sender is not known, or if its account nonce hasn't been notified yet.
+func (txMap *txListBySenderMap) GetSenderNonce(sender []byte) (uint64, bool) {
+	listForSender, ok := txMap.getListForSender(string(sender))
+	if !ok {
+		return 0, false
+	}
+
+	return listForSender.getLastNotifiedAccountNonce()
+}
+
 func (txMap *txListBySenderMap) getSnapshotAscending() []*txListForSender {
 	itemsSnapshot := txMap.backingMap.GetSnapshotAscending()
 	listsSnapshot := make([]*txListForSender, len(itemsSnapshot))
@@ -165,7 +231,48 @@ func (txMap *txListBySenderMap) getSnapshotDescending() []*txListForSender {
 	return listsSnapshot
 }
 
+// GetSendersByShardID returns the lists of the senders (in ascending order of their score) for which
+// the provided function yields the given shard. A nil function yields an empty result.
+func (txMap *txListBySenderMap) GetSendersByShardID(shardID uint32, shardIDForSender func(senderAddr []byte) uint32) []*txListForSender {
+	if shardIDForSender == nil {
+		return make([]*txListForSender, 0)
+	}
+
+	snapshot := txMap.getSnapshotAscending()
+	result := make([]*txListForSender, 0, len(snapshot))
+
+	for _, listForSender := range snapshot {
+		if shardIDForSender([]byte(listForSender.sender)) == shardID {
+			result = append(result, listForSender)
+		}
+	}
+
+	return result
+}
+
+// forEachSenderAscendingWeakly visits the senders (in ascending order of their score) without taking a snapshot of the whole map.
+// The iteration is weakly consistent (see BucketSortedMap.IterCbSortedAscendingWeakly): under concurrent mutation,
+// a sender might be missed or visited twice.
+func (txMap *txListBySenderMap) forEachSenderAscendingWeakly(function func(listForSender *txListForSender) bool) {
+	txMap.backingMap.IterCbSortedAscendingWeakly(func(_ string, item maps.BucketSortedMapItem) bool {
+		return function(item.(*txListForSender))
+	})
+}
+
+func (txMap *txListBySenderMap) recomputeAllScores() {
+	for _, listForSender := range txMap.getSnapshotAscending() {
+		listForSender.recomputeScore()
+	}
+}
+
+// normalizeScore maps a score (which ranges over the configured number of score chunks) to the default range of scores,
+// so that the score-based coefficients (e.g. of the selection) do not depend on the number of score chunks
+func (txMap *txListBySenderMap) normalizeScore(score uint32) uint32 {
+	return uint32(uint64(score) * uint64(defaultNumScoreChunks) / uint64(txMap.numScoreChunks))
+}
+
 func (txMap *txListBySenderMap) clear() {
 	txMap.backingMap.Clear()
+	txMap.recentSenders.clear()
 	txMap.counter.Set(0)
 }
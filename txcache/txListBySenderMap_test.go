@@ -6,6 +6,7 @@ import (
 	"sync"
 	"testing"
 
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/stretchr/testify/require"
 )
 
@@ -62,6 +63,24 @@ func TestSendersMap_RemoveSender(t *testing.T) {
 	require.Equal(t, int64(0), myMap.counter.Get())
 }
 
+func TestSendersMap_NotifyScoreChange_NoResurrectionOfRemovedSender(t *testing.T) {
+	myMap := newSendersMapToTest()
+
+	myMap.addTx(createTx([]byte("a"), "alice", 1))
+	listForAlice, _ := myMap.getListForSender("alice")
+
+	myMap.removeSender("alice")
+
+	require.NotPanics(t, func() {
+		myMap.notifyScoreChange(listForAlice, senderScoreParams{count: 1, feeScore: 100, gas: 50000})
+	})
+
+	require.False(t, myMap.backingMap.Has("alice"))
+	require.Equal(t, uint32(0), myMap.backingMap.CountSorted())
+	require.Len(t, myMap.getSnapshotAscending(), 0)
+	require.Equal(t, int64(0), myMap.counter.Get())
+}
+
 func TestSendersMap_RemoveSendersBulk_ConcurrentWithAddition(t *testing.T) {
 	myMap := newSendersMapToTest()
 
@@ -113,6 +132,31 @@ func TestSendersMap_notifyAccountNonce(t *testing.T) {
 	require.True(t, alice.accountNonceKnown.IsSet())
 }
 
+func TestSendersMap_GetSenderNonce(t *testing.T) {
+	myMap := newSendersMapToTest()
+
+	// Unknown sender
+	nonce, ok := myMap.GetSenderNonce([]byte("alice"))
+	require.False(t, ok)
+	require.Equal(t, uint64(0), nonce)
+
+	// Known sender, but account nonce not notified yet
+	myMap.addTx(createTx([]byte("tx-42"), "alice", uint64(42)))
+	nonce, ok = myMap.GetSenderNonce([]byte("alice"))
+	require.False(t, ok)
+	require.Equal(t, uint64(0), nonce)
+
+	myMap.notifyAccountNonce([]byte("alice"), 42)
+	nonce, ok = myMap.GetSenderNonce([]byte("alice"))
+	require.True(t, ok)
+	require.Equal(t, uint64(42), nonce)
+
+	myMap.notifyAccountNonce([]byte("alice"), 43)
+	nonce, ok = myMap.GetSenderNonce([]byte("alice"))
+	require.True(t, ok)
+	require.Equal(t, uint64(43), nonce)
+}
+
 func BenchmarkSendersMap_GetSnapshotAscending(b *testing.B) {
 	if b.N > 10 {
 		fmt.Println("impractical benchmark: b.N too high")
@@ -135,6 +179,82 @@ func BenchmarkSendersMap_GetSnapshotAscending(b *testing.B) {
 	}
 }
 
+func TestSendersMap_DetachAndAttachSender(t *testing.T) {
+	source := newSendersMapToTest()
+	destination := newSendersMapToTest()
+	destination.senderConstraints.useNonceIndex = true
+
+	source.addTx(createTx([]byte("alice-1"), "alice", 1))
+	source.addTx(createTx([]byte("alice-2"), "alice", 2))
+	source.addTx(createTx([]byte("bob-1"), "bob", 1))
+
+	listForSender, ok := source.DetachSender("carol")
+	require.False(t, ok)
+	require.Nil(t, listForSender)
+
+	listForSender, ok = source.DetachSender("alice")
+	require.True(t, ok)
+	require.Equal(t, int64(1), source.counter.Get())
+	require.False(t, source.backingMap.Has("alice"))
+	require.Nil(t, listForSender.GetScoreChunk())
+	require.Equal(t, []string{"alice-1", "alice-2"}, listForSender.getTxHashesAsStrings())
+
+	_, ok = source.DetachSender("alice")
+	require.False(t, ok)
+
+	err := destination.AttachSender(nil)
+	require.Equal(t, common.ErrNilTxListForSender, err)
+
+	err = destination.AttachSender(listForSender)
+	require.Nil(t, err)
+	require.Equal(t, int64(1), destination.counter.Get())
+	require.NotNil(t, listForSender.GetScoreChunk())
+	require.Len(t, listForSender.nonceIndex, 2)
+
+	err = destination.AttachSender(listForSender)
+	require.Equal(t, common.ErrItemAlreadyInCache, err)
+
+	// The attached list is fully functional within the destination map
+	destination.addTx(createTx([]byte("alice-3"), "alice", 3))
+	attached, ok := destination.getListForSender("alice")
+	require.True(t, ok)
+	require.Equal(t, []string{"alice-1", "alice-2", "alice-3"}, attached.getTxHashesAsStrings())
+	require.Len(t, destination.getSnapshotAscending(), 1)
+
+	require.True(t, destination.removeTx(createTx([]byte("alice-1"), "alice", 1)))
+	require.True(t, destination.removeTx(createTx([]byte("alice-2"), "alice", 2)))
+	require.True(t, destination.removeTx(createTx([]byte("alice-3"), "alice", 3)))
+	require.Equal(t, int64(0), destination.counter.Get())
+
+	// The source map is not affected by the changes in the destination
+	require.Len(t, source.getSnapshotAscending(), 1)
+}
+
+func TestSendersMap_GetSendersByShardID(t *testing.T) {
+	myMap := newSendersMapToTest()
+	myMap.addTx(createTx([]byte("alice-1"), "alice", 1))
+	myMap.addTx(createTx([]byte("bob-1"), "bob", 1))
+	myMap.addTx(createTx([]byte("carol-1"), "carol", 1))
+
+	shardIDForSender := func(senderAddr []byte) uint32 {
+		if string(senderAddr) == "bob" {
+			return 1
+		}
+		return 0
+	}
+
+	senders := myMap.GetSendersByShardID(0, shardIDForSender)
+	require.Len(t, senders, 2)
+	require.ElementsMatch(t, []string{"alice", "carol"}, []string{senders[0].sender, senders[1].sender})
+
+	senders = myMap.GetSendersByShardID(1, shardIDForSender)
+	require.Len(t, senders, 1)
+	require.Equal(t, "bob", senders[0].sender)
+
+	require.Len(t, myMap.GetSendersByShardID(2, shardIDForSender), 0)
+	require.Len(t, myMap.GetSendersByShardID(0, nil), 0)
+}
+
 func TestSendersMap_GetSnapshots_NoPanic_IfAlsoConcurrentMutation(t *testing.T) {
 	myMap := newSendersMapToTest()
 
@@ -164,6 +284,115 @@ func TestSendersMap_GetSnapshots_NoPanic_IfAlsoConcurrentMutation(t *testing.T)
 	wg.Wait()
 }
 
+func TestSendersMap_GetListForSender_RecentSenders(t *testing.T) {
+	myMap := newSendersMapToTest()
+	listForAlice := myMap.getOrAddListForSender("alice")
+
+	_, _, isCached := myMap.recentSenders.get("alice")
+	require.False(t, isCached)
+
+	// The lookup in the backing map caches the sender
+	listForSender, ok := myMap.getListForSender("alice")
+	require.True(t, ok)
+	require.Same(t, listForAlice, listForSender)
+	listForSender, _, isCached = myMap.recentSenders.get("alice")
+	require.True(t, isCached)
+	require.Same(t, listForAlice, listForSender)
+
+	// The oldest sender is evicted from the cache
+	for i := 0; i < numRecentSenders; i++ {
+		sender := fmt.Sprintf("sender-%d", i)
+		myMap.getOrAddListForSender(sender)
+		_, _ = myMap.getListForSender(sender)
+	}
+	_, _, isCached = myMap.recentSenders.get("alice")
+	require.False(t, isCached)
+	_, _, isCached = myMap.recentSenders.get("sender-1")
+	require.True(t, isCached)
+
+	// Missing senders are not cached
+	_, ok = myMap.getListForSender("bob")
+	require.False(t, ok)
+	_, _, isCached = myMap.recentSenders.get("bob")
+	require.False(t, isCached)
+}
+
+func TestSendersMap_GetListForSender_RemovedSendersAreNotReturned(t *testing.T) {
+	myMap := newSendersMapToTest()
+
+	lookUp := func(sender string) bool {
+		myMap.getOrAddListForSender(sender)
+		_, ok := myMap.getListForSender(sender)
+		return ok
+	}
+
+	require.True(t, lookUp("alice"))
+	myMap.removeSender("alice")
+	_, ok := myMap.getListForSender("alice")
+	require.False(t, ok)
+
+	require.True(t, lookUp("bob"))
+	_, _ = myMap.DetachSender("bob")
+	_, ok = myMap.getListForSender("bob")
+	require.False(t, ok)
+
+	require.True(t, lookUp("carol"))
+	myMap.RemoveSendersBulk([]string{"carol"})
+	_, ok = myMap.getListForSender("carol")
+	require.False(t, ok)
+
+	require.True(t, lookUp("dave"))
+	myMap.clear()
+	_, ok = myMap.getListForSender("dave")
+	require.False(t, ok)
+}
+
+func TestSendersMap_GetListForSender_RecentSendersUnderConcurrentRemoval(t *testing.T) {
+	myMap := newSendersMapToTest()
+	numSenders := 16
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 1000; i++ {
+		sender := fmt.Sprintf("sender-%d", i%numSenders)
+
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			myMap.getOrAddListForSender(sender)
+		}()
+		go func() {
+			defer wg.Done()
+			_, _ = myMap.getListForSender(sender)
+		}()
+		go func() {
+			defer wg.Done()
+			myMap.removeSender(sender)
+		}()
+	}
+	wg.Wait()
+
+	// Whatever the interleaving, the cache only holds senders still in the map
+	for i := 0; i < numSenders; i++ {
+		sender := fmt.Sprintf("sender-%d", i)
+		listForSender, _, isCached := myMap.recentSenders.get(sender)
+		if isCached {
+			listInMap, ok := myMap.backingMap.Get(sender)
+			require.True(t, ok)
+			require.Same(t, listInMap, listForSender)
+		}
+	}
+}
+
+func BenchmarkSendersMap_GetListForSender(b *testing.B) {
+	myMap := createTxListBySenderMap(10000)
+	senders := []string{"Sender-1", "Sender-2", "Sender-3", "Sender-4"}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _ = myMap.getListForSender(senders[i%len(senders)])
+	}
+}
+
 func createTxListBySenderMap(numSenders int) *txListBySenderMap {
 	myMap := newSendersMapToTest()
 	for i := 0; i < numSenders; i++ {
@@ -177,7 +406,7 @@ func createTxListBySenderMap(numSenders int) *txListBySenderMap {
 
 func newSendersMapToTest() *txListBySenderMap {
 	txGasHandler, txFeeHelper := dummyParams()
-	return newTxListBySenderMap(4, senderConstraints{
+	return newTxListBySenderMap(4, defaultNumScoreChunks, senderConstraints{
 		maxNumBytes: math.MaxUint32,
 		maxNumTxs:   math.MaxUint32,
 	}, &disabledScoreComputer{}, txGasHandler, txFeeHelper)
@@ -3,9 +3,12 @@ package txcache
 import (
 	"bytes"
 	"container/list"
+	"errors"
+	"math"
 	"sync"
 
 	"github.com/multiversx/mx-chain-core-go/core/atomic"
+	"github.com/multiversx/mx-chain-core-go/data"
 	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/txcache/maps"
 )
@@ -21,7 +24,11 @@ type txListForSender struct {
 	copyPreviousNonce   uint64
 	sender              string
 	items               *list.List
-	copyBatchIndex      *list.Element
+	nonceIndex          map[uint64]*list.Element
+	nonceGroups         *nonceSkipList
+	copySnapshot        txListSnapshot
+	copyCursor          txListSnapshotCursor
+	selectedInRound     map[*WrappedTransaction]struct{}
 	constraints         *senderConstraints
 	scoreChunk          *maps.MapChunk
 	accountNonce        atomic.Uint64
@@ -29,59 +36,83 @@ type txListForSender struct {
 	totalGas            atomic.Counter
 	totalFeeScore       atomic.Counter
 	numFailedSelections atomic.Counter
+	reputation          atomic.Counter
 	onScoreChange       scoreChangeCallback
 
+	// snapshot is the (copy-on-write) view of the list, published on each mutation; see Snapshot
+	snapshot        txListSnapshotHolder
+	pendingSnapshot *txListSnapshot
+
 	scoreChunkMutex sync.RWMutex
 	mutex           sync.RWMutex
+	// mutSelection guards the state of the selection (the "copy" fields and the marks), which does not lock the list itself
+	mutSelection sync.Mutex
 }
 
 type scoreChangeCallback func(value *txListForSender, scoreParams senderScoreParams)
 
 // newTxListForSender creates a new (sorted) list of transactions
 func newTxListForSender(sender string, constraints *senderConstraints, onScoreChange scoreChangeCallback) *txListForSender {
-	return &txListForSender{
+	listForSender := &txListForSender{
 		items:         list.New(),
+		nonceGroups:   newNonceSkipList(),
 		sender:        sender,
 		constraints:   constraints,
 		onScoreChange: onScoreChange,
 	}
+
+	if constraints.useNonceIndex {
+		listForSender.nonceIndex = make(map[uint64]*list.Element)
+	}
+
+	return listForSender
 }
 
 // AddTx adds a transaction in sender's list
 // This is a "sorted" insert
-func (listForSender *txListForSender) AddTx(tx *WrappedTransaction, gasHandler TxGasHandler, txFeeHelper feeHelper) (bool, [][]byte) {
+// Returns an error if the transaction cannot be inserted at all (e.g. common.ErrNonceOverflow).
+// Duplicates and transactions above the bytes limit of the sender (if rejected by the constraints) are not added, without an error.
+func (listForSender *txListForSender) AddTx(tx *WrappedTransaction, gasHandler TxGasHandler, txFeeHelper feeHelper) (bool, [][]byte, error) {
 	// We don't allow concurrent interceptor goroutines to mutate a given sender's list
 	listForSender.mutex.Lock()
 	defer listForSender.mutex.Unlock()
+	defer listForSender.publishSnapshotIfStale()
 
 	insertionPlace, err := listForSender.findInsertionPlace(tx)
+	if errors.Is(err, common.ErrItemAlreadyInCache) {
+		return false, nil, nil
+	}
 	if err != nil {
-		return false, nil
+		return false, nil, err
 	}
-
-	if insertionPlace == nil {
-		listForSender.items.PushFront(tx)
-	} else {
-		listForSender.items.InsertAfter(tx, insertionPlace)
+	if listForSender.constraints.rejectAboveMaxNumBytes && listForSender.isMaxNumBytesExceededBy(tx) {
+		return false, nil, nil
 	}
 
+	listForSender.insertTx(tx, insertionPlace)
 	listForSender.onAddedTransaction(tx, gasHandler, txFeeHelper)
 	evicted := listForSender.applySizeConstraints()
 	listForSender.triggerScoreChange()
-	return true, evicted
+	return true, evicted, nil
 }
 
 // This function should only be used in critical section (listForSender.mutex)
 func (listForSender *txListForSender) applySizeConstraints() [][]byte {
+	if listForSender.constraints.evictLowestFeeFirst {
+		return listForSender.applySizeConstraintsByFee()
+	}
+
 	evictedTxHashes := make([][]byte, 0)
 
-	// Iterate back to front
-	for element := listForSender.items.Back(); element != nil; element = element.Prev() {
+	// Iterate back to front (the links of an element are cleared on removal, thus the previous one is captured beforehand)
+	var previous *list.Element
+	for element := listForSender.items.Back(); element != nil; element = previous {
 		if !listForSender.isCapacityExceeded() {
 			break
 		}
 
-		listForSender.items.Remove(element)
+		previous = element.Prev()
+		listForSender.removeListElement(element)
 		listForSender.onRemovedListElement(element)
 
 		// Keep track of removed transactions
@@ -92,6 +123,192 @@ func (listForSender *txListForSender) applySizeConstraints() [][]byte {
 	return evictedTxHashes
 }
 
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) applySizeConstraintsByFee() [][]byte {
+	evictedTxHashes := make([][]byte, 0)
+
+	for listForSender.isCapacityExceeded() {
+		removed := listForSender.removeLowestFeeTxsNoLock(1)
+		if len(removed) == 0 {
+			break
+		}
+
+		evictedTxHashes = append(evictedTxHashes, removed...)
+	}
+
+	return evictedTxHashes
+}
+
+// RemoveLowestFeeTxs removes (at most) "count" transactions, the ones with the smallest estimated fee (see computeTxFee) first.
+// Only the transactions whose removal does not introduce a nonce gap are considered (the ones having the highest nonce,
+// or having alternatives with the same nonce), so that the lower nonces are never blocked. Among equal fees, the highest nonces go first.
+// Returns the hashes of the removed transactions.
+func (listForSender *txListForSender) RemoveLowestFeeTxs(count int) [][]byte {
+	listForSender.mutex.Lock()
+	defer listForSender.mutex.Unlock()
+	defer listForSender.publishSnapshotIfStale()
+
+	removed := listForSender.removeLowestFeeTxsNoLock(count)
+	if len(removed) > 0 {
+		listForSender.triggerScoreChange()
+	}
+
+	return removed
+}
+
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) removeLowestFeeTxsNoLock(count int) [][]byte {
+	removed := make([][]byte, 0)
+
+	for len(removed) < count {
+		element := listForSender.findLowestFeeRemovableElement()
+		if element == nil {
+			break
+		}
+
+		listForSender.removeListElement(element)
+		listForSender.onRemovedListElement(element)
+		removed = append(removed, element.Value.(*WrappedTransaction).TxHash)
+	}
+
+	return removed
+}
+
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) findLowestFeeRemovableElement() *list.Element {
+	var lowest *list.Element
+	lowestFee := uint64(0)
+
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		if !isRemovableWithoutNonceGap(element) {
+			continue
+		}
+
+		fee := computeTxFee(element.Value.(*WrappedTransaction))
+		if lowest == nil || fee <= lowestFee {
+			lowest = element
+			lowestFee = fee
+		}
+	}
+
+	return lowest
+}
+
+// isRemovableWithoutNonceGap checks whether the element is the last one, or it has a neighbour with the same nonce
+func isRemovableWithoutNonceGap(element *list.Element) bool {
+	next := element.Next()
+	if next == nil {
+		return true
+	}
+
+	nonce := element.Value.(*WrappedTransaction).Tx.GetNonce()
+	if next.Value.(*WrappedTransaction).Tx.GetNonce() == nonce {
+		return true
+	}
+
+	previous := element.Prev()
+	return previous != nil && previous.Value.(*WrappedTransaction).Tx.GetNonce() == nonce
+}
+
+// insertTx inserts the transaction right after the insertion place (or at the head of the list, if the insertion place is nil)
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) insertTx(tx *WrappedTransaction, insertionPlace *list.Element) {
+	listForSender.insertIntoSnapshot(tx)
+
+	var element *list.Element
+	if insertionPlace == nil {
+		element = listForSender.items.PushFront(tx)
+	} else {
+		element = listForSender.items.InsertAfter(tx, insertionPlace)
+	}
+
+	// The nonce groups (and the index) point to the first (highest priority) transaction having a given nonce
+	nonce := tx.Tx.GetNonce()
+	previous := element.Prev()
+	isFirstWithNonce := previous == nil || getNonceOfElement(previous) != nonce
+	if isFirstWithNonce {
+		listForSender.setFirstElementWithNonce(nonce, element)
+	}
+}
+
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) removeListElement(element *list.Element) {
+	// The links of the element are cleared on removal, thus the next element is captured beforehand
+	next := element.Next()
+	listForSender.items.Remove(element)
+	listForSender.removeFromSnapshot(element.Value.(*WrappedTransaction))
+
+	nonce := getNonceOfElement(element)
+	if listForSender.getFirstElementWithNonce(nonce) != element {
+		return
+	}
+
+	hasNextWithSameNonce := next != nil && getNonceOfElement(next) == nonce
+	if hasNextWithSameNonce {
+		listForSender.setFirstElementWithNonce(nonce, next)
+		return
+	}
+
+	listForSender.removeNonceGroup(nonce)
+}
+
+// setFirstElementWithNonce records the first (highest priority) element having the given nonce, adding a new group if necessary.
+// The nonce groups (the first element of each nonce, held by a skip list) are always maintained, unlike the (optional) nonce index,
+// so that the insertion place of a transaction is found in logarithmic time (see findInsertionPlace).
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) setFirstElementWithNonce(nonce uint64, element *list.Element) {
+	if listForSender.nonceIndex != nil {
+		listForSender.nonceIndex[nonce] = element
+	}
+
+	listForSender.nonceGroups.set(nonce, element)
+}
+
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) removeNonceGroup(nonce uint64) {
+	delete(listForSender.nonceIndex, nonce)
+	listForSender.nonceGroups.remove(nonce)
+}
+
+func getNonceOfElement(element *list.Element) uint64 {
+	return element.Value.(*WrappedTransaction).Tx.GetNonce()
+}
+
+// rebind makes the list subject to other constraints and report its score changes to another map (see txListBySenderMap.AttachSender)
+func (listForSender *txListForSender) rebind(constraints *senderConstraints, onScoreChange scoreChangeCallback) {
+	listForSender.mutex.Lock()
+	defer listForSender.mutex.Unlock()
+
+	listForSender.constraints = constraints
+	listForSender.onScoreChange = onScoreChange
+	listForSender.rebuildNonceIndex()
+}
+
+// rebuildNonceIndex creates (or drops) the nonce index, according to the constraints. The nonce groups are not affected.
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) rebuildNonceIndex() {
+	if !listForSender.constraints.useNonceIndex {
+		listForSender.nonceIndex = nil
+		return
+	}
+
+	listForSender.nonceIndex = make(map[uint64]*list.Element, listForSender.nonceGroups.length)
+	listForSender.nonceGroups.forEach(func(nonce uint64, element *list.Element) {
+		listForSender.nonceIndex[nonce] = element
+	})
+}
+
+// getFirstElementWithNonce returns the first (highest priority) element having the given nonce (nil if missing),
+// in constant time if the nonce index is enabled, in logarithmic time otherwise.
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) getFirstElementWithNonce(nonce uint64) *list.Element {
+	if listForSender.nonceIndex != nil {
+		return listForSender.nonceIndex[nonce]
+	}
+
+	return listForSender.nonceGroups.get(nonce)
+}
+
 func (listForSender *txListForSender) isCapacityExceeded() bool {
 	maxBytes := int64(listForSender.constraints.maxNumBytes)
 	maxNumTxs := uint64(listForSender.constraints.maxNumTxs)
@@ -101,6 +318,20 @@ func (listForSender *txListForSender) isCapacityExceeded() bool {
 	return tooManyBytes || tooManyTxs
 }
 
+// isMaxNumBytesExceededBy checks whether adding the transaction would bring the sender above its maximum number of bytes
+func (listForSender *txListForSender) isMaxNumBytesExceededBy(tx *WrappedTransaction) bool {
+	maxBytes := int64(listForSender.constraints.maxNumBytes)
+	return listForSender.totalBytes.Get()+tx.Size > maxBytes
+}
+
+// wouldExceedMaxNumBytes is the concurrent-safe variant of isMaxNumBytesExceededBy
+func (listForSender *txListForSender) wouldExceedMaxNumBytes(tx *WrappedTransaction) bool {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	return listForSender.isMaxNumBytesExceededBy(tx)
+}
+
 func (listForSender *txListForSender) onAddedTransaction(tx *WrappedTransaction, gasHandler TxGasHandler, txFeeHelper feeHelper) {
 	listForSender.totalBytes.Add(tx.Size)
 	listForSender.totalGas.Add(int64(estimateTxGas(tx)))
@@ -112,55 +343,86 @@ func (listForSender *txListForSender) triggerScoreChange() {
 	listForSender.onScoreChange(listForSender, scoreParams)
 }
 
+// recomputeScore recomputes the score of the sender, even if the list hasn't changed
+func (listForSender *txListForSender) recomputeScore() {
+	listForSender.mutex.Lock()
+	defer listForSender.mutex.Unlock()
+
+	listForSender.triggerScoreChange()
+}
+
 // This function should only be used in critical section (listForSender.mutex)
 func (listForSender *txListForSender) getScoreParams() senderScoreParams {
 	fee := listForSender.totalFeeScore.GetUint64()
 	gas := listForSender.totalGas.GetUint64()
 	count := listForSender.countTx()
+	reputation := listForSender.reputation.Get()
+
+	return senderScoreParams{count: count, feeScore: fee, gas: gas, reputation: reputation}
+}
 
-	return senderScoreParams{count: count, feeScore: fee, gas: gas}
+// adjustReputation changes the reputation of the sender (keeping it within bounds), then recomputes its score
+func (listForSender *txListForSender) adjustReputation(delta int64) {
+	listForSender.mutex.Lock()
+	defer listForSender.mutex.Unlock()
+
+	reputation := listForSender.reputation.Get() + delta
+	if reputation < reputationLowerBound {
+		reputation = reputationLowerBound
+	}
+	if reputation > reputationUpperBound {
+		reputation = reputationUpperBound
+	}
+
+	listForSender.reputation.Set(reputation)
+	listForSender.triggerScoreChange()
 }
 
+// findInsertionPlace locates the group of transactions having the incoming nonce (or its successor) by a search
+// in the skip list of nonce groups, then only walks the group (if any). The returned element is the one after which the incoming
+// transaction should be inserted (nil for the head of the list).
 // This function should only be used in critical section (listForSender.mutex)
 func (listForSender *txListForSender) findInsertionPlace(incomingTx *WrappedTransaction) (*list.Element, error) {
 	incomingNonce := incomingTx.Tx.GetNonce()
 	incomingGasPrice := incomingTx.Tx.GetGasPrice()
 
-	for element := listForSender.items.Back(); element != nil; element = element.Prev() {
-		currentTx := element.Value.(*WrappedTransaction)
-		currentTxNonce := currentTx.Tx.GetNonce()
-		currentTxGasPrice := currentTx.Tx.GetGasPrice()
+	if incomingNonce == math.MaxUint64 {
+		// The nonce arithmetic (e.g. the detection of gaps) relies on "nonce + 1" not overflowing
+		return nil, common.ErrNonceOverflow
+	}
 
+	successor := listForSender.nonceGroups.ceiling(incomingNonce)
+	if successor == nil {
+		// All the nonces are lower than the incoming one
+		return listForSender.items.Back(), nil
+	}
+
+	if getNonceOfElement(successor) != incomingNonce {
+		// The incoming transaction will be placed right before the first transaction with a higher nonce
+		return successor.Prev(), nil
+	}
+
+	// Within the group of transactions having the incoming nonce (sorted by gas price, descending, then by hash),
+	// the incoming transaction will be placed after the ones having a higher price, or the same price and a lower hash.
+	insertionPlace := successor.Prev()
+	for element := successor; element != nil; element = element.Next() {
+		currentTx := element.Value.(*WrappedTransaction)
+		if currentTx.Tx.GetNonce() != incomingNonce {
+			break
+		}
 		if incomingTx.sameAs(currentTx) {
-			// The incoming transaction will be discarded
 			return nil, common.ErrItemAlreadyInCache
 		}
 
-		if currentTxNonce == incomingNonce {
-			if currentTxGasPrice > incomingGasPrice {
-				// The incoming transaction will be placed right after the existing one, which has same nonce but higher price.
-				// If the nonces are the same, but the incoming gas price is higher or equal, the search loop continues.
-				return element, nil
-			}
-			if currentTxGasPrice == incomingGasPrice {
-				// The incoming transaction will be placed right after the existing one, which has same nonce and the same price.
-				// (but different hash, because of some other fields like receiver, value or data)
-				// This will order out the transactions having the same nonce and gas price
-				if bytes.Compare(currentTx.TxHash, incomingTx.TxHash) < 0 {
-					return element, nil
-				}
-			}
-		}
-
-		if currentTxNonce < incomingNonce {
-			// We've found the first transaction with a lower nonce than the incoming one,
-			// thus the incoming transaction will be placed right after this one.
-			return element, nil
+		currentTxGasPrice := currentTx.Tx.GetGasPrice()
+		isHigherPriority := currentTxGasPrice > incomingGasPrice ||
+			(currentTxGasPrice == incomingGasPrice && bytes.Compare(currentTx.TxHash, incomingTx.TxHash) < 0)
+		if isHigherPriority {
+			insertionPlace = element
 		}
 	}
 
-	// The incoming transaction will be inserted at the head of the list.
-	return nil, nil
+	return insertionPlace, nil
 }
 
 // RemoveTx removes a transaction from the sender's list
@@ -168,11 +430,12 @@ func (listForSender *txListForSender) RemoveTx(tx *WrappedTransaction) bool {
 	// We don't allow concurrent interceptor goroutines to mutate a given sender's list
 	listForSender.mutex.Lock()
 	defer listForSender.mutex.Unlock()
+	defer listForSender.publishSnapshotIfStale()
 
 	marker := listForSender.findListElementWithTx(tx)
 	isFound := marker != nil
 	if isFound {
-		listForSender.items.Remove(marker)
+		listForSender.removeListElement(marker)
 		listForSender.onRemovedListElement(marker)
 		listForSender.triggerScoreChange()
 	}
@@ -193,7 +456,7 @@ func (listForSender *txListForSender) findListElementWithTx(txToFind *WrappedTra
 	txToFindHash := txToFind.TxHash
 	txToFindNonce := txToFind.Tx.GetNonce()
 
-	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+	for element := listForSender.getFirstElementWithNonce(txToFindNonce); element != nil; element = element.Next() {
 		value := element.Value.(*WrappedTransaction)
 
 		if bytes.Equal(value.TxHash, txToFindHash) {
@@ -209,6 +472,167 @@ func (listForSender *txListForSender) findListElementWithTx(txToFind *WrappedTra
 	return nil
 }
 
+// GetTxAtNonce returns the transaction (and its hash) having the given nonce
+// If more transactions share the nonce, the one with the highest priority (first in list) is returned
+func (listForSender *txListForSender) GetTxAtNonce(nonce uint64) (data.TransactionHandler, []byte, bool) {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	first := listForSender.getFirstElementWithNonce(nonce)
+	if first == nil {
+		return nil, nil, false
+	}
+
+	value := first.Value.(*WrappedTransaction)
+	return value.Tx, value.TxHash, true
+}
+
+// GetFirstUnexecutableNonce returns the first nonce at which the execution of the sender's transactions would stall, given the account nonce.
+// Starting with the account nonce, the transactions are walked in nonce order (transactions with nonces lower than the account nonce are ignored):
+//   - for a contiguous sequence, the returned value is the nonce following the last transaction (the account nonce, for an empty list)
+//   - on a gap, the returned value is the first missing nonce
+//   - on a duplicate (more transactions sharing a nonce), the returned value is the nonce following the duplicate,
+//     since only one of the colliding transactions can be executed, and the ones after it are not reliable anymore
+//
+// Thus, the selection can safely take the transactions with nonces in the interval [accountNonce, returned value).
+func (listForSender *txListForSender) GetFirstUnexecutableNonce(accountNonce uint64) uint64 {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	nextNonce := accountNonce
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		value := element.Value.(*WrappedTransaction)
+		txNonce := value.Tx.GetNonce()
+
+		if txNonce < accountNonce {
+			continue
+		}
+		if txNonce == nextNonce {
+			nextNonce++
+			continue
+		}
+
+		// Either a gap (txNonce > nextNonce), or a duplicate (txNonce == nextNonce - 1)
+		break
+	}
+
+	return nextNonce
+}
+
+// GetHighestContiguousNonce returns the highest nonce reachable from the account nonce without gaps.
+// Unlike GetFirstUnexecutableNonce, transactions sharing a nonce do not break the sequence.
+// The returned bool is false if there is no transaction having the account nonce.
+func (listForSender *txListForSender) GetHighestContiguousNonce(accountNonce uint64) (uint64, bool) {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	nextNonce := accountNonce
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		value := element.Value.(*WrappedTransaction)
+		txNonce := value.Tx.GetNonce()
+
+		if txNonce < nextNonce {
+			// lower nonce than the account nonce, or a duplicate
+			continue
+		}
+		if txNonce > nextNonce {
+			break
+		}
+
+		nextNonce++
+	}
+
+	if nextNonce == accountNonce {
+		return 0, false
+	}
+
+	return nextNonce - 1, true
+}
+
+// getFirstMissingNonce returns the account nonce (see getAccountNonceBaseline), along with the first nonce missing between it
+// and the highest nonce of the sender's transactions.
+// As for GetHighestContiguousNonce, transactions sharing a nonce do not break the sequence.
+// The returned bool is false if the account nonce is not known, or if there is no gap.
+func (listForSender *txListForSender) getFirstMissingNonce() (uint64, uint64, bool) {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	accountNonce, ok := listForSender.getAccountNonceBaseline()
+	if !ok {
+		return 0, 0, false
+	}
+
+	nextNonce := accountNonce
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		value := element.Value.(*WrappedTransaction)
+		txNonce := value.Tx.GetNonce()
+
+		if txNonce < nextNonce {
+			// lower nonce than the account nonce, or a duplicate
+			continue
+		}
+		if txNonce > nextNonce {
+			return accountNonce, nextNonce, true
+		}
+
+		nextNonce++
+	}
+
+	return accountNonce, 0, false
+}
+
+// GetTransactionsCountInNonceRange returns the number of transactions having the nonce in the interval [low, high].
+// Transactions sharing a nonce are counted individually. Since the list is sorted by nonce, the scan stops at the first nonce above high
+// (and it starts at the first nonce not below low, found in the skip list of nonce groups).
+func (listForSender *txListForSender) GetTransactionsCountInNonceRange(low uint64, high uint64) uint64 {
+	if low > high {
+		return 0
+	}
+
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	// The first element having a nonce greater than or equal to "low"
+	start := listForSender.nonceGroups.ceiling(low)
+
+	count := uint64(0)
+	for element := start; element != nil; element = element.Next() {
+		txNonce := element.Value.(*WrappedTransaction).Tx.GetNonce()
+		if txNonce < low {
+			continue
+		}
+		if txNonce > high {
+			break
+		}
+
+		count++
+	}
+
+	return count
+}
+
+// IterateByNonce calls the provided function for each transaction of the sender, until the function returns false.
+// The list is sorted by nonce (ascending), thus the transactions are visited in nonce order.
+// Transactions sharing a nonce are visited in the order of their priority (highest gas price first).
+// The list is read-locked during the iteration, so the function must not call back into the list.
+func (listForSender *txListForSender) IterateByNonce(fn func(nonce uint64, txHash []byte, tx data.TransactionHandler) bool) {
+	if fn == nil {
+		return
+	}
+
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		value := element.Value.(*WrappedTransaction)
+
+		shouldContinue := fn(value.Tx.GetNonce(), value.TxHash, value.Tx)
+		if !shouldContinue {
+			return
+		}
+	}
+}
+
 // IsEmpty checks whether the list is empty
 func (listForSender *txListForSender) IsEmpty() bool {
 	return listForSender.countTxWithLock() == 0
@@ -216,27 +640,35 @@ func (listForSender *txListForSender) IsEmpty() bool {
 
 // selectBatchTo copies a batch (usually small) of transactions of a limited gas bandwidth and limited number of transactions to a destination slice
 // It also updates the internal state used for copy operations
+// The first batch starts a new selection round, thus it clears the "selected" marks of the transactions. Within a round,
+// the selected transactions are marked, and the already selected ones are skipped by the subsequent batches (and passes).
+// The transactions are read from the snapshot of the list taken by the first batch (see Snapshot), thus the list is not locked
+// while reading them, and the transactions added (or removed) meanwhile are only accounted by the next round.
 func (listForSender *txListForSender) selectBatchTo(isFirstBatch bool, destination []*WrappedTransaction, batchSize int, bandwidth uint64) batchSelectionJournal {
-	// We can't read from multiple goroutines at the same time
-	// And we can't mutate the sender's list while reading it
-	listForSender.mutex.Lock()
-	defer listForSender.mutex.Unlock()
+	// We can't select from multiple goroutines at the same time
+	listForSender.mutSelection.Lock()
+	defer listForSender.mutSelection.Unlock()
 
 	journal := batchSelectionJournal{}
 
 	// Reset the internal state used for copy operations
 	if isFirstBatch {
+		listForSender.mutex.RLock()
 		hasInitialGap := listForSender.verifyInitialGapOnSelectionStart()
+		listForSender.copySnapshot = listForSender.Snapshot()
+		listForSender.mutex.RUnlock()
 
-		listForSender.copyBatchIndex = listForSender.items.Front()
+		listForSender.copyCursor = txListSnapshotCursor{}
 		listForSender.copyPreviousNonce = 0
 		listForSender.copyDetectedGap = hasInitialGap
+		listForSender.clearSelectionMarks()
 
 		journal.isFirstBatch = true
 		journal.hasInitialGap = hasInitialGap
 	}
 
-	element := listForSender.copyBatchIndex
+	snapshot := listForSender.copySnapshot
+	cursor := listForSender.copyCursor
 	availableSpace := len(destination)
 	detectedGap := listForSender.copyDetectedGap
 	previousNonce := listForSender.copyPreviousNonce
@@ -254,16 +686,14 @@ func (listForSender *txListForSender) selectBatchTo(isFirstBatch bool, destinati
 	}
 
 	copiedBandwidth := uint64(0)
-	lastTxGasLimit := uint64(0)
 	copied := 0
-	for ; ; copied, copiedBandwidth = copied+1, copiedBandwidth+lastTxGasLimit {
-		if element == nil || copied == batchSize || copied == availableSpace || copiedBandwidth >= bandwidth {
+	for copied < batchSize && copied < availableSpace && copiedBandwidth < bandwidth {
+		value, ok := snapshot.get(cursor)
+		if !ok {
 			break
 		}
 
-		value := element.Value.(*WrappedTransaction)
 		txNonce := value.Tx.GetNonce()
-		lastTxGasLimit = value.Tx.GetGasLimit()
 
 		if previousNonce > 0 && txNonce > previousNonce+1 {
 			listForSender.copyDetectedGap = true
@@ -271,27 +701,159 @@ func (listForSender *txListForSender) selectBatchTo(isFirstBatch bool, destinati
 			break
 		}
 
-		destination[copied] = value
-		element = element.Next()
+		cursor = snapshot.next(cursor)
 		previousNonce = txNonce
+
+		if listForSender.isSelected(value) {
+			continue
+		}
+
+		listForSender.markAsSelected(value)
+		destination[copied] = value
+		copied++
+		copiedBandwidth += value.Tx.GetGasLimit()
 	}
 
-	listForSender.copyBatchIndex = element
+	listForSender.copyCursor = cursor
 	listForSender.copyPreviousNonce = previousNonce
 	journal.copied = copied
 	return journal
 }
 
+// copyBatchToWithFilter copies a batch of transactions (and their hashes) to the destination slices, skipping the ones rejected by the filter
+// The copy continues from the position reached by the previous batch of the current selection (see selectBatchTo), within the same snapshot.
+// Rejected transactions are passed over (the internal position advances), so that they do not stall the subsequent batches.
+// Copied transactions are marked as selected, and are skipped by the subsequent calls, until the next selection round (see selectBatchTo)
+// or until ResetSelection is called.
+// Returns the number of copied transactions.
+func (listForSender *txListForSender) copyBatchToWithFilter(destination []data.TransactionHandler, destinationHashes [][]byte, batchSize int, filter func(data.TransactionHandler) bool) int {
+	listForSender.mutSelection.Lock()
+	defer listForSender.mutSelection.Unlock()
+
+	if listForSender.copyDetectedGap {
+		return 0
+	}
+
+	snapshot := listForSender.copySnapshot
+	cursor := listForSender.copyCursor
+	availableSpace := len(destination)
+	if len(destinationHashes) < availableSpace {
+		availableSpace = len(destinationHashes)
+	}
+
+	previousNonce := listForSender.copyPreviousNonce
+	copied := 0
+	for copied < batchSize && copied < availableSpace {
+		value, ok := snapshot.get(cursor)
+		if !ok {
+			break
+		}
+
+		txNonce := value.Tx.GetNonce()
+
+		if previousNonce > 0 && txNonce > previousNonce+1 {
+			listForSender.copyDetectedGap = true
+			break
+		}
+
+		cursor = snapshot.next(cursor)
+		previousNonce = txNonce
+
+		if listForSender.isSelected(value) {
+			continue
+		}
+		if filter != nil && !filter(value.Tx) {
+			continue
+		}
+
+		destination[copied] = value.Tx
+		destinationHashes[copied] = value.TxHash
+		listForSender.markAsSelected(value)
+		copied++
+	}
+
+	listForSender.copyCursor = cursor
+	listForSender.copyPreviousNonce = previousNonce
+	return copied
+}
+
+// ResetSelection clears the "selected" marks of the transactions, so that they become available for selection again
+func (listForSender *txListForSender) ResetSelection() {
+	listForSender.mutSelection.Lock()
+	defer listForSender.mutSelection.Unlock()
+
+	listForSender.clearSelectionMarks()
+}
+
+// clearSelectionMarks drops the marks of the current selection round. Its cost is proportional to the number of marks
+// (thus, to the number of transactions selected in the round), not to the number of transactions of the sender.
+// This function should only be used in critical section (listForSender.mutSelection)
+func (listForSender *txListForSender) clearSelectionMarks() {
+	for tx := range listForSender.selectedInRound {
+		delete(listForSender.selectedInRound, tx)
+	}
+}
+
+// isSelected tells whether the transaction has been selected in the current selection round.
+// This function should only be used in critical section (listForSender.mutSelection)
+func (listForSender *txListForSender) isSelected(tx *WrappedTransaction) bool {
+	_, ok := listForSender.selectedInRound[tx]
+	return ok
+}
+
+// This function should only be used in critical section (listForSender.mutSelection)
+func (listForSender *txListForSender) markAsSelected(tx *WrappedTransaction) {
+	if listForSender.selectedInRound == nil {
+		listForSender.selectedInRound = make(map[*WrappedTransaction]struct{})
+	}
+
+	listForSender.selectedInRound[tx] = struct{}{}
+}
+
 // getTxHashes returns the hashes of transactions in the list
 func (listForSender *txListForSender) getTxHashes() [][]byte {
+	snapshot := listForSender.Snapshot()
+	result := make([][]byte, 0, snapshot.Len())
+
+	snapshot.ForEach(func(tx *WrappedTransaction) bool {
+		result = append(result, tx.TxHash)
+		return true
+	})
+
+	return result
+}
+
+func (listForSender *txListForSender) getTxs() []*WrappedTransaction {
+	return listForSender.Snapshot().toSlice()
+}
+
+// getSelectableTxs returns the transactions which can be selected, in nonce order, up to the first nonce gap.
+// If the sender has an initial nonce gap, only the first transaction is returned (if the sender is in the grace period), or none at all.
+// Unlike selectBatchTo, it does not alter the state of the sender (e.g. the number of failed selections).
+func (listForSender *txListForSender) getSelectableTxs() []*WrappedTransaction {
 	listForSender.mutex.RLock()
 	defer listForSender.mutex.RUnlock()
 
-	result := make([][]byte, 0, listForSender.countTx())
+	maxNumTxs := listForSender.items.Len()
+	if listForSender.hasInitialGap() {
+		if !listForSender.isInGracePeriod() {
+			return nil
+		}
+		maxNumTxs = 1
+	}
 
-	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+	result := make([]*WrappedTransaction, 0, maxNumTxs)
+	previousNonce := uint64(0)
+
+	for element := listForSender.items.Front(); element != nil && len(result) < maxNumTxs; element = element.Next() {
 		value := element.Value.(*WrappedTransaction)
-		result = append(result, value.TxHash)
+		txNonce := value.Tx.GetNonce()
+		if previousNonce > 0 && txNonce > previousNonce+1 {
+			break
+		}
+
+		result = append(result, value)
+		previousNonce = txNonce
 	}
 
 	return result
@@ -308,6 +870,19 @@ func (listForSender *txListForSender) countTxWithLock() uint64 {
 	return uint64(listForSender.items.Len())
 }
 
+// getTotalFee returns the cumulative fee (see computeTxFee) of the transactions of the sender, saturating at math.MaxUint64
+func (listForSender *txListForSender) getTotalFee() uint64 {
+	listForSender.mutex.RLock()
+	defer listForSender.mutex.RUnlock()
+
+	totalFee := uint64(0)
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		totalFee = addSaturating(totalFee, computeTxFee(element.Value.(*WrappedTransaction)))
+	}
+
+	return totalFee
+}
+
 func approximatelyCountTxInLists(lists []*txListForSender) uint64 {
 	count := uint64(0)
 
@@ -325,6 +900,33 @@ func (listForSender *txListForSender) notifyAccountNonce(nonce uint64) {
 	_ = listForSender.accountNonceKnown.SetReturningPrevious()
 }
 
+// getLastNotifiedAccountNonce returns the last notified account nonce, and whether it has been notified at all
+func (listForSender *txListForSender) getLastNotifiedAccountNonce() (uint64, bool) {
+	if !listForSender.accountNonceKnown.IsSet() {
+		return 0, false
+	}
+
+	return listForSender.accountNonce.Get(), true
+}
+
+// getAccountNonceBaseline returns the account nonce against which the nonce gaps are detected: the last notified one or, absent a notification
+// (and if configured, see ConfigSourceMe.UseLowestNonceAsImplicitAccountNonce), the lowest nonce of the sender's transactions.
+// The returned bool is false if the account nonce is not known.
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) getAccountNonceBaseline() (uint64, bool) {
+	accountNonce, ok := listForSender.getLastNotifiedAccountNonce()
+	if ok || !listForSender.constraints.useLowestNonceAsAccountNonce {
+		return accountNonce, ok
+	}
+
+	lowestNonceTx := listForSender.getLowestNonceTx()
+	if lowestNonceTx == nil {
+		return 0, false
+	}
+
+	return lowestNonceTx.Tx.GetNonce(), true
+}
+
 // This function should only be used in critical section (listForSender.mutex)
 func (listForSender *txListForSender) verifyInitialGapOnSelectionStart() bool {
 	hasInitialGap := listForSender.hasInitialGap()
@@ -345,7 +947,7 @@ func (listForSender *txListForSender) verifyInitialGapOnSelectionStart() bool {
 // hasInitialGap should only be called at tx selection time, since only then we can detect initial gaps with certainty
 // This function should only be used in critical section (listForSender.mutex)
 func (listForSender *txListForSender) hasInitialGap() bool {
-	accountNonceKnown := listForSender.accountNonceKnown.IsSet()
+	accountNonce, accountNonceKnown := listForSender.getAccountNonceBaseline()
 	if !accountNonceKnown {
 		return false
 	}
@@ -356,7 +958,6 @@ func (listForSender *txListForSender) hasInitialGap() bool {
 	}
 
 	firstTxNonce := firstTx.Tx.GetNonce()
-	accountNonce := listForSender.accountNonce.Get()
 	hasGap := firstTxNonce > accountNonce
 	return hasGap
 }
@@ -1,10 +1,16 @@
 package txcache
 
 import (
+	"bytes"
+	"container/list"
+	"fmt"
 	"math"
+	"math/rand"
 	"testing"
 
+	"github.com/multiversx/mx-chain-core-go/data"
 	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/testscommon/txcachemocks"
 	"github.com/stretchr/testify/require"
 )
@@ -54,16 +60,31 @@ func TestListForSender_AddTx_IgnoresDuplicates(t *testing.T) {
 	list := newUnconstrainedListToTest()
 	txGasHandler, txFeeHelper := dummyParams()
 
-	added, _ := list.AddTx(createTx([]byte("tx1"), ".", 1), txGasHandler, txFeeHelper)
+	added, _, _ := list.AddTx(createTx([]byte("tx1"), ".", 1), txGasHandler, txFeeHelper)
 	require.True(t, added)
-	added, _ = list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
+	added, _, _ = list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
 	require.True(t, added)
-	added, _ = list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
+	added, _, _ = list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
 	require.True(t, added)
-	added, _ = list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
+	added, _, _ = list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
 	require.False(t, added)
 }
 
+func TestListForSender_AddTx_RejectsMaxNonce(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	added, _, err := list.AddTx(createTx([]byte("tx1"), ".", math.MaxUint64-1), txGasHandler, txFeeHelper)
+	require.True(t, added)
+	require.Nil(t, err)
+	added, evicted, err := list.AddTx(createTx([]byte("tx2"), ".", math.MaxUint64), txGasHandler, txFeeHelper)
+	require.False(t, added)
+	require.Nil(t, evicted)
+	require.Equal(t, common.ErrNonceOverflow, err)
+	require.Equal(t, []string{"tx1"}, list.getTxHashesAsStrings())
+	require.Equal(t, uint64(math.MaxUint64), list.GetFirstUnexecutableNonce(math.MaxUint64-1))
+}
+
 func TestListForSender_AddTx_AppliesSizeConstraintsForNumTransactions(t *testing.T) {
 	list := newListToTest(math.MaxUint32, 3)
 	txGasHandler, txFeeHelper := dummyParams()
@@ -74,17 +95,17 @@ func TestListForSender_AddTx_AppliesSizeConstraintsForNumTransactions(t *testing
 	list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2", "tx4"}, list.getTxHashesAsStrings())
 
-	_, evicted := list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
+	_, evicted, _ := list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2", "tx3"}, list.getTxHashesAsStrings())
 	require.Equal(t, []string{"tx4"}, hashesAsStrings(evicted))
 
 	// Gives priority to higher gas - though undesirably to some extent, "tx3" is evicted
-	_, evicted = list.AddTx(createTxWithParams([]byte("tx2++"), ".", 2, 128, 42, 42), txGasHandler, txFeeHelper)
+	_, evicted, _ = list.AddTx(createTxWithParams([]byte("tx2++"), ".", 2, 128, 42, 42), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2++", "tx2"}, list.getTxHashesAsStrings())
 	require.Equal(t, []string{"tx3"}, hashesAsStrings(evicted))
 
 	// Though Undesirably to some extent, "tx3++"" is added, then evicted
-	_, evicted = list.AddTx(createTxWithParams([]byte("tx3++"), ".", 3, 128, 42, 42), txGasHandler, txFeeHelper)
+	_, evicted, _ = list.AddTx(createTxWithParams([]byte("tx3++"), ".", 3, 128, 42, 42), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2++", "tx2"}, list.getTxHashesAsStrings())
 	require.Equal(t, []string{"tx3++"}, hashesAsStrings(evicted))
 }
@@ -96,22 +117,175 @@ func TestListForSender_AddTx_AppliesSizeConstraintsForNumBytes(t *testing.T) {
 	list.AddTx(createTxWithParams([]byte("tx1"), ".", 1, 128, 42, 42), txGasHandler, txFeeHelper)
 	list.AddTx(createTxWithParams([]byte("tx2"), ".", 2, 512, 42, 42), txGasHandler, txFeeHelper)
 	list.AddTx(createTxWithParams([]byte("tx3"), ".", 3, 256, 42, 42), txGasHandler, txFeeHelper)
-	_, evicted := list.AddTx(createTxWithParams([]byte("tx5"), ".", 4, 256, 42, 42), txGasHandler, txFeeHelper)
+	_, evicted, _ := list.AddTx(createTxWithParams([]byte("tx5"), ".", 4, 256, 42, 42), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2", "tx3"}, list.getTxHashesAsStrings())
 	require.Equal(t, []string{"tx5"}, hashesAsStrings(evicted))
 
-	_, evicted = list.AddTx(createTxWithParams([]byte("tx5--"), ".", 4, 128, 42, 42), txGasHandler, txFeeHelper)
+	_, evicted, _ = list.AddTx(createTxWithParams([]byte("tx5--"), ".", 4, 128, 42, 42), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2", "tx3", "tx5--"}, list.getTxHashesAsStrings())
 	require.Equal(t, []string{}, hashesAsStrings(evicted))
 
-	_, evicted = list.AddTx(createTxWithParams([]byte("tx4"), ".", 4, 128, 42, 42), txGasHandler, txFeeHelper)
+	_, evicted, _ = list.AddTx(createTxWithParams([]byte("tx4"), ".", 4, 128, 42, 42), txGasHandler, txFeeHelper)
 	require.Equal(t, []string{"tx1", "tx2", "tx3", "tx4"}, list.getTxHashesAsStrings())
 	require.Equal(t, []string{"tx5--"}, hashesAsStrings(evicted))
 
-	// Gives priority to higher gas - though undesirably to some extent, "tx4" is evicted
-	_, evicted = list.AddTx(createTxWithParams([]byte("tx3++"), ".", 3, 256, 42, 100), txGasHandler, txFeeHelper)
-	require.Equal(t, []string{"tx1", "tx2", "tx3++", "tx3"}, list.getTxHashesAsStrings())
-	require.Equal(t, []string{"tx4"}, hashesAsStrings(evicted))
+	// Gives priority to higher gas - though undesirably to some extent, "tx4" and "tx3" are evicted
+	_, evicted, _ = list.AddTx(createTxWithParams([]byte("tx3++"), ".", 3, 256, 42, 100), txGasHandler, txFeeHelper)
+	require.Equal(t, []string{"tx1", "tx2", "tx3++"}, list.getTxHashesAsStrings())
+	require.Equal(t, []string{"tx4", "tx3"}, hashesAsStrings(evicted))
+	require.LessOrEqual(t, list.totalBytes.Get(), int64(1024))
+}
+
+func TestListForSender_applySizeConstraints_EvictsUntilWithinConstraints(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	// Many evictions are needed to get back within the maximum number of bytes
+	list := newListToTest(1024, math.MaxUint32)
+	list.AddTx(createTxWithParams([]byte("tx1"), ".", 1, 256, 42, 42), txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("tx2"), ".", 2, 256, 42, 42), txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("tx3"), ".", 3, 256, 42, 42), txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("tx4"), ".", 4, 256, 42, 42), txGasHandler, txFeeHelper)
+	_, evicted, _ := list.AddTx(createTxWithParams([]byte("tx0"), ".", 0, 769, 42, 42), txGasHandler, txFeeHelper)
+	require.Equal(t, []string{"tx4", "tx3", "tx2", "tx1"}, hashesAsStrings(evicted))
+	require.Equal(t, []string{"tx0"}, list.getTxHashesAsStrings())
+	require.Equal(t, int64(769), list.totalBytes.Get())
+
+	// Many evictions are needed to get back within the maximum number of transactions
+	list = newListToTest(math.MaxUint32, 3)
+	list.AddTx(createTx([]byte("tx1"), ".", 1), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
+	list.constraints.maxNumTxs = 1
+	evicted = list.applySizeConstraints()
+	list.publishSnapshotIfStale()
+	require.Equal(t, []string{"tx3", "tx2"}, hashesAsStrings(evicted))
+	require.Equal(t, []string{"tx1"}, list.getTxHashesAsStrings())
+	require.Equal(t, uint64(1), list.countTx())
+}
+
+func TestListForSender_AddTx_MaxNumBytesBoundary(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	t.Run("evict policy", func(t *testing.T) {
+		list := newListToTest(1024, math.MaxUint32)
+
+		list.AddTx(createTxWithParams([]byte("tx1"), ".", 1, 512, 42, 42), txGasHandler, txFeeHelper)
+		added, evicted, _ := list.AddTx(createTxWithParams([]byte("tx2"), ".", 2, 512, 42, 42), txGasHandler, txFeeHelper)
+		require.True(t, added)
+		require.Empty(t, evicted)
+		require.Equal(t, int64(1024), list.totalBytes.Get())
+
+		// Above the cap (by 128 bytes)
+		added, evicted, _ = list.AddTx(createTxWithParams([]byte("tx0"), ".", 0, 128, 42, 42), txGasHandler, txFeeHelper)
+		require.True(t, added)
+		require.Equal(t, []string{"tx2"}, hashesAsStrings(evicted))
+		require.Equal(t, []string{"tx0", "tx1"}, list.getTxHashesAsStrings())
+		require.Equal(t, int64(640), list.totalBytes.Get())
+	})
+
+	t.Run("reject policy", func(t *testing.T) {
+		list := newListToTest(1024, math.MaxUint32)
+		list.constraints.rejectAboveMaxNumBytes = true
+
+		list.AddTx(createTxWithParams([]byte("tx1"), ".", 1, 512, 42, 42), txGasHandler, txFeeHelper)
+		added, evicted, _ := list.AddTx(createTxWithParams([]byte("tx2"), ".", 2, 511, 42, 42), txGasHandler, txFeeHelper)
+		require.True(t, added)
+		require.Empty(t, evicted)
+
+		// Above the cap (by 129 bytes)
+		added, evicted, _ = list.AddTx(createTxWithParams([]byte("tx3"), ".", 3, 130, 42, 42), txGasHandler, txFeeHelper)
+		require.False(t, added)
+		require.Empty(t, evicted)
+		require.Equal(t, int64(1023), list.totalBytes.Get())
+
+		// Exactly at the cap
+		txAtCap := createTx([]byte("tx3"), ".", 3)
+		txAtCap.Size = 1
+		require.False(t, list.wouldExceedMaxNumBytes(txAtCap))
+		txAtCap.Size = 2
+		require.True(t, list.wouldExceedMaxNumBytes(txAtCap))
+		require.Equal(t, []string{"tx1", "tx2"}, list.getTxHashesAsStrings())
+	})
+}
+
+func TestListForSender_RemoveLowestFeeTxs(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	t.Run("lowest fees first, without introducing nonce gaps", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+
+		list.AddTx(createTxWithParams([]byte("tx1"), ".", 1, 128, 50_000, 5*oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx2-high"), ".", 2, 128, 50_000, 4*oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx2-low"), ".", 2, 128, 50_000, oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx3"), ".", 3, 128, 50_000, 3*oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx4-high"), ".", 4, 128, 50_000, 3*oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx4-low"), ".", 4, 128, 50_000, 2*oneBillion), txGasHandler, txFeeHelper)
+		require.Equal(t, []string{"tx1", "tx2-high", "tx2-low", "tx3", "tx4-high", "tx4-low"}, list.getTxHashesAsStrings())
+
+		removed := list.RemoveLowestFeeTxs(2)
+		require.Equal(t, []string{"tx2-low", "tx4-low"}, hashesAsStrings(removed))
+		require.Equal(t, []string{"tx1", "tx2-high", "tx3", "tx4-high"}, list.getTxHashesAsStrings())
+
+		// "tx2-high" is cheaper than "tx4-high", but its removal would block "tx3" and "tx4-high"
+		removed = list.RemoveLowestFeeTxs(1)
+		require.Equal(t, []string{"tx4-high"}, hashesAsStrings(removed))
+		require.Equal(t, []string{"tx1", "tx2-high", "tx3"}, list.getTxHashesAsStrings())
+		require.Equal(t, int64(3*128), list.totalBytes.Get())
+	})
+
+	t.Run("equal fees, highest nonces first", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+
+		list.AddTx(createTx([]byte("tx1"), ".", 1), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
+
+		removed := list.RemoveLowestFeeTxs(2)
+		require.Equal(t, []string{"tx3", "tx2"}, hashesAsStrings(removed))
+		require.Equal(t, []string{"tx1"}, list.getTxHashesAsStrings())
+	})
+
+	t.Run("count above the number of transactions", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+
+		list.AddTx(createTx([]byte("tx1"), ".", 1), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
+
+		removed := list.RemoveLowestFeeTxs(42)
+		require.Len(t, removed, 2)
+		require.True(t, list.IsEmpty())
+		require.Len(t, list.RemoveLowestFeeTxs(1), 0)
+	})
+
+	t.Run("with nonce index", func(t *testing.T) {
+		list := newListWithNonceIndexToTest(math.MaxUint32, math.MaxUint32)
+
+		list.AddTx(createTxWithParams([]byte("tx1-low"), ".", 1, 128, 50_000, oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx1-high"), ".", 1, 128, 50_000, 2*oneBillion), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("tx2"), ".", 2, 128, 50_000, 3*oneBillion), txGasHandler, txFeeHelper)
+
+		removed := list.RemoveLowestFeeTxs(1)
+		require.Equal(t, []string{"tx1-low"}, hashesAsStrings(removed))
+
+		_, txHash, ok := list.GetTxAtNonce(1)
+		require.True(t, ok)
+		require.Equal(t, []byte("tx1-high"), txHash)
+	})
+}
+
+func TestListForSender_AddTx_EvictsLowestFeeFirstWhenConfigured(t *testing.T) {
+	list := newListToTest(math.MaxUint32, 3)
+	list.constraints.evictLowestFeeFirst = true
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTxWithParams([]byte("tx1"), ".", 1, 128, 50_000, 3*oneBillion), txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("tx2"), ".", 2, 128, 50_000, 3*oneBillion), txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("tx3"), ".", 3, 128, 50_000, 5*oneBillion), txGasHandler, txFeeHelper)
+
+	// Without the fee-based policy, "tx3" would be evicted
+	_, evicted, _ := list.AddTx(createTxWithParams([]byte("tx2++"), ".", 2, 128, 50_000, 4*oneBillion), txGasHandler, txFeeHelper)
+	require.Equal(t, []string{"tx2"}, hashesAsStrings(evicted))
+	require.Equal(t, []string{"tx1", "tx2++", "tx3"}, list.getTxHashesAsStrings())
 }
 
 func TestListForSender_findTx(t *testing.T) {
@@ -151,6 +325,346 @@ func TestListForSender_findTx_CoverNonceComparisonOptimization(t *testing.T) {
 	require.Nil(t, noElement)
 }
 
+func TestListForSender_GetTxAtNonce(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTx([]byte("tx-41"), ".", 41), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("tx-42"), ".", 42), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("tx-44"), ".", 44), txGasHandler, txFeeHelper)
+
+	// Present nonce
+	tx, hash, ok := list.GetTxAtNonce(42)
+	require.True(t, ok)
+	require.Equal(t, []byte("tx-42"), hash)
+	require.Equal(t, uint64(42), tx.GetNonce())
+
+	// Absent nonce, between existing ones
+	tx, hash, ok = list.GetTxAtNonce(43)
+	require.False(t, ok)
+	require.Nil(t, hash)
+	require.Nil(t, tx)
+
+	// Nonces outside the range
+	_, _, ok = list.GetTxAtNonce(40)
+	require.False(t, ok)
+	_, _, ok = list.GetTxAtNonce(45)
+	require.False(t, ok)
+}
+
+func TestListForSender_GetTxAtNonce_ReturnsHighestPriorityWhenSameNonce(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTxWithParams([]byte("a"), ".", 1, 128, 42, 42), txGasHandler, txFeeHelper)
+	list.AddTx(createTxWithParams([]byte("b"), ".", 1, 128, 42, 100), txGasHandler, txFeeHelper)
+
+	_, hash, ok := list.GetTxAtNonce(1)
+	require.True(t, ok)
+	require.Equal(t, []byte("b"), hash)
+}
+
+func TestListForSender_NonceIndex(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	t.Run("disabled by default", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+		list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+		require.Nil(t, list.nonceIndex)
+	})
+
+	t.Run("consistent across adds and removes", func(t *testing.T) {
+		list := newListWithNonceIndexToTest(math.MaxUint32, math.MaxUint32)
+
+		list.AddTx(createTxWithParams([]byte("a"), ".", 3, 128, 42, 100), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("b"), ".", 1, 128, 42, 100), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("c"), ".", 3, 128, 42, 101), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("d"), ".", 3, 128, 42, 99), txGasHandler, txFeeHelper)
+		list.AddTx(createTxWithParams([]byte("e"), ".", 2, 128, 42, 100), txGasHandler, txFeeHelper)
+		require.Equal(t, []string{"b", "e", "c", "a", "d"}, list.getTxHashesAsStrings())
+		requireNonceIndexConsistent(t, list)
+
+		_, hash, ok := list.GetTxAtNonce(3)
+		require.True(t, ok)
+		require.Equal(t, []byte("c"), hash)
+
+		// Remove the first transaction having a duplicated nonce
+		require.True(t, list.RemoveTx(createTx([]byte("c"), ".", 3)))
+		requireNonceIndexConsistent(t, list)
+		_, hash, _ = list.GetTxAtNonce(3)
+		require.Equal(t, []byte("a"), hash)
+
+		// Remove a transaction which is not the first having its nonce
+		require.True(t, list.RemoveTx(createTx([]byte("d"), ".", 3)))
+		requireNonceIndexConsistent(t, list)
+
+		require.True(t, list.RemoveTx(createTx([]byte("e"), ".", 2)))
+		requireNonceIndexConsistent(t, list)
+		_, _, ok = list.GetTxAtNonce(2)
+		require.False(t, ok)
+
+		require.False(t, list.RemoveTx(createTx([]byte("x"), ".", 3)))
+		require.True(t, list.RemoveTx(createTx([]byte("a"), ".", 3)))
+		require.True(t, list.RemoveTx(createTx([]byte("b"), ".", 1)))
+		requireNonceIndexConsistent(t, list)
+		require.Len(t, list.nonceIndex, 0)
+	})
+
+	t.Run("consistent across high-nonce evictions", func(t *testing.T) {
+		list := newListWithNonceIndexToTest(math.MaxUint32, 3)
+
+		list.AddTx(createTx([]byte("tx-2"), ".", 2), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("tx-3"), ".", 3), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("tx-4"), ".", 4), txGasHandler, txFeeHelper)
+		_, evicted, _ := list.AddTx(createTx([]byte("tx-1"), ".", 1), txGasHandler, txFeeHelper)
+		require.Equal(t, [][]byte{[]byte("tx-4")}, evicted)
+		requireNonceIndexConsistent(t, list)
+
+		_, evicted, _ = list.AddTx(createTxWithParams([]byte("tx-3-bis"), ".", 3, 128, 42, 42), txGasHandler, txFeeHelper)
+		require.Equal(t, [][]byte{[]byte("tx-3")}, evicted)
+		requireNonceIndexConsistent(t, list)
+
+		_, _, ok := list.GetTxAtNonce(4)
+		require.False(t, ok)
+		_, hash, ok := list.GetTxAtNonce(3)
+		require.True(t, ok)
+		require.Equal(t, []byte("tx-3-bis"), hash)
+	})
+}
+
+func requireNonceGroupsConsistent(t *testing.T, listForSender *txListForSender) {
+	expected := make([]*list.Element, 0)
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		previous := element.Prev()
+		if previous == nil || getNonceOfElement(previous) != getNonceOfElement(element) {
+			expected = append(expected, element)
+		}
+	}
+
+	actual := make([]*list.Element, 0)
+	listForSender.nonceGroups.forEach(func(nonce uint64, element *list.Element) {
+		require.Equal(t, getNonceOfElement(element), nonce)
+		actual = append(actual, element)
+	})
+
+	require.Equal(t, len(expected), listForSender.nonceGroups.length)
+	require.Equal(t, len(expected), len(actual))
+	for i, element := range expected {
+		require.True(t, element == actual[i], "nonce groups are inconsistent at position %d", i)
+	}
+}
+
+func requireNonceIndexConsistent(t *testing.T, listForSender *txListForSender) {
+	expected := make(map[uint64]*list.Element)
+	for element := listForSender.items.Front(); element != nil; element = element.Next() {
+		nonce := element.Value.(*WrappedTransaction).Tx.GetNonce()
+		_, ok := expected[nonce]
+		if !ok {
+			expected[nonce] = element
+		}
+	}
+
+	require.Equal(t, len(expected), len(listForSender.nonceIndex))
+	for nonce, element := range expected {
+		require.True(t, element == listForSender.nonceIndex[nonce], "nonce index is inconsistent for nonce %d", nonce)
+	}
+}
+
+func TestListForSender_GetFirstUnexecutableNonce(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	t.Run("empty list", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+		require.Equal(t, uint64(7), list.GetFirstUnexecutableNonce(7))
+	})
+
+	t.Run("contiguous sequence", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+		list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("c"), ".", 3), txGasHandler, txFeeHelper)
+
+		require.Equal(t, uint64(4), list.GetFirstUnexecutableNonce(1))
+		// Transactions with lower nonces are ignored
+		require.Equal(t, uint64(4), list.GetFirstUnexecutableNonce(2))
+		require.Equal(t, uint64(4), list.GetFirstUnexecutableNonce(4))
+		// Initial gap
+		require.Equal(t, uint64(0), list.GetFirstUnexecutableNonce(0))
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+		list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("d"), ".", 4), txGasHandler, txFeeHelper)
+
+		require.Equal(t, uint64(3), list.GetFirstUnexecutableNonce(1))
+	})
+
+	t.Run("duplicate", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+		list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("b'"), ".", 2), txGasHandler, txFeeHelper)
+		list.AddTx(createTx([]byte("c"), ".", 3), txGasHandler, txFeeHelper)
+
+		require.Equal(t, uint64(3), list.GetFirstUnexecutableNonce(1))
+	})
+}
+
+func TestListForSender_GetTransactionsCountInNonceRange(t *testing.T) {
+	t.Run("without nonce index", func(t *testing.T) {
+		testGetTransactionsCountInNonceRange(t, newUnconstrainedListToTest())
+	})
+	t.Run("with nonce index", func(t *testing.T) {
+		testGetTransactionsCountInNonceRange(t, newListWithNonceIndexToTest(math.MaxUint32, math.MaxUint32))
+	})
+}
+
+func testGetTransactionsCountInNonceRange(t *testing.T, list *txListForSender) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	require.Equal(t, uint64(0), list.GetTransactionsCountInNonceRange(0, math.MaxUint64))
+
+	list.AddTx(createTx([]byte("a"), ".", 3), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("b"), ".", 4), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("c"), ".", 4), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("d"), ".", 5), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("e"), ".", 8), txGasHandler, txFeeHelper)
+
+	// Empty windows
+	require.Equal(t, uint64(0), list.GetTransactionsCountInNonceRange(0, 2))
+	require.Equal(t, uint64(0), list.GetTransactionsCountInNonceRange(6, 7))
+	require.Equal(t, uint64(0), list.GetTransactionsCountInNonceRange(9, 100))
+	require.Equal(t, uint64(0), list.GetTransactionsCountInNonceRange(5, 4))
+
+	// Partial overlap
+	require.Equal(t, uint64(1), list.GetTransactionsCountInNonceRange(0, 3))
+	require.Equal(t, uint64(3), list.GetTransactionsCountInNonceRange(4, 7))
+	require.Equal(t, uint64(2), list.GetTransactionsCountInNonceRange(5, 10))
+	require.Equal(t, uint64(2), list.GetTransactionsCountInNonceRange(4, 4))
+
+	// Full coverage
+	require.Equal(t, uint64(5), list.GetTransactionsCountInNonceRange(3, 8))
+	require.Equal(t, uint64(5), list.GetTransactionsCountInNonceRange(0, math.MaxUint64))
+	require.Equal(t, list.countTx(), list.GetTransactionsCountInNonceRange(0, math.MaxUint64))
+}
+
+func TestListForSender_NonceIndexIsEquivalent(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+	random := rand.New(rand.NewSource(42))
+
+	withoutIndex := newUnconstrainedListToTest()
+	withIndex := newListWithNonceIndexToTest(math.MaxUint32, math.MaxUint32)
+
+	for i := 0; i < 5000; i++ {
+		// A hash identifies a transaction (thus its nonce and its gas price)
+		nonce := uint64(random.Intn(300))
+		variant := random.Intn(6)
+		gasPrice := oneBillion * uint64(1+variant%3)
+		hash := []byte(fmt.Sprintf("hash-%d-%d", nonce, variant))
+		tx := createTxWithParams(hash, ".", nonce, 128, 50000, gasPrice)
+
+		if random.Intn(4) == 0 {
+			removedWithoutIndex := withoutIndex.RemoveTx(tx)
+			removedWithIndex := withIndex.RemoveTx(tx)
+			require.Equal(t, removedWithoutIndex, removedWithIndex)
+			continue
+		}
+
+		addedWithoutIndex, _, _ := withoutIndex.AddTx(tx, txGasHandler, txFeeHelper)
+		addedWithIndex, _, _ := withIndex.AddTx(tx, txGasHandler, txFeeHelper)
+		require.Equal(t, addedWithoutIndex, addedWithIndex)
+	}
+
+	require.Equal(t, withoutIndex.getTxHashes(), withIndex.getTxHashes())
+	requireSortedList(t, withoutIndex)
+	requireNonceGroupsConsistent(t, withoutIndex)
+	requireNonceGroupsConsistent(t, withIndex)
+	requireNonceIndexConsistent(t, withIndex)
+}
+
+// requireSortedList checks that the transactions are sorted by nonce (ascending), then by gas price (descending), then by hash
+func requireSortedList(t *testing.T, listForSender *txListForSender) {
+	for element := listForSender.items.Front(); element != nil && element.Next() != nil; element = element.Next() {
+		current := element.Value.(*WrappedTransaction)
+		next := element.Next().Value.(*WrappedTransaction)
+
+		if current.Tx.GetNonce() != next.Tx.GetNonce() {
+			require.Less(t, current.Tx.GetNonce(), next.Tx.GetNonce())
+			continue
+		}
+		if current.Tx.GetGasPrice() != next.Tx.GetGasPrice() {
+			require.Greater(t, current.Tx.GetGasPrice(), next.Tx.GetGasPrice())
+			continue
+		}
+		require.Equal(t, -1, bytes.Compare(current.TxHash, next.TxHash))
+	}
+}
+
+func BenchmarkListForSender_AddTx(b *testing.B) {
+	for _, numTxs := range []int{100, 1000, 10000} {
+		b.Run(fmt.Sprintf("default config, %d txs", numTxs), func(b *testing.B) {
+			benchmarkListForSenderAddTx(b, numTxs, newUnconstrainedListToTest)
+		})
+		b.Run(fmt.Sprintf("with nonce index, %d txs", numTxs), func(b *testing.B) {
+			benchmarkListForSenderAddTx(b, numTxs, func() *txListForSender {
+				return newListWithNonceIndexToTest(math.MaxUint32, math.MaxUint32)
+			})
+		})
+	}
+}
+
+func benchmarkListForSenderAddTx(b *testing.B, numTxs int, newList func() *txListForSender) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	// The transactions are added in random order of nonces
+	txs := make([]*WrappedTransaction, numTxs)
+	for i, nonce := range rand.New(rand.NewSource(42)).Perm(numTxs) {
+		txs[i] = createTx([]byte(fmt.Sprintf("hash-%d", nonce)), ".", uint64(nonce))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		list := newList()
+		for _, tx := range txs {
+			list.AddTx(tx, txGasHandler, txFeeHelper)
+		}
+	}
+}
+
+func TestListForSender_IterateByNonce(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTx([]byte("c"), ".", 3), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("d"), ".", 4), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+
+	nonces := make([]uint64, 0)
+	hashes := make([]string, 0)
+	list.IterateByNonce(func(nonce uint64, txHash []byte, tx data.TransactionHandler) bool {
+		require.Equal(t, nonce, tx.GetNonce())
+		nonces = append(nonces, nonce)
+		hashes = append(hashes, string(txHash))
+		return true
+	})
+	require.Equal(t, []uint64{1, 2, 3, 4}, nonces)
+	require.Equal(t, []string{"a", "b", "c", "d"}, hashes)
+
+	// Early exit
+	nonces = make([]uint64, 0)
+	list.IterateByNonce(func(nonce uint64, _ []byte, _ data.TransactionHandler) bool {
+		nonces = append(nonces, nonce)
+		return nonce < 2
+	})
+	require.Equal(t, []uint64{1, 2}, nonces)
+
+	// No panic on nil function
+	list.IterateByNonce(nil)
+}
+
 func TestListForSender_RemoveTransaction(t *testing.T) {
 	list := newUnconstrainedListToTest()
 	tx := createTx([]byte("a"), ".", 1)
@@ -254,6 +768,151 @@ func TestListForSender_SelectBatchTo_NoPanicWhenCornerCases(t *testing.T) {
 	require.Equal(t, 5, journal.copied)
 }
 
+func TestListForSender_CopyBatchToWithFilter(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	for index := 0; index < 100; index++ {
+		list.AddTx(createTx([]byte{byte(index)}, ".", uint64(index)), txGasHandler, txFeeHelper)
+	}
+
+	isEvenNonce := func(tx data.TransactionHandler) bool {
+		return tx.GetNonce()%2 == 0
+	}
+
+	destination := make([]data.TransactionHandler, 1000)
+	destinationHashes := make([][]byte, 1000)
+
+	// Start a new selection
+	journal := list.selectBatchTo(true, make([]*WrappedTransaction, 0), 0, math.MaxUint64)
+	require.Equal(t, 0, journal.copied)
+
+	// First batch
+	copied := list.copyBatchToWithFilter(destination, destinationHashes, 10, isEvenNonce)
+	require.Equal(t, 10, copied)
+	require.Equal(t, uint64(0), destination[0].GetNonce())
+	require.Equal(t, uint64(18), destination[9].GetNonce())
+	require.Equal(t, []byte{18}, destinationHashes[9])
+	require.Nil(t, destination[10])
+
+	// Second batch continues after the skipped transactions
+	copied = list.copyBatchToWithFilter(destination[10:], destinationHashes[10:], 10, isEvenNonce)
+	require.Equal(t, 10, copied)
+	require.Equal(t, uint64(20), destination[10].GetNonce())
+	require.Equal(t, uint64(38), destination[19].GetNonce())
+
+	// Rejecting everything consumes the rest of the list
+	copied = list.copyBatchToWithFilter(destination, destinationHashes, 10, func(_ data.TransactionHandler) bool {
+		return false
+	})
+	require.Equal(t, 0, copied)
+
+	copied = list.copyBatchToWithFilter(destination, destinationHashes, 10, nil)
+	require.Equal(t, 0, copied)
+
+	// Restart copy (with reset of selection marks), without filter
+	list.ResetSelection()
+	_ = list.selectBatchTo(true, make([]*WrappedTransaction, 0), 0, math.MaxUint64)
+	copied = list.copyBatchToWithFilter(destination, destinationHashes, 12345, nil)
+	require.Equal(t, 100, copied)
+}
+
+func TestListForSender_CopyBatchToWithFilter_StopsOnMiddleGap(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+	list.notifyAccountNonce(1)
+
+	list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("c"), ".", 3), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("d"), ".", 5), txGasHandler, txFeeHelper)
+
+	acceptAll := func(_ data.TransactionHandler) bool {
+		return true
+	}
+
+	destination := make([]data.TransactionHandler, 10)
+	destinationHashes := make([][]byte, 10)
+
+	_ = list.selectBatchTo(true, make([]*WrappedTransaction, 0), 0, math.MaxUint64)
+	copied := list.copyBatchToWithFilter(destination, destinationHashes, 10, acceptAll)
+	require.Equal(t, 3, copied)
+	require.True(t, list.copyDetectedGap)
+
+	copied = list.copyBatchToWithFilter(destination, destinationHashes, 10, acceptAll)
+	require.Equal(t, 0, copied)
+}
+
+func TestListForSender_CopyBatchToWithFilter_ShouldNotReselect(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	for index := 0; index < 10; index++ {
+		list.AddTx(createTx([]byte{byte(index)}, ".", uint64(index)), txGasHandler, txFeeHelper)
+	}
+
+	destination := make([]data.TransactionHandler, 100)
+	destinationHashes := make([][]byte, 100)
+
+	_ = list.selectBatchTo(true, make([]*WrappedTransaction, 0), 0, math.MaxUint64)
+	copied := list.copyBatchToWithFilter(destination, destinationHashes, 4, nil)
+	require.Equal(t, 4, copied)
+
+	// Rewind the position (as if another pass of the same round started over): the already selected transactions are skipped
+	list.copyCursor = txListSnapshotCursor{}
+	list.copyPreviousNonce = 0
+	wrappedDestination := make([]*WrappedTransaction, 100)
+	journal := list.selectBatchTo(false, wrappedDestination, 100, math.MaxUint64)
+	require.Equal(t, 6, journal.copied)
+	require.Equal(t, uint64(4), wrappedDestination[0].Tx.GetNonce())
+
+	list.copyCursor = txListSnapshotCursor{}
+	list.copyPreviousNonce = 0
+	copied = list.copyBatchToWithFilter(destination, destinationHashes, 100, nil)
+	require.Equal(t, 0, copied)
+
+	// After reset, all transactions are available again
+	list.ResetSelection()
+	list.copyCursor = txListSnapshotCursor{}
+	list.copyPreviousNonce = 0
+	copied = list.copyBatchToWithFilter(destination, destinationHashes, 100, nil)
+	require.Equal(t, 10, copied)
+	require.Equal(t, uint64(0), destination[0].GetNonce())
+
+	// A new selection round clears the marks, as well
+	journal = list.selectBatchTo(true, wrappedDestination, 100, math.MaxUint64)
+	require.Equal(t, 10, journal.copied)
+
+	// The marks are held by the list (not by the transactions), and are dropped by the next round
+	require.Len(t, list.selectedInRound, 10)
+	list.RemoveTx(wrappedDestination[9])
+	journal = list.selectBatchTo(true, wrappedDestination, 0, math.MaxUint64)
+	require.Equal(t, 0, journal.copied)
+	require.Empty(t, list.selectedInRound)
+}
+
+func TestListForSender_SelectBatchTo_ReadsFromTheSnapshotOfTheRound(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTx([]byte("tx1"), ".", 1), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("tx2"), ".", 2), txGasHandler, txFeeHelper)
+
+	destination := make([]*WrappedTransaction, 100)
+	journal := list.selectBatchTo(true, destination, 1, math.MaxUint64)
+	require.Equal(t, 1, journal.copied)
+
+	// Mutations within the round do not affect the round
+	list.AddTx(createTx([]byte("tx3"), ".", 3), txGasHandler, txFeeHelper)
+	journal = list.selectBatchTo(false, destination, 100, math.MaxUint64)
+	require.Equal(t, 1, journal.copied)
+	require.Equal(t, []byte("tx2"), destination[0].TxHash)
+
+	// ... but they are accounted by the next one
+	journal = list.selectBatchTo(true, destination, 100, math.MaxUint64)
+	require.Equal(t, 3, journal.copied)
+}
+
 func TestListForSender_SelectBatchTo_WhenInitialGap(t *testing.T) {
 	list := newUnconstrainedListToTest()
 	txGasHandler, txFeeHelper := dummyParams()
@@ -377,6 +1036,51 @@ func TestListForSender_hasInitialGap(t *testing.T) {
 	require.False(t, list.hasInitialGap())
 }
 
+func TestListForSender_getAccountNonceBaseline(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParams()
+
+	t.Run("without implicit account nonce", func(t *testing.T) {
+		list := newUnconstrainedListToTest()
+		list.AddTx(createTx([]byte("tx-43"), ".", 43), txGasHandler, txFeeHelper)
+
+		_, ok := list.getAccountNonceBaseline()
+		require.False(t, ok)
+		require.False(t, list.hasInitialGap())
+
+		list.notifyAccountNonce(42)
+		accountNonce, ok := list.getAccountNonceBaseline()
+		require.True(t, ok)
+		require.Equal(t, uint64(42), accountNonce)
+		require.True(t, list.hasInitialGap())
+	})
+
+	t.Run("with implicit account nonce", func(t *testing.T) {
+		list := newTxListForSender(".", &senderConstraints{
+			maxNumBytes:                  math.MaxUint32,
+			maxNumTxs:                    math.MaxUint32,
+			useLowestNonceAsAccountNonce: true,
+		}, func(_ *txListForSender, _ senderScoreParams) {})
+
+		// No transaction, no account nonce
+		_, ok := list.getAccountNonceBaseline()
+		require.False(t, ok)
+
+		// The lowest nonce serves as account nonce, thus there is no initial gap
+		list.AddTx(createTx([]byte("tx-43"), ".", 43), txGasHandler, txFeeHelper)
+		accountNonce, ok := list.getAccountNonceBaseline()
+		require.True(t, ok)
+		require.Equal(t, uint64(43), accountNonce)
+		require.False(t, list.hasInitialGap())
+
+		// The explicit notification takes precedence
+		list.notifyAccountNonce(42)
+		accountNonce, ok = list.getAccountNonceBaseline()
+		require.True(t, ok)
+		require.Equal(t, uint64(42), accountNonce)
+		require.True(t, list.hasInitialGap())
+	})
+}
+
 func TestListForSender_getTxHashes(t *testing.T) {
 	list := newUnconstrainedListToTest()
 	require.Len(t, list.getTxHashes(), 0)
@@ -435,6 +1139,14 @@ func newUnconstrainedListToTest() *txListForSender {
 	}, func(_ *txListForSender, _ senderScoreParams) {})
 }
 
+func newListWithNonceIndexToTest(maxNumBytes uint32, maxNumTxs uint32) *txListForSender {
+	return newTxListForSender(".", &senderConstraints{
+		maxNumBytes:   maxNumBytes,
+		maxNumTxs:     maxNumTxs,
+		useNonceIndex: true,
+	}, func(_ *txListForSender, _ senderScoreParams) {})
+}
+
 func newListToTest(maxNumBytes uint32, maxNumTxs uint32) *txListForSender {
 	return newTxListForSender(".", &senderConstraints{
 		maxNumBytes: maxNumBytes,
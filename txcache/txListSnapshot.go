@@ -0,0 +1,272 @@
+package txcache
+
+import (
+	"bytes"
+	"sort"
+	"sync/atomic"
+)
+
+// maxTxsPerSnapshotChunk is the maximum number of transactions held by a chunk of a snapshot.
+// It bounds the copy performed by each mutation of the list (see txListSnapshot).
+const maxTxsPerSnapshotChunk = 128
+
+// txListSnapshot is a stable (read-only) view of the transactions of a sender, in the order of the list (by nonce, then by priority).
+// It can be iterated without holding any lock, and it is not affected by the mutations of the list performed after its creation.
+// The transactions are held in (immutable, never empty) chunks, shared among the successive views of the list,
+// so that a mutation of the list only copies the affected chunk and the (much shorter) slice of chunks, instead of the whole view.
+// The snapshot is shared among its readers, thus it must never be altered.
+// Note that the transactions themselves are not copied.
+type txListSnapshot struct {
+	chunks [][]*WrappedTransaction
+	length int
+}
+
+// txListSnapshotCursor is a position within a snapshot
+type txListSnapshotCursor struct {
+	chunk  int
+	offset int
+}
+
+// Len returns the number of transactions in the snapshot
+func (snapshot txListSnapshot) Len() int {
+	return snapshot.length
+}
+
+// At returns the transaction at the given position
+func (snapshot txListSnapshot) At(index int) *WrappedTransaction {
+	for _, chunk := range snapshot.chunks {
+		if index < len(chunk) {
+			return chunk[index]
+		}
+
+		index -= len(chunk)
+	}
+
+	panic("txListSnapshot.At(): index out of range")
+}
+
+// ForEach calls the provided function for each transaction of the snapshot, until the function returns false
+func (snapshot txListSnapshot) ForEach(fn func(tx *WrappedTransaction) bool) {
+	for _, chunk := range snapshot.chunks {
+		for _, tx := range chunk {
+			if !fn(tx) {
+				return
+			}
+		}
+	}
+}
+
+// get returns the transaction at the given position (false past the end of the snapshot)
+func (snapshot txListSnapshot) get(cursor txListSnapshotCursor) (*WrappedTransaction, bool) {
+	if cursor.chunk >= len(snapshot.chunks) {
+		return nil, false
+	}
+
+	return snapshot.chunks[cursor.chunk][cursor.offset], true
+}
+
+// next returns the position following the given one
+func (snapshot txListSnapshot) next(cursor txListSnapshotCursor) txListSnapshotCursor {
+	cursor.offset++
+	if cursor.offset == len(snapshot.chunks[cursor.chunk]) {
+		cursor.chunk++
+		cursor.offset = 0
+	}
+
+	return cursor
+}
+
+// toSlice returns (a copy of) the transactions of the snapshot
+func (snapshot txListSnapshot) toSlice() []*WrappedTransaction {
+	result := make([]*WrappedTransaction, 0, snapshot.length)
+	for _, chunk := range snapshot.chunks {
+		result = append(result, chunk...)
+	}
+
+	return result
+}
+
+// insert places the transaction as in the list (see isBeforeInList), copying the affected chunk (split if it becomes too large).
+// It must only be called on a view not published yet, which owns its slice of chunks (see getPendingSnapshot).
+func (snapshot *txListSnapshot) insert(tx *WrappedTransaction) {
+	snapshot.length++
+
+	if len(snapshot.chunks) == 0 {
+		snapshot.chunks = append(snapshot.chunks, []*WrappedTransaction{tx})
+		return
+	}
+
+	// The first chunk ending with a transaction placed after the incoming one (or else, the last chunk)
+	chunkIndex := sort.Search(len(snapshot.chunks), func(i int) bool {
+		chunk := snapshot.chunks[i]
+		return isBeforeInList(tx, chunk[len(chunk)-1])
+	})
+	if chunkIndex == len(snapshot.chunks) {
+		chunkIndex--
+	}
+
+	chunk := snapshot.chunks[chunkIndex]
+	position := sort.Search(len(chunk), func(i int) bool {
+		return isBeforeInList(tx, chunk[i])
+	})
+
+	newChunk := make([]*WrappedTransaction, len(chunk)+1)
+	copy(newChunk, chunk[:position])
+	newChunk[position] = tx
+	copy(newChunk[position+1:], chunk[position:])
+
+	if len(newChunk) <= maxTxsPerSnapshotChunk {
+		snapshot.chunks[chunkIndex] = newChunk
+		return
+	}
+
+	// The halves share the backing array, but none of the elements
+	half := len(newChunk) / 2
+	snapshot.chunks = append(snapshot.chunks, nil)
+	copy(snapshot.chunks[chunkIndex+2:], snapshot.chunks[chunkIndex+1:])
+	snapshot.chunks[chunkIndex] = newChunk[:half:half]
+	snapshot.chunks[chunkIndex+1] = newChunk[half:]
+}
+
+// remove removes the transaction (if present), copying the affected chunk (merged with a neighbour if they become small enough).
+// It must only be called on a view not published yet, which owns its slice of chunks (see getPendingSnapshot).
+func (snapshot *txListSnapshot) remove(tx *WrappedTransaction) {
+	// The first chunk ending with a transaction not placed before the one to remove
+	chunkIndex := sort.Search(len(snapshot.chunks), func(i int) bool {
+		chunk := snapshot.chunks[i]
+		return !isBeforeInList(chunk[len(chunk)-1], tx)
+	})
+	if chunkIndex == len(snapshot.chunks) {
+		return
+	}
+
+	chunk := snapshot.chunks[chunkIndex]
+	position := sort.Search(len(chunk), func(i int) bool {
+		return !isBeforeInList(chunk[i], tx)
+	})
+	if position == len(chunk) || chunk[position] != tx {
+		return
+	}
+
+	snapshot.length--
+
+	if len(chunk) == 1 {
+		snapshot.removeChunk(chunkIndex)
+		return
+	}
+
+	newChunk := make([]*WrappedTransaction, 0, len(chunk)-1)
+	newChunk = append(newChunk, chunk[:position]...)
+	newChunk = append(newChunk, chunk[position+1:]...)
+	snapshot.chunks[chunkIndex] = newChunk
+
+	snapshot.mergeWithNextChunkIfSmall(chunkIndex)
+	snapshot.mergeWithNextChunkIfSmall(chunkIndex - 1)
+}
+
+// mergeWithNextChunkIfSmall merges two neighbouring chunks if they fit in half of a chunk,
+// so that the removals do not fragment the view into many small chunks
+func (snapshot *txListSnapshot) mergeWithNextChunkIfSmall(chunkIndex int) {
+	if chunkIndex < 0 || chunkIndex+1 >= len(snapshot.chunks) {
+		return
+	}
+
+	chunk, nextChunk := snapshot.chunks[chunkIndex], snapshot.chunks[chunkIndex+1]
+	if len(chunk)+len(nextChunk) > maxTxsPerSnapshotChunk/2 {
+		return
+	}
+
+	mergedChunk := make([]*WrappedTransaction, 0, len(chunk)+len(nextChunk))
+	mergedChunk = append(mergedChunk, chunk...)
+	mergedChunk = append(mergedChunk, nextChunk...)
+	snapshot.chunks[chunkIndex] = mergedChunk
+	snapshot.removeChunk(chunkIndex + 1)
+}
+
+func (snapshot *txListSnapshot) removeChunk(chunkIndex int) {
+	copy(snapshot.chunks[chunkIndex:], snapshot.chunks[chunkIndex+1:])
+	snapshot.chunks[len(snapshot.chunks)-1] = nil
+	snapshot.chunks = snapshot.chunks[:len(snapshot.chunks)-1]
+}
+
+// txListSnapshotHolder holds the current snapshot of a list, replaced (never altered) on each mutation of the list
+type txListSnapshotHolder struct {
+	value atomic.Value
+}
+
+func (holder *txListSnapshotHolder) load() txListSnapshot {
+	snapshot, ok := holder.value.Load().(*txListSnapshot)
+	if !ok {
+		return txListSnapshot{}
+	}
+
+	return *snapshot
+}
+
+func (holder *txListSnapshotHolder) store(snapshot *txListSnapshot) {
+	holder.value.Store(snapshot)
+}
+
+// Snapshot returns a stable view of the transactions of the sender (see txListSnapshot).
+// The view is published (copy-on-write) by each mutation of the list, thus reading it is a mere atomic load, without locking the list.
+func (listForSender *txListForSender) Snapshot() txListSnapshot {
+	return listForSender.snapshot.load()
+}
+
+// insertIntoSnapshot inserts the transaction into the next view (see publishSnapshotIfStale), at the same position as in the list
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) insertIntoSnapshot(tx *WrappedTransaction) {
+	listForSender.getPendingSnapshot().insert(tx)
+}
+
+// removeFromSnapshot removes the transaction from the next view (see publishSnapshotIfStale)
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) removeFromSnapshot(tx *WrappedTransaction) {
+	listForSender.getPendingSnapshot().remove(tx)
+}
+
+// getPendingSnapshot returns the next view, created by the first mutation of an operation:
+// it shares the chunks of the current view, but owns its slice of chunks.
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) getPendingSnapshot() *txListSnapshot {
+	if listForSender.pendingSnapshot == nil {
+		current := listForSender.Snapshot()
+		chunks := make([][]*WrappedTransaction, len(current.chunks), len(current.chunks)+1)
+		copy(chunks, current.chunks)
+
+		listForSender.pendingSnapshot = &txListSnapshot{
+			chunks: chunks,
+			length: current.length,
+		}
+	}
+
+	return listForSender.pendingSnapshot
+}
+
+// publishSnapshotIfStale publishes the next view, if the list has been mutated since the previous publication.
+// It is called once per (public) mutating operation, thus the readers never observe the intermediate states of an operation.
+// This function should only be used in critical section (listForSender.mutex)
+func (listForSender *txListForSender) publishSnapshotIfStale() {
+	if listForSender.pendingSnapshot == nil {
+		return
+	}
+
+	listForSender.snapshot.store(listForSender.pendingSnapshot)
+	listForSender.pendingSnapshot = nil
+}
+
+// isBeforeInList tells whether a transaction precedes another one in the list of a sender: by nonce (ascending),
+// then by gas price (descending), then by hash (ascending), as placed by findInsertionPlace
+func isBeforeInList(tx *WrappedTransaction, another *WrappedTransaction) bool {
+	nonce, anotherNonce := tx.Tx.GetNonce(), another.Tx.GetNonce()
+	if nonce != anotherNonce {
+		return nonce < anotherNonce
+	}
+
+	gasPrice, anotherGasPrice := tx.Tx.GetGasPrice(), another.Tx.GetGasPrice()
+	if gasPrice != anotherGasPrice {
+		return gasPrice > anotherGasPrice
+	}
+
+	return bytes.Compare(tx.TxHash, another.TxHash) < 0
+}
@@ -0,0 +1,169 @@
+package txcache
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListForSender_SnapshotIsNotAffectedByMutations(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("c"), ".", 3), txGasHandler, txFeeHelper)
+
+	snapshot := list.Snapshot()
+	require.Equal(t, 2, snapshot.Len())
+
+	list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+	list.RemoveTx(createTx([]byte("a"), ".", 1))
+
+	require.Equal(t, 2, snapshot.Len())
+	require.Equal(t, []byte("a"), snapshot.At(0).TxHash)
+	require.Equal(t, []byte("c"), snapshot.At(1).TxHash)
+
+	newSnapshot := list.Snapshot()
+	hashes := make([]string, 0)
+	newSnapshot.ForEach(func(tx *WrappedTransaction) bool {
+		hashes = append(hashes, string(tx.TxHash))
+		return true
+	})
+	require.Equal(t, []string{"b", "c"}, hashes)
+}
+
+func TestListForSender_SnapshotIsPublishedOnMutation(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+
+	first := list.Snapshot()
+	second := list.Snapshot()
+	require.Same(t, &first.chunks[0], &second.chunks[0])
+
+	list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+	third := list.Snapshot()
+	require.Equal(t, 2, third.Len())
+	require.NotSame(t, &first.chunks[0], &third.chunks[0])
+
+	// Rejected additions (duplicates) and removals of missing transactions do not replace the view
+	list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+	list.RemoveTx(createTx([]byte("c"), ".", 3))
+	fourth := list.Snapshot()
+	require.Same(t, &third.chunks[0], &fourth.chunks[0])
+
+	list.RemoveLowestFeeTxs(1)
+	fifth := list.Snapshot()
+	require.Equal(t, 1, fifth.Len())
+	require.Equal(t, 2, fourth.Len())
+}
+
+func TestListForSender_SnapshotOfNewList(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	require.Equal(t, 0, list.Snapshot().Len())
+}
+
+func TestListForSender_SnapshotMatchesTheList(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+	random := rand.New(rand.NewSource(42))
+
+	added := make([]*WrappedTransaction, 0)
+	for i := 0; i < 5000; i++ {
+		if len(added) > 0 && random.Intn(3) == 0 {
+			index := random.Intn(len(added))
+			list.RemoveTx(added[index])
+			added = append(added[:index], added[index+1:]...)
+		} else {
+			// Few nonces, thus many transactions having the same nonce (and different gas prices)
+			nonce := uint64(random.Intn(300))
+			gasPrice := oneBillion + uint64(random.Intn(10))
+			tx := createTxWithParams([]byte(fmt.Sprintf("tx-%d", i)), ".", nonce, 128, 50000, gasPrice)
+			list.AddTx(tx, txGasHandler, txFeeHelper)
+			added = append(added, tx)
+		}
+
+		if i%100 == 0 {
+			requireSnapshotMatchesTheList(t, list)
+		}
+	}
+
+	snapshot := list.Snapshot()
+	require.Greater(t, len(snapshot.chunks), 1)
+	for _, chunk := range snapshot.chunks {
+		require.NotEmpty(t, chunk)
+		require.LessOrEqual(t, len(chunk), maxTxsPerSnapshotChunk)
+	}
+
+	requireSnapshotMatchesTheList(t, list)
+}
+
+func requireSnapshotMatchesTheList(t *testing.T, list *txListForSender) {
+	expected := make([]*WrappedTransaction, 0)
+	for element := list.items.Front(); element != nil; element = element.Next() {
+		expected = append(expected, element.Value.(*WrappedTransaction))
+	}
+
+	snapshot := list.Snapshot()
+	require.Equal(t, len(expected), snapshot.Len())
+	require.Equal(t, expected, snapshot.toSlice())
+	for i, tx := range expected {
+		require.Same(t, tx, snapshot.At(i))
+	}
+}
+
+func TestListForSender_SnapshotForEachEarlyExit(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	list.AddTx(createTx([]byte("a"), ".", 1), txGasHandler, txFeeHelper)
+	list.AddTx(createTx([]byte("b"), ".", 2), txGasHandler, txFeeHelper)
+
+	numVisited := 0
+	list.Snapshot().ForEach(func(_ *WrappedTransaction) bool {
+		numVisited++
+		return false
+	})
+	require.Equal(t, 1, numVisited)
+}
+
+func TestListForSender_SnapshotConcurrentWithMutations(t *testing.T) {
+	list := newUnconstrainedListToTest()
+	txGasHandler, txFeeHelper := dummyParams()
+
+	wg := sync.WaitGroup{}
+	wg.Add(2)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			nonce := uint64(i%100) + 1
+			tx := createTx(createFakeTxHash([]byte("."), int(nonce)), ".", nonce)
+			list.AddTx(tx, txGasHandler, txFeeHelper)
+			if i%3 == 0 {
+				list.RemoveTx(tx)
+			}
+		}
+	}()
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 1000; i++ {
+			snapshot := list.Snapshot()
+			previousNonce := uint64(0)
+			snapshot.ForEach(func(tx *WrappedTransaction) bool {
+				require.True(t, tx.Tx.GetNonce() >= previousNonce)
+				previousNonce = tx.Tx.GetNonce()
+				return true
+			})
+		}
+	}()
+
+	wg.Wait()
+}
@@ -2,8 +2,16 @@ package txcache
 
 import (
 	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"time"
 
+	"github.com/multiversx/mx-chain-core-go/core/check"
 	"github.com/multiversx/mx-chain-core-go/data"
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-storage-go/common"
 )
 
 const processFeeFactor = float64(0.8) // 80%
@@ -16,6 +24,97 @@ type WrappedTransaction struct {
 	ReceiverShardID      uint32
 	Size                 int64
 	TxFeeScoreNormalized uint64
+	// PrecomputedFee is the exact fee of the transaction, if known by the wrapper (e.g. after a full validation).
+	// If set (non-zero), it is used instead of the fee estimations.
+	PrecomputedFee uint64
+	// ReceivedAt is the moment the transaction has been received. If not set by the wrapper, it is set when added to the cache.
+	ReceivedAt time.Time
+}
+
+// Age returns the time elapsed since the transaction has been received (zero if the moment of receipt is not known)
+func (wrappedTx *WrappedTransaction) Age(now time.Time) time.Duration {
+	if wrappedTx.ReceivedAt.IsZero() || now.Before(wrappedTx.ReceivedAt) {
+		return 0
+	}
+
+	return now.Sub(wrappedTx.ReceivedAt)
+}
+
+// wrappedTransactionJSON is the JSON representation of a wrapped transaction (hash and sender in hex, value in base 10, data in base64)
+type wrappedTransactionJSON struct {
+	Hash        string `json:"hash"`
+	Sender      string `json:"sender"`
+	Nonce       uint64 `json:"nonce"`
+	GasPrice    uint64 `json:"gasPrice"`
+	GasLimit    uint64 `json:"gasLimit"`
+	Value       string `json:"value"`
+	Data        []byte `json:"data"`
+	SizeInBytes int64  `json:"sizeInBytes"`
+	Score       uint64 `json:"score"`
+}
+
+// MarshalJSON returns the JSON representation of the wrapped transaction, meant for debugging (e.g. dumping the cache).
+// Only the main fields of the transaction are included, the score being the (normalized) fee score.
+func (wrappedTx *WrappedTransaction) MarshalJSON() ([]byte, error) {
+	if check.IfNil(wrappedTx.Tx) {
+		return nil, common.ErrNilTransaction
+	}
+
+	value := "0"
+	if wrappedTx.Tx.GetValue() != nil {
+		value = wrappedTx.Tx.GetValue().String()
+	}
+
+	return json.Marshal(wrappedTransactionJSON{
+		Hash:        hex.EncodeToString(wrappedTx.TxHash),
+		Sender:      hex.EncodeToString(wrappedTx.Tx.GetSndAddr()),
+		Nonce:       wrappedTx.Tx.GetNonce(),
+		GasPrice:    wrappedTx.Tx.GetGasPrice(),
+		GasLimit:    wrappedTx.Tx.GetGasLimit(),
+		Value:       value,
+		Data:        wrappedTx.Tx.GetData(),
+		SizeInBytes: wrappedTx.Size,
+		Score:       wrappedTx.TxFeeScoreNormalized,
+	})
+}
+
+// UnmarshalJSON reconstructs the wrapped transaction (as a transaction.Transaction) from the output of MarshalJSON.
+// The fields of the transaction not present in the JSON representation (e.g. the receiver or the signature) are left empty.
+func (wrappedTx *WrappedTransaction) UnmarshalJSON(buff []byte) error {
+	var txJSON wrappedTransactionJSON
+	err := json.Unmarshal(buff, &txJSON)
+	if err != nil {
+		return err
+	}
+
+	txHash, err := hex.DecodeString(txJSON.Hash)
+	if err != nil {
+		return err
+	}
+	sender, err := hex.DecodeString(txJSON.Sender)
+	if err != nil {
+		return err
+	}
+	value, ok := big.NewInt(0).SetString(txJSON.Value, 10)
+	if !ok {
+		return fmt.Errorf("%w: %q", common.ErrInvalidTxValue, txJSON.Value)
+	}
+
+	*wrappedTx = WrappedTransaction{
+		Tx: &transaction.Transaction{
+			Nonce:    txJSON.Nonce,
+			Value:    value,
+			SndAddr:  sender,
+			GasPrice: txJSON.GasPrice,
+			GasLimit: txJSON.GasLimit,
+			Data:     txJSON.Data,
+		},
+		TxHash:               txHash,
+		Size:                 txJSON.SizeInBytes,
+		TxFeeScoreNormalized: txJSON.Score,
+	}
+
+	return nil
 }
 
 func (wrappedTx *WrappedTransaction) sameAs(another *WrappedTransaction) bool {
@@ -29,7 +128,13 @@ func estimateTxGas(tx *WrappedTransaction) uint64 {
 }
 
 // estimateTxFeeScore returns a normalized approximation for the cost of a transaction
+// If the fee is precomputed, it is only normalized (instead of being estimated)
 func estimateTxFeeScore(tx *WrappedTransaction, txGasHandler TxGasHandler, txFeeHelper feeHelper) uint64 {
+	if tx.PrecomputedFee > 0 {
+		tx.TxFeeScoreNormalized = tx.PrecomputedFee >> (txFeeHelper.gasLimitShift() + txFeeHelper.gasPriceShift())
+		return tx.TxFeeScoreNormalized
+	}
+
 	moveGas, processGas := txGasHandler.SplitTxGasInCategories(tx.Tx)
 
 	normalizedMoveGas := moveGas >> txFeeHelper.gasLimitShift()
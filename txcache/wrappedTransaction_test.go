@@ -1,8 +1,14 @@
 package txcache
 
 import (
+	"encoding/json"
+	"errors"
+	"math/big"
 	"testing"
+	"time"
 
+	"github.com/multiversx/mx-chain-core-go/data/transaction"
+	"github.com/multiversx/mx-chain-storage-go/common"
 	"github.com/multiversx/mx-chain-storage-go/testscommon/txcachemocks"
 	"github.com/stretchr/testify/require"
 )
@@ -24,6 +30,23 @@ func Test_estimateTxFeeScore(t *testing.T) {
 	require.Equal(t, uint64(205079820), C.TxFeeScoreNormalized)
 }
 
+func Test_estimateTxFeeScore_WithPrecomputedFee(t *testing.T) {
+	txGasHandler, txFeeHelper := dummyParamsWithGasPrice(100 * oneBillion)
+	shift := txFeeHelper.gasLimitShift() + txFeeHelper.gasPriceShift()
+
+	A := createTxWithParams([]byte("a"), "a", 1, 200, 50000, 100*oneBillion)
+	A.PrecomputedFee = 50000 * 100 * oneBillion * 3
+	scoreA := estimateTxFeeScore(A, txGasHandler, txFeeHelper)
+	require.Equal(t, A.PrecomputedFee>>shift, scoreA)
+	require.Equal(t, scoreA, A.TxFeeScoreNormalized)
+
+	// Falls back to the estimation if not set
+	B := createTxWithParams([]byte("b"), "b", 1, 200, 50000, 100*oneBillion)
+	scoreB := estimateTxFeeScore(B, txGasHandler, txFeeHelper)
+	require.Equal(t, uint64(8940), scoreB)
+	require.Greater(t, scoreA, scoreB)
+}
+
 func Test_normalizeGasPriceProcessing(t *testing.T) {
 	txGasHandler, txFeeHelper := dummyParamsWithGasPriceAndDivisor(100*oneBillion, 100)
 	A := createTxWithParams([]byte("A"), "a", 1, 200, 1500000000, 100*oneBillion)
@@ -73,3 +96,92 @@ func dummyParamsWithGasPriceAndDivisor(minGasPrice, processingPriceDivisor uint6
 	}
 	return txGasHandler, txFeeHelper
 }
+
+func TestWrappedTransaction_Age(t *testing.T) {
+	receivedAt := time.Unix(1_000_000, 0)
+	tx := createTx([]byte("a"), "a", 1)
+
+	// Unknown moment of receipt
+	require.Equal(t, time.Duration(0), tx.Age(receivedAt))
+
+	tx.ReceivedAt = receivedAt
+	require.Equal(t, time.Duration(0), tx.Age(receivedAt))
+	require.Equal(t, time.Second, tx.Age(receivedAt.Add(time.Second)))
+	require.Equal(t, time.Minute, tx.Age(receivedAt.Add(time.Minute)))
+
+	// Clock skew
+	require.Equal(t, time.Duration(0), tx.Age(receivedAt.Add(-time.Second)))
+}
+
+func TestWrappedTransaction_MarshalJSON(t *testing.T) {
+	wrappedTx := &WrappedTransaction{
+		Tx: &transaction.Transaction{
+			Nonce:    7,
+			Value:    big.NewInt(1000000000000000000),
+			RcvAddr:  []byte("bob"),
+			SndAddr:  []byte("alice"),
+			GasPrice: oneBillion,
+			GasLimit: 50000,
+			Data:     []byte("hello"),
+		},
+		TxHash:               []byte("hash-alice-7"),
+		Size:                 150,
+		TxFeeScoreNormalized: 42,
+	}
+
+	buff, err := json.Marshal(wrappedTx)
+	require.Nil(t, err)
+	require.JSONEq(t, `{
+		"hash": "686173682d616c6963652d37",
+		"sender": "616c696365",
+		"nonce": 7,
+		"gasPrice": 1000000000,
+		"gasLimit": 50000,
+		"value": "1000000000000000000",
+		"data": "aGVsbG8=",
+		"sizeInBytes": 150,
+		"score": 42
+	}`, string(buff))
+
+	unmarshalled := &WrappedTransaction{}
+	err = json.Unmarshal(buff, unmarshalled)
+	require.Nil(t, err)
+	require.Equal(t, wrappedTx.TxHash, unmarshalled.TxHash)
+	require.Equal(t, wrappedTx.Size, unmarshalled.Size)
+	require.Equal(t, wrappedTx.TxFeeScoreNormalized, unmarshalled.TxFeeScoreNormalized)
+	// The receiver is not part of the JSON representation
+	require.Equal(t, &transaction.Transaction{
+		Nonce:    7,
+		Value:    big.NewInt(1000000000000000000),
+		SndAddr:  []byte("alice"),
+		GasPrice: oneBillion,
+		GasLimit: 50000,
+		Data:     []byte("hello"),
+	}, unmarshalled.Tx)
+
+	// Nil value
+	wrappedTx.Tx.(*transaction.Transaction).Value = nil
+	buff, err = json.Marshal(wrappedTx)
+	require.Nil(t, err)
+	require.Contains(t, string(buff), `"value":"0"`)
+
+	// Nil transaction
+	_, err = json.Marshal(&WrappedTransaction{})
+	require.True(t, errors.Is(err, common.ErrNilTransaction))
+}
+
+func TestWrappedTransaction_UnmarshalJSON_InvalidInput(t *testing.T) {
+	unmarshalled := &WrappedTransaction{}
+
+	err := json.Unmarshal([]byte(`{"hash": "not hex", "sender": "", "value": "0"}`), unmarshalled)
+	require.NotNil(t, err)
+
+	err = json.Unmarshal([]byte(`{"hash": "", "sender": "zz", "value": "0"}`), unmarshalled)
+	require.NotNil(t, err)
+
+	err = json.Unmarshal([]byte(`{"hash": "", "sender": "", "value": "one"}`), unmarshalled)
+	require.True(t, errors.Is(err, common.ErrInvalidTxValue))
+
+	err = json.Unmarshal([]byte(`{"hash": 1}`), unmarshalled)
+	require.NotNil(t, err)
+}
@@ -0,0 +1,6 @@
+package typedcache
+
+import "errors"
+
+// ErrNilCacher signals that a nil cacher has been provided
+var ErrNilCacher = errors.New("nil cacher")
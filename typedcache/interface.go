@@ -0,0 +1,12 @@
+package typedcache
+
+// Cacher defines the subset of the storage cacher operations needed by the typed wrapper.
+// Any types.Cacher of the parent module satisfies it.
+type Cacher interface {
+	Put(key []byte, value interface{}, sizeInBytes int) (evicted bool)
+	Get(key []byte) (value interface{}, ok bool)
+	Peek(key []byte) (value interface{}, ok bool)
+	Remove(key []byte)
+	Keys() [][]byte
+	IsInterfaceNil() bool
+}
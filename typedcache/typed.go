@@ -0,0 +1,94 @@
+package typedcache
+
+import (
+	"sync/atomic"
+)
+
+// Typed wraps a cacher holding values of a single type, so that the consumers do not have to type-assert the values.
+// If the underlying cacher holds a value of an unexpected dynamic type (e.g. added through the untyped cacher),
+// the value is treated as missing (instead of causing a panic), and a mismatch counter is incremented.
+type Typed[T any] struct {
+	// accessed atomically, kept first for 64-bit alignment on 32-bit platforms
+	numTypeMismatches uint64
+	cacher            Cacher
+}
+
+// NewTyped creates a typed wrapper over the provided cacher
+func NewTyped[T any](cacher Cacher) (*Typed[T], error) {
+	if cacher == nil || cacher.IsInterfaceNil() {
+		return nil, ErrNilCacher
+	}
+
+	return &Typed[T]{
+		cacher: cacher,
+	}, nil
+}
+
+// Put adds a value to the cache. Returns true if an eviction occurred.
+func (typed *Typed[T]) Put(key []byte, value T, sizeInBytes int) bool {
+	return typed.cacher.Put(key, value, sizeInBytes)
+}
+
+// Get looks up a key's value from the cache
+func (typed *Typed[T]) Get(key []byte) (T, bool) {
+	value, ok := typed.cacher.Get(key)
+	return typed.convert(value, ok)
+}
+
+// Peek returns the key's value without updating the "recently used"-ness of the key
+func (typed *Typed[T]) Peek(key []byte) (T, bool) {
+	value, ok := typed.cacher.Peek(key)
+	return typed.convert(value, ok)
+}
+
+// Remove removes the provided key from the cache
+func (typed *Typed[T]) Remove(key []byte) {
+	typed.cacher.Remove(key)
+}
+
+// ForEach iterates over the values in the cache (in the order given by the underlying cacher's Keys).
+// Keys removed concurrently, as well as values of an unexpected type, are skipped.
+func (typed *Typed[T]) ForEach(callback func(key []byte, value T)) {
+	if callback == nil {
+		return
+	}
+
+	for _, key := range typed.cacher.Keys() {
+		value, ok := typed.Peek(key)
+		if !ok {
+			continue
+		}
+
+		callback(key, value)
+	}
+}
+
+// NumTypeMismatches returns the number of values of an unexpected type encountered so far
+func (typed *Typed[T]) NumTypeMismatches() uint64 {
+	return atomic.LoadUint64(&typed.numTypeMismatches)
+}
+
+// Cacher returns the underlying (untyped) cacher
+func (typed *Typed[T]) Cacher() Cacher {
+	return typed.cacher
+}
+
+func (typed *Typed[T]) convert(value interface{}, ok bool) (T, bool) {
+	var zero T
+	if !ok {
+		return zero, false
+	}
+
+	valueAsT, isT := value.(T)
+	if !isT {
+		atomic.AddUint64(&typed.numTypeMismatches, 1)
+		return zero, false
+	}
+
+	return valueAsT, true
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (typed *Typed[T]) IsInterfaceNil() bool {
+	return typed == nil
+}
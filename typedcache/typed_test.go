@@ -0,0 +1,163 @@
+package typedcache_test
+
+import (
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/multiversx/mx-chain-storage-go/typedcache"
+	"github.com/stretchr/testify/require"
+)
+
+type cacherStub struct {
+	mutex sync.RWMutex
+	items map[string]interface{}
+}
+
+func newCacherStub() *cacherStub {
+	return &cacherStub{
+		items: make(map[string]interface{}),
+	}
+}
+
+func (stub *cacherStub) Put(key []byte, value interface{}, _ int) bool {
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+
+	stub.items[string(key)] = value
+	return false
+}
+
+func (stub *cacherStub) Get(key []byte) (interface{}, bool) {
+	stub.mutex.RLock()
+	defer stub.mutex.RUnlock()
+
+	value, ok := stub.items[string(key)]
+	return value, ok
+}
+
+func (stub *cacherStub) Peek(key []byte) (interface{}, bool) {
+	return stub.Get(key)
+}
+
+func (stub *cacherStub) Remove(key []byte) {
+	stub.mutex.Lock()
+	defer stub.mutex.Unlock()
+
+	delete(stub.items, string(key))
+}
+
+func (stub *cacherStub) Keys() [][]byte {
+	stub.mutex.RLock()
+	defer stub.mutex.RUnlock()
+
+	keys := make([][]byte, 0, len(stub.items))
+	for key := range stub.items {
+		keys = append(keys, []byte(key))
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		return string(keys[i]) < string(keys[j])
+	})
+
+	return keys
+}
+
+func (stub *cacherStub) IsInterfaceNil() bool {
+	return stub == nil
+}
+
+type dummyValue struct {
+	field int
+}
+
+func TestNewTyped(t *testing.T) {
+	t.Parallel()
+
+	typed, err := typedcache.NewTyped[int](nil)
+	require.Nil(t, typed)
+	require.Equal(t, typedcache.ErrNilCacher, err)
+
+	var nilStub *cacherStub
+	typed, err = typedcache.NewTyped[int](nilStub)
+	require.Nil(t, typed)
+	require.Equal(t, typedcache.ErrNilCacher, err)
+
+	stub := newCacherStub()
+	typed, err = typedcache.NewTyped[int](stub)
+	require.Nil(t, err)
+	require.False(t, typed.IsInterfaceNil())
+	require.Equal(t, stub, typed.Cacher())
+}
+
+func TestTyped_PutGetPeekRemove(t *testing.T) {
+	t.Parallel()
+
+	typed, _ := typedcache.NewTyped[*dummyValue](newCacherStub())
+
+	value, ok := typed.Get([]byte("a"))
+	require.False(t, ok)
+	require.Nil(t, value)
+
+	_ = typed.Put([]byte("a"), &dummyValue{field: 42}, 8)
+
+	value, ok = typed.Get([]byte("a"))
+	require.True(t, ok)
+	require.Equal(t, 42, value.field)
+
+	value, ok = typed.Peek([]byte("a"))
+	require.True(t, ok)
+	require.Equal(t, 42, value.field)
+
+	typed.Remove([]byte("a"))
+	_, ok = typed.Peek([]byte("a"))
+	require.False(t, ok)
+	require.Equal(t, uint64(0), typed.NumTypeMismatches())
+}
+
+func TestTyped_UnexpectedTypeDoesNotPanic(t *testing.T) {
+	t.Parallel()
+
+	stub := newCacherStub()
+	typed, _ := typedcache.NewTyped[dummyValue](stub)
+
+	// Added through the untyped cacher
+	_ = stub.Put([]byte("a"), "not a dummyValue", 1)
+	_ = stub.Put([]byte("b"), &dummyValue{field: 1}, 1)
+
+	require.NotPanics(t, func() {
+		value, ok := typed.Get([]byte("a"))
+		require.False(t, ok)
+		require.Equal(t, dummyValue{}, value)
+
+		value, ok = typed.Peek([]byte("b"))
+		require.False(t, ok)
+		require.Equal(t, dummyValue{}, value)
+	})
+
+	require.Equal(t, uint64(2), typed.NumTypeMismatches())
+}
+
+func TestTyped_ForEach(t *testing.T) {
+	t.Parallel()
+
+	stub := newCacherStub()
+	typed, _ := typedcache.NewTyped[int](stub)
+
+	_ = typed.Put([]byte("a"), 1, 1)
+	_ = typed.Put([]byte("b"), 2, 1)
+	_ = stub.Put([]byte("c"), "three", 1)
+	_ = typed.Put([]byte("d"), 4, 1)
+
+	visited := make(map[string]int)
+	typed.ForEach(func(key []byte, value int) {
+		visited[string(key)] = value
+	})
+
+	require.Equal(t, map[string]int{"a": 1, "b": 2, "d": 4}, visited)
+	require.Equal(t, uint64(1), typed.NumTypeMismatches())
+
+	require.NotPanics(t, func() {
+		typed.ForEach(nil)
+	})
+}
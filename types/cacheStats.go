@@ -0,0 +1,34 @@
+package types
+
+import "time"
+
+// CacheStats holds the usage statistics of a cache, since its creation or since the last reset of the statistics
+type CacheStats struct {
+	Hits        uint64        `json:"hits"`
+	Misses      uint64        `json:"misses"`
+	Puts        uint64        `json:"puts"`
+	Evictions   uint64        `json:"evictions"`
+	Len         int           `json:"len"`
+	SizeInBytes uint64        `json:"sizeInBytes"`
+	Uptime      time.Duration `json:"uptime"`
+}
+
+// CacheStatsHandler defines the behavior of a cache able to report its usage statistics
+type CacheStatsHandler interface {
+	Stats() CacheStats
+	ResetStats()
+}
+
+// KeyAccessCount holds how many times a key has been read. The key is identified by its hash (see cacheStats.HashOfKey).
+type KeyAccessCount struct {
+	KeyHash uint64 `json:"keyHash"`
+	Count   int64  `json:"count"`
+}
+
+// InspectableCacher defines a cacher able to report how many times each of its keys has been read (see Get), in order to identify the hot keys.
+// The counting is disabled by default.
+type InspectableCacher interface {
+	Cacher
+	SetAccessCountingEnabled(enabled bool)
+	GetAccessCounts() []KeyAccessCount
+}
@@ -23,10 +23,25 @@ type Persister interface {
 	// DestroyClosed removes the already closed persistence medium stored data
 	DestroyClosed() error
 	RangeKeys(handler func(key []byte, val []byte) bool)
+	// NewIterator returns an iterator over a consistent snapshot of the (key, value) pairs having the given prefix
+	NewIterator(prefix []byte) (Iterator, error)
 	// IsInterfaceNil returns true if there is no value under the interface
 	IsInterfaceNil() bool
 }
 
+// Iterator allows the caller to iterate over the (key, value) pairs of a persister, at its own pace.
+// An iterator must be closed after use, in order to release the held resources
+type Iterator interface {
+	// Next moves the iterator to the next (key, value) pair. It returns false if the iterator is exhausted
+	Next() bool
+	// Key returns the key of the current pair
+	Key() []byte
+	// Value returns the value of the current pair
+	Value() []byte
+	// Close releases the resources associated with the iterator
+	Close() error
+}
+
 // Batcher allows to batch the data first then write the batch to the persister in one go
 type Batcher interface {
 	// Put inserts one entry - key, value pair - into the batch
@@ -95,6 +110,7 @@ type Storer interface {
 	DestroyUnit() error
 	GetFromEpoch(key []byte, epoch uint32) ([]byte, error)
 	GetBulkFromEpoch(keys [][]byte, epoch uint32) ([]storage.KeyValuePair, error)
+	GetBulk(keys [][]byte) (map[string][]byte, error)
 	GetOldestEpoch() (uint32, error)
 	RangeKeys(handler func(key []byte, val []byte) bool)
 	Close() error
@@ -151,6 +167,13 @@ type ShardCoordinator interface {
 // ForEachItem is an iterator callback
 type ForEachItem func(key []byte, value interface{})
 
+// KeyValueSizePair holds an entry to be added into a cacher, along with its size in bytes (see the bulk additions of the cachers)
+type KeyValueSizePair struct {
+	Key         []byte
+	Value       interface{}
+	SizeInBytes int
+}
+
 // LRUCacheHandler is the interface for LRU cache.
 type LRUCacheHandler interface {
 	Add(key, value interface{}) bool
@@ -0,0 +1,22 @@
+package types
+
+// RemovalReason describes why an entry was removed from a cache
+type RemovalReason string
+
+const (
+	// RemovalReasonEvicted is used for entries evicted by the eviction policy of the cache (e.g. LRU)
+	RemovalReasonEvicted RemovalReason = "evicted"
+	// RemovalReasonExpired is used for entries whose time-to-live has elapsed
+	RemovalReasonExpired RemovalReason = "expired"
+	// RemovalReasonRemoved is used for entries explicitly removed by the caller
+	RemovalReasonRemoved RemovalReason = "removed"
+	// RemovalReasonCleared is used for entries dropped when the whole cache is cleared
+	RemovalReasonCleared RemovalReason = "cleared"
+	// RemovalReasonResized is used for entries dropped when the capacity of the cache is reduced
+	RemovalReasonResized RemovalReason = "resized"
+	// RemovalReasonExplicitOldest is used for entries removed by an explicit request to free the slot of the oldest entry
+	RemovalReasonExplicitOldest RemovalReason = "explicit-oldest"
+)
+
+// RemovalHandler is called when an entry leaves a cache
+type RemovalHandler func(key []byte, value interface{}, reason RemovalReason)
@@ -0,0 +1,132 @@
+package wal
+
+import (
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+)
+
+// A record holds the entries of one write (a single entry for Put and Remove, more entries for WriteBatch):
+//
+//	| payload length (4 bytes) | payload | CRC32 of the payload (4 bytes) |
+//
+// where the payload is made of the number of entries (4 bytes), followed by the entries themselves:
+//
+//	| key length (4 bytes) | value length (4 bytes) | key | value |
+//
+// A removal is encoded with the value length set to removedValueMarker (and no value bytes).
+func encodeRecord(entries []storageCore.KeyValuePair) []byte {
+	payloadSize := 4
+	for _, entry := range entries {
+		payloadSize += entryHeaderSize + len(entry.Key) + len(entry.Value)
+	}
+
+	record := make([]byte, recordHeaderSize+payloadSize+recordChecksumSize)
+	binary.LittleEndian.PutUint32(record, uint32(payloadSize))
+
+	payload := record[recordHeaderSize : recordHeaderSize+payloadSize]
+	binary.LittleEndian.PutUint32(payload, uint32(len(entries)))
+	offset := 4
+	for _, entry := range entries {
+		valueLength := uint32(len(entry.Value))
+		if entry.Value == nil {
+			valueLength = removedValueMarker
+		}
+
+		binary.LittleEndian.PutUint32(payload[offset:], uint32(len(entry.Key)))
+		binary.LittleEndian.PutUint32(payload[offset+4:], valueLength)
+		offset += entryHeaderSize
+		offset += copy(payload[offset:], entry.Key)
+		offset += copy(payload[offset:], entry.Value)
+	}
+
+	binary.LittleEndian.PutUint32(record[recordHeaderSize+payloadSize:], crc32.ChecksumIEEE(payload))
+
+	return record
+}
+
+// readRecord reads the next record. It returns io.EOF if there are no more records, and errCorruptedRecord
+// if the record is truncated or does not match its checksum.
+func readRecord(reader io.Reader) ([]storageCore.KeyValuePair, error) {
+	header := make([]byte, recordHeaderSize)
+	_, err := io.ReadFull(reader, header)
+	if err == io.EOF {
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, errCorruptedRecord
+	}
+
+	payloadSize := binary.LittleEndian.Uint32(header)
+	if payloadSize < 4 || payloadSize > maxRecordPayloadSize {
+		return nil, errCorruptedRecord
+	}
+
+	payloadAndChecksum := make([]byte, int(payloadSize)+recordChecksumSize)
+	_, err = io.ReadFull(reader, payloadAndChecksum)
+	if err != nil {
+		return nil, errCorruptedRecord
+	}
+
+	payload := payloadAndChecksum[:payloadSize]
+	checksum := binary.LittleEndian.Uint32(payloadAndChecksum[payloadSize:])
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return nil, errCorruptedRecord
+	}
+
+	return decodePayload(payload)
+}
+
+func decodePayload(payload []byte) ([]storageCore.KeyValuePair, error) {
+	numEntries := binary.LittleEndian.Uint32(payload)
+	offset := uint64(4)
+	payloadSize := uint64(len(payload))
+	if uint64(numEntries)*entryHeaderSize > payloadSize {
+		return nil, errCorruptedRecord
+	}
+
+	entries := make([]storageCore.KeyValuePair, 0, numEntries)
+	for i := uint32(0); i < numEntries; i++ {
+		if offset+entryHeaderSize > payloadSize {
+			return nil, errCorruptedRecord
+		}
+
+		keyLength := uint64(binary.LittleEndian.Uint32(payload[offset:]))
+		valueLength := binary.LittleEndian.Uint32(payload[offset+4:])
+		offset += entryHeaderSize
+
+		isRemoval := valueLength == removedValueMarker
+		if isRemoval {
+			valueLength = 0
+		}
+		if offset+keyLength+uint64(valueLength) > payloadSize {
+			return nil, errCorruptedRecord
+		}
+
+		entry := storageCore.KeyValuePair{
+			Key: copyBytes(payload[offset : offset+keyLength]),
+		}
+		offset += keyLength
+		if !isRemoval {
+			entry.Value = copyBytes(payload[offset : offset+uint64(valueLength)])
+			offset += uint64(valueLength)
+		}
+
+		entries = append(entries, entry)
+	}
+
+	if offset != payloadSize {
+		return nil, errCorruptedRecord
+	}
+
+	return entries, nil
+}
+
+func copyBytes(data []byte) []byte {
+	result := make([]byte, len(data))
+	copy(result, data)
+
+	return result
+}
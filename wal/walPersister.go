@@ -0,0 +1,471 @@
+package wal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	logger "github.com/multiversx/mx-chain-logger-go"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/types"
+)
+
+var _ types.Persister = (*walPersister)(nil)
+
+var log = logger.GetOrCreate("storage/wal")
+
+const segmentFileExtension = ".wal"
+const segmentFilePermissions = 0644
+const recordHeaderSize = 4
+const recordChecksumSize = 4
+const entryHeaderSize = 8
+const removedValueMarker = math.MaxUint32
+const maxRecordPayloadSize = 1 << 30
+
+var errCorruptedRecord = errors.New("corrupted record")
+
+// atomicBatchWriter defines a persister able to write more entries atomically. Writing an empty batch commits
+// the entries still pending in the persister (e.g. the batch of a LevelDB persister).
+type atomicBatchWriter interface {
+	WriteBatch(entries []storageCore.KeyValuePair) error
+}
+
+// multiGetter defines a persister able to fetch more keys at once
+type multiGetter interface {
+	GetMulti(keys [][]byte) (map[string][]byte, error)
+}
+
+// pendingEntriesProvider defines a persister holding written entries not yet visible to its RangeKeys
+type pendingEntriesProvider interface {
+	GetPendingEntries() []storageCore.KeyValuePair
+}
+
+// walPersister is a persister decorator which appends each write (Put, Remove, WriteBatch) to an fsynced log,
+// before handing it to the wrapped (batching) persister. Once the log grows above the configured size, the pending
+// writes of the persister are committed and the log is truncated. The records surviving a crash are replayed
+// into the persister when the decorator is created.
+type walPersister struct {
+	persister             types.Persister
+	batchWriter           atomicBatchWriter
+	dirPath               string
+	maxSegmentSizeInBytes uint64
+
+	// mutLog serializes the writes, so that the order of the records in the log matches the order of the writes in the persister
+	mutLog             sync.Mutex
+	activeSegment      *os.File
+	activeSegmentIndex uint64
+	activeSegmentSize  uint64
+	isClosed           bool
+}
+
+// NewWALPersister creates a write-ahead log persister, wrapping the provided one. The log segments are kept under dirPath.
+// The wrapped persister must be able to write batches atomically, since that is how its pending writes get committed.
+func NewWALPersister(persister types.Persister, dirPath string, maxSegmentSizeInBytes uint64) (*walPersister, error) {
+	if check.IfNil(persister) {
+		return nil, common.ErrNilPersister
+	}
+	batchWriter, ok := persister.(atomicBatchWriter)
+	if !ok {
+		return nil, common.ErrAtomicBatchNotSupported
+	}
+	if len(dirPath) == 0 {
+		return nil, fmt.Errorf("%w: WAL directory path is invalid", common.ErrInvalidConfig)
+	}
+	if maxSegmentSizeInBytes == 0 {
+		return nil, fmt.Errorf("%w: WAL maximum segment size is invalid", common.ErrInvalidConfig)
+	}
+
+	err := os.MkdirAll(dirPath, os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	wp := &walPersister{
+		persister:             persister,
+		batchWriter:           batchWriter,
+		dirPath:               dirPath,
+		maxSegmentSizeInBytes: maxSegmentSizeInBytes,
+	}
+
+	err = wp.replay()
+	if err != nil {
+		return nil, err
+	}
+
+	return wp, nil
+}
+
+// replay applies the records surviving in the log onto the persister, commits them, then starts a fresh log.
+// The replay stops at the first bad record (e.g. a record partially written at crash time).
+func (wp *walPersister) replay() error {
+	segmentIndexes, err := wp.listSegmentIndexes()
+	if err != nil {
+		return err
+	}
+
+	numReplayed := 0
+	for _, segmentIndex := range segmentIndexes {
+		numReplayedInSegment, errReplay := wp.replaySegment(segmentIndex)
+		numReplayed += numReplayedInSegment
+		if errors.Is(errReplay, errCorruptedRecord) {
+			log.Warn("walPersister.replay: bad record found, skipping the rest of the log",
+				"dir", wp.dirPath, "segment", segmentIndex, "num replayed records", numReplayed)
+			break
+		}
+		if errReplay != nil {
+			return errReplay
+		}
+	}
+
+	if numReplayed > 0 {
+		log.Debug("walPersister.replay", "dir", wp.dirPath, "num replayed records", numReplayed)
+	}
+
+	lastSegmentIndex := uint64(0)
+	if len(segmentIndexes) > 0 {
+		lastSegmentIndex = segmentIndexes[len(segmentIndexes)-1]
+	}
+
+	return wp.commitAndTruncate(lastSegmentIndex + 1)
+}
+
+func (wp *walPersister) replaySegment(segmentIndex uint64) (int, error) {
+	file, err := os.Open(wp.segmentPath(segmentIndex))
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+
+	reader := bufio.NewReader(file)
+	numReplayed := 0
+	for {
+		entries, errRead := readRecord(reader)
+		if errRead == io.EOF {
+			return numReplayed, nil
+		}
+		if errRead != nil {
+			return numReplayed, errRead
+		}
+
+		err = wp.batchWriter.WriteBatch(entries)
+		if err != nil {
+			return numReplayed, err
+		}
+		numReplayed++
+	}
+}
+
+// Put appends the entry to the log, then writes it into the persister
+func (wp *walPersister) Put(key, val []byte) error {
+	loggedValue := val
+	if loggedValue == nil {
+		// a nil value would mark a removal
+		loggedValue = make([]byte, 0)
+	}
+
+	wp.mutLog.Lock()
+	defer wp.mutLog.Unlock()
+
+	err := wp.appendRecord([]storageCore.KeyValuePair{{Key: key, Value: loggedValue}})
+	if err != nil {
+		return err
+	}
+
+	err = wp.persister.Put(key, val)
+	if err != nil {
+		return err
+	}
+
+	return wp.rotateIfNeeded()
+}
+
+// Remove appends the removal to the log, then removes the key from the persister
+func (wp *walPersister) Remove(key []byte) error {
+	wp.mutLog.Lock()
+	defer wp.mutLog.Unlock()
+
+	err := wp.appendRecord([]storageCore.KeyValuePair{{Key: key}})
+	if err != nil {
+		return err
+	}
+
+	err = wp.persister.Remove(key)
+	if err != nil {
+		return err
+	}
+
+	return wp.rotateIfNeeded()
+}
+
+// WriteBatch appends the entries to the log, as a single record, then writes them into the persister atomically.
+// An entry having a nil value marks its key for deletion.
+func (wp *walPersister) WriteBatch(entries []storageCore.KeyValuePair) error {
+	wp.mutLog.Lock()
+	defer wp.mutLog.Unlock()
+
+	if len(entries) > 0 {
+		err := wp.appendRecord(entries)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := wp.batchWriter.WriteBatch(entries)
+	if err != nil {
+		return err
+	}
+
+	return wp.rotateIfNeeded()
+}
+
+// appendRecord writes the entries at the end of the active segment and waits for them to reach the disk.
+// This function should only be called under the (already acquired) mutLog.
+func (wp *walPersister) appendRecord(entries []storageCore.KeyValuePair) error {
+	if wp.isClosed {
+		return common.ErrDBIsClosed
+	}
+
+	record := encodeRecord(entries)
+	_, err := wp.activeSegment.Write(record)
+	if err != nil {
+		return err
+	}
+	wp.activeSegmentSize += uint64(len(record))
+
+	return wp.activeSegment.Sync()
+}
+
+// rotateIfNeeded commits the pending writes of the persister and truncates the log, once the active segment is large enough.
+// Should the commit fail, a new segment is started anyway, the older ones being kept until the next successful commit.
+// This function should only be called under the (already acquired) mutLog.
+func (wp *walPersister) rotateIfNeeded() error {
+	if wp.activeSegmentSize < wp.maxSegmentSizeInBytes {
+		return nil
+	}
+
+	err := wp.commitAndTruncate(wp.activeSegmentIndex + 1)
+	if err == nil {
+		return nil
+	}
+
+	log.Warn("walPersister.rotateIfNeeded: could not commit the pending writes, keeping the log",
+		"dir", wp.dirPath, "error", err)
+
+	return wp.openSegment(wp.activeSegmentIndex + 1)
+}
+
+// commitAndTruncate commits the pending writes of the persister, removes all the log segments, then opens a new
+// (empty) segment having the provided index. The log is kept as it is if the commit fails.
+func (wp *walPersister) commitAndTruncate(newSegmentIndex uint64) error {
+	err := wp.batchWriter.WriteBatch(nil)
+	if err != nil {
+		return err
+	}
+
+	err = wp.closeActiveSegment()
+	if err != nil {
+		return err
+	}
+
+	err = wp.removeSegments()
+	if err != nil {
+		return err
+	}
+
+	return wp.openSegment(newSegmentIndex)
+}
+
+func (wp *walPersister) openSegment(segmentIndex uint64) error {
+	err := wp.closeActiveSegment()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(wp.segmentPath(segmentIndex), os.O_CREATE|os.O_WRONLY|os.O_APPEND, segmentFilePermissions)
+	if err != nil {
+		return err
+	}
+
+	wp.activeSegment = file
+	wp.activeSegmentIndex = segmentIndex
+	wp.activeSegmentSize = 0
+
+	return nil
+}
+
+func (wp *walPersister) closeActiveSegment() error {
+	if wp.activeSegment == nil {
+		return nil
+	}
+
+	err := wp.activeSegment.Close()
+	wp.activeSegment = nil
+
+	return err
+}
+
+func (wp *walPersister) removeSegments() error {
+	segmentIndexes, err := wp.listSegmentIndexes()
+	if err != nil {
+		return err
+	}
+
+	for _, segmentIndex := range segmentIndexes {
+		err = os.Remove(wp.segmentPath(segmentIndex))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// listSegmentIndexes returns the indexes of the log segments, in ascending order (the order they have been written in)
+func (wp *walPersister) listSegmentIndexes() ([]uint64, error) {
+	dirEntries, err := os.ReadDir(wp.dirPath)
+	if err != nil {
+		return nil, err
+	}
+
+	segmentIndexes := make([]uint64, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		name := dirEntry.Name()
+		if dirEntry.IsDir() || !strings.HasSuffix(name, segmentFileExtension) {
+			continue
+		}
+
+		segmentIndex, errParse := strconv.ParseUint(strings.TrimSuffix(name, segmentFileExtension), 10, 64)
+		if errParse != nil {
+			continue
+		}
+
+		segmentIndexes = append(segmentIndexes, segmentIndex)
+	}
+
+	sort.Slice(segmentIndexes, func(i, j int) bool {
+		return segmentIndexes[i] < segmentIndexes[j]
+	})
+
+	return segmentIndexes, nil
+}
+
+func (wp *walPersister) segmentPath(segmentIndex uint64) string {
+	return filepath.Join(wp.dirPath, fmt.Sprintf("%020d%s", segmentIndex, segmentFileExtension))
+}
+
+// Get returns the value associated to the key
+func (wp *walPersister) Get(key []byte) ([]byte, error) {
+	return wp.persister.Get(key)
+}
+
+// GetMulti gets the values associated to the provided keys. The missing keys are not part of the result.
+func (wp *walPersister) GetMulti(keys [][]byte) (map[string][]byte, error) {
+	getter, ok := wp.persister.(multiGetter)
+	if ok {
+		return getter.GetMulti(keys)
+	}
+
+	results := make(map[string][]byte, len(keys))
+	for _, key := range keys {
+		value, err := wp.persister.Get(key)
+		if common.IsNotFoundError(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		results[string(key)] = value
+	}
+
+	return results, nil
+}
+
+// GetPendingEntries returns the entries written (or removed) into the persister, but not yet visible to its RangeKeys
+func (wp *walPersister) GetPendingEntries() []storageCore.KeyValuePair {
+	provider, ok := wp.persister.(pendingEntriesProvider)
+	if !ok {
+		return nil
+	}
+
+	return provider.GetPendingEntries()
+}
+
+// Has returns nil if the given key is present in the persister
+func (wp *walPersister) Has(key []byte) error {
+	return wp.persister.Has(key)
+}
+
+// RangeKeys iterates over the (key, value) pairs of the persister
+func (wp *walPersister) RangeKeys(handler func(key []byte, val []byte) bool) {
+	wp.persister.RangeKeys(handler)
+}
+
+// NewIterator returns an iterator over the (key, value) pairs of the persister having the given prefix
+func (wp *walPersister) NewIterator(prefix []byte) (types.Iterator, error) {
+	return wp.persister.NewIterator(prefix)
+}
+
+// Close commits the pending writes of the persister, truncates the log, then closes the persister.
+// The log is kept (thus replayed at the next start) if the commit fails.
+func (wp *walPersister) Close() error {
+	wp.mutLog.Lock()
+	defer wp.mutLog.Unlock()
+
+	if !wp.isClosed {
+		wp.isClosed = true
+		err := wp.batchWriter.WriteBatch(nil)
+		if err == nil {
+			_ = wp.closeActiveSegment()
+			err = wp.removeSegments()
+		}
+		if err != nil {
+			log.Warn("walPersister.Close: could not truncate the log", "dir", wp.dirPath, "error", err)
+		}
+		_ = wp.closeActiveSegment()
+	}
+
+	return wp.persister.Close()
+}
+
+// Destroy removes the log, as well as the data of the persister
+func (wp *walPersister) Destroy() error {
+	wp.mutLog.Lock()
+	wp.isClosed = true
+	_ = wp.closeActiveSegment()
+	wp.mutLog.Unlock()
+
+	err := os.RemoveAll(wp.dirPath)
+	if err != nil {
+		return err
+	}
+
+	return wp.persister.Destroy()
+}
+
+// DestroyClosed removes the log, as well as the data of the already closed persister
+func (wp *walPersister) DestroyClosed() error {
+	err := os.RemoveAll(wp.dirPath)
+	if err != nil {
+		return err
+	}
+
+	return wp.persister.DestroyClosed()
+}
+
+// IsInterfaceNil returns true if there is no value under the interface
+func (wp *walPersister) IsInterfaceNil() bool {
+	return wp == nil
+}
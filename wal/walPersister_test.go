@@ -0,0 +1,236 @@
+package wal_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/multiversx/mx-chain-core-go/core/check"
+	storageCore "github.com/multiversx/mx-chain-core-go/storage"
+	"github.com/multiversx/mx-chain-storage-go/common"
+	"github.com/multiversx/mx-chain-storage-go/memorydb"
+	"github.com/multiversx/mx-chain-storage-go/testscommon"
+	"github.com/multiversx/mx-chain-storage-go/wal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingCommitDB is a memory DB whose commits (empty batches) fail, as a batching persister failing to flush would
+type failingCommitDB struct {
+	*memorydb.DB
+	commitErr error
+}
+
+func (db *failingCommitDB) WriteBatch(entries []storageCore.KeyValuePair) error {
+	if len(entries) == 0 && db.commitErr != nil {
+		return db.commitErr
+	}
+
+	return db.DB.WriteBatch(entries)
+}
+
+func listSegments(t *testing.T, dirPath string) []string {
+	dirEntries, err := os.ReadDir(dirPath)
+	require.Nil(t, err)
+
+	names := make([]string, 0, len(dirEntries))
+	for _, dirEntry := range dirEntries {
+		names = append(names, dirEntry.Name())
+	}
+
+	return names
+}
+
+func TestNewWALPersister(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+
+	wp, err := wal.NewWALPersister(nil, dirPath, 1024)
+	assert.True(t, check.IfNil(wp))
+	assert.Equal(t, common.ErrNilPersister, err)
+
+	wp, err = wal.NewWALPersister(&testscommon.PersisterStub{}, dirPath, 1024)
+	assert.True(t, check.IfNil(wp))
+	assert.Equal(t, common.ErrAtomicBatchNotSupported, err)
+
+	wp, err = wal.NewWALPersister(memorydb.New(), "", 1024)
+	assert.True(t, check.IfNil(wp))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	wp, err = wal.NewWALPersister(memorydb.New(), dirPath, 0)
+	assert.True(t, check.IfNil(wp))
+	assert.True(t, errors.Is(err, common.ErrInvalidConfig))
+
+	wp, err = wal.NewWALPersister(memorydb.New(), dirPath, 1024)
+	assert.False(t, check.IfNil(wp))
+	assert.Nil(t, err)
+	assert.Equal(t, 1, len(listSegments(t, dirPath)))
+	assert.Nil(t, wp.Close())
+}
+
+func TestWALPersister_ReplaysTheWritesLostAtCrash(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+	wp, _ := wal.NewWALPersister(memorydb.New(), dirPath, 1024*1024)
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	_ = wp.Put([]byte("b"), []byte("bbb"))
+	_ = wp.Put([]byte("empty"), nil)
+	_ = wp.Remove([]byte("a"))
+	_ = wp.WriteBatch([]storageCore.KeyValuePair{
+		{Key: []byte("c"), Value: []byte("ccc")},
+		{Key: []byte("b")},
+	})
+
+	// Crash: the writes held by the wrapped persister are lost, the log is not truncated
+	db := memorydb.New()
+	_ = db.Put([]byte("b"), []byte("stale b"))
+	replayed, err := wal.NewWALPersister(db, dirPath, 1024*1024)
+	require.Nil(t, err)
+
+	assert.NotNil(t, replayed.Has([]byte("a")))
+	assert.NotNil(t, replayed.Has([]byte("b")))
+	value, _ := replayed.Get([]byte("c"))
+	assert.Equal(t, []byte("ccc"), value)
+	value, err = replayed.Get([]byte("empty"))
+	assert.Nil(t, err)
+	assert.Equal(t, 0, len(value))
+
+	// The replayed records have been committed, thus the log is truncated
+	assert.Equal(t, 1, len(listSegments(t, dirPath)))
+	info, _ := os.Stat(filepath.Join(dirPath, listSegments(t, dirPath)[0]))
+	assert.Equal(t, int64(0), info.Size())
+	assert.Nil(t, replayed.Close())
+}
+
+func TestWALPersister_ReplayStopsAtFirstBadRecord(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+	wp, _ := wal.NewWALPersister(memorydb.New(), dirPath, 1024*1024)
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	_ = wp.Put([]byte("b"), []byte("bbb"))
+	_ = wp.Put([]byte("c"), []byte("ccc"))
+
+	// The last record is partially written (torn write at crash time)
+	segmentPath := filepath.Join(dirPath, listSegments(t, dirPath)[0])
+	info, _ := os.Stat(segmentPath)
+	require.Nil(t, os.Truncate(segmentPath, info.Size()-3))
+
+	replayed, err := wal.NewWALPersister(memorydb.New(), dirPath, 1024*1024)
+	require.Nil(t, err)
+	assert.Nil(t, replayed.Has([]byte("a")))
+	assert.Nil(t, replayed.Has([]byte("b")))
+	assert.NotNil(t, replayed.Has([]byte("c")))
+	assert.Nil(t, replayed.Close())
+}
+
+func TestWALPersister_ReplayIgnoresRecordsAfterChecksumMismatch(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+	wp, _ := wal.NewWALPersister(memorydb.New(), dirPath, 1024*1024)
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	_ = wp.Put([]byte("b"), []byte("bbb"))
+	_ = wp.Put([]byte("c"), []byte("ccc"))
+
+	// Flip the last byte of the value of "b" (each record is 4 + 4 + 8 + 1 + 3 + 4 = 24 bytes long)
+	segmentPath := filepath.Join(dirPath, listSegments(t, dirPath)[0])
+	content, _ := os.ReadFile(segmentPath)
+	require.Equal(t, 72, len(content))
+	content[43] ^= 0xFF
+	require.Nil(t, os.WriteFile(segmentPath, content, 0644))
+
+	replayed, err := wal.NewWALPersister(memorydb.New(), dirPath, 1024*1024)
+	require.Nil(t, err)
+	assert.Nil(t, replayed.Has([]byte("a")))
+	assert.NotNil(t, replayed.Has([]byte("b")))
+	assert.NotNil(t, replayed.Has([]byte("c")))
+	assert.Nil(t, replayed.Close())
+}
+
+func TestWALPersister_RotationCommitsAndTruncatesTheLog(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+	db := memorydb.New()
+	wp, _ := wal.NewWALPersister(db, dirPath, 50)
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	_ = wp.Put([]byte("b"), []byte("bbb"))
+	segments := listSegments(t, dirPath)
+	assert.Equal(t, 1, len(segments))
+
+	// The third record exceeds the threshold
+	_ = wp.Put([]byte("c"), []byte("ccc"))
+	newSegments := listSegments(t, dirPath)
+	assert.Equal(t, 1, len(newSegments))
+	assert.NotEqual(t, segments[0], newSegments[0])
+	info, _ := os.Stat(filepath.Join(dirPath, newSegments[0]))
+	assert.Equal(t, int64(0), info.Size())
+
+	for _, key := range []string{"a", "b", "c"} {
+		assert.Nil(t, db.Has([]byte(key)))
+	}
+	assert.Nil(t, wp.Close())
+}
+
+func TestWALPersister_FailedCommitKeepsTheLog(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+	db := &failingCommitDB{DB: memorydb.New()}
+	wp, _ := wal.NewWALPersister(db, dirPath, 50)
+	db.commitErr = errors.New("commit failure")
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	_ = wp.Put([]byte("b"), []byte("bbb"))
+	_ = wp.Put([]byte("c"), []byte("ccc"))
+	_ = wp.Put([]byte("d"), []byte("ddd"))
+
+	// A new segment is started, the previous one being kept until the next successful commit
+	assert.Equal(t, 2, len(listSegments(t, dirPath)))
+	assert.Nil(t, wp.Close())
+	assert.Equal(t, 2, len(listSegments(t, dirPath)))
+
+	replayed, err := wal.NewWALPersister(memorydb.New(), dirPath, 50)
+	require.Nil(t, err)
+	for _, key := range []string{"a", "b", "c", "d"} {
+		assert.Nil(t, replayed.Has([]byte(key)))
+	}
+	assert.Equal(t, 1, len(listSegments(t, dirPath)))
+	assert.Nil(t, replayed.Close())
+}
+
+func TestWALPersister_CloseTruncatesTheLog(t *testing.T) {
+	t.Parallel()
+
+	dirPath := t.TempDir()
+	wp, _ := wal.NewWALPersister(memorydb.New(), dirPath, 1024*1024)
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	assert.Nil(t, wp.Close())
+	assert.Equal(t, 0, len(listSegments(t, dirPath)))
+
+	err := wp.Put([]byte("b"), []byte("bbb"))
+	assert.Equal(t, common.ErrDBIsClosed, err)
+}
+
+func TestWALPersister_GetMulti(t *testing.T) {
+	t.Parallel()
+
+	wp, _ := wal.NewWALPersister(memorydb.New(), t.TempDir(), 1024*1024)
+	defer func() {
+		_ = wp.Close()
+	}()
+
+	_ = wp.Put([]byte("a"), []byte("aaa"))
+	results, err := wp.GetMulti([][]byte{[]byte("a"), []byte("missing")})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string][]byte{"a": []byte("aaa")}, results)
+}